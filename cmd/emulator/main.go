@@ -52,27 +52,59 @@ func main() {
 	chatRepo := repository.NewChatRepository(db)
 	messageRepo := repository.NewMessageRepository(db)
 	botRepo := repository.NewBotRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	fileRepo := repository.NewFileRepository(db)
 
 	// Инициализация WebSocket сервера
 	wsServer := websocket.NewServer()
 
 	// Инициализация менеджеров
 	userManager := emulator.NewUserManager(userRepo, botRepo)
-	botManager := emulator.NewBotManager(botRepo, userRepo, messageRepo, chatRepo)
+	botManager := emulator.NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
 	chatManager := emulator.NewChatManager(chatRepo, messageRepo, userRepo)
 	messageManager := emulator.NewMessageManager(messageRepo, chatRepo, userRepo, botManager, wsServer)
+	fileManager := emulator.NewFileManager(fileRepo, cfg.Files.Dir)
+
+	// Таймаут доставки обновлений через webhook
+	if webhookTimeout, err := cfg.GetWebhookTimeout(); err != nil {
+		log.Warn("Некорректный timeout webhook, используется значение по умолчанию", zap.Error(err))
+	} else {
+		botManager.SetWebhookTimeout(webhookTimeout)
+	}
 
 	// Устанавливаем MessageManager и BotManager в WebSocket сервер
 	wsServer.SetMessageManager(messageManager)
 	wsServer.SetBotManager(botManager)
 
+	// Сообщения пользователей с вложениями сохраняются в хранилище файлов
+	messageManager.SetFileManager(fileManager)
+
+	// Участие ботов в чатах проверяется при отправке, удалении, пересылке и копировании сообщений
+	messageManager.SetChatManager(chatManager)
+
+	// Устанавливаем BotManager и MessageManager в ChatManager для уведомлений об изменении участников
+	chatManager.SetBotManager(botManager)
+	chatManager.SetMessageManager(messageManager)
+
 	go wsServer.Start()
 
+	// Доставляем обновления, накопленные для webhook до перезапуска
+	if err := botManager.StartWebhookDelivery(); err != nil {
+		log.Error("Ошибка запуска доставки webhook", zap.Error(err))
+	}
+
 	// Создание тестовых данных
 	if err := createTestData(userManager, chatManager); err != nil {
 		log.Error("Ошибка создания тестовых данных", zap.Error(err))
 	}
 
+	// Правила ботов-заглушек из YAML файла
+	if cfg.Bots.StubRulesFile != "" {
+		if err := botManager.LoadStubRules(cfg.Bots.StubRulesFile); err != nil {
+			log.Error("Ошибка загрузки правил ботов-заглушек", zap.Error(err))
+		}
+	}
+
 	log.Info("Telegram эмулятор успешно запущен!")
 	log.Info(fmt.Sprintf("Сервер доступен по адресу: http://%s:%d", cfg.Emulator.Host, cfg.Emulator.Port))
 
@@ -98,7 +130,7 @@ func main() {
 	})
 
 	// Настройка маршрутов
-	api.SetupRoutes(router, userManager, chatManager, messageManager, botManager, wsServer)
+	api.SetupRoutes(router, userManager, chatManager, messageManager, botManager, fileManager, wsServer)
 
 	// Запуск сервера с правильными настройками HTTP
 	addr := fmt.Sprintf("%s:%d", cfg.Emulator.Host, cfg.Emulator.Port)
@@ -139,6 +171,11 @@ func initDatabase(dbURL string) (*gorm.DB, error) {
 		&models.Message{},
 		&models.Bot{},
 		&models.ChatMember{},
+		&models.UpdateRecord{},
+		&models.UpdateSequence{},
+		&models.File{},
+		&models.BotCommandSet{},
+		&models.StubRule{},
 	); err != nil {
 		return nil, fmt.Errorf("ошибка миграции БД: %w", err)
 	}
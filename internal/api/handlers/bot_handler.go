@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"telegram-emulator/internal/emulator"
 	"telegram-emulator/internal/models"
 
 	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
 )
 
 // BotHandler обрабатывает запросы к API ботов
@@ -194,7 +199,8 @@ func (h *BotHandler) SendMessage(c *gin.Context) {
 
 	message, err := h.botManager.SendBotMessage(id, messageData.ChatID, messageData.Text, messageData.ParseMode)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		// Ошибки разметки возвращаются с кодом Telegram
+		respondError(c, err)
 		return
 	}
 
@@ -269,3 +275,202 @@ func (h *BotHandler) Webhook(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"ok": true})
 }
+
+// GetCommands возвращает все списки команд бота с их областями видимости и языками
+func (h *BotHandler) GetCommands(c *gin.Context) {
+	id, err := ParseBotID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID бота"})
+		return
+	}
+
+	if _, err := h.botManager.GetBot(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Бот не найден"})
+		return
+	}
+
+	commands, err := h.botManager.GetAllCommands(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"commands": commands,
+	})
+}
+
+// GetCommandMenu возвращает меню команд бота, которое видит пользователь user_id в чате chat_id
+// с языком интерфейса language_code
+func (h *BotHandler) GetCommandMenu(c *gin.Context) {
+	id, err := ParseBotID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID бота"})
+		return
+	}
+
+	chatID, err := ParseChatID(c.Query("chat_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID чата"})
+		return
+	}
+
+	var userID int64
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		userID, err = ParseUserID(userIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID пользователя"})
+			return
+		}
+	}
+
+	if _, err := h.botManager.GetBot(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Бот не найден"})
+		return
+	}
+
+	commands, err := h.botManager.GetCommandMenu(id, chatID, userID, c.Query("language_code"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"commands": commands,
+	})
+}
+
+// GetStubRules возвращает правила бота-заглушки
+func (h *BotHandler) GetStubRules(c *gin.Context) {
+	id, err := ParseBotID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID бота"})
+		return
+	}
+
+	rules, err := h.botManager.GetStubRules(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Бот не найден"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules": stubRuleInfos(rules),
+	})
+}
+
+// AddStubRule добавляет правило бота-заглушки
+func (h *BotHandler) AddStubRule(c *gin.Context) {
+	id, err := ParseBotID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID бота"})
+		return
+	}
+
+	var config models.StubRuleConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := config.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.botManager.GetBot(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Бот не найден"})
+		return
+	}
+
+	rule, err := h.botManager.AddStubRule(id, config)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"rule": rule.ToStubRuleInfo(),
+	})
+}
+
+// SetStubRules заменяет все правила бота-заглушки.
+// Принимает JSON массив или YAML список (Content-Type: application/yaml)
+func (h *BotHandler) SetStubRules(c *gin.Context) {
+	id, err := ParseBotID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID бота"})
+		return
+	}
+
+	var configs []models.StubRuleConfig
+	if strings.Contains(c.ContentType(), "yaml") {
+		body, err := io.ReadAll(c.Request.Body)
+		if err == nil {
+			err = yaml.Unmarshal(body, &configs)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else if err := c.ShouldBindJSON(&configs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for i := range configs {
+		if err := configs[i].Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("правило %d: %s", i+1, err)})
+			return
+		}
+	}
+
+	if _, err := h.botManager.GetBot(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Бот не найден"})
+		return
+	}
+
+	rules, err := h.botManager.SetStubRules(id, configs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules": stubRuleInfos(rules),
+	})
+}
+
+// DeleteStubRule удаляет правило бота-заглушки
+func (h *BotHandler) DeleteStubRule(c *gin.Context) {
+	id, err := ParseBotID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID бота"})
+		return
+	}
+
+	ruleID, err := strconv.ParseInt(c.Param("ruleID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID правила"})
+		return
+	}
+
+	if err := h.botManager.DeleteStubRule(id, ruleID); err != nil {
+		if errors.Is(err, models.ErrStubRuleNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Правило не найдено"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Правило успешно удалено"})
+}
+
+// stubRuleInfos конвертирует правила бота-заглушки в представление для REST API
+func stubRuleInfos(rules []models.StubRule) []models.StubRuleInfo {
+	infos := make([]models.StubRuleInfo, 0, len(rules))
+	for i := range rules {
+		infos = append(infos, rules[i].ToStubRuleInfo())
+	}
+	return infos
+}
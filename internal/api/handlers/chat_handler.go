@@ -224,7 +224,8 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 	})
 }
 
-// AddMember добавляет участника в чат
+// AddMember добавляет участника в чат. Необязательный from_user_id - пользователь, который добавляет участника,
+// без него участник вступает в чат сам
 func (h *ChatHandler) AddMember(c *gin.Context) {
 	idStr := c.Param("id")
 	if idStr == "" {
@@ -239,15 +240,16 @@ func (h *ChatHandler) AddMember(c *gin.Context) {
 	}
 
 	var req struct {
-		UserID int64 `json:"user_id" binding:"required"`
+		UserID     int64 `json:"user_id" binding:"required"`
+		FromUserID int64 `json:"from_user_id"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.chatManager.AddMember(chatID, req.UserID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.chatManager.AddMember(chatID, req.UserID, req.FromUserID); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -276,13 +278,22 @@ func (h *ChatHandler) GetMembers(c *gin.Context) {
 		return
 	}
 
+	// Статусы и права участников
+	chatMembers, err := h.chatManager.GetMemberRecords(chatID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"members": members,
-		"count":   len(members),
+		"members":      members,
+		"chat_members": chatMembers,
+		"count":        len(members),
 	})
 }
 
-// RemoveMember удаляет участника из чата
+// RemoveMember удаляет участника из чата. Необязательный параметр from_user_id - пользователь, который удаляет участника,
+// без него участник выходит из чата сам
 func (h *ChatHandler) RemoveMember(c *gin.Context) {
 	idStr := c.Param("id")
 	if idStr == "" {
@@ -308,8 +319,17 @@ func (h *ChatHandler) RemoveMember(c *gin.Context) {
 		return
 	}
 
-	if err := h.chatManager.RemoveMember(chatID, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var fromUserID int64
+	if fromUserIDStr := c.Query("from_user_id"); fromUserIDStr != "" {
+		fromUserID, err = ParseUserID(fromUserIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID пользователя"})
+			return
+		}
+	}
+
+	if err := h.chatManager.RemoveMember(chatID, userID, fromUserID); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -317,3 +337,107 @@ func (h *ChatHandler) RemoveMember(c *gin.Context) {
 		"message": "Участник успешно удален",
 	})
 }
+
+// PromoteMember назначает участника группы администратором.
+// Без указанных прав выдаются все права, кроме can_promote_members
+func (h *ChatHandler) PromoteMember(c *gin.Context) {
+	chatID, err := ParseChatID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID чата"})
+		return
+	}
+
+	userID, err := ParseUserID(c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID пользователя"})
+		return
+	}
+
+	var rights models.ChatAdministratorRights
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&rights); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if rights.IsEmpty() {
+		rights = models.FullAdministratorRights()
+		rights.CanPromoteMembers = false
+	}
+
+	chatMember, err := h.chatManager.PromoteMember(chatID, userID, rights)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"chat_member": chatMember,
+	})
+}
+
+// DemoteMember снимает с участника группы права администратора
+func (h *ChatHandler) DemoteMember(c *gin.Context) {
+	chatID, err := ParseChatID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID чата"})
+		return
+	}
+
+	userID, err := ParseUserID(c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID пользователя"})
+		return
+	}
+
+	chatMember, err := h.chatManager.DemoteMember(chatID, userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"chat_member": chatMember,
+	})
+}
+
+// BlockBot блокирует бота пользователем в их приватном чате
+func (h *ChatHandler) BlockBot(c *gin.Context) {
+	h.setBotBlocked(c, true)
+}
+
+// UnblockBot снимает блокировку бота пользователем в их приватном чате
+func (h *ChatHandler) UnblockBot(c *gin.Context) {
+	h.setBotBlocked(c, false)
+}
+
+// setBotBlocked блокирует или разблокирует бота пользователем user_id из тела запроса
+func (h *ChatHandler) setBotBlocked(c *gin.Context, blocked bool) {
+	chatID, err := ParseChatID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID чата"})
+		return
+	}
+
+	var req struct {
+		UserID int64 `json:"user_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if blocked {
+		err = h.chatManager.BlockBot(chatID, req.UserID)
+	} else {
+		err = h.chatManager.UnblockBot(chatID, req.UserID)
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"blocked": blocked,
+	})
+}
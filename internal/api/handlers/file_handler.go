@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"telegram-emulator/internal/emulator"
+	"telegram-emulator/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FileHandler обрабатывает запросы к API файлов
+type FileHandler struct {
+	fileManager *emulator.FileManager
+}
+
+// NewFileHandler создает новый экземпляр FileHandler
+func NewFileHandler(fileManager *emulator.FileManager) *FileHandler {
+	return &FileHandler{
+		fileManager: fileManager,
+	}
+}
+
+// Upload загружает файл, который затем можно отправить в чат по file_id
+func (h *FileHandler) Upload(c *gin.Context) {
+	messageType := c.DefaultPostForm("type", models.MessageTypeFile)
+	fileType, ok := models.FileTypeForMessage(messageType)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный тип файла"})
+		return
+	}
+
+	input, err := readUploadedFile(c, "file")
+	if err != nil {
+		if errors.Is(err, http.ErrMissingFile) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Файл обязателен"})
+			return
+		}
+		respondError(c, err)
+		return
+	}
+
+	media, err := h.fileManager.BuildMedia(fileType, input, emulator.MediaAttributes{})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"file_id": media.FileID(),
+		"type":    models.MessageTypeForFile(fileType),
+		"media":   media,
+	})
+}
+
+// Download отдает содержимое файла по file_id
+func (h *FileHandler) Download(c *gin.Context) {
+	file, fullPath, err := h.fileManager.GetFileContent(c.Param("fileID"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Middleware Bot API выставляет JSON, поэтому тип содержимого задаем явно
+	mimeType := file.MimeType
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	c.Header("Content-Type", mimeType)
+	c.File(fullPath)
+}
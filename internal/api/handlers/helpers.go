@@ -1,9 +1,20 @@
 package handlers
 
 import (
+	"errors"
+	"io"
+	"net/http"
 	"strconv"
+
+	"telegram-emulator/internal/emulator"
+	"telegram-emulator/internal/models"
+
+	"github.com/gin-gonic/gin"
 )
 
+// maxUploadSize ограничивает размер файла, загружаемого через веб-интерфейс
+const maxUploadSize = 50 << 20
+
 // ParseBotID парсит ID бота из строки в int64
 func ParseBotID(id string) (int64, error) {
 	return strconv.ParseInt(id, 10, 64)
@@ -23,3 +34,42 @@ func ParseMessageID(id string) (int64, error) {
 func ParseUserID(id string) (int64, error) {
 	return strconv.ParseInt(id, 10, 64)
 }
+
+// respondError отправляет ошибку. Ошибки models.TelegramError возвращаются с их кодом
+func respondError(c *gin.Context, err error) {
+	var telegramErr *models.TelegramError
+	if errors.As(err, &telegramErr) {
+		c.JSON(telegramErr.ErrorCode, gin.H{"error": telegramErr.Description})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// readUploadedFile читает файл из части field запроса multipart/form-data
+func readUploadedFile(c *gin.Context, field string) (*emulator.InputFile, error) {
+	header, err := c.FormFile(field)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.Size > maxUploadSize {
+		return nil, models.ErrRequestEntityTooLarge
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return &emulator.InputFile{
+		FileName: header.Filename,
+		MimeType: header.Header.Get("Content-Type"),
+		Data:     data,
+	}, nil
+}
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"telegram-emulator/internal/emulator"
+	"telegram-emulator/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,11 +24,15 @@ func NewMessageHandler(messageManager *emulator.MessageManager) *MessageHandler
 	}
 }
 
-// SendMessageRequest представляет запрос на отправку сообщения
+// SendMessageRequest представляет запрос на отправку сообщения.
+// Сообщение с вложением ссылается на файл, загруженный через /api/files, или передает его в multipart/form-data
 type SendMessageRequest struct {
-	FromUserID int64  `json:"from_user_id" binding:"required"`
-	Text       string `json:"text" binding:"required"`
-	Type       string `json:"type"` // text, file, voice, photo
+	FromUserID int64  `json:"from_user_id" form:"from_user_id" binding:"required"`
+	Text       string `json:"text" form:"text"`
+	Type       string `json:"type" form:"type"`       // text, file, voice, photo, audio, video
+	FileID     string `json:"file_id" form:"file_id"` // Файл, ранее загруженный через /api/files
+
+	ReplyToMessageID int64 `json:"reply_to_message_id" form:"reply_to_message_id"` // Сообщение, на которое отвечает пользователь
 }
 
 // UpdateMessageStatusRequest представляет запрос на обновление статуса сообщения
@@ -73,19 +80,53 @@ func (h *MessageHandler) SendMessage(c *gin.Context) {
 	}
 
 	var req SendMessageRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := c.ShouldBind(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Файл, загруженный вместе с сообщением
+	var upload *emulator.InputFile
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		upload, err = readUploadedFile(c, "file")
+		if err != nil && !errors.Is(err, http.ErrMissingFile) {
+			respondError(c, err)
+			return
+		}
+	}
+
 	// Устанавливаем тип сообщения по умолчанию
 	if req.Type == "" {
 		req.Type = "text"
+		if upload != nil || req.FileID != "" {
+			req.Type = models.MessageTypeFile
+		}
+	}
+
+	var message *models.Message
+	if upload != nil || req.FileID != "" {
+		fileType, ok := models.FileTypeForMessage(req.Type)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Тип сообщения не поддерживает вложения"})
+			return
+		}
+		if upload == nil {
+			upload = &emulator.InputFile{FileID: req.FileID}
+		}
+		message, err = h.messageManager.SendMedia(chatID, req.FromUserID, fileType, upload, req.Text, req.ReplyToMessageID)
+	} else {
+		if req.Text == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Текст сообщения обязателен"})
+			return
+		}
+		message, err = h.messageManager.SendContent(chatID, req.FromUserID, emulator.MessageContent{
+			Type:             req.Type,
+			Text:             req.Text,
+			ReplyToMessageID: req.ReplyToMessageID,
+		})
 	}
-
-	message, err := h.messageManager.SendMessage(chatID, req.FromUserID, req.Text, req.Type, nil)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -293,3 +334,62 @@ func (h *MessageHandler) HandleCallbackQuery(c *gin.Context) {
 		"callback_query": callbackQuery,
 	})
 }
+
+// SendChatActionRequest представляет запрос на отображение действия пользователя в чате
+type SendChatActionRequest struct {
+	UserID int64  `json:"user_id" binding:"required"`
+	Action string `json:"action"` // typing, upload_photo, record_voice и другие действия Telegram, по умолчанию typing
+}
+
+// GetChatActions возвращает действия участников, которые сейчас отображаются в чате
+func (h *MessageHandler) GetChatActions(c *gin.Context) {
+	idStr := c.Param("id")
+	if idStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID чата обязателен"})
+		return
+	}
+
+	chatID, err := ParseChatID(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID чата"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"actions": h.messageManager.GetChatActions(chatID),
+	})
+}
+
+// SendChatAction отображает действие пользователя в чате, как событие typing из WebSocket
+func (h *MessageHandler) SendChatAction(c *gin.Context) {
+	idStr := c.Param("id")
+	if idStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID чата обязателен"})
+		return
+	}
+
+	chatID, err := ParseChatID(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID чата"})
+		return
+	}
+
+	var req SendChatActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Action == "" {
+		req.Action = models.ChatActionTyping
+	}
+
+	if err := h.messageManager.SendChatAction(chatID, req.UserID, req.Action); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"actions": h.messageManager.GetChatActions(chatID),
+	})
+}
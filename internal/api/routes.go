@@ -9,9 +9,9 @@ import (
 )
 
 // SetupRoutes настраивает маршруты API
-func SetupRoutes(router *gin.Engine, userManager *emulator.UserManager, chatManager *emulator.ChatManager, messageManager *emulator.MessageManager, botManager *emulator.BotManager, wsServer *websocket.Server) {
+func SetupRoutes(router *gin.Engine, userManager *emulator.UserManager, chatManager *emulator.ChatManager, messageManager *emulator.MessageManager, botManager *emulator.BotManager, fileManager *emulator.FileManager, wsServer *websocket.Server) {
 	// Telegram Bot API
-	telegramAPI := NewTelegramBotAPI(botManager, userManager, chatManager, messageManager)
+	telegramAPI := NewTelegramBotAPI(botManager, userManager, chatManager, messageManager, fileManager)
 	telegramAPI.SetupTelegramBotRoutes(router)
 	// API группа
 	api := router.Group("/api")
@@ -40,6 +40,10 @@ func SetupRoutes(router *gin.Engine, userManager *emulator.UserManager, chatMana
 			chats.GET("/:id/members", chatHandler.GetMembers)
 			chats.POST("/:id/members", chatHandler.AddMember)
 			chats.DELETE("/:id/members/:userID", chatHandler.RemoveMember)
+			chats.POST("/:id/members/:userID/promote", chatHandler.PromoteMember)
+			chats.POST("/:id/members/:userID/demote", chatHandler.DemoteMember)
+			chats.POST("/:id/block", chatHandler.BlockBot)
+			chats.POST("/:id/unblock", chatHandler.UnblockBot)
 		}
 
 		// Сообщения
@@ -52,12 +56,22 @@ func SetupRoutes(router *gin.Engine, userManager *emulator.UserManager, chatMana
 			messages.POST("/:id/callback", messageHandler.HandleCallbackQuery)
 		}
 
+		// Файлы
+		files := api.Group("/files")
+		{
+			fileHandler := handlers.NewFileHandler(fileManager)
+			files.POST("", fileHandler.Upload)
+			files.GET("/:fileID", fileHandler.Download)
+		}
+
 		// Сообщения чатов
 		messageHandler := handlers.NewMessageHandler(messageManager)
 		chats.GET("/:id/messages", messageHandler.GetChatMessages)
 		chats.POST("/:id/messages", messageHandler.SendMessage)
 		chats.PUT("/:id/read", messageHandler.MarkChatAsRead)
 		chats.GET("/:id/search", messageHandler.SearchMessages)
+		chats.GET("/:id/actions", messageHandler.GetChatActions)
+		chats.POST("/:id/actions", messageHandler.SendChatAction)
 	}
 
 	// Боты
@@ -72,6 +86,12 @@ func SetupRoutes(router *gin.Engine, userManager *emulator.UserManager, chatMana
 		bots.POST("/:id/sendMessage", botHandler.SendMessage)
 		bots.GET("/:id/updates", botHandler.GetUpdates)
 		bots.POST("/:id/webhook", botHandler.Webhook)
+		bots.GET("/:id/commands", botHandler.GetCommands)
+		bots.GET("/:id/commands/menu", botHandler.GetCommandMenu)
+		bots.GET("/:id/stub-rules", botHandler.GetStubRules)
+		bots.POST("/:id/stub-rules", botHandler.AddStubRule)
+		bots.PUT("/:id/stub-rules", botHandler.SetStubRules)
+		bots.DELETE("/:id/stub-rules/:ruleID", botHandler.DeleteStubRule)
 	}
 
 	// WebSocket endpoint
@@ -2,9 +2,11 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -22,16 +24,18 @@ type TelegramBotAPI struct {
 	userManager    *emulator.UserManager
 	chatManager    *emulator.ChatManager
 	messageManager *emulator.MessageManager
+	fileManager    *emulator.FileManager
 	logger         *zap.Logger
 }
 
 // NewTelegramBotAPI создает новый экземпляр TelegramBotAPI
-func NewTelegramBotAPI(botManager *emulator.BotManager, userManager *emulator.UserManager, chatManager *emulator.ChatManager, messageManager *emulator.MessageManager) *TelegramBotAPI {
+func NewTelegramBotAPI(botManager *emulator.BotManager, userManager *emulator.UserManager, chatManager *emulator.ChatManager, messageManager *emulator.MessageManager, fileManager *emulator.FileManager) *TelegramBotAPI {
 	return &TelegramBotAPI{
 		botManager:     botManager,
 		userManager:    userManager,
 		chatManager:    chatManager,
 		messageManager: messageManager,
+		fileManager:    fileManager,
 		logger:         botManager.GetLogger(),
 	}
 }
@@ -59,6 +63,15 @@ func (api *TelegramBotAPI) SetupTelegramBotRoutes(router *gin.Engine) {
 	router.GET("/bot:token/getUpdates", api.GetUpdates)
 	router.POST("/bot:token/getUpdates", api.GetUpdates)
 	router.POST("/bot:token/sendMessage", api.SendMessage)
+	router.POST("/bot:token/sendPhoto", api.SendPhoto)
+	router.POST("/bot:token/sendDocument", api.SendDocument)
+	router.POST("/bot:token/sendAudio", api.SendAudio)
+	router.POST("/bot:token/sendVoice", api.SendVoice)
+	router.POST("/bot:token/sendVideo", api.SendVideo)
+	router.GET("/bot:token/sendChatAction", api.SendChatAction)
+	router.POST("/bot:token/sendChatAction", api.SendChatAction)
+	router.GET("/bot:token/getFile", api.GetFile)
+	router.POST("/bot:token/getFile", api.GetFile)
 	router.GET("/bot:token/setWebhook", api.SetWebhook)
 	router.POST("/bot:token/setWebhook", api.SetWebhook)
 	router.GET("/bot:token/deleteWebhook", api.DeleteWebhook)
@@ -69,6 +82,37 @@ func (api *TelegramBotAPI) SetupTelegramBotRoutes(router *gin.Engine) {
 	router.POST("/bot:token/answerCallbackQuery", api.AnswerCallbackQuery)
 	router.POST("/bot:token/editMessageText", api.EditMessageText)
 	router.POST("/bot:token/editMessageReplyMarkup", api.EditMessageReplyMarkup)
+	router.POST("/bot:token/deleteMessage", api.DeleteMessage)
+	router.POST("/bot:token/forwardMessage", api.ForwardMessage)
+	router.POST("/bot:token/copyMessage", api.CopyMessage)
+	router.GET("/bot:token/setMyCommands", api.SetMyCommands)
+	router.POST("/bot:token/setMyCommands", api.SetMyCommands)
+	router.GET("/bot:token/getMyCommands", api.GetMyCommands)
+	router.POST("/bot:token/getMyCommands", api.GetMyCommands)
+	router.GET("/bot:token/deleteMyCommands", api.DeleteMyCommands)
+	router.POST("/bot:token/deleteMyCommands", api.DeleteMyCommands)
+	router.GET("/bot:token/getChat", api.GetChat)
+	router.POST("/bot:token/getChat", api.GetChat)
+	router.GET("/bot:token/getChatMember", api.GetChatMember)
+	router.POST("/bot:token/getChatMember", api.GetChatMember)
+	router.GET("/bot:token/getChatAdministrators", api.GetChatAdministrators)
+	router.POST("/bot:token/getChatAdministrators", api.GetChatAdministrators)
+	router.GET("/bot:token/getChatMemberCount", api.GetChatMemberCount)
+	router.POST("/bot:token/getChatMemberCount", api.GetChatMemberCount)
+	router.GET("/bot:token/getChatMembersCount", api.GetChatMemberCount)
+	router.POST("/bot:token/getChatMembersCount", api.GetChatMemberCount)
+	router.GET("/bot:token/banChatMember", api.BanChatMember)
+	router.POST("/bot:token/banChatMember", api.BanChatMember)
+	router.GET("/bot:token/kickChatMember", api.BanChatMember)
+	router.POST("/bot:token/kickChatMember", api.BanChatMember)
+	router.GET("/bot:token/unbanChatMember", api.UnbanChatMember)
+	router.POST("/bot:token/unbanChatMember", api.UnbanChatMember)
+	router.GET("/bot:token/restrictChatMember", api.RestrictChatMember)
+	router.POST("/bot:token/restrictChatMember", api.RestrictChatMember)
+	router.GET("/bot:token/promoteChatMember", api.PromoteChatMember)
+	router.POST("/bot:token/promoteChatMember", api.PromoteChatMember)
+	router.GET("/bot:token/setChatAdministratorCustomTitle", api.SetChatAdministratorCustomTitle)
+	router.POST("/bot:token/setChatAdministratorCustomTitle", api.SetChatAdministratorCustomTitle)
 
 	// Формат 2: /bot/<token>/method (со слешем) - для совместимости с python-telegram-bot
 	router.GET("/bot/:token2/getMe", api.GetMe)
@@ -76,6 +120,15 @@ func (api *TelegramBotAPI) SetupTelegramBotRoutes(router *gin.Engine) {
 	router.GET("/bot/:token2/getUpdates", api.GetUpdates)
 	router.POST("/bot/:token2/getUpdates", api.GetUpdates)
 	router.POST("/bot/:token2/sendMessage", api.SendMessage)
+	router.POST("/bot/:token2/sendPhoto", api.SendPhoto)
+	router.POST("/bot/:token2/sendDocument", api.SendDocument)
+	router.POST("/bot/:token2/sendAudio", api.SendAudio)
+	router.POST("/bot/:token2/sendVoice", api.SendVoice)
+	router.POST("/bot/:token2/sendVideo", api.SendVideo)
+	router.GET("/bot/:token2/sendChatAction", api.SendChatAction)
+	router.POST("/bot/:token2/sendChatAction", api.SendChatAction)
+	router.GET("/bot/:token2/getFile", api.GetFile)
+	router.POST("/bot/:token2/getFile", api.GetFile)
 	router.GET("/bot/:token2/setWebhook", api.SetWebhook)
 	router.POST("/bot/:token2/setWebhook", api.SetWebhook)
 	router.GET("/bot/:token2/deleteWebhook", api.DeleteWebhook)
@@ -86,6 +139,41 @@ func (api *TelegramBotAPI) SetupTelegramBotRoutes(router *gin.Engine) {
 	router.POST("/bot/:token2/answerCallbackQuery", api.AnswerCallbackQuery)
 	router.POST("/bot/:token2/editMessageText", api.EditMessageText)
 	router.POST("/bot/:token2/editMessageReplyMarkup", api.EditMessageReplyMarkup)
+	router.POST("/bot/:token2/deleteMessage", api.DeleteMessage)
+	router.POST("/bot/:token2/forwardMessage", api.ForwardMessage)
+	router.POST("/bot/:token2/copyMessage", api.CopyMessage)
+	router.GET("/bot/:token2/setMyCommands", api.SetMyCommands)
+	router.POST("/bot/:token2/setMyCommands", api.SetMyCommands)
+	router.GET("/bot/:token2/getMyCommands", api.GetMyCommands)
+	router.POST("/bot/:token2/getMyCommands", api.GetMyCommands)
+	router.GET("/bot/:token2/deleteMyCommands", api.DeleteMyCommands)
+	router.POST("/bot/:token2/deleteMyCommands", api.DeleteMyCommands)
+	router.GET("/bot/:token2/getChat", api.GetChat)
+	router.POST("/bot/:token2/getChat", api.GetChat)
+	router.GET("/bot/:token2/getChatMember", api.GetChatMember)
+	router.POST("/bot/:token2/getChatMember", api.GetChatMember)
+	router.GET("/bot/:token2/getChatAdministrators", api.GetChatAdministrators)
+	router.POST("/bot/:token2/getChatAdministrators", api.GetChatAdministrators)
+	router.GET("/bot/:token2/getChatMemberCount", api.GetChatMemberCount)
+	router.POST("/bot/:token2/getChatMemberCount", api.GetChatMemberCount)
+	router.GET("/bot/:token2/getChatMembersCount", api.GetChatMemberCount)
+	router.POST("/bot/:token2/getChatMembersCount", api.GetChatMemberCount)
+	router.GET("/bot/:token2/banChatMember", api.BanChatMember)
+	router.POST("/bot/:token2/banChatMember", api.BanChatMember)
+	router.GET("/bot/:token2/kickChatMember", api.BanChatMember)
+	router.POST("/bot/:token2/kickChatMember", api.BanChatMember)
+	router.GET("/bot/:token2/unbanChatMember", api.UnbanChatMember)
+	router.POST("/bot/:token2/unbanChatMember", api.UnbanChatMember)
+	router.GET("/bot/:token2/restrictChatMember", api.RestrictChatMember)
+	router.POST("/bot/:token2/restrictChatMember", api.RestrictChatMember)
+	router.GET("/bot/:token2/promoteChatMember", api.PromoteChatMember)
+	router.POST("/bot/:token2/promoteChatMember", api.PromoteChatMember)
+	router.GET("/bot/:token2/setChatAdministratorCustomTitle", api.SetChatAdministratorCustomTitle)
+	router.POST("/bot/:token2/setChatAdministratorCustomTitle", api.SetChatAdministratorCustomTitle)
+
+	// Скачивание файлов: /file/bot<token>/<file_path> и /file/bot/<token>/<file_path>
+	router.GET("/file/bot:token/*filePath", api.DownloadFile)
+	router.GET("/file/bot/:token2/*filePath", api.DownloadFile)
 }
 
 // GetMe возвращает информацию о боте
@@ -134,9 +222,10 @@ func (api *TelegramBotAPI) GetUpdates(c *gin.Context) {
 
 	// Получаем параметры запроса из query, form (POST) или JSON
 	var params struct {
-		Offset  int `form:"offset" json:"offset"`
-		Limit   int `form:"limit" json:"limit"`
-		Timeout int `form:"timeout" json:"timeout"`
+		Offset         int      `form:"offset" json:"offset"`
+		Limit          int      `form:"limit" json:"limit"`
+		Timeout        int      `form:"timeout" json:"timeout"`
+		AllowedUpdates []string `form:"allowed_updates" json:"allowed_updates"`
 	}
 	// Значения по умолчанию
 	params.Limit = 100
@@ -159,59 +248,44 @@ func (api *TelegramBotAPI) GetUpdates(c *gin.Context) {
 		timeout = 50
 	}
 
-	// Получаем обновления
-	updates, err := api.botManager.GetBotUpdates(bot.ID, offset, limit)
+	// allowed_updates сохраняется для бота и действует до следующего явного изменения
+	if params.AllowedUpdates != nil {
+		allowedUpdates, err := parseAllowedUpdates(params.AllowedUpdates)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: can't parse allowed_updates JSON array"})
+			return
+		}
+		if err := api.botManager.SetAllowedUpdates(bot.ID, allowedUpdates); err != nil {
+			api.respondError(c, err)
+			return
+		}
+	}
+
+	// Получаем обновления; при указанном timeout ждем их появления (long polling)
+	startTime := time.Now()
+	updates, err := api.botManager.WaitForUpdates(c.Request.Context(), bot.ID, offset, limit, time.Duration(timeout)*time.Second)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": 500, "description": "Internal Server Error"})
+		if c.Request.Context().Err() != nil {
+			// Клиент отключился, отвечать некому
+			api.logger.Debug("Long polling: запрос отменен клиентом", zap.Int64("bot_id", bot.ID))
+			c.Abort()
+			return
+		}
+		api.respondError(c, err)
 		return
 	}
 
-	// Если нет обновлений и указан timeout, ждем новые обновления
-	if len(updates) == 0 && timeout > 0 {
-		api.logger.Info("Long polling: ожидаем новые обновления",
+	if timeout > 0 && len(updates) > 0 {
+		api.logger.Info("Long polling: получены новые обновления",
 			zap.Int64("bot_id", bot.ID),
-			zap.Int("timeout", timeout))
-
-		// Ждем новые обновления в течение timeout секунд
-		startTime := time.Now()
-		for time.Since(startTime) < time.Duration(timeout)*time.Second {
-			// Проверяем новые обновления каждые 1 секунду (увеличено с 100ms для снижения нагрузки на БД)
-			time.Sleep(1 * time.Second)
-
-			updates, err = api.botManager.GetBotUpdates(bot.ID, offset, limit)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": 500, "description": "Internal Server Error"})
-				return
-			}
-
-			if len(updates) > 0 {
-				api.logger.Info("Long polling: получены новые обновления",
-					zap.Int64("bot_id", bot.ID),
-					zap.Int("count", len(updates)),
-					zap.Duration("wait_time", time.Since(startTime)))
-				break
-			}
-		}
+			zap.Int("count", len(updates)),
+			zap.Duration("wait_time", time.Since(startTime)))
 	}
 
 	// Конвертируем в формат Telegram Bot API
-	telegramUpdates := make([]gin.H, 0)
-	for _, update := range updates {
-		telegramUpdate := gin.H{
-			"update_id": update.UpdateID,
-		}
-
-		if update.Message != nil {
-			telegramUpdate["message"] = update.Message.ToTelegramMessage()
-		}
-		if update.EditedMessage != nil {
-			telegramUpdate["edited_message"] = update.EditedMessage.ToTelegramMessage()
-		}
-		if update.CallbackQuery != nil {
-			telegramUpdate["callback_query"] = update.CallbackQuery.ToTelegramCallbackQuery()
-		}
-
-		telegramUpdates = append(telegramUpdates, telegramUpdate)
+	telegramUpdates := make([]map[string]interface{}, 0, len(updates))
+	for i := range updates {
+		telegramUpdates = append(telegramUpdates, updates[i].ToTelegramUpdate(bot.ID))
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -321,15 +395,22 @@ func (api *TelegramBotAPI) SendMessage(c *gin.Context) {
 	}
 
 	// Отправляем сообщение через обычный API с клавиатурой
-	message, err := api.messageManager.SendMessage(chatID, botUser.ID, request.Text, "text", request.ReplyMarkup)
+	message, err := api.messageManager.SendContent(chatID, botUser.ID, emulator.MessageContent{
+		Type:                     models.MessageTypeText,
+		Text:                     request.Text,
+		ParseMode:                request.ParseMode,
+		ReplyMarkup:              request.ReplyMarkup,
+		ReplyToMessageID:         request.ReplyToMessageID,
+		AllowSendingWithoutReply: request.AllowSendingWithoutReply,
+	})
 	if err != nil {
 		api.logger.Error("Ошибка отправки сообщения", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": 500, "description": "Failed to send message"})
+		api.respondError(c, err)
 		return
 	}
 
 	// Конвертируем в формат Telegram Bot API
-	telegramMessage := message.ToTelegramMessage()
+	telegramMessage := message.ToTelegramMessage(bot.ID)
 
 	api.logger.Info("Сообщение успешно отправлено",
 		zap.Int64("bot_id", bot.ID),
@@ -374,8 +455,39 @@ func (api *TelegramBotAPI) SetWebhook(c *gin.Context) {
 		return
 	}
 
-	// Обновляем webhook URL
+	if request.SecretToken != "" && !secretTokenRegex.MatchString(request.SecretToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: secret token contains unallowed characters"})
+		return
+	}
+
+	// allowed_updates меняются только если переданы явно
+	if request.AllowedUpdates != nil {
+		allowedUpdates, err := parseAllowedUpdates(request.AllowedUpdates)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: can't parse allowed_updates JSON array"})
+			return
+		}
+		if err := bot.SetAllowedUpdates(allowedUpdates); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": 500, "description": "Internal Server Error"})
+			return
+		}
+	}
+
+	if request.DropPendingUpdates {
+		if err := api.botManager.ClearUpdates(bot.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": 500, "description": "Internal Server Error"})
+			return
+		}
+	}
+
+	// Обновляем параметры webhook
 	bot.WebhookURL = request.URL
+	bot.WebhookSecretToken = request.SecretToken
+	bot.WebhookIPAddress = request.IPAddress
+	// Без max_connections действует значение по умолчанию, а не лимит прежнего webhook
+	bot.WebhookMaxConnections = request.MaxConnections
+	// Ошибки доставки прежнего webhook не относятся к новому
+	api.botManager.ResetWebhookStats(bot.ID)
 	if err := api.botManager.UpdateBot(bot); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": 500, "description": "Internal Server Error"})
 		return
@@ -402,8 +514,24 @@ func (api *TelegramBotAPI) DeleteWebhook(c *gin.Context) {
 		return
 	}
 
+	var request struct {
+		DropPendingUpdates bool `json:"drop_pending_updates" form:"drop_pending_updates"`
+	}
+	_ = c.ShouldBind(&request)
+
+	if request.DropPendingUpdates {
+		if err := api.botManager.ClearUpdates(bot.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": 500, "description": "Internal Server Error"})
+			return
+		}
+	}
+
 	// Удаляем webhook URL
 	bot.WebhookURL = ""
+	bot.WebhookSecretToken = ""
+	bot.WebhookIPAddress = ""
+	bot.WebhookMaxConnections = 0
+	api.botManager.ResetWebhookStats(bot.ID)
 	if err := api.botManager.UpdateBot(bot); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": 500, "description": "Internal Server Error"})
 		return
@@ -430,27 +558,10 @@ func (api *TelegramBotAPI) GetWebhookInfo(c *gin.Context) {
 		return
 	}
 
-	webhookInfo := gin.H{
-		"url": bot.WebhookURL,
-	}
-
-	if bot.WebhookURL == "" {
-		webhookInfo["url"] = ""
-		webhookInfo["has_custom_certificate"] = false
-		webhookInfo["pending_update_count"] = 0
-		webhookInfo["max_connections"] = 40
-		webhookInfo["last_error_date"] = nil
-		webhookInfo["last_error_message"] = nil
-		webhookInfo["last_synchronization_error_date"] = nil
-		webhookInfo["allowed_updates"] = []string{}
-	} else {
-		webhookInfo["has_custom_certificate"] = false
-		webhookInfo["pending_update_count"] = 0
-		webhookInfo["max_connections"] = 40
-		webhookInfo["last_error_date"] = nil
-		webhookInfo["last_error_message"] = nil
-		webhookInfo["last_synchronization_error_date"] = nil
-		webhookInfo["allowed_updates"] = []string{}
+	webhookInfo, err := api.botManager.GetWebhookInfo(bot.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": 500, "description": "Internal Server Error"})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -459,6 +570,41 @@ func (api *TelegramBotAPI) GetWebhookInfo(c *gin.Context) {
 	})
 }
 
+// respondError отправляет ошибку в формате Telegram Bot API.
+// Ошибки models.TelegramError передаются с их кодом и описанием, остальные считаются внутренними
+func (api *TelegramBotAPI) respondError(c *gin.Context, err error) {
+	var telegramErr *models.TelegramError
+	if errors.As(err, &telegramErr) {
+		c.JSON(telegramErr.ErrorCode, gin.H{"ok": false, "error_code": telegramErr.ErrorCode, "description": telegramErr.Description})
+		return
+	}
+
+	api.logger.Error("Внутренняя ошибка Telegram Bot API", zap.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": 500, "description": "Internal Server Error"})
+}
+
+// secretTokenRegex описывает допустимый secret_token webhook
+var secretTokenRegex = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// parseAllowedUpdates разбирает allowed_updates, переданный списком или JSON массивом в одном параметре
+func parseAllowedUpdates(values []string) ([]string, error) {
+	if len(values) == 1 && strings.HasPrefix(strings.TrimSpace(values[0]), "[") {
+		var allowedUpdates []string
+		if err := json.Unmarshal([]byte(values[0]), &allowedUpdates); err != nil {
+			return nil, err
+		}
+		return allowedUpdates, nil
+	}
+
+	allowedUpdates := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			allowedUpdates = append(allowedUpdates, value)
+		}
+	}
+	return allowedUpdates, nil
+}
+
 // extractTokenFromPath извлекает токен из пути /bot<token>/method или /bot/<token>/method
 func (api *TelegramBotAPI) extractTokenFromPath(c *gin.Context) string {
 	// Сначала пробуем получить токен из параметров Gin
@@ -558,13 +704,19 @@ func (api *TelegramBotAPI) AnswerCallbackQuery(c *gin.Context) {
 		return
 	}
 
-	// Логируем ответ на callback query
-	api.logger.Info("Ответ на callback query",
-		zap.Int64("bot_id", bot.ID),
-		zap.String("callback_query_id", request.CallbackQueryID),
-		zap.String("text", request.Text),
-		zap.Bool("show_alert", request.ShowAlert),
-		zap.String("url", request.URL))
+	answer := &models.CallbackQueryAnswer{
+		CallbackQueryID: request.CallbackQueryID,
+		Text:            request.Text,
+		ShowAlert:       request.ShowAlert,
+		URL:             request.URL,
+		CacheTime:       request.CacheTime,
+	}
+
+	// Проверяем callback query и доставляем ответ пользователю, нажавшему кнопку
+	if err := api.messageManager.AnswerCallbackQuery(bot.ID, answer); err != nil {
+		api.respondError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"ok":     true,
@@ -588,52 +740,50 @@ func (api *TelegramBotAPI) EditMessageReplyMarkup(c *gin.Context) {
 	}
 
 	var request struct {
-		ChatID      string      `json:"chat_id" binding:"required"`
-		MessageID   string      `json:"message_id" binding:"required"`
-		ReplyMarkup interface{} `json:"reply_markup,omitempty"`
+		ChatID            json.Number `json:"chat_id" form:"chat_id"`
+		MessageID         json.Number `json:"message_id" form:"message_id"`
+		InlineMessageID   string      `json:"inline_message_id" form:"inline_message_id"`
+		ReplyMarkup       interface{} `json:"reply_markup"`
+		ReplyMarkupString string      `form:"reply_markup"`
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
 		return
 	}
 
-	// Валидация reply_markup если он передан
-	if request.ReplyMarkup != nil {
-		if err := api.validateReplyMarkup(request.ReplyMarkup); err != nil {
-			api.logger.Error("Ошибка валидации reply_markup", zap.Error(err))
-			c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: invalid reply_markup format"})
-			return
-		}
+	chatID, messageID, ok := api.parseEditTarget(c, request.ChatID, request.MessageID, request.InlineMessageID)
+	if !ok {
+		return
 	}
 
-	// Логируем редактирование клавиатуры
-	api.logger.Info("Редактирование клавиатуры сообщения",
-		zap.Int64("bot_id", bot.ID),
-		zap.String("chat_id", request.ChatID),
-		zap.String("message_id", request.MessageID))
+	replyMarkup, ok := api.parseReplyMarkup(c, request.ReplyMarkup, request.ReplyMarkupString)
+	if !ok {
+		return
+	}
 
-	// Конвертируем строковый ID в числовой
-	botID := bot.ID
+	botUser, err := api.userManager.GetUserByUsername(bot.Username)
+	if err != nil {
+		api.logger.Error("Ошибка получения пользователя-бота", zap.String("bot_username", bot.Username), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": 500, "description": "Bot user not found"})
+		return
+	}
+
+	message, err := api.messageManager.EditMessageReplyMarkup(botUser.ID, chatID, messageID, replyMarkup)
+	if err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	api.logger.Info("Клавиатура сообщения отредактирована",
+		zap.Int64("bot_id", bot.ID),
+		zap.Int64("chat_id", chatID),
+		zap.Int64("message_id", messageID))
 
 	c.JSON(http.StatusOK, gin.H{
-		"ok": true,
-		"result": gin.H{
-			"message_id": request.MessageID,
-			"from": gin.H{
-				"id":         botID,
-				"is_bot":     true,
-				"first_name": bot.Name,
-				"username":   bot.Username,
-			},
-			"chat": gin.H{
-				"id":    request.ChatID,
-				"type":  "private",
-				"title": bot.Name,
-			},
-			"date":         time.Now().Unix(),
-			"reply_markup": request.ReplyMarkup,
-		},
+		"ok":     true,
+		"result": message.ToTelegramMessage(bot.ID),
 	})
 }
 
@@ -653,47 +803,108 @@ func (api *TelegramBotAPI) EditMessageText(c *gin.Context) {
 	}
 
 	var request struct {
-		ChatID      string      `json:"chat_id" binding:"required"`
-		MessageID   string      `json:"message_id" binding:"required"`
-		Text        string      `json:"text" binding:"required"`
-		ReplyMarkup interface{} `json:"reply_markup,omitempty"`
+		ChatID            json.Number `json:"chat_id" form:"chat_id"`
+		MessageID         json.Number `json:"message_id" form:"message_id"`
+		InlineMessageID   string      `json:"inline_message_id" form:"inline_message_id"`
+		Text              string      `json:"text" form:"text"`
+		ParseMode         string      `json:"parse_mode" form:"parse_mode"`
+		ReplyMarkup       interface{} `json:"reply_markup"`
+		ReplyMarkupString string      `form:"reply_markup"`
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
 		return
 	}
 
-	// Логируем редактирование сообщения
-	api.logger.Info("Редактирование сообщения",
-		zap.String("chat_id", request.ChatID),
-		zap.String("message_id", request.MessageID),
-		zap.String("text", request.Text))
+	chatID, messageID, ok := api.parseEditTarget(c, request.ChatID, request.MessageID, request.InlineMessageID)
+	if !ok {
+		return
+	}
 
-	// Конвертируем строковый ID в числовой
-	botID := bot.ID
+	replyMarkup, ok := api.parseReplyMarkup(c, request.ReplyMarkup, request.ReplyMarkupString)
+	if !ok {
+		return
+	}
+
+	botUser, err := api.userManager.GetUserByUsername(bot.Username)
+	if err != nil {
+		api.logger.Error("Ошибка получения пользователя-бота", zap.String("bot_username", bot.Username), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": 500, "description": "Bot user not found"})
+		return
+	}
+
+	message, err := api.messageManager.EditMessageText(botUser.ID, chatID, messageID, request.Text, request.ParseMode, replyMarkup)
+	if err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	api.logger.Info("Сообщение отредактировано",
+		zap.Int64("bot_id", bot.ID),
+		zap.Int64("chat_id", chatID),
+		zap.Int64("message_id", messageID))
 
 	c.JSON(http.StatusOK, gin.H{
-		"ok": true,
-		"result": gin.H{
-			"message_id": request.MessageID,
-			"from": gin.H{
-				"id":         botID,
-				"is_bot":     true,
-				"first_name": bot.Name,
-				"username":   bot.Username,
-			},
-			"chat": gin.H{
-				"id":    request.ChatID,
-				"type":  "private",
-				"title": bot.Name,
-			},
-			"date": time.Now().Unix(),
-			"text": request.Text,
-		},
+		"ok":     true,
+		"result": message.ToTelegramMessage(bot.ID),
 	})
 }
 
+// parseEditTarget разбирает chat_id и message_id редактируемого или удаляемого сообщения.
+// При ошибке отправляет ответ и возвращает false
+func (api *TelegramBotAPI) parseEditTarget(c *gin.Context, rawChatID, rawMessageID json.Number, inlineMessageID string) (int64, int64, bool) {
+	if inlineMessageID != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: MESSAGE_ID_INVALID"})
+		return 0, 0, false
+	}
+
+	if rawChatID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: chat_id is empty"})
+		return 0, 0, false
+	}
+	chatID, err := rawChatID.Int64()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: invalid chat_id format"})
+		return 0, 0, false
+	}
+
+	if rawMessageID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: message identifier is not specified"})
+		return 0, 0, false
+	}
+	messageID, err := rawMessageID.Int64()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: invalid message_id format"})
+		return 0, 0, false
+	}
+
+	return chatID, messageID, true
+}
+
+// parseReplyMarkup возвращает reply_markup, переданный объектом JSON или строкой form data, и валидирует его.
+// При ошибке отправляет ответ и возвращает false
+func (api *TelegramBotAPI) parseReplyMarkup(c *gin.Context, replyMarkup interface{}, replyMarkupString string) (interface{}, bool) {
+	if replyMarkup == nil && replyMarkupString != "" {
+		if err := json.Unmarshal([]byte(replyMarkupString), &replyMarkup); err != nil {
+			api.logger.Error("Ошибка парсинга reply_markup JSON", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: can't parse reply keyboard markup JSON object"})
+			return nil, false
+		}
+	}
+
+	if replyMarkup != nil {
+		if err := api.validateReplyMarkup(replyMarkup); err != nil {
+			api.logger.Error("Ошибка валидации reply_markup", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: invalid reply_markup format"})
+			return nil, false
+		}
+	}
+
+	return replyMarkup, true
+}
+
 // validateReplyMarkup валидирует формат reply_markup
 func (api *TelegramBotAPI) validateReplyMarkup(replyMarkup interface{}) error {
 	// Базовая валидация - проверяем, что это map
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"telegram-emulator/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SendChatAction показывает участникам чата действие бота (typing, upload_photo и т.д.) на 5 секунд
+// или до следующего сообщения бота
+func (api *TelegramBotAPI) SendChatAction(c *gin.Context) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: token is empty"})
+		return
+	}
+
+	// Находим бота по токену
+	bot, err := api.findBotByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		ChatID json.Number `json:"chat_id" form:"chat_id"`
+		Action string      `json:"action" form:"action"`
+	}
+
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
+		return
+	}
+
+	if request.ChatID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: chat_id is empty"})
+		return
+	}
+	chatID, err := request.ChatID.Int64()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: invalid chat_id format"})
+		return
+	}
+
+	action := strings.TrimSpace(request.Action)
+	if action == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: action is empty"})
+		return
+	}
+
+	botUser, err := api.userManager.GetUserByUsername(bot.Username)
+	if err != nil {
+		api.logger.Error("Ошибка получения пользователя-бота", zap.String("bot_username", bot.Username), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": 500, "description": "Bot user not found"})
+		return
+	}
+
+	if err := api.messageManager.SendBotChatAction(chatID, botUser.ID, action); err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":     true,
+		"result": true,
+	})
+}
+
+// GetChat возвращает полную информацию о чате, в котором состоит бот
+func (api *TelegramBotAPI) GetChat(c *gin.Context) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: token is empty"})
+		return
+	}
+
+	// Находим бота по токену
+	bot, err := api.findBotByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		ChatID json.Number `json:"chat_id" form:"chat_id"`
+	}
+
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
+		return
+	}
+
+	chat, botUser, ok := api.getBotChat(c, bot, request.ChatID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":     true,
+		"result": chat.ToTelegramChatFullInfo(botUser.ID),
+	})
+}
+
+// GetChatMember возвращает статус, права и ограничения пользователя в чате
+func (api *TelegramBotAPI) GetChatMember(c *gin.Context) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: token is empty"})
+		return
+	}
+
+	// Находим бота по токену
+	bot, err := api.findBotByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		ChatID json.Number `json:"chat_id" form:"chat_id"`
+		UserID json.Number `json:"user_id" form:"user_id"`
+	}
+
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
+		return
+	}
+
+	chat, botUser, userID, ok := api.getBotChatMember(c, bot, request.ChatID, request.UserID)
+	if !ok {
+		return
+	}
+
+	chatMember, err := api.chatManager.GetTelegramChatMember(chat.ID, userID, botUser.ID)
+	if err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":     true,
+		"result": chatMember,
+	})
+}
+
+// GetChatAdministrators возвращает создателя и администраторов группы
+func (api *TelegramBotAPI) GetChatAdministrators(c *gin.Context) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: token is empty"})
+		return
+	}
+
+	// Находим бота по токену
+	bot, err := api.findBotByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		ChatID json.Number `json:"chat_id" form:"chat_id"`
+	}
+
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
+		return
+	}
+
+	chat, botUser, ok := api.getBotChat(c, bot, request.ChatID)
+	if !ok {
+		return
+	}
+
+	administrators, err := api.chatManager.GetAdministrators(chat.ID, botUser.ID)
+	if err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":     true,
+		"result": administrators,
+	})
+}
+
+// GetChatMemberCount возвращает количество участников чата
+func (api *TelegramBotAPI) GetChatMemberCount(c *gin.Context) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: token is empty"})
+		return
+	}
+
+	// Находим бота по токену
+	bot, err := api.findBotByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		ChatID json.Number `json:"chat_id" form:"chat_id"`
+	}
+
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
+		return
+	}
+
+	chat, _, ok := api.getBotChat(c, bot, request.ChatID)
+	if !ok {
+		return
+	}
+
+	count, err := api.chatManager.GetMemberCount(chat.ID)
+	if err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":     true,
+		"result": count,
+	})
+}
+
+// getBotChat разбирает chat_id и возвращает чат, в котором состоит бот, вместе с пользователем-ботом.
+// При ошибке отправляет ответ и возвращает false
+func (api *TelegramBotAPI) getBotChat(c *gin.Context, bot *models.Bot, rawChatID json.Number) (*models.Chat, *models.User, bool) {
+	if rawChatID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: chat_id is empty"})
+		return nil, nil, false
+	}
+	chatID, err := rawChatID.Int64()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: invalid chat_id format"})
+		return nil, nil, false
+	}
+
+	botUser, err := api.userManager.GetUserByUsername(bot.Username)
+	if err != nil {
+		api.logger.Error("Ошибка получения пользователя-бота", zap.String("bot_username", bot.Username), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": 500, "description": "Bot user not found"})
+		return nil, nil, false
+	}
+
+	chat, err := api.chatManager.GetBotChat(chatID, botUser.ID)
+	if err != nil {
+		api.respondError(c, err)
+		return nil, nil, false
+	}
+
+	return chat, botUser, true
+}
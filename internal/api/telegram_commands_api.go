@@ -0,0 +1,198 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"telegram-emulator/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// botCommandScopeRequest представляет параметр scope методов команд бота.
+// chat_id и user_id принимаются как числом, так и строкой
+type botCommandScopeRequest struct {
+	Type   string      `json:"type"`
+	ChatID json.Number `json:"chat_id"`
+	UserID json.Number `json:"user_id"`
+}
+
+// SetMyCommands устанавливает список команд бота для области scope и языка language_code
+func (api *TelegramBotAPI) SetMyCommands(c *gin.Context) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: token is empty"})
+		return
+	}
+
+	// Находим бота по токену
+	bot, err := api.findBotByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		Commands       []models.BotCommand     `json:"commands" form:"-"`
+		CommandsString string                  `json:"-" form:"commands"`
+		Scope          *botCommandScopeRequest `json:"scope" form:"-"`
+		ScopeString    string                  `json:"-" form:"scope"`
+		LanguageCode   string                  `json:"language_code" form:"language_code"`
+	}
+
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
+		return
+	}
+
+	if request.Commands == nil {
+		if request.CommandsString == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: parameter \"commands\" is required"})
+			return
+		}
+		if err := json.Unmarshal([]byte(request.CommandsString), &request.Commands); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: can't parse commands JSON array"})
+			return
+		}
+	}
+
+	scope, ok := api.parseCommandScope(c, request.Scope, request.ScopeString)
+	if !ok {
+		return
+	}
+
+	if err := api.botManager.SetMyCommands(bot.ID, request.Commands, scope, request.LanguageCode); err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":     true,
+		"result": true,
+	})
+}
+
+// GetMyCommands возвращает список команд бота для области scope и языка language_code
+func (api *TelegramBotAPI) GetMyCommands(c *gin.Context) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: token is empty"})
+		return
+	}
+
+	// Находим бота по токену
+	bot, err := api.findBotByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		Scope        *botCommandScopeRequest `json:"scope" form:"-"`
+		ScopeString  string                  `json:"-" form:"scope"`
+		LanguageCode string                  `json:"language_code" form:"language_code"`
+	}
+
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
+		return
+	}
+
+	scope, ok := api.parseCommandScope(c, request.Scope, request.ScopeString)
+	if !ok {
+		return
+	}
+
+	commands, err := api.botManager.GetMyCommands(bot.ID, scope, request.LanguageCode)
+	if err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":     true,
+		"result": commands,
+	})
+}
+
+// DeleteMyCommands удаляет список команд бота для области scope и языка language_code
+func (api *TelegramBotAPI) DeleteMyCommands(c *gin.Context) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: token is empty"})
+		return
+	}
+
+	// Находим бота по токену
+	bot, err := api.findBotByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		Scope        *botCommandScopeRequest `json:"scope" form:"-"`
+		ScopeString  string                  `json:"-" form:"scope"`
+		LanguageCode string                  `json:"language_code" form:"language_code"`
+	}
+
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
+		return
+	}
+
+	scope, ok := api.parseCommandScope(c, request.Scope, request.ScopeString)
+	if !ok {
+		return
+	}
+
+	if err := api.botManager.DeleteMyCommands(bot.ID, scope, request.LanguageCode); err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":     true,
+		"result": true,
+	})
+}
+
+// parseCommandScope разбирает параметр scope из JSON объекта или строки формы.
+// Отсутствующий scope означает область default. При ошибке отправляет ответ и возвращает false
+func (api *TelegramBotAPI) parseCommandScope(c *gin.Context, scope *botCommandScopeRequest, scopeString string) (models.BotCommandScope, bool) {
+	if scope == nil && scopeString != "" {
+		scope = &botCommandScopeRequest{}
+		if err := json.Unmarshal([]byte(scopeString), scope); err != nil {
+			api.logger.Error("Ошибка парсинга scope JSON", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: can't parse BotCommandScope JSON object"})
+			return models.BotCommandScope{}, false
+		}
+	}
+	if scope == nil {
+		return models.BotCommandScope{Type: models.BotCommandScopeDefault}, true
+	}
+
+	result := models.BotCommandScope{Type: scope.Type}
+	if scope.ChatID != "" {
+		chatID, err := scope.ChatID.Int64()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: invalid chat_id format"})
+			return models.BotCommandScope{}, false
+		}
+		result.ChatID = chatID
+	}
+	if scope.UserID != "" {
+		userID, err := scope.UserID.Int64()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: invalid user_id format"})
+			return models.BotCommandScope{}, false
+		}
+		result.UserID = userID
+	}
+
+	return result, true
+}
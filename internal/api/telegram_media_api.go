@@ -0,0 +1,301 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"telegram-emulator/internal/emulator"
+	"telegram-emulator/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// attachPrefix обозначает ссылку на файл, загруженный в другой части multipart/form-data
+const attachPrefix = "attach://"
+
+// maxMultipartFileSize ограничивает размер файла, читаемого из multipart/form-data
+const maxMultipartFileSize = 50 << 20
+
+// SendPhoto отправляет фотографию
+func (api *TelegramBotAPI) SendPhoto(c *gin.Context) {
+	api.sendMedia(c, models.FileTypePhoto)
+}
+
+// SendDocument отправляет документ
+func (api *TelegramBotAPI) SendDocument(c *gin.Context) {
+	api.sendMedia(c, models.FileTypeDocument)
+}
+
+// SendAudio отправляет аудиофайл
+func (api *TelegramBotAPI) SendAudio(c *gin.Context) {
+	api.sendMedia(c, models.FileTypeAudio)
+}
+
+// SendVoice отправляет голосовое сообщение
+func (api *TelegramBotAPI) SendVoice(c *gin.Context) {
+	api.sendMedia(c, models.FileTypeVoice)
+}
+
+// SendVideo отправляет видео
+func (api *TelegramBotAPI) SendVideo(c *gin.Context) {
+	api.sendMedia(c, models.FileTypeVideo)
+}
+
+// sendMedia отправляет сообщение с вложением типа fileType.
+// Файл передается в одноименном параметре: загруженным через multipart/form-data, ссылкой attach://,
+// file_id ранее отправленного файла или HTTP URL
+func (api *TelegramBotAPI) sendMedia(c *gin.Context, fileType string) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: token is empty"})
+		return
+	}
+
+	// Находим бота по токену
+	bot, err := api.findBotByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		ChatID                   json.Number            `json:"chat_id" form:"chat_id"`
+		Photo                    string                 `json:"photo" form:"-"`
+		Document                 string                 `json:"document" form:"-"`
+		Audio                    string                 `json:"audio" form:"-"`
+		Voice                    string                 `json:"voice" form:"-"`
+		Video                    string                 `json:"video" form:"-"`
+		Caption                  string                 `json:"caption" form:"caption"`
+		ParseMode                string                 `json:"parse_mode" form:"parse_mode"`
+		CaptionEntities          []models.MessageEntity `json:"caption_entities" form:"-"`
+		CaptionEntitiesString    string                 `json:"-" form:"caption_entities"`
+		Duration                 int                    `json:"duration" form:"duration"`
+		Width                    int                    `json:"width" form:"width"`
+		Height                   int                    `json:"height" form:"height"`
+		Performer                string                 `json:"performer" form:"performer"`
+		Title                    string                 `json:"title" form:"title"`
+		ReplyMarkup              interface{}            `json:"reply_markup" form:"-"`
+		ReplyMarkupString        string                 `json:"-" form:"reply_markup"`
+		ReplyToMessageID         int64                  `json:"reply_to_message_id" form:"reply_to_message_id"`
+		AllowSendingWithoutReply bool                   `json:"allow_sending_without_reply" form:"allow_sending_without_reply"`
+	}
+
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST multipart, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
+		return
+	}
+
+	if request.ChatID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: chat_id is empty"})
+		return
+	}
+	chatID, err := request.ChatID.Int64()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: invalid chat_id format"})
+		return
+	}
+
+	// Параметр вложения может быть частью multipart/form-data с файлом,
+	// поэтому из формы он читается отдельно от биндинга
+	mediaValues := map[string]string{
+		models.FileTypePhoto:    request.Photo,
+		models.FileTypeDocument: request.Document,
+		models.FileTypeAudio:    request.Audio,
+		models.FileTypeVoice:    request.Voice,
+		models.FileTypeVideo:    request.Video,
+	}
+	mediaValue := mediaValues[fileType]
+	if mediaValue == "" {
+		mediaValue = c.Request.FormValue(fileType)
+	}
+	input, err := api.parseInputFile(c, fileType, mediaValue)
+	if err != nil {
+		api.respondError(c, err)
+		return
+	}
+	if input == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: there is no " + fileType + " in the request"})
+		return
+	}
+
+	if request.CaptionEntities == nil && request.CaptionEntitiesString != "" {
+		if err := json.Unmarshal([]byte(request.CaptionEntitiesString), &request.CaptionEntities); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: can't parse caption_entities JSON array"})
+			return
+		}
+	}
+
+	replyMarkup, ok := api.parseReplyMarkup(c, request.ReplyMarkup, request.ReplyMarkupString)
+	if !ok {
+		return
+	}
+
+	botUser, err := api.userManager.GetUserByUsername(bot.Username)
+	if err != nil {
+		api.logger.Error("Ошибка получения пользователя-бота", zap.String("bot_username", bot.Username), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": 500, "description": "Bot user not found"})
+		return
+	}
+
+	media, err := api.fileManager.BuildMedia(fileType, input, emulator.MediaAttributes{
+		Duration:  request.Duration,
+		Width:     request.Width,
+		Height:    request.Height,
+		Performer: request.Performer,
+		Title:     request.Title,
+	})
+	if err != nil {
+		api.logger.Warn("Ошибка обработки файла", zap.String("type", fileType), zap.Error(err))
+		api.respondError(c, err)
+		return
+	}
+
+	message, err := api.messageManager.SendContent(chatID, botUser.ID, emulator.MessageContent{
+		Type:                     models.MessageTypeForFile(fileType),
+		Text:                     request.Caption,
+		ParseMode:                request.ParseMode,
+		Entities:                 request.CaptionEntities,
+		Media:                    media,
+		ReplyMarkup:              replyMarkup,
+		ReplyToMessageID:         request.ReplyToMessageID,
+		AllowSendingWithoutReply: request.AllowSendingWithoutReply,
+	})
+	if err != nil {
+		api.logger.Error("Ошибка отправки сообщения с вложением", zap.Error(err))
+		// Загруженный файл не попал в сообщение и больше не нужен
+		api.fileManager.DiscardMedia(input, media)
+		api.respondError(c, err)
+		return
+	}
+
+	api.logger.Info("Сообщение с вложением отправлено",
+		zap.Int64("bot_id", bot.ID),
+		zap.Int64("chat_id", chatID),
+		zap.Int64("message_id", message.ID),
+		zap.String("type", fileType))
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":     true,
+		"result": message.ToTelegramMessage(bot.ID),
+	})
+}
+
+// parseInputFile возвращает файл из параметра name: загруженную часть multipart/form-data,
+// ссылку attach:// на другую часть, file_id или HTTP URL. Если файл не передан, возвращает nil
+func (api *TelegramBotAPI) parseInputFile(c *gin.Context, name, value string) (*emulator.InputFile, error) {
+	value = strings.TrimSpace(value)
+	partName := name
+	if strings.HasPrefix(value, attachPrefix) {
+		partName = strings.TrimPrefix(value, attachPrefix)
+	} else if value != "" {
+		return emulator.NewInputFileReference(value), nil
+	}
+
+	if !strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		return nil, nil
+	}
+
+	header, err := c.FormFile(partName)
+	if err != nil {
+		if value != "" {
+			// Ссылка attach:// указывает на отсутствующую часть запроса
+			return nil, models.ErrWrongFileIdentifier
+		}
+		return nil, nil
+	}
+
+	if header.Size > maxMultipartFileSize {
+		return nil, models.ErrRequestEntityTooLarge
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return &emulator.InputFile{
+		FileName: header.Filename,
+		MimeType: header.Header.Get("Content-Type"),
+		Data:     data,
+	}, nil
+}
+
+// GetFile возвращает информацию о файле и путь для его скачивания через /file/bot<token>/<file_path>
+func (api *TelegramBotAPI) GetFile(c *gin.Context) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: token is empty"})
+		return
+	}
+
+	// Находим бота по токену
+	bot, err := api.findBotByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		FileID string `json:"file_id" form:"file_id"`
+	}
+
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
+		return
+	}
+
+	file, err := api.fileManager.GetFile(strings.TrimSpace(request.FileID))
+	if err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	api.logger.Info("Выдан файл для скачивания",
+		zap.Int64("bot_id", bot.ID),
+		zap.String("file_id", file.FileID),
+		zap.String("file_path", file.FilePath))
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":     true,
+		"result": file.ToTelegramFile(),
+	})
+}
+
+// DownloadFile отдает содержимое файла по пути, полученному из getFile
+func (api *TelegramBotAPI) DownloadFile(c *gin.Context) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusNotFound, gin.H{"ok": false, "error_code": 404, "description": "Not Found"})
+		return
+	}
+
+	if _, err := api.findBotByToken(token); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	file, fullPath, err := api.fileManager.ResolveFilePath(c.Param("filePath"))
+	if err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	// Middleware Bot API выставляет JSON, поэтому тип содержимого задаем явно
+	mimeType := file.MimeType
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	c.Header("Content-Type", mimeType)
+	c.File(fullPath)
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"telegram-emulator/internal/emulator"
+	"telegram-emulator/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// DeleteMessage удаляет сообщение. Бот может удалить сообщение младше 48 часов,
+// а чужие сообщения в группах - только если он администратор
+func (api *TelegramBotAPI) DeleteMessage(c *gin.Context) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: token is empty"})
+		return
+	}
+
+	// Находим бота по токену
+	bot, err := api.findBotByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		ChatID    json.Number `json:"chat_id" form:"chat_id"`
+		MessageID json.Number `json:"message_id" form:"message_id"`
+	}
+
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
+		return
+	}
+
+	chatID, messageID, ok := api.parseEditTarget(c, request.ChatID, request.MessageID, "")
+	if !ok {
+		return
+	}
+
+	botUser, err := api.userManager.GetUserByUsername(bot.Username)
+	if err != nil {
+		api.logger.Error("Ошибка получения пользователя-бота", zap.String("bot_username", bot.Username), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": 500, "description": "Bot user not found"})
+		return
+	}
+
+	if err := api.messageManager.DeleteBotMessage(botUser.ID, chatID, messageID); err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	api.logger.Info("Сообщение удалено ботом",
+		zap.Int64("bot_id", bot.ID),
+		zap.Int64("chat_id", chatID),
+		zap.Int64("message_id", messageID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":     true,
+		"result": true,
+	})
+}
+
+// ForwardMessage пересылает сообщение из from_chat_id в chat_id
+func (api *TelegramBotAPI) ForwardMessage(c *gin.Context) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: token is empty"})
+		return
+	}
+
+	// Находим бота по токену
+	bot, err := api.findBotByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		ChatID     json.Number `json:"chat_id" form:"chat_id"`
+		FromChatID json.Number `json:"from_chat_id" form:"from_chat_id"`
+		MessageID  json.Number `json:"message_id" form:"message_id"`
+	}
+
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
+		return
+	}
+
+	chatID, fromChatID, messageID, ok := api.parseSourceMessage(c, request.ChatID, request.FromChatID, request.MessageID)
+	if !ok {
+		return
+	}
+
+	botUser, err := api.userManager.GetUserByUsername(bot.Username)
+	if err != nil {
+		api.logger.Error("Ошибка получения пользователя-бота", zap.String("bot_username", bot.Username), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": 500, "description": "Bot user not found"})
+		return
+	}
+
+	message, err := api.messageManager.ForwardMessage(botUser.ID, chatID, fromChatID, messageID)
+	if err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	api.logger.Info("Сообщение переслано ботом",
+		zap.Int64("bot_id", bot.ID),
+		zap.Int64("from_chat_id", fromChatID),
+		zap.Int64("chat_id", chatID),
+		zap.Int64("message_id", message.ID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":     true,
+		"result": message.ToTelegramMessage(bot.ID),
+	})
+}
+
+// CopyMessage копирует сообщение из from_chat_id в chat_id без ссылки на оригинал.
+// Возвращает идентификатор нового сообщения
+func (api *TelegramBotAPI) CopyMessage(c *gin.Context) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: token is empty"})
+		return
+	}
+
+	// Находим бота по токену
+	bot, err := api.findBotByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		ChatID                   json.Number            `json:"chat_id" form:"chat_id"`
+		FromChatID               json.Number            `json:"from_chat_id" form:"from_chat_id"`
+		MessageID                json.Number            `json:"message_id" form:"message_id"`
+		Caption                  *string                `json:"caption" form:"caption"`
+		ParseMode                string                 `json:"parse_mode" form:"parse_mode"`
+		CaptionEntities          []models.MessageEntity `json:"caption_entities" form:"-"`
+		CaptionEntitiesString    string                 `json:"-" form:"caption_entities"`
+		ReplyMarkup              interface{}            `json:"reply_markup" form:"-"`
+		ReplyMarkupString        string                 `json:"-" form:"reply_markup"`
+		ReplyToMessageID         int64                  `json:"reply_to_message_id" form:"reply_to_message_id"`
+		AllowSendingWithoutReply bool                   `json:"allow_sending_without_reply" form:"allow_sending_without_reply"`
+	}
+
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
+		return
+	}
+
+	chatID, fromChatID, messageID, ok := api.parseSourceMessage(c, request.ChatID, request.FromChatID, request.MessageID)
+	if !ok {
+		return
+	}
+
+	if request.CaptionEntities == nil && request.CaptionEntitiesString != "" {
+		if err := json.Unmarshal([]byte(request.CaptionEntitiesString), &request.CaptionEntities); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: can't parse caption_entities JSON array"})
+			return
+		}
+	}
+
+	replyMarkup, ok := api.parseReplyMarkup(c, request.ReplyMarkup, request.ReplyMarkupString)
+	if !ok {
+		return
+	}
+
+	botUser, err := api.userManager.GetUserByUsername(bot.Username)
+	if err != nil {
+		api.logger.Error("Ошибка получения пользователя-бота", zap.String("bot_username", bot.Username), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": 500, "description": "Bot user not found"})
+		return
+	}
+
+	message, err := api.messageManager.CopyMessage(botUser.ID, chatID, fromChatID, messageID, emulator.CopyOptions{
+		Caption:                  request.Caption,
+		ParseMode:                request.ParseMode,
+		CaptionEntities:          request.CaptionEntities,
+		ReplyMarkup:              replyMarkup,
+		ReplyToMessageID:         request.ReplyToMessageID,
+		AllowSendingWithoutReply: request.AllowSendingWithoutReply,
+	})
+	if err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	api.logger.Info("Сообщение скопировано ботом",
+		zap.Int64("bot_id", bot.ID),
+		zap.Int64("from_chat_id", fromChatID),
+		zap.Int64("chat_id", chatID),
+		zap.Int64("message_id", message.ID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":     true,
+		"result": gin.H{"message_id": message.ID},
+	})
+}
+
+// parseSourceMessage разбирает chat_id, from_chat_id и message_id пересылаемого или копируемого сообщения.
+// При ошибке отправляет ответ и возвращает false
+func (api *TelegramBotAPI) parseSourceMessage(c *gin.Context, rawChatID, rawFromChatID, rawMessageID json.Number) (int64, int64, int64, bool) {
+	chatID, messageID, ok := api.parseEditTarget(c, rawChatID, rawMessageID, "")
+	if !ok {
+		return 0, 0, 0, false
+	}
+
+	if rawFromChatID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: from_chat_id is empty"})
+		return 0, 0, 0, false
+	}
+	fromChatID, err := rawFromChatID.Int64()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: invalid from_chat_id format"})
+		return 0, 0, 0, false
+	}
+
+	return chatID, fromChatID, messageID, true
+}
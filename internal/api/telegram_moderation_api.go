@@ -0,0 +1,307 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"telegram-emulator/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// BanChatMember блокирует пользователя в группе до until_date
+func (api *TelegramBotAPI) BanChatMember(c *gin.Context) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: token is empty"})
+		return
+	}
+
+	// Находим бота по токену
+	bot, err := api.findBotByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		ChatID    json.Number `json:"chat_id" form:"chat_id"`
+		UserID    json.Number `json:"user_id" form:"user_id"`
+		UntilDate json.Number `json:"until_date" form:"until_date"`
+	}
+
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
+		return
+	}
+
+	chat, botUser, userID, ok := api.getBotChatMember(c, bot, request.ChatID, request.UserID)
+	if !ok {
+		return
+	}
+	untilDate, ok := parseUntilDate(c, request.UntilDate)
+	if !ok {
+		return
+	}
+
+	if err := api.chatManager.BanChatMember(botUser.ID, chat.ID, userID, untilDate); err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":     true,
+		"result": true,
+	})
+}
+
+// UnbanChatMember снимает блокировку пользователя. Без only_if_banned участник группы удаляется из нее
+func (api *TelegramBotAPI) UnbanChatMember(c *gin.Context) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: token is empty"})
+		return
+	}
+
+	// Находим бота по токену
+	bot, err := api.findBotByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		ChatID       json.Number `json:"chat_id" form:"chat_id"`
+		UserID       json.Number `json:"user_id" form:"user_id"`
+		OnlyIfBanned bool        `json:"only_if_banned" form:"only_if_banned"`
+	}
+
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
+		return
+	}
+
+	chat, botUser, userID, ok := api.getBotChatMember(c, bot, request.ChatID, request.UserID)
+	if !ok {
+		return
+	}
+
+	if err := api.chatManager.UnbanChatMember(botUser.ID, chat.ID, userID, request.OnlyIfBanned); err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":     true,
+		"result": true,
+	})
+}
+
+// RestrictChatMember ограничивает разрешения участника группы до until_date
+func (api *TelegramBotAPI) RestrictChatMember(c *gin.Context) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: token is empty"})
+		return
+	}
+
+	// Находим бота по токену
+	bot, err := api.findBotByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		ChatID                        json.Number             `json:"chat_id" form:"chat_id"`
+		UserID                        json.Number             `json:"user_id" form:"user_id"`
+		Permissions                   *models.ChatPermissions `json:"permissions" form:"-"`
+		PermissionsString             string                  `json:"-" form:"permissions"`
+		UseIndependentChatPermissions bool                    `json:"use_independent_chat_permissions" form:"use_independent_chat_permissions"`
+		UntilDate                     json.Number             `json:"until_date" form:"until_date"`
+	}
+
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
+		return
+	}
+
+	if request.Permissions == nil {
+		if request.PermissionsString == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: parameter \"permissions\" is required"})
+			return
+		}
+		request.Permissions = &models.ChatPermissions{}
+		if err := json.Unmarshal([]byte(request.PermissionsString), request.Permissions); err != nil {
+			api.logger.Error("Ошибка парсинга permissions JSON", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: can't parse ChatPermissions JSON object"})
+			return
+		}
+	}
+
+	chat, botUser, userID, ok := api.getBotChatMember(c, bot, request.ChatID, request.UserID)
+	if !ok {
+		return
+	}
+	untilDate, ok := parseUntilDate(c, request.UntilDate)
+	if !ok {
+		return
+	}
+
+	permissions := request.Permissions.Normalize(request.UseIndependentChatPermissions)
+	if err := api.chatManager.RestrictChatMember(botUser.ID, chat.ID, userID, permissions, untilDate); err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":     true,
+		"result": true,
+	})
+}
+
+// PromoteChatMember назначает участника группы администратором. Все права false снимают администратора
+func (api *TelegramBotAPI) PromoteChatMember(c *gin.Context) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: token is empty"})
+		return
+	}
+
+	// Находим бота по токену
+	bot, err := api.findBotByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		ChatID              json.Number `json:"chat_id" form:"chat_id"`
+		UserID              json.Number `json:"user_id" form:"user_id"`
+		IsAnonymous         bool        `json:"is_anonymous" form:"is_anonymous"`
+		CanManageChat       bool        `json:"can_manage_chat" form:"can_manage_chat"`
+		CanDeleteMessages   bool        `json:"can_delete_messages" form:"can_delete_messages"`
+		CanManageVideoChats bool        `json:"can_manage_video_chats" form:"can_manage_video_chats"`
+		CanRestrictMembers  bool        `json:"can_restrict_members" form:"can_restrict_members"`
+		CanPromoteMembers   bool        `json:"can_promote_members" form:"can_promote_members"`
+		CanChangeInfo       bool        `json:"can_change_info" form:"can_change_info"`
+		CanInviteUsers      bool        `json:"can_invite_users" form:"can_invite_users"`
+		CanPinMessages      bool        `json:"can_pin_messages" form:"can_pin_messages"`
+	}
+
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
+		return
+	}
+
+	chat, botUser, userID, ok := api.getBotChatMember(c, bot, request.ChatID, request.UserID)
+	if !ok {
+		return
+	}
+
+	rights := models.ChatAdministratorRights{
+		IsAnonymous:         request.IsAnonymous,
+		CanManageChat:       request.CanManageChat,
+		CanDeleteMessages:   request.CanDeleteMessages,
+		CanManageVideoChats: request.CanManageVideoChats,
+		CanRestrictMembers:  request.CanRestrictMembers,
+		CanPromoteMembers:   request.CanPromoteMembers,
+		CanChangeInfo:       request.CanChangeInfo,
+		CanInviteUsers:      request.CanInviteUsers,
+		CanPinMessages:      request.CanPinMessages,
+	}
+	if err := api.chatManager.PromoteChatMember(botUser.ID, chat.ID, userID, rights); err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":     true,
+		"result": true,
+	})
+}
+
+// SetChatAdministratorCustomTitle задает звание администратору группы, назначенному ботом
+func (api *TelegramBotAPI) SetChatAdministratorCustomTitle(c *gin.Context) {
+	token := api.extractTokenFromPath(c)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: token is empty"})
+		return
+	}
+
+	// Находим бота по токену
+	bot, err := api.findBotByToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		ChatID      json.Number `json:"chat_id" form:"chat_id"`
+		UserID      json.Number `json:"user_id" form:"user_id"`
+		CustomTitle string      `json:"custom_title" form:"custom_title"`
+	}
+
+	// Унифицированный биндинг: поддерживает GET query, POST form, POST JSON
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: " + err.Error()})
+		return
+	}
+
+	chat, botUser, userID, ok := api.getBotChatMember(c, bot, request.ChatID, request.UserID)
+	if !ok {
+		return
+	}
+
+	if err := api.chatManager.SetChatAdministratorCustomTitle(botUser.ID, chat.ID, userID, request.CustomTitle); err != nil {
+		api.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ok":     true,
+		"result": true,
+	})
+}
+
+// getBotChatMember разбирает chat_id и user_id и возвращает чат бота, пользователя-бота и ID участника.
+// При ошибке отправляет ответ и возвращает false
+func (api *TelegramBotAPI) getBotChatMember(c *gin.Context, bot *models.Bot, rawChatID, rawUserID json.Number) (*models.Chat, *models.User, int64, bool) {
+	chat, botUser, ok := api.getBotChat(c, bot, rawChatID)
+	if !ok {
+		return nil, nil, 0, false
+	}
+
+	if rawUserID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: user_id is empty"})
+		return nil, nil, 0, false
+	}
+	userID, err := rawUserID.Int64()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: invalid user_id format"})
+		return nil, nil, 0, false
+	}
+
+	return chat, botUser, userID, true
+}
+
+// parseUntilDate разбирает until_date в Unix времени. Отсутствующий until_date означает бессрочное ограничение.
+// При ошибке отправляет ответ и возвращает false
+func parseUntilDate(c *gin.Context, rawUntilDate json.Number) (*time.Time, bool) {
+	if rawUntilDate == "" {
+		return nil, true
+	}
+
+	untilDate, err := rawUntilDate.Int64()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": 400, "description": "Bad Request: invalid until_date format"})
+		return nil, false
+	}
+	return models.UntilDateFromUnix(untilDate, time.Now()), true
+}
@@ -0,0 +1,193 @@
+package emulator
+
+import (
+	"errors"
+
+	"telegram-emulator/internal/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// SetMyCommands сохраняет список команд бота для области scope и языка languageCode.
+// Пустой список удаляет команды, как и deleteMyCommands
+func (m *BotManager) SetMyCommands(botID int64, commands []models.BotCommand, scope models.BotCommandScope, languageCode string) error {
+	scope, err := m.validateCommandScope(botID, scope, languageCode)
+	if err != nil {
+		return err
+	}
+
+	commands, err = models.ValidateBotCommands(commands)
+	if err != nil {
+		return err
+	}
+	if len(commands) == 0 {
+		return m.deleteCommands(botID, scope, languageCode)
+	}
+
+	set, err := models.NewBotCommandSet(botID, scope, languageCode, commands)
+	if err != nil {
+		return err
+	}
+
+	if err := m.botRepo.SetCommands(set); err != nil {
+		m.logger.Error("Ошибка сохранения команд бота", zap.Int64("bot_id", botID), zap.Error(err))
+		return err
+	}
+
+	m.logger.Info("Команды бота изменены",
+		zap.Int64("bot_id", botID),
+		zap.String("scope", scope.Type),
+		zap.String("language_code", languageCode),
+		zap.Int("commands", len(commands)))
+	return nil
+}
+
+// GetMyCommands возвращает список команд бота, установленный именно для области scope и языка languageCode.
+// Если список не установлен, возвращается пустой список
+func (m *BotManager) GetMyCommands(botID int64, scope models.BotCommandScope, languageCode string) ([]models.BotCommand, error) {
+	scope, err := m.validateCommandScope(botID, scope, languageCode)
+	if err != nil {
+		return nil, err
+	}
+
+	set, err := m.botRepo.GetCommands(botID, scope, languageCode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return []models.BotCommand{}, nil
+		}
+		return nil, err
+	}
+
+	return set.GetCommands(), nil
+}
+
+// DeleteMyCommands удаляет список команд бота для области scope и языка languageCode.
+// После удаления пользователям показываются команды из областей с меньшим приоритетом
+func (m *BotManager) DeleteMyCommands(botID int64, scope models.BotCommandScope, languageCode string) error {
+	scope, err := m.validateCommandScope(botID, scope, languageCode)
+	if err != nil {
+		return err
+	}
+
+	return m.deleteCommands(botID, scope, languageCode)
+}
+
+// GetAllCommands возвращает все списки команд бота
+func (m *BotManager) GetAllCommands(botID int64) ([]models.BotCommandList, error) {
+	if _, err := m.GetBot(botID); err != nil {
+		return nil, err
+	}
+
+	sets, err := m.botRepo.GetAllCommands(botID)
+	if err != nil {
+		return nil, err
+	}
+
+	lists := make([]models.BotCommandList, 0, len(sets))
+	for i := range sets {
+		lists = append(lists, sets[i].ToBotCommandList())
+	}
+	return lists, nil
+}
+
+// GetCommandMenu возвращает меню команд бота, которое видит пользователь userID в чате chatID.
+// Список выбирается по правилам Telegram: от самой узкой области к default,
+// в каждой области сначала для языка languageCode, затем для всех языков
+func (m *BotManager) GetCommandMenu(botID, chatID, userID int64, languageCode string) ([]models.BotCommand, error) {
+	if _, err := m.GetBot(botID); err != nil {
+		return nil, err
+	}
+
+	chat, err := m.chatRepo.GetByID(chatID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrChatNotFound
+		}
+		return nil, err
+	}
+
+	sets, err := m.botRepo.GetAllCommands(botID)
+	if err != nil {
+		return nil, err
+	}
+
+	languages := []string{""}
+	if languageCode != "" {
+		languages = []string{languageCode, ""}
+	}
+
+	for _, scope := range models.BotCommandScopesFor(chat, userID) {
+		for _, language := range languages {
+			for i := range sets {
+				if sets[i].Scope() == scope && sets[i].LanguageCode == language {
+					return sets[i].GetCommands(), nil
+				}
+			}
+		}
+	}
+
+	return []models.BotCommand{}, nil
+}
+
+// deleteCommands удаляет список команд бота из базы данных
+func (m *BotManager) deleteCommands(botID int64, scope models.BotCommandScope, languageCode string) error {
+	if err := m.botRepo.DeleteCommands(botID, scope, languageCode); err != nil {
+		m.logger.Error("Ошибка удаления команд бота", zap.Int64("bot_id", botID), zap.Error(err))
+		return err
+	}
+
+	m.logger.Info("Команды бота удалены",
+		zap.Int64("bot_id", botID),
+		zap.String("scope", scope.Type),
+		zap.String("language_code", languageCode))
+	return nil
+}
+
+// validateCommandScope проверяет бота, область видимости команд и код языка.
+// Для областей chat и chat_member чат должен существовать, а пользователь - состоять в нем
+func (m *BotManager) validateCommandScope(botID int64, scope models.BotCommandScope, languageCode string) (models.BotCommandScope, error) {
+	if _, err := m.GetBot(botID); err != nil {
+		return scope, err
+	}
+
+	scope = scope.Normalize()
+	if err := scope.Validate(); err != nil {
+		return scope, err
+	}
+	if !models.IsValidLanguageCode(languageCode) {
+		return scope, models.ErrInvalidLanguageCode
+	}
+
+	if scope.Type != models.BotCommandScopeChat && scope.Type != models.BotCommandScopeChatMember {
+		return scope, nil
+	}
+
+	chat, err := m.chatRepo.GetByID(scope.ChatID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return scope, models.ErrChatNotFound
+		}
+		return scope, err
+	}
+
+	if scope.Type == models.BotCommandScopeChatMember {
+		// В приватных чатах меню команд не зависит от собеседника
+		if chat.IsPrivate() {
+			return scope, models.ErrWrongBotCommandScope
+		}
+
+		isMember := false
+		for _, member := range chat.Members {
+			if member.ID == scope.UserID {
+				isMember = true
+				break
+			}
+		}
+		if !isMember {
+			return scope, models.ErrUserNotFound
+		}
+	}
+
+	return scope, nil
+}
@@ -1,13 +1,9 @@
 package emulator
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"math/rand"
-	"net/http"
-	"strconv"
 	"time"
 
 	"telegram-emulator/internal/models"
@@ -19,32 +15,90 @@ import (
 
 // BotManager управляет ботами в эмуляторе
 type BotManager struct {
-	botRepo      *repository.BotRepository
-	userRepo     *repository.UserRepository
-	messageRepo  *repository.MessageRepository
-	chatRepo     *repository.ChatRepository
-	logger       *zap.Logger
-	updateQueue  map[int64][]models.Update // Очередь обновлений для каждого бота
-	updateID     int64                     // Глобальный счетчик update_id
-	chatIDMap    map[int64]string          // Маппинг Telegram chat_id -> внутренний chat_id
-	nextUpdateID map[int64]int64           // Персональный счетчик update_id для каждого бота
+	botRepo     *repository.BotRepository
+	userRepo    *repository.UserRepository
+	messageRepo *repository.MessageRepository
+	chatRepo    *repository.ChatRepository
+	updateRepo  *repository.UpdateRepository
+	logger      *zap.Logger
+	updates     *updateQueues          // Очереди обновлений для каждого бота
+	webhooks    *webhookDispatcher     // Доставка обновлений через webhook
+	callbacks   *callbackQueryRegistry // Callback query, ожидающие ответа ботов
 }
 
 // NewBotManager создает новый экземпляр BotManager
-func NewBotManager(botRepo *repository.BotRepository, userRepo *repository.UserRepository, messageRepo *repository.MessageRepository, chatRepo *repository.ChatRepository) *BotManager {
+func NewBotManager(botRepo *repository.BotRepository, userRepo *repository.UserRepository, messageRepo *repository.MessageRepository, chatRepo *repository.ChatRepository, updateRepo *repository.UpdateRepository) *BotManager {
 	// Инициализируем генератор случайных чисел (удалено rand.Seed - deprecated)
 
-	return &BotManager{
-		botRepo:      botRepo,
-		userRepo:     userRepo,
-		messageRepo:  messageRepo,
-		chatRepo:     chatRepo,
-		logger:       logger.GetLogger(),
-		updateQueue:  make(map[int64][]models.Update),
-		updateID:     1,
-		chatIDMap:    make(map[int64]string),
-		nextUpdateID: make(map[int64]int64),
+	manager := &BotManager{
+		botRepo:     botRepo,
+		userRepo:    userRepo,
+		messageRepo: messageRepo,
+		chatRepo:    chatRepo,
+		updateRepo:  updateRepo,
+		logger:      logger.GetLogger(),
+		updates:     newUpdateQueues(updateRepo),
+		callbacks:   newCallbackQueryRegistry(callbackQueryTimeout),
 	}
+	manager.webhooks = newWebhookDispatcher(manager)
+
+	return manager
+}
+
+// SetWebhookTimeout устанавливает таймаут запроса к webhook ботов
+func (m *BotManager) SetWebhookTimeout(timeout time.Duration) {
+	m.webhooks.setTimeout(timeout)
+}
+
+// ResetWebhookStats сбрасывает последнюю ошибку доставки webhook бота.
+// Вызывается при установке и удалении webhook, чтобы getWebhookInfo не показывал ошибки прежнего URL
+func (m *BotManager) ResetWebhookStats(botID int64) {
+	m.webhooks.resetStats(botID)
+}
+
+// StartWebhookDelivery запускает доставку накопленных обновлений ботам с установленным webhook
+func (m *BotManager) StartWebhookDelivery() error {
+	bots, err := m.botRepo.GetActive()
+	if err != nil {
+		return err
+	}
+
+	for _, bot := range bots {
+		if bot.WebhookURL != "" {
+			m.webhooks.notify(bot.ID)
+		}
+	}
+
+	return nil
+}
+
+// GetWebhookInfo возвращает состояние webhook бота
+func (m *BotManager) GetWebhookInfo(botID int64) (*models.WebhookInfo, error) {
+	bot, err := m.GetBot(botID)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := m.updates.get(botID).size()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &models.WebhookInfo{
+		URL:                bot.WebhookURL,
+		PendingUpdateCount: pending,
+		AllowedUpdates:     bot.GetAllowedUpdates(),
+	}
+
+	if bot.WebhookURL != "" {
+		stats := m.webhooks.getStats(botID)
+		info.IPAddress = bot.WebhookIPAddress
+		info.LastErrorDate = stats.lastErrorDate
+		info.LastErrorMessage = stats.lastErrorMessage
+		info.MaxConnections = bot.GetWebhookMaxConnections()
+	}
+
+	return info, nil
 }
 
 // CreateBot создает нового бота
@@ -128,6 +182,11 @@ func (m *BotManager) UpdateBot(bot *models.Bot) error {
 		return err
 	}
 
+	// После установки webhook доставляем накопленные обновления
+	if bot.WebhookURL != "" {
+		m.webhooks.notify(bot.ID)
+	}
+
 	m.logger.Info("Бот обновлен", zap.Int64("id", bot.ID))
 	return nil
 }
@@ -144,6 +203,21 @@ func (m *BotManager) DeleteBot(id int64) error {
 		m.logger.Error("Ошибка удаления пользователя-бота", zap.Int64("id", id), zap.Error(err))
 	}
 
+	// Удаляем сохраненную очередь обновлений бота
+	if err := m.updateRepo.DeleteByBotID(id); err != nil {
+		m.logger.Error("Ошибка удаления очереди обновлений бота", zap.Int64("id", id), zap.Error(err))
+	}
+
+	// Удаляем списки команд бота
+	if err := m.botRepo.DeleteAllCommands(id); err != nil {
+		m.logger.Error("Ошибка удаления команд бота", zap.Int64("id", id), zap.Error(err))
+	}
+
+	// Удаляем правила бота-заглушки
+	if err := m.botRepo.DeleteStubRules(id); err != nil {
+		m.logger.Error("Ошибка удаления правил бота-заглушки", zap.Int64("id", id), zap.Error(err))
+	}
+
 	m.logger.Info("Бот удален", zap.Int64("id", id))
 	return nil
 }
@@ -179,7 +253,6 @@ func (m *BotManager) SendBotMessage(botID int64, chatID int64, text, parseMode s
 		ChatID:     chatID, // Используем chatID напрямую, так как он уже int64
 		FromID:     botUser.ID,
 		From:       *botUser,
-		Text:       text,
 		Type:       models.MessageTypeText,
 		Status:     models.MessageStatusSent,
 		IsOutgoing: false,
@@ -187,6 +260,11 @@ func (m *BotManager) SendBotMessage(botID int64, chatID int64, text, parseMode s
 		CreatedAt:  time.Now(),
 	}
 
+	// Разбираем разметку текста в режиме parseMode
+	if err := message.SetFormattedText(text, parseMode); err != nil {
+		return nil, err
+	}
+
 	if err := m.messageRepo.Create(message); err != nil {
 		m.logger.Error("Ошибка создания сообщения бота", zap.Error(err))
 		return nil, err
@@ -200,83 +278,145 @@ func (m *BotManager) SendBotMessage(botID int64, chatID int64, text, parseMode s
 	return message, nil
 }
 
+// SetAllowedUpdates сохраняет типы обновлений, которые бот будет получать.
+// Пустой список означает набор обновлений по умолчанию
+func (m *BotManager) SetAllowedUpdates(botID int64, allowedUpdates []string) error {
+	bot, err := m.GetBot(botID)
+	if err != nil {
+		return err
+	}
+
+	previous := bot.AllowedUpdatesJSON
+	if err := bot.SetAllowedUpdates(allowedUpdates); err != nil {
+		return err
+	}
+	if bot.AllowedUpdatesJSON == previous {
+		return nil
+	}
+
+	if err := m.botRepo.SetAllowedUpdates(botID, bot.AllowedUpdatesJSON); err != nil {
+		m.logger.Error("Ошибка сохранения allowed_updates", zap.Int64("bot_id", botID), zap.Error(err))
+		return err
+	}
+
+	m.logger.Info("Типы получаемых обновлений изменены",
+		zap.Int64("bot_id", botID),
+		zap.Strings("allowed_updates", allowedUpdates))
+	return nil
+}
+
 // GetBotUpdates возвращает обновления для бота
 func (m *BotManager) GetBotUpdates(botID int64, offset, limit int) ([]models.Update, error) {
-	// Получаем бота
+	updates, _, err := m.collectUpdates(botID, offset, limit)
+	return updates, err
+}
+
+// WaitForUpdates возвращает обновления для бота, ожидая их появления не дольше timeout (long polling).
+// Ожидание прерывается сразу после добавления обновления в очередь или при отмене ctx.
+// Возвращает ErrWebhookActive при установленном webhook и ErrTerminatedByOtherGetUpdates,
+// если во время ожидания пришел другой запрос getUpdates.
+func (m *BotManager) WaitForUpdates(ctx context.Context, botID int64, offset, limit int, timeout time.Duration) ([]models.Update, error) {
 	bot, err := m.GetBot(botID)
 	if err != nil {
 		return nil, err
 	}
 
-	if !bot.IsActive {
-		return nil, fmt.Errorf("бот неактивен")
+	// Как и Telegram, не отдаем обновления через getUpdates при установленном webhook
+	if bot.WebhookURL != "" {
+		return nil, models.ErrWebhookActive
 	}
 
-	// Если offset не указан, используем сохраненный offset бота
-	if offset == 0 {
-		offset = int(bot.LastUpdateOffset)
+	// Новый запрос getUpdates вытесняет уже ожидающий
+	terminated, done := m.updates.get(botID).beginPoll()
+	defer done()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		updates, signal, err := m.collectUpdates(botID, offset, limit)
+		if err != nil || len(updates) > 0 || timeout <= 0 {
+			return updates, err
+		}
+
+		select {
+		case <-signal:
+			m.logger.Debug("Long polling: получен сигнал о новых обновлениях", zap.Int64("bot_id", botID))
+		case <-deadline.C:
+			return updates, nil
+		case <-terminated:
+			return nil, models.ErrTerminatedByOtherGetUpdates
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
 	}
+}
 
-	// Получаем обновления из очереди
-	queue, exists := m.updateQueue[botID]
-	if !exists {
-		m.logger.Debug("Очередь обновлений пуста для бота", zap.Int64("bot_id", botID))
-		return []models.Update{}, nil
+// collectUpdates выбирает обновления из очереди бота и возвращает канал,
+// который будет закрыт при появлении следующего обновления
+func (m *BotManager) collectUpdates(botID int64, offset, limit int) ([]models.Update, <-chan struct{}, error) {
+	// Получаем бота
+	bot, err := m.GetBot(botID)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	m.logger.Debug("Получена очередь обновлений",
-		zap.Int64("bot_id", botID),
-		zap.Int("queue_size", len(queue)),
-		zap.Int("offset", offset))
-
-	// Проверяем, есть ли обновления с update_id больше offset
-	maxUpdateID := int64(0)
-	for _, update := range queue {
-		if update.UpdateID > maxUpdateID {
-			maxUpdateID = update.UpdateID
-		}
+	if !bot.IsActive {
+		return nil, nil, fmt.Errorf("бот неактивен")
 	}
 
-	// Если offset больше максимального update_id, возвращаем пустой список
-	if int64(offset) > maxUpdateID && maxUpdateID > 0 {
-		m.logger.Info("Offset больше максимального update_id, возвращаем пустой список",
-			zap.Int64("bot_id", botID),
-			zap.Int("offset", offset),
-			zap.Int64("max_update_id", maxUpdateID))
-		return []models.Update{}, nil
-	}
-
-	// Фильтруем по offset - возвращаем обновления с update_id >= offset
-	var filteredUpdates []models.Update
-	for _, update := range queue {
-		if update.UpdateID >= int64(offset) {
-			filteredUpdates = append(filteredUpdates, update)
-			// Логируем информацию о callback query
-			if update.CallbackQuery != nil {
-				m.logger.Debug("Найден callback query в обновлении",
-					zap.Int64("bot_id", botID),
-					zap.Int64("update_id", update.UpdateID),
-					zap.String("callback_data", update.CallbackQuery.Data))
-			}
+	queue := m.updates.get(botID)
+
+	// Семантика offset как в Telegram Bot API:
+	// положительный offset подтверждает и удаляет все обновления с меньшим update_id,
+	// отрицательный возвращает последние -offset обновлений и забывает все предыдущие,
+	// нулевой возвращает все неподтвержденные обновления
+	switch {
+	case offset > 0:
+		if err := queue.confirm(int64(offset)); err != nil {
+			return nil, nil, err
+		}
+		m.saveConfirmedOffset(bot, int64(offset))
+	case offset < 0:
+		confirmed, err := queue.keepLast(-offset)
+		if err != nil {
+			return nil, nil, err
 		}
+		m.saveConfirmedOffset(bot, confirmed)
+		offset = int(confirmed)
+	default:
+		offset = int(bot.LastUpdateOffset)
 	}
 
-	// Ограничиваем по limit
-	if len(filteredUpdates) > limit {
-		filteredUpdates = filteredUpdates[:limit]
+	updates, signal, err := queue.get(int64(offset), limit)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// НЕ обновляем offset бота автоматически - бот должен сам управлять своим offset
-	// Это стандартное поведение Telegram Bot API
-
-	m.logger.Info("Получены обновления для бота",
+	m.logger.Debug("Получены обновления для бота",
 		zap.Int64("bot_id", botID),
-		zap.Int("count", len(filteredUpdates)),
+		zap.Int("count", len(updates)),
 		zap.Int("offset", offset),
 		zap.Int("limit", limit),
 		zap.Int64("last_update_offset", bot.LastUpdateOffset))
 
-	return filteredUpdates, nil
+	return updates, signal, nil
+}
+
+// saveConfirmedOffset сохраняет подтвержденный ботом offset, если он продвинулся вперед
+func (m *BotManager) saveConfirmedOffset(bot *models.Bot, offset int64) {
+	if offset <= bot.LastUpdateOffset {
+		return
+	}
+
+	if err := m.botRepo.SetLastUpdateOffset(bot.ID, offset); err != nil {
+		m.logger.Error("Ошибка сохранения подтвержденного offset",
+			zap.Int64("bot_id", bot.ID),
+			zap.Int64("offset", offset),
+			zap.Error(err))
+		return
+	}
+	bot.LastUpdateOffset = offset
 }
 
 // ProcessWebhook обрабатывает webhook от бота
@@ -323,46 +463,43 @@ func (m *BotManager) AddUpdate(botID int64, update *models.Update) error {
 		return fmt.Errorf("бот неактивен")
 	}
 
-	// Устанавливаем update_id персонифицировано для бота
-	nextID := m.nextUpdateID[botID]
-	if nextID == 0 {
-		nextID = 1
-	}
-	update.UpdateID = nextID
-	m.nextUpdateID[botID] = nextID + 1
-
-	// Устанавливаем timestamp
-	update.Timestamp = time.Now()
+	return m.enqueue(bot, update)
+}
 
-	// Сохраняем маппинг chat_id если есть сообщение
-	if update.Message != nil {
-		// ChatID уже int64, сохраняем маппинг
-		m.chatIDMap[update.Message.ChatID] = strconv.FormatInt(update.Message.ChatID, 10)
+// enqueue добавляет обновление в очередь бота, если бот подписан на обновления этого типа
+func (m *BotManager) enqueue(bot *models.Bot, update *models.Update) error {
+	if !bot.AcceptsUpdate(update) {
+		m.logger.Debug("Обновление отфильтровано по allowed_updates",
+			zap.Int64("bot_id", bot.ID),
+			zap.String("update_type", update.Type()))
+		return nil
 	}
 
-	// Добавляем в очередь
-	if m.updateQueue[botID] == nil {
-		m.updateQueue[botID] = []models.Update{}
+	// Запоминаем callback query до постановки в очередь, чтобы бот мог ответить сразу после получения
+	if update.CallbackQuery != nil {
+		m.callbacks.register(bot.ID, update.CallbackQuery.ID, update.CallbackQuery.From.ID)
 	}
 
-	m.updateQueue[botID] = append(m.updateQueue[botID], *update)
-
-	// Ограничиваем размер очереди (максимум 1000 обновлений)
-	if len(m.updateQueue[botID]) > 1000 {
-		m.updateQueue[botID] = m.updateQueue[botID][len(m.updateQueue[botID])-1000:]
+	// Добавляем в очередь: update_id назначается персонально для бота
+	// и не должен повторять уже подтвержденные ботом значения
+	queue := m.updates.get(bot.ID)
+	if err := queue.push(update, bot.LastUpdateOffset); err != nil {
+		m.logger.Error("Ошибка сохранения обновления в очереди",
+			zap.Int64("bot_id", bot.ID),
+			zap.Error(err))
+		return err
 	}
 
+	queueSize, _ := queue.size()
 	m.logger.Info("Обновление добавлено в очередь",
-		zap.Int64("bot_id", botID),
-		zap.Int64("update_id", update.UpdateID))
+		zap.Int64("bot_id", bot.ID),
+		zap.Int64("update_id", update.UpdateID),
+		zap.String("update_type", update.Type()),
+		zap.Int("queue_size", queueSize))
 
-	// Обрабатываем команды автоматически
-	if update.Message != nil && update.Message.IsCommand() {
-		m.logger.Info("Найдена команда, запускаем обработку",
-			zap.Int64("bot_id", botID),
-			zap.String("command", update.Message.GetCommand()),
-			zap.Int64("message_id", update.Message.ID))
-		go m.handleCommand(botID, update.Message)
+	// Если у бота есть webhook URL, доставляем обновление через webhook
+	if bot.WebhookURL != "" {
+		m.webhooks.notify(bot.ID)
 	}
 
 	return nil
@@ -392,36 +529,14 @@ func (m *BotManager) AddCallbackQuery(botToken string, callbackQuery *models.Cal
 		return fmt.Errorf("бот неактивен")
 	}
 
-	// Создаем обновление с callback query с персонифицированным update_id
-	nextID := m.nextUpdateID[bot.ID]
-	if nextID == 0 {
-		nextID = 1
-	}
 	update := &models.Update{
-		UpdateID:      nextID,
 		CallbackQuery: callbackQuery,
-		Timestamp:     time.Now(),
-	}
-	m.nextUpdateID[bot.ID] = nextID + 1
-
-	// Добавляем в очередь
-	if m.updateQueue[bot.ID] == nil {
-		m.updateQueue[bot.ID] = []models.Update{}
-	}
-
-	m.updateQueue[bot.ID] = append(m.updateQueue[bot.ID], *update)
-
-	// Ограничиваем размер очереди
-	if len(m.updateQueue[bot.ID]) > 1000 {
-		m.updateQueue[bot.ID] = m.updateQueue[bot.ID][len(m.updateQueue[bot.ID])-1000:]
 	}
-
-	// Если у бота есть webhook URL, отправляем обновление в webhook
-	if bot.WebhookURL != "" {
-		go m.sendWebhookUpdate(bot, update)
+	if err := m.enqueue(bot, update); err != nil {
+		return err
 	}
 
-	m.logger.Info("Callback query добавлен в очередь",
+	m.logger.Info("Callback query обработан",
 		zap.Int64("bot_id", bot.ID),
 		zap.String("bot_token", botToken),
 		zap.Int64("update_id", update.UpdateID),
@@ -430,9 +545,33 @@ func (m *BotManager) AddCallbackQuery(botToken string, callbackQuery *models.Cal
 	return nil
 }
 
+// AnswerCallbackQuery принимает ответ бота на callback query и возвращает ID нажавшего кнопку пользователя.
+// На каждый callback query можно ответить только один раз и только пока он не устарел
+func (m *BotManager) AnswerCallbackQuery(botID int64, answer *models.CallbackQueryAnswer) (int64, error) {
+	userID, ok := m.callbacks.answer(botID, answer.CallbackQueryID)
+	if !ok {
+		m.logger.Warn("Ответ на неизвестный или устаревший callback query",
+			zap.Int64("bot_id", botID),
+			zap.String("callback_query_id", answer.CallbackQueryID))
+		return 0, models.ErrCallbackQueryTooOld
+	}
+
+	m.logger.Info("Ответ на callback query принят",
+		zap.Int64("bot_id", botID),
+		zap.Int64("user_id", userID),
+		zap.String("callback_query_id", answer.CallbackQueryID),
+		zap.String("text", answer.Text),
+		zap.Bool("show_alert", answer.ShowAlert),
+		zap.String("url", answer.URL))
+
+	return userID, nil
+}
+
 // ClearUpdates очищает очередь обновлений для бота
 func (m *BotManager) ClearUpdates(botID int64) error {
-	delete(m.updateQueue, botID)
+	if err := m.updates.get(botID).clear(); err != nil {
+		return err
+	}
 	m.logger.Info("Очередь обновлений очищена", zap.Int64("bot_id", botID))
 	return nil
 }
@@ -449,131 +588,3 @@ func (m *BotManager) generateID() (int64, error) {
 	random := rand.Int63n(1000) // случайное число от 0 до 999
 	return timestamp*1000 + random, nil
 }
-
-// sendWebhookUpdate отправляет обновление через webhook
-func (m *BotManager) sendWebhookUpdate(bot *models.Bot, update *models.Update) {
-	m.logger.Info("Начинаем отправку webhook",
-		zap.Int64("bot_id", bot.ID),
-		zap.String("webhook_url", bot.WebhookURL),
-		zap.Int64("update_id", update.UpdateID))
-
-	if bot.WebhookURL == "" {
-		m.logger.Error("Webhook URL не установлен для бота", zap.Int64("bot_id", bot.ID))
-		return
-	}
-
-	// Конвертируем обновление в формат Telegram Bot API
-	webhookUpdate := map[string]interface{}{
-		"update_id": update.UpdateID,
-	}
-
-	if update.Message != nil {
-		webhookUpdate["message"] = update.Message.ToTelegramMessage()
-		m.logger.Debug("Добавлено сообщение в webhook", zap.Int64("bot_id", bot.ID))
-	}
-	if update.EditedMessage != nil {
-		webhookUpdate["edited_message"] = update.EditedMessage.ToTelegramMessage()
-		m.logger.Debug("Добавлено редактированное сообщение в webhook", zap.Int64("bot_id", bot.ID))
-	}
-	if update.CallbackQuery != nil {
-		webhookUpdate["callback_query"] = update.CallbackQuery.ToTelegramCallbackQuery()
-		m.logger.Debug("Добавлен callback query в webhook",
-			zap.Int64("bot_id", bot.ID),
-			zap.String("callback_data", update.CallbackQuery.Data))
-	}
-
-	jsonData, err := json.Marshal(webhookUpdate)
-	if err != nil {
-		m.logger.Error("Ошибка маршалинга обновления для webhook", zap.Error(err))
-		return
-	}
-
-	m.logger.Debug("Отправляем webhook",
-		zap.Int64("bot_id", bot.ID),
-		zap.String("webhook_url", bot.WebhookURL),
-		zap.String("json_data", string(jsonData)))
-
-	resp, err := http.Post(bot.WebhookURL, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		m.logger.Error("Ошибка отправки обновления через webhook",
-			zap.Int64("bot_id", bot.ID),
-			zap.String("webhook_url", bot.WebhookURL),
-			zap.Error(err))
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		m.logger.Error("Webhook вернул не OK статус",
-			zap.Int64("bot_id", bot.ID),
-			zap.String("webhook_url", bot.WebhookURL),
-			zap.Int("status_code", resp.StatusCode),
-			zap.String("response_body", string(body)))
-	} else {
-		m.logger.Info("Обновление успешно отправлено через webhook",
-			zap.Int64("bot_id", bot.ID),
-			zap.String("webhook_url", bot.WebhookURL),
-			zap.Int64("update_id", update.UpdateID))
-	}
-}
-
-// handleCommand обрабатывает команды бота
-func (m *BotManager) handleCommand(botID int64, message *models.Message) {
-	// Получаем бота
-	bot, err := m.GetBot(botID)
-	if err != nil {
-		m.logger.Error("Ошибка получения бота для обработки команды",
-			zap.Int64("bot_id", botID),
-			zap.Error(err))
-		return
-	}
-
-	// Получаем пользователя-бота
-	botUser, err := m.userRepo.GetByUsername(bot.Username)
-	if err != nil {
-		m.logger.Error("Ошибка получения пользователя-бота",
-			zap.Int64("bot_id", botID),
-			zap.String("bot_username", bot.Username),
-			zap.Error(err))
-		return
-	}
-
-	// Получаем команду
-	command := message.GetCommand()
-	m.logger.Info("Обрабатываем команду",
-		zap.Int64("bot_id", botID),
-		zap.String("command", command),
-		zap.Int64("chat_id", message.ChatID))
-
-	// Обрабатываем команду /start
-	if command == "/start" {
-		responseText := fmt.Sprintf("Привет! Я бот %s. Добро пожаловать!", bot.Name)
-
-		// Отправляем ответное сообщение
-		responseMessage := &models.Message{
-			ID:         time.Now().UnixNano(),
-			ChatID:     message.ChatID,
-			FromID:     botUser.ID,
-			Text:       responseText,
-			Type:       "text",
-			Status:     "sending",
-			IsOutgoing: true,
-			Timestamp:  time.Now(),
-			CreatedAt:  time.Now(),
-		}
-
-		// Сохраняем сообщение в базе данных
-		if err := m.messageRepo.Create(responseMessage); err != nil {
-			m.logger.Error("Ошибка сохранения ответного сообщения",
-				zap.Int64("bot_id", botID),
-				zap.Error(err))
-			return
-		}
-
-		m.logger.Info("Ответ на команду /start отправлен",
-			zap.Int64("bot_id", botID),
-			zap.Int64("message_id", responseMessage.ID),
-			zap.Int64("chat_id", message.ChatID))
-	}
-}
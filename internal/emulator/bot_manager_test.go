@@ -1,7 +1,12 @@
 package emulator
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"telegram-emulator/internal/models"
 	"telegram-emulator/internal/repository"
@@ -13,7 +18,8 @@ func TestBotManager_CreateBot(t *testing.T) {
 	userRepo := repository.NewUserRepository(db)
 	messageRepo := repository.NewMessageRepository(db)
 	chatRepo := repository.NewChatRepository(db)
-	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
 
 	// Test creating a bot
 	bot, err := botManager.CreateBot("TestBot", "testbot", "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", "https://example.com/webhook")
@@ -48,7 +54,8 @@ func TestBotManager_GetBot(t *testing.T) {
 	userRepo := repository.NewUserRepository(db)
 	messageRepo := repository.NewMessageRepository(db)
 	chatRepo := repository.NewChatRepository(db)
-	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
 
 	// Create a bot
 	createdBot, err := botManager.CreateBot("TestBot", "testbot", "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11", "")
@@ -77,7 +84,8 @@ func TestBotManager_GetAllBots(t *testing.T) {
 	userRepo := repository.NewUserRepository(db)
 	messageRepo := repository.NewMessageRepository(db)
 	chatRepo := repository.NewChatRepository(db)
-	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
 
 	// Create multiple bots
 	_, err := botManager.CreateBot("Bot1", "bot1", "token1", "")
@@ -107,7 +115,8 @@ func TestBotManager_UpdateBot(t *testing.T) {
 	userRepo := repository.NewUserRepository(db)
 	messageRepo := repository.NewMessageRepository(db)
 	chatRepo := repository.NewChatRepository(db)
-	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
 
 	// Create a bot
 	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
@@ -144,7 +153,8 @@ func TestBotManager_DeleteBot(t *testing.T) {
 	userRepo := repository.NewUserRepository(db)
 	messageRepo := repository.NewMessageRepository(db)
 	chatRepo := repository.NewChatRepository(db)
-	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
 
 	// Create a bot
 	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
@@ -171,7 +181,8 @@ func TestBotManager_GetBotNotFound(t *testing.T) {
 	userRepo := repository.NewUserRepository(db)
 	messageRepo := repository.NewMessageRepository(db)
 	chatRepo := repository.NewChatRepository(db)
-	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
 
 	// Try to get non-existent bot
 	_, err := botManager.GetBot(999999)
@@ -186,7 +197,8 @@ func TestBotManager_UpdateBotNotFound(t *testing.T) {
 	userRepo := repository.NewUserRepository(db)
 	messageRepo := repository.NewMessageRepository(db)
 	chatRepo := repository.NewChatRepository(db)
-	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
 
 	// Try to update non-existent bot
 	nonExistentBot := &models.Bot{
@@ -209,7 +221,8 @@ func TestBotManager_DeleteBotNotFound(t *testing.T) {
 	userRepo := repository.NewUserRepository(db)
 	messageRepo := repository.NewMessageRepository(db)
 	chatRepo := repository.NewChatRepository(db)
-	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
 
 	// Try to delete non-existent bot
 	err := botManager.DeleteBot(999999)
@@ -225,7 +238,8 @@ func TestBotManager_GetBotUpdates(t *testing.T) {
 	userRepo := repository.NewUserRepository(db)
 	messageRepo := repository.NewMessageRepository(db)
 	chatRepo := repository.NewChatRepository(db)
-	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
 
 	// Create a bot
 	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
@@ -250,7 +264,8 @@ func TestBotManager_AddUpdate(t *testing.T) {
 	userRepo := repository.NewUserRepository(db)
 	messageRepo := repository.NewMessageRepository(db)
 	chatRepo := repository.NewChatRepository(db)
-	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
 
 	// Create a bot
 	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
@@ -286,3 +301,760 @@ func TestBotManager_AddUpdate(t *testing.T) {
 		t.Errorf("Expected 1 update, got %d", len(updates))
 	}
 }
+
+func TestBotManager_WaitForUpdatesWakesOnAddUpdate(t *testing.T) {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		update := &models.Update{
+			Message: &models.Message{ID: 1, ChatID: 1, FromID: 1, Text: "Test message", Type: "text"},
+		}
+		if err := botManager.AddUpdate(bot.ID, update); err != nil {
+			t.Errorf("Failed to add update: %v", err)
+		}
+	}()
+
+	start := time.Now()
+	updates, err := botManager.WaitForUpdates(context.Background(), bot.ID, 0, 10, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Failed to wait for updates: %v", err)
+	}
+
+	if len(updates) != 1 {
+		t.Fatalf("Expected 1 update, got %d", len(updates))
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected long polling to return right after AddUpdate, took %v", elapsed)
+	}
+}
+
+func TestBotManager_WaitForUpdatesTimeout(t *testing.T) {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	updates, err := botManager.WaitForUpdates(context.Background(), bot.ID, 0, 10, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to wait for updates: %v", err)
+	}
+
+	if len(updates) != 0 {
+		t.Errorf("Expected 0 updates, got %d", len(updates))
+	}
+}
+
+func TestBotManager_WaitForUpdatesCancelled(t *testing.T) {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = botManager.WaitForUpdates(ctx, bot.ID, 0, 10, 5*time.Second)
+	if err == nil {
+		t.Fatal("Expected error when request context is cancelled")
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected long polling to stop on cancellation, took %v", elapsed)
+	}
+}
+
+func TestBotManager_GetBotUpdatesConfirmsOffset(t *testing.T) {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		update := &models.Update{
+			Message: &models.Message{ID: int64(i + 1), ChatID: 1, FromID: 1, Text: "Test message", Type: "text"},
+		}
+		if err := botManager.AddUpdate(bot.ID, update); err != nil {
+			t.Fatalf("Failed to add update: %v", err)
+		}
+	}
+
+	// offset=3 confirms updates 1 and 2
+	updates, err := botManager.GetBotUpdates(bot.ID, 3, 10)
+	if err != nil {
+		t.Fatalf("Failed to get bot updates: %v", err)
+	}
+	if len(updates) != 1 || updates[0].UpdateID != 3 {
+		t.Fatalf("Expected only update 3, got %+v", updates)
+	}
+
+	// Confirmed updates are gone even without offset
+	updates, err = botManager.GetBotUpdates(bot.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("Failed to get bot updates: %v", err)
+	}
+	if len(updates) != 1 {
+		t.Errorf("Expected 1 update after confirmation, got %d", len(updates))
+	}
+
+	storedBot, err := botManager.GetBot(bot.ID)
+	if err != nil {
+		t.Fatalf("Failed to get bot: %v", err)
+	}
+	if storedBot.LastUpdateOffset != 3 {
+		t.Errorf("Expected persisted offset 3, got %d", storedBot.LastUpdateOffset)
+	}
+
+	// A lower offset never moves the confirmed offset back
+	if _, err := botManager.GetBotUpdates(bot.ID, 1, 10); err != nil {
+		t.Fatalf("Failed to get bot updates: %v", err)
+	}
+	storedBot, err = botManager.GetBot(bot.ID)
+	if err != nil {
+		t.Fatalf("Failed to get bot: %v", err)
+	}
+	if storedBot.LastUpdateOffset != 3 {
+		t.Errorf("Expected persisted offset to stay 3, got %d", storedBot.LastUpdateOffset)
+	}
+}
+
+func TestBotManager_GetBotUpdatesNegativeOffset(t *testing.T) {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		update := &models.Update{
+			Message: &models.Message{ID: int64(i + 1), ChatID: 1, FromID: 1, Text: "Test message", Type: "text"},
+		}
+		if err := botManager.AddUpdate(bot.ID, update); err != nil {
+			t.Fatalf("Failed to add update: %v", err)
+		}
+	}
+
+	updates, err := botManager.GetBotUpdates(bot.ID, -2, 10)
+	if err != nil {
+		t.Fatalf("Failed to get bot updates: %v", err)
+	}
+	if len(updates) != 2 || updates[0].UpdateID != 4 || updates[1].UpdateID != 5 {
+		t.Fatalf("Expected updates 4 and 5, got %+v", updates)
+	}
+
+	// Earlier updates are forgotten
+	updates, err = botManager.GetBotUpdates(bot.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("Failed to get bot updates: %v", err)
+	}
+	if len(updates) != 2 {
+		t.Errorf("Expected 2 updates left in queue, got %d", len(updates))
+	}
+}
+
+func TestBotManager_UpdateIDsContinueAfterConfirmedOffset(t *testing.T) {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	// Simulate a restart: the confirmed offset is persisted, the queue is fresh
+	if err := botRepo.SetLastUpdateOffset(bot.ID, 10); err != nil {
+		t.Fatalf("Failed to set offset: %v", err)
+	}
+
+	update := &models.Update{
+		Message: &models.Message{ID: 1, ChatID: 1, FromID: 1, Text: "Test message", Type: "text"},
+	}
+	if err := botManager.AddUpdate(bot.ID, update); err != nil {
+		t.Fatalf("Failed to add update: %v", err)
+	}
+
+	updates, err := botManager.GetBotUpdates(bot.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("Failed to get bot updates: %v", err)
+	}
+	if len(updates) != 1 || updates[0].UpdateID != 10 {
+		t.Fatalf("Expected update 10 to be delivered, got %+v", updates)
+	}
+}
+
+func TestBotManager_UpdatesSurviveRestart(t *testing.T) {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		update := &models.Update{
+			Message: &models.Message{ID: int64(i + 1), ChatID: 1, FromID: 1, Text: "Test message", Type: "text"},
+		}
+		if err := botManager.AddUpdate(bot.ID, update); err != nil {
+			t.Fatalf("Failed to add update: %v", err)
+		}
+	}
+
+	// Confirm the first update, then simulate a restart with a fresh manager on the same database
+	if _, err := botManager.GetBotUpdates(bot.ID, 2, 10); err != nil {
+		t.Fatalf("Failed to get bot updates: %v", err)
+	}
+	restarted := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+
+	updates, err := restarted.GetBotUpdates(bot.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("Failed to get bot updates: %v", err)
+	}
+	if len(updates) != 2 || updates[0].UpdateID != 2 || updates[1].UpdateID != 3 {
+		t.Fatalf("Expected updates 2 and 3 after restart, got %+v", updates)
+	}
+	if updates[0].Message == nil || updates[0].Message.ID != 2 {
+		t.Errorf("Expected message payload to survive restart, got %+v", updates[0].Message)
+	}
+
+	update := &models.Update{
+		Message: &models.Message{ID: 4, ChatID: 1, FromID: 1, Text: "Test message", Type: "text"},
+	}
+	if err := restarted.AddUpdate(bot.ID, update); err != nil {
+		t.Fatalf("Failed to add update: %v", err)
+	}
+	if update.UpdateID != 4 {
+		t.Errorf("Expected update_id 4 after restart, got %d", update.UpdateID)
+	}
+}
+
+func TestBotManager_AddUpdateRespectsAllowedUpdates(t *testing.T) {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	bot.SetAllowedUpdates([]string{models.UpdateTypeCallbackQuery})
+	if err := botManager.UpdateBot(bot); err != nil {
+		t.Fatalf("Failed to update bot: %v", err)
+	}
+
+	if err := botManager.AddUpdate(bot.ID, &models.Update{
+		Message: &models.Message{ID: 1, ChatID: 1, FromID: 1, Text: "Test message", Type: "text"},
+	}); err != nil {
+		t.Fatalf("Failed to add update: %v", err)
+	}
+	if err := botManager.AddUpdate(bot.ID, &models.Update{
+		CallbackQuery: &models.CallbackQuery{ID: "1", Data: "data"},
+	}); err != nil {
+		t.Fatalf("Failed to add update: %v", err)
+	}
+
+	updates, err := botManager.GetBotUpdates(bot.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("Failed to get bot updates: %v", err)
+	}
+	if len(updates) != 1 || updates[0].CallbackQuery == nil {
+		t.Errorf("Expected only the callback query update, got %+v", updates)
+	}
+}
+
+func TestBotManager_WaitForUpdatesConflictsWithWebhook(t *testing.T) {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "http://127.0.0.1:1/webhook")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	_, err = botManager.WaitForUpdates(context.Background(), bot.ID, 0, 10, 0)
+	if !errors.Is(err, models.ErrWebhookActive) {
+		t.Fatalf("Expected ErrWebhookActive, got %v", err)
+	}
+
+	var telegramErr *models.TelegramError
+	if !errors.As(err, &telegramErr) || telegramErr.ErrorCode != 409 {
+		t.Errorf("Expected 409 Telegram error, got %v", err)
+	}
+}
+
+func TestBotManager_WaitForUpdatesTerminatedByOtherRequest(t *testing.T) {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := botManager.WaitForUpdates(context.Background(), bot.ID, 0, 10, 5*time.Second)
+		firstErr <- err
+	}()
+
+	// Give the first request time to start waiting
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err := botManager.WaitForUpdates(context.Background(), bot.ID, 0, 10, 0); err != nil {
+		t.Fatalf("Expected second request to succeed, got %v", err)
+	}
+
+	select {
+	case err := <-firstErr:
+		if !errors.Is(err, models.ErrTerminatedByOtherGetUpdates) {
+			t.Errorf("Expected ErrTerminatedByOtherGetUpdates, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected first request to be terminated")
+	}
+}
+
+func TestBotManager_SetAllowedUpdates(t *testing.T) {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	if err := botManager.SetAllowedUpdates(bot.ID, []string{models.UpdateTypeMessage, models.UpdateTypeEditedMessage}); err != nil {
+		t.Fatalf("Failed to set allowed updates: %v", err)
+	}
+
+	stored, err := botManager.GetBot(bot.ID)
+	if err != nil {
+		t.Fatalf("Failed to get bot: %v", err)
+	}
+	allowed := stored.GetAllowedUpdates()
+	if len(allowed) != 2 || allowed[0] != models.UpdateTypeMessage || allowed[1] != models.UpdateTypeEditedMessage {
+		t.Fatalf("Expected allowed updates to be persisted, got %v", allowed)
+	}
+
+	message := &models.Message{ID: 1, ChatID: 1, FromID: 1, Text: "Test message", Type: "text"}
+	botManager.AddUpdate(bot.ID, &models.Update{Message: message})
+	botManager.AddUpdate(bot.ID, &models.Update{EditedMessage: message})
+	botManager.AddUpdate(bot.ID, &models.Update{CallbackQuery: &models.CallbackQuery{ID: "1", Message: message}})
+
+	updates, err := botManager.GetBotUpdates(bot.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("Failed to get bot updates: %v", err)
+	}
+	if len(updates) != 2 {
+		t.Fatalf("Expected 2 updates, got %d", len(updates))
+	}
+	for _, update := range updates {
+		if update.CallbackQuery != nil {
+			t.Error("Expected callback query to be filtered out")
+		}
+	}
+
+	// An empty list restores the default set of updates
+	if err := botManager.SetAllowedUpdates(bot.ID, []string{}); err != nil {
+		t.Fatalf("Failed to reset allowed updates: %v", err)
+	}
+	botManager.AddUpdate(bot.ID, &models.Update{CallbackQuery: &models.CallbackQuery{ID: "2", Message: message}})
+
+	updates, err = botManager.GetBotUpdates(bot.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("Failed to get bot updates: %v", err)
+	}
+	if len(updates) != 3 || updates[2].CallbackQuery == nil {
+		t.Errorf("Expected callback query after reset, got %+v", updates)
+	}
+}
+
+func TestBotManager_AnswerCallbackQuery(t *testing.T) {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+	otherBot, err := botManager.CreateBot("OtherBot", "otherbot", "other_token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	message := &models.Message{ID: 1, ChatID: 1, FromID: 1, Text: "Test message", Type: "text"}
+	callbackQuery := &models.CallbackQuery{ID: "cq_1", From: models.User{ID: 42}, Message: message, Data: "data"}
+	if err := botManager.AddUpdate(bot.ID, &models.Update{CallbackQuery: callbackQuery}); err != nil {
+		t.Fatalf("Failed to add update: %v", err)
+	}
+
+	if _, err := botManager.AnswerCallbackQuery(bot.ID, &models.CallbackQueryAnswer{CallbackQueryID: "unknown"}); !errors.Is(err, models.ErrCallbackQueryTooOld) {
+		t.Errorf("Expected ErrCallbackQueryTooOld for unknown query, got %v", err)
+	}
+	if _, err := botManager.AnswerCallbackQuery(otherBot.ID, &models.CallbackQueryAnswer{CallbackQueryID: "cq_1"}); !errors.Is(err, models.ErrCallbackQueryTooOld) {
+		t.Errorf("Expected ErrCallbackQueryTooOld for query of another bot, got %v", err)
+	}
+
+	userID, err := botManager.AnswerCallbackQuery(bot.ID, &models.CallbackQueryAnswer{CallbackQueryID: "cq_1", Text: "Done"})
+	if err != nil {
+		t.Fatalf("Failed to answer callback query: %v", err)
+	}
+	if userID != 42 {
+		t.Errorf("Expected user ID 42, got %d", userID)
+	}
+
+	if _, err := botManager.AnswerCallbackQuery(bot.ID, &models.CallbackQueryAnswer{CallbackQueryID: "cq_1"}); !errors.Is(err, models.ErrCallbackQueryTooOld) {
+		t.Errorf("Expected ErrCallbackQueryTooOld for duplicate answer, got %v", err)
+	}
+}
+
+func TestBotManager_AnswerCallbackQueryExpired(t *testing.T) {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+	botManager.callbacks.timeout = 10 * time.Millisecond
+
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	message := &models.Message{ID: 1, ChatID: 1, FromID: 1, Text: "Test message", Type: "text"}
+	callbackQuery := &models.CallbackQuery{ID: "cq_1", From: models.User{ID: 42}, Message: message, Data: "data"}
+	if err := botManager.AddUpdate(bot.ID, &models.Update{CallbackQuery: callbackQuery}); err != nil {
+		t.Fatalf("Failed to add update: %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := botManager.AnswerCallbackQuery(bot.ID, &models.CallbackQueryAnswer{CallbackQueryID: "cq_1"}); !errors.Is(err, models.ErrCallbackQueryTooOld) {
+		t.Errorf("Expected ErrCallbackQueryTooOld for expired query, got %v", err)
+	}
+}
+
+func TestBotManager_SetMyCommands(t *testing.T) {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+	chatManager := NewChatManager(chatRepo, messageRepo, userRepo)
+	userManager := NewUserManager(userRepo, botRepo)
+
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+	user, _ := userManager.CreateUser("user", "User", "", false)
+	outsider, _ := userManager.CreateUser("outsider", "Outsider", "", false)
+	private, _ := chatManager.CreatePrivateChat(user.ID, bot.ID)
+
+	defaultScope := models.BotCommandScope{Type: models.BotCommandScopeDefault}
+	commands := []models.BotCommand{{Command: "/start", Description: "Start"}, {Command: "help", Description: "Help"}}
+	if err := botManager.SetMyCommands(bot.ID, commands, defaultScope, ""); err != nil {
+		t.Fatalf("Failed to set commands: %v", err)
+	}
+
+	stored, err := botManager.GetMyCommands(bot.ID, defaultScope, "")
+	if err != nil {
+		t.Fatalf("Failed to get commands: %v", err)
+	}
+	if len(stored) != 2 || stored[0].Command != "start" {
+		t.Errorf("Expected commands without leading slash, got %+v", stored)
+	}
+
+	// getMyCommands matches the scope and language exactly
+	localized, err := botManager.GetMyCommands(bot.ID, defaultScope, "ru")
+	if err != nil {
+		t.Fatalf("Failed to get localized commands: %v", err)
+	}
+	if len(localized) != 0 {
+		t.Errorf("Expected no commands for language ru, got %+v", localized)
+	}
+
+	invalid := []models.BotCommand{{Command: "Start", Description: "Start"}}
+	if err := botManager.SetMyCommands(bot.ID, invalid, defaultScope, ""); err != models.ErrBotCommandInvalid {
+		t.Errorf("Expected ErrBotCommandInvalid, got %v", err)
+	}
+	if err := botManager.SetMyCommands(bot.ID, commands, defaultScope, "rus"); err != models.ErrInvalidLanguageCode {
+		t.Errorf("Expected ErrInvalidLanguageCode, got %v", err)
+	}
+	if err := botManager.SetMyCommands(bot.ID, commands, models.BotCommandScope{Type: models.BotCommandScopeChat, ChatID: 999}, ""); err != models.ErrChatNotFound {
+		t.Errorf("Expected ErrChatNotFound, got %v", err)
+	}
+	if err := botManager.SetMyCommands(bot.ID, commands, models.BotCommandScope{Type: models.BotCommandScopeChatMember, ChatID: private.ID, UserID: user.ID}, ""); err != models.ErrWrongBotCommandScope {
+		t.Errorf("Expected ErrWrongBotCommandScope for chat_member scope in a private chat, got %v", err)
+	}
+
+	group, _ := chatManager.CreateChat("group", "Group", "", "", []int64{user.ID, bot.ID})
+	if err := botManager.SetMyCommands(bot.ID, commands, models.BotCommandScope{Type: models.BotCommandScopeChatMember, ChatID: group.ID, UserID: outsider.ID}, ""); err != models.ErrUserNotFound {
+		t.Errorf("Expected ErrUserNotFound for a user outside the group, got %v", err)
+	}
+
+	// An empty list removes the commands like deleteMyCommands
+	if err := botManager.SetMyCommands(bot.ID, []models.BotCommand{}, defaultScope, ""); err != nil {
+		t.Fatalf("Failed to clear commands: %v", err)
+	}
+	lists, err := botManager.GetAllCommands(bot.ID)
+	if err != nil {
+		t.Fatalf("Failed to get all commands: %v", err)
+	}
+	if len(lists) != 0 {
+		t.Errorf("Expected no command lists after clearing, got %+v", lists)
+	}
+}
+
+func TestBotManager_GetCommandMenu(t *testing.T) {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+	chatManager := NewChatManager(chatRepo, messageRepo, userRepo)
+	userManager := NewUserManager(userRepo, botRepo)
+
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+	alice, _ := userManager.CreateUser("alice", "Alice", "", false)
+	bob, _ := userManager.CreateUser("bob", "Bob", "", false)
+	private, _ := chatManager.CreatePrivateChat(alice.ID, bot.ID)
+	group, _ := chatManager.CreateChat("group", "Group", "", "", []int64{alice.ID, bob.ID, bot.ID})
+
+	set := func(scope models.BotCommandScope, languageCode, command string) {
+		t.Helper()
+		if err := botManager.SetMyCommands(bot.ID, []models.BotCommand{{Command: command, Description: command}}, scope, languageCode); err != nil {
+			t.Fatalf("Failed to set %s commands: %v", command, err)
+		}
+	}
+	menu := func(chatID, userID int64, languageCode string) string {
+		t.Helper()
+		commands, err := botManager.GetCommandMenu(bot.ID, chatID, userID, languageCode)
+		if err != nil {
+			t.Fatalf("Failed to get command menu: %v", err)
+		}
+		if len(commands) == 0 {
+			return ""
+		}
+		return commands[0].Command
+	}
+
+	if got := menu(private.ID, alice.ID, "en"); got != "" {
+		t.Errorf("Expected empty menu without commands, got %q", got)
+	}
+
+	set(models.BotCommandScope{Type: models.BotCommandScopeDefault}, "", "fallback")
+	set(models.BotCommandScope{Type: models.BotCommandScopeDefault}, "ru", "fallback_ru")
+	set(models.BotCommandScope{Type: models.BotCommandScopeAllPrivateChats}, "", "private")
+	set(models.BotCommandScope{Type: models.BotCommandScopeAllGroupChats}, "", "groups")
+	set(models.BotCommandScope{Type: models.BotCommandScopeChatMember, ChatID: group.ID, UserID: bob.ID}, "", "bob")
+
+	tests := []struct {
+		name         string
+		chatID       int64
+		userID       int64
+		languageCode string
+		want         string
+	}{
+		{"private chat uses all_private_chats", private.ID, alice.ID, "", "private"},
+		{"narrower scope wins over language", private.ID, alice.ID, "ru", "private"},
+		{"group member without own scope", group.ID, alice.ID, "en", "groups"},
+		{"chat_member scope for bob", group.ID, bob.ID, "", "bob"},
+	}
+	for _, tt := range tests {
+		if got := menu(tt.chatID, tt.userID, tt.languageCode); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// Without narrower scopes the language-specific default list is preferred
+	if err := botManager.DeleteMyCommands(bot.ID, models.BotCommandScope{Type: models.BotCommandScopeAllPrivateChats}, ""); err != nil {
+		t.Fatalf("Failed to delete commands: %v", err)
+	}
+	if got := menu(private.ID, alice.ID, "ru"); got != "fallback_ru" {
+		t.Errorf("Expected fallback_ru, got %q", got)
+	}
+	if got := menu(private.ID, alice.ID, "en"); got != "fallback" {
+		t.Errorf("Expected fallback, got %q", got)
+	}
+
+	if _, err := botManager.GetCommandMenu(bot.ID, 999, alice.ID, ""); err != models.ErrChatNotFound {
+		t.Errorf("Expected ErrChatNotFound, got %v", err)
+	}
+}
+
+func TestBotManager_StubRules(t *testing.T) {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	rule, err := botManager.AddStubRule(bot.ID, models.StubRuleConfig{Command: "/start", Text: "Hello"})
+	if err != nil {
+		t.Fatalf("Failed to add stub rule: %v", err)
+	}
+	if _, err := botManager.AddStubRule(bot.ID, models.StubRuleConfig{Text: "No condition"}); err == nil {
+		t.Error("Expected validation error for a rule without command or pattern")
+	}
+	if _, err := botManager.AddStubRule(999, models.StubRuleConfig{Command: "start", Text: "Hello"}); err == nil {
+		t.Error("Expected error for unknown bot")
+	}
+
+	matched, err := botManager.MatchStubRule(bot, &models.Message{Text: "/start"})
+	if err != nil || matched == nil || matched.ID != rule.ID {
+		t.Errorf("Expected /start to match rule %d, got %+v (%v)", rule.ID, matched, err)
+	}
+	matched, _ = botManager.MatchStubRule(bot, &models.Message{Text: "hello"})
+	if matched != nil {
+		t.Errorf("Expected no rule for plain text, got %+v", matched)
+	}
+
+	if err := botManager.DeleteStubRule(bot.ID, 12345); err != models.ErrStubRuleNotFound {
+		t.Errorf("Expected ErrStubRuleNotFound, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "stub_rules.yaml")
+	yamlRules := `bots:
+  testbot:
+    - pattern: "(?i)weather"
+      text: Sunny
+      delay_ms: 100
+      reply: true
+      reply_markup:
+        inline_keyboard:
+          - - text: Forecast
+              callback_data: forecast
+  unknown_bot:
+    - command: start
+      text: Hi
+`
+	if err := os.WriteFile(path, []byte(yamlRules), 0o644); err != nil {
+		t.Fatalf("Failed to write rules file: %v", err)
+	}
+
+	// Rules from the file replace stored ones; unknown bots are skipped
+	if err := botManager.LoadStubRules(path); err != nil {
+		t.Fatalf("Failed to load stub rules: %v", err)
+	}
+	rules, err := botManager.GetStubRules(bot.ID)
+	if err != nil {
+		t.Fatalf("Failed to get stub rules: %v", err)
+	}
+	if len(rules) != 1 || rules[0].Pattern != "(?i)weather" || rules[0].DelayMS != 100 || !rules[0].Reply {
+		t.Fatalf("Expected rule loaded from YAML, got %+v", rules)
+	}
+	if rules[0].GetReplyMarkup() == nil {
+		t.Error("Expected keyboard loaded from YAML")
+	}
+
+	invalid := filepath.Join(t.TempDir(), "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("bots:\n  testbot:\n    - command: start\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write rules file: %v", err)
+	}
+	if err := botManager.LoadStubRules(invalid); err == nil {
+		t.Error("Expected error for a rule without text")
+	}
+
+	// Deleting the bot removes its rules
+	if err := botManager.DeleteBot(bot.ID); err != nil {
+		t.Fatalf("Failed to delete bot: %v", err)
+	}
+	if remaining, _ := botRepo.GetStubRules(bot.ID); len(remaining) != 0 {
+		t.Errorf("Expected rules to be deleted with the bot, got %d", len(remaining))
+	}
+}
@@ -0,0 +1,75 @@
+package emulator
+
+import (
+	"sync"
+	"time"
+)
+
+// callbackQueryTimeout задает время, в течение которого бот может ответить на callback query
+const callbackQueryTimeout = 15 * time.Second
+
+// callbackQueryKey идентифицирует callback query, выданный конкретному боту
+type callbackQueryKey struct {
+	botID   int64
+	queryID string
+}
+
+// pendingCallbackQuery представляет callback query, ожидающий ответа бота
+type pendingCallbackQuery struct {
+	userID    int64
+	createdAt time.Time
+}
+
+// callbackQueryRegistry хранит выданные ботам callback query до ответа или истечения срока
+type callbackQueryRegistry struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	queries map[callbackQueryKey]pendingCallbackQuery
+}
+
+// newCallbackQueryRegistry создает пустой реестр callback query
+func newCallbackQueryRegistry(timeout time.Duration) *callbackQueryRegistry {
+	return &callbackQueryRegistry{
+		timeout: timeout,
+		queries: make(map[callbackQueryKey]pendingCallbackQuery),
+	}
+}
+
+// register запоминает callback query, выданный боту
+func (r *callbackQueryRegistry) register(botID int64, queryID string, userID int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.removeExpired(time.Now())
+	r.queries[callbackQueryKey{botID: botID, queryID: queryID}] = pendingCallbackQuery{
+		userID:    userID,
+		createdAt: time.Now(),
+	}
+}
+
+// answer отмечает callback query отвеченным и возвращает ID нажавшего кнопку пользователя.
+// Неизвестные, просроченные и уже отвеченные запросы отклоняются
+func (r *callbackQueryRegistry) answer(botID int64, queryID string) (int64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.removeExpired(time.Now())
+
+	key := callbackQueryKey{botID: botID, queryID: queryID}
+	query, exists := r.queries[key]
+	if !exists {
+		return 0, false
+	}
+
+	delete(r.queries, key)
+	return query.userID, true
+}
+
+// removeExpired удаляет callback query с истекшим сроком ответа
+func (r *callbackQueryRegistry) removeExpired(now time.Time) {
+	for key, query := range r.queries {
+		if now.Sub(query.createdAt) > r.timeout {
+			delete(r.queries, key)
+		}
+	}
+}
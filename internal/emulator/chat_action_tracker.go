@@ -0,0 +1,85 @@
+package emulator
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"telegram-emulator/internal/models"
+)
+
+// chatActionKey идентифицирует действие пользователя в конкретном чате
+type chatActionKey struct {
+	chatID int64
+	userID int64
+}
+
+// ChatActionTracker хранит текущие действия пользователей в чатах (набор текста, загрузка файла и т.д.).
+// Действие перестает отображаться через models.ChatActionDuration или при отправке пользователем сообщения
+type ChatActionTracker struct {
+	actions  map[chatActionKey]models.ChatAction
+	duration time.Duration
+	now      func() time.Time
+	mutex    sync.Mutex
+}
+
+// NewChatActionTracker создает новый экземпляр ChatActionTracker
+func NewChatActionTracker() *ChatActionTracker {
+	return &ChatActionTracker{
+		actions:  make(map[chatActionKey]models.ChatAction),
+		duration: models.ChatActionDuration,
+		now:      time.Now,
+	}
+}
+
+// Set запоминает действие пользователя в чате, заменяя предыдущее
+func (t *ChatActionTracker) Set(chatID, userID int64, action string) models.ChatAction {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	chatAction := models.ChatAction{
+		ChatID:    chatID,
+		UserID:    userID,
+		Action:    action,
+		ExpiresAt: t.now().Add(t.duration),
+	}
+	t.actions[chatActionKey{chatID: chatID, userID: userID}] = chatAction
+	return chatAction
+}
+
+// Clear завершает действие пользователя в чате. Возвращает true, если действие еще отображалось
+func (t *ChatActionTracker) Clear(chatID, userID int64) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	key := chatActionKey{chatID: chatID, userID: userID}
+	chatAction, ok := t.actions[key]
+	if !ok {
+		return false
+	}
+	delete(t.actions, key)
+	return !chatAction.IsExpired(t.now())
+}
+
+// GetChatActions возвращает действия, которые сейчас отображаются в чате
+func (t *ChatActionTracker) GetChatActions(chatID int64) []models.ChatAction {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := t.now()
+	actions := make([]models.ChatAction, 0)
+	for key, chatAction := range t.actions {
+		if chatAction.IsExpired(now) {
+			delete(t.actions, key)
+			continue
+		}
+		if key.chatID == chatID {
+			actions = append(actions, chatAction)
+		}
+	}
+
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].UserID < actions[j].UserID
+	})
+	return actions
+}
@@ -0,0 +1,45 @@
+package emulator
+
+import (
+	"testing"
+	"time"
+
+	"telegram-emulator/internal/models"
+)
+
+func TestChatActionTracker(t *testing.T) {
+	now := time.Now()
+	tracker := NewChatActionTracker()
+	tracker.now = func() time.Time { return now }
+
+	tracker.Set(1, 10, models.ChatActionTyping)
+	tracker.Set(1, 20, models.ChatActionUploadPhoto)
+	tracker.Set(2, 10, models.ChatActionRecordVoice)
+
+	actions := tracker.GetChatActions(1)
+	if len(actions) != 2 || actions[0].UserID != 10 || actions[1].Action != models.ChatActionUploadPhoto {
+		t.Fatalf("Expected two actions in chat 1, got %+v", actions)
+	}
+
+	// A new action replaces the previous one of the same user
+	tracker.Set(1, 10, models.ChatActionUploadDocument)
+	if actions := tracker.GetChatActions(1); len(actions) != 2 || actions[0].Action != models.ChatActionUploadDocument {
+		t.Errorf("Expected action to be replaced, got %+v", actions)
+	}
+
+	if !tracker.Clear(1, 10) {
+		t.Error("Expected Clear to report an active action")
+	}
+	if tracker.Clear(1, 10) {
+		t.Error("Expected second Clear to report nothing")
+	}
+
+	// Actions disappear after ChatActionDuration
+	now = now.Add(models.ChatActionDuration)
+	if actions := tracker.GetChatActions(1); len(actions) != 0 {
+		t.Errorf("Expected expired actions to be hidden, got %+v", actions)
+	}
+	if tracker.Clear(2, 10) {
+		t.Error("Expected Clear of an expired action to report nothing")
+	}
+}
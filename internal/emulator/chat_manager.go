@@ -3,6 +3,7 @@ package emulator
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"time"
 
 	"telegram-emulator/internal/models"
@@ -10,14 +11,17 @@ import (
 	"telegram-emulator/internal/repository"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 // ChatManager управляет чатами в эмуляторе
 type ChatManager struct {
-	chatRepo    *repository.ChatRepository
-	messageRepo *repository.MessageRepository
-	userRepo    *repository.UserRepository
-	logger      *zap.Logger
+	chatRepo       *repository.ChatRepository
+	messageRepo    *repository.MessageRepository
+	userRepo       *repository.UserRepository
+	botManager     *BotManager
+	messageManager *MessageManager
+	logger         *zap.Logger
 }
 
 // NewChatManager создает новый экземпляр ChatManager
@@ -30,7 +34,17 @@ func NewChatManager(chatRepo *repository.ChatRepository, messageRepo *repository
 	}
 }
 
-// CreateChat создает новый чат
+// SetBotManager устанавливает BotManager для уведомления ботов об изменении участников чатов
+func (m *ChatManager) SetBotManager(botManager *BotManager) {
+	m.botManager = botManager
+}
+
+// SetMessageManager устанавливает MessageManager для служебных сообщений о входе и выходе участников групп
+func (m *ChatManager) SetMessageManager(messageManager *MessageManager) {
+	m.messageManager = messageManager
+}
+
+// CreateChat создает новый чат. Первый пользователь группы становится ее создателем
 func (m *ChatManager) CreateChat(chatType, title, username, description string, userIDs []int64) (*models.Chat, error) {
 	// Генерируем уникальный ID
 	id, err := m.generateID()
@@ -59,8 +73,17 @@ func (m *ChatManager) CreateChat(chatType, title, username, description string,
 	}
 
 	// Добавляем участников
-	for _, userID := range userIDs {
-		if err := m.chatRepo.AddMember(chat.ID, userID); err != nil {
+	for i, userID := range userIDs {
+		chatMember := &models.ChatMember{
+			ChatID:   chat.ID,
+			UserID:   userID,
+			Status:   models.ChatMemberStatusMember,
+			JoinedAt: time.Now(),
+		}
+		if i == 0 && chat.IsGroup() {
+			chatMember.Status = models.ChatMemberStatusCreator
+		}
+		if err := m.chatRepo.SaveMember(chatMember); err != nil {
 			m.logger.Error("Ошибка добавления участника в чат", zap.Int64("chat_id", chat.ID), zap.Int64("user_id", userID), zap.Error(err))
 			return nil, err
 		}
@@ -78,6 +101,11 @@ func (m *ChatManager) CreateChat(chatType, title, username, description string,
 		zap.String("type", chat.Type),
 		zap.String("title", chat.Title))
 
+	// Боты, добавленные в новую группу, узнают об этом из my_chat_member
+	if chat.IsGroup() && len(userIDs) > 0 {
+		m.notifyNewGroupBots(chat, userIDs[0])
+	}
+
 	return chat, nil
 }
 
@@ -162,26 +190,415 @@ func (m *ChatManager) DeleteChat(id int64) error {
 	return nil
 }
 
-// AddMember добавляет участника в чат
-func (m *ChatManager) AddMember(chatID int64, userID int64) error {
-	if err := m.chatRepo.AddMember(chatID, userID); err != nil {
+// AddMember добавляет участника userID в чат от имени actorID (0 - пользователь вступает сам).
+// Вышедший пользователь возвращается в чат обычным участником, заблокированного пользователя добавить нельзя
+func (m *ChatManager) AddMember(chatID, userID, actorID int64) error {
+	chat, err := m.getChat(chatID)
+	if err != nil {
+		return err
+	}
+	user, err := m.getUser(userID)
+	if err != nil {
+		return err
+	}
+
+	chatMember, err := m.getMemberRecord(chatID, userID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		chatMember = &models.ChatMember{ChatID: chatID, UserID: userID, Status: models.ChatMemberStatusLeft}
+	case err != nil:
+		return err
+	case chatMember.IsActive():
+		return models.ErrUserAlreadyParticipant
+	case chatMember.Status == models.ChatMemberStatusKicked:
+		return models.ErrUserKicked
+	}
+
+	oldChatMember := *chatMember
+	chatMember.Status = models.ChatMemberStatusMember
+	chatMember.JoinedAt = time.Now()
+	if err := m.chatRepo.SaveMember(chatMember); err != nil {
 		m.logger.Error("Ошибка добавления участника", zap.Int64("chat_id", chatID), zap.Int64("user_id", userID), zap.Error(err))
 		return err
 	}
 	m.logger.Info("Участник добавлен в чат", zap.Int64("chat_id", chatID), zap.Int64("user_id", userID))
+
+	if actorID == 0 {
+		actorID = userID
+	}
+	m.notifyMemberChange(chat, actorID, user, &oldChatMember, chatMember)
 	return nil
 }
 
-// RemoveMember удаляет участника из чата
-func (m *ChatManager) RemoveMember(chatID int64, userID int64) error {
-	if err := m.chatRepo.RemoveMember(chatID, userID); err != nil {
+// RemoveMember удаляет участника userID из чата от имени actorID (0 - пользователь выходит сам):
+// участник получает статус left и теряет права. Создателя чата удалить нельзя
+func (m *ChatManager) RemoveMember(chatID, userID, actorID int64) error {
+	chat, err := m.getChat(chatID)
+	if err != nil {
+		return err
+	}
+	user, err := m.getUser(userID)
+	if err != nil {
+		return err
+	}
+
+	chatMember, err := m.getActiveMember(chatID, userID)
+	if err != nil {
+		return err
+	}
+	if chatMember.Status == models.ChatMemberStatusCreator {
+		return models.ErrCantRemoveChatOwner
+	}
+
+	oldChatMember := *chatMember
+	resetChatMember(chatMember, models.ChatMemberStatusLeft)
+	if err := m.chatRepo.SaveMember(chatMember); err != nil {
 		m.logger.Error("Ошибка удаления участника", zap.Int64("chat_id", chatID), zap.Int64("user_id", userID), zap.Error(err))
 		return err
 	}
 	m.logger.Info("Участник удален из чата", zap.Int64("chat_id", chatID), zap.Int64("user_id", userID))
+
+	if actorID == 0 {
+		actorID = userID
+	}
+	m.notifyMemberChange(chat, actorID, user, &oldChatMember, chatMember)
 	return nil
 }
 
+// BlockBot блокирует бота пользователем userID в их приватном чате: бот получает my_chat_member
+// со статусом kicked и больше не может писать пользователю
+func (m *ChatManager) BlockBot(chatID, userID int64) error {
+	return m.setBotBlocked(chatID, userID, true)
+}
+
+// UnblockBot снимает блокировку бота пользователем userID в их приватном чате
+func (m *ChatManager) UnblockBot(chatID, userID int64) error {
+	return m.setBotBlocked(chatID, userID, false)
+}
+
+// setBotBlocked блокирует или разблокирует бота в приватном чате с пользователем userID.
+// Повторная блокировка и разблокировка ничего не меняют
+func (m *ChatManager) setBotBlocked(chatID, userID int64, blocked bool) error {
+	chat, botUser, botMember, err := m.getPrivateChatBot(chatID, userID)
+	if err != nil {
+		return err
+	}
+
+	status := models.ChatMemberStatusMember
+	if blocked {
+		status = models.ChatMemberStatusKicked
+	}
+	if botMember.Status == status {
+		return nil
+	}
+
+	oldChatMember := *botMember
+	resetChatMember(botMember, status)
+	if err := m.chatRepo.SaveMember(botMember); err != nil {
+		m.logger.Error("Ошибка изменения блокировки бота", zap.Int64("chat_id", chatID), zap.Int64("bot_id", botUser.ID), zap.Error(err))
+		return err
+	}
+	m.logger.Info("Пользователь изменил блокировку бота",
+		zap.Int64("chat_id", chatID),
+		zap.Int64("user_id", userID),
+		zap.Int64("bot_id", botUser.ID),
+		zap.Bool("blocked", blocked))
+
+	m.notifyMemberChange(chat, userID, botUser, &oldChatMember, botMember)
+	return nil
+}
+
+// getPrivateChatBot получает приватный чат пользователя userID с ботом, пользователя-бота и его участие в чате.
+// Заблокированный бот тоже возвращается
+func (m *ChatManager) getPrivateChatBot(chatID, userID int64) (*models.Chat, *models.User, *models.ChatMember, error) {
+	chat, err := m.getChat(chatID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !chat.IsPrivate() {
+		return nil, nil, nil, models.ErrNotBotPrivateChat
+	}
+
+	chatMembers, err := m.chatRepo.GetAllMemberRecords(chatID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var isMember bool
+	var botMember *models.ChatMember
+	for i := range chatMembers {
+		if chatMembers[i].UserID == userID {
+			isMember = true
+		} else {
+			botMember = &chatMembers[i]
+		}
+	}
+	if !isMember {
+		return nil, nil, nil, models.ErrUserNotParticipant
+	}
+	if botMember == nil {
+		return nil, nil, nil, models.ErrNotBotPrivateChat
+	}
+
+	botUser, err := m.getUser(botMember.UserID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !botUser.IsBot {
+		return nil, nil, nil, models.ErrNotBotPrivateChat
+	}
+	return chat, botUser, botMember, nil
+}
+
+// PromoteMember назначает участника группы администратором с правами rights.
+// Пустые права снимают администратора, как promoteChatMember без прав в Telegram.
+// Боты получают об изменении обновления от имени создателя группы
+func (m *ChatManager) PromoteMember(chatID, userID int64, rights models.ChatAdministratorRights) (*models.ChatMember, error) {
+	chat, err := m.getChat(chatID)
+	if err != nil {
+		return nil, err
+	}
+	if !chat.IsGroup() {
+		return nil, models.ErrGroupChatsOnly
+	}
+	user, err := m.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	chatMember, err := m.getActiveMember(chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if chatMember.Status == models.ChatMemberStatusCreator {
+		return nil, models.ErrCantDemoteChatCreator
+	}
+
+	oldChatMember := *chatMember
+	setAdministratorRights(chatMember, rights, 0)
+	if err := m.chatRepo.SaveMember(chatMember); err != nil {
+		m.logger.Error("Ошибка изменения прав участника", zap.Int64("chat_id", chatID), zap.Int64("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	m.logger.Info("Права участника чата изменены",
+		zap.Int64("chat_id", chatID),
+		zap.Int64("user_id", userID),
+		zap.String("status", chatMember.Status))
+
+	m.notifyMemberChange(chat, 0, user, &oldChatMember, chatMember)
+	return chatMember, nil
+}
+
+// DemoteMember снимает с участника группы права администратора
+func (m *ChatManager) DemoteMember(chatID, userID int64) (*models.ChatMember, error) {
+	return m.PromoteMember(chatID, userID, models.ChatAdministratorRights{})
+}
+
+// GetMember возвращает участие пользователя в чате
+func (m *ChatManager) GetMember(chatID, userID int64) (*models.ChatMember, error) {
+	return m.getMemberRecord(chatID, userID)
+}
+
+// GetMemberRecords возвращает статусы и права участников чата
+func (m *ChatManager) GetMemberRecords(chatID int64) ([]models.ChatMember, error) {
+	chatMembers, err := m.chatRepo.GetMemberRecords(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range chatMembers {
+		if chatMembers[i].Expire(now) {
+			m.saveExpiredMember(&chatMembers[i])
+		}
+	}
+	return chatMembers, nil
+}
+
+// GetBotChat возвращает чат, в котором состоит бот botUserID. Для бота чаты, в которых его нет, не существуют
+func (m *ChatManager) GetBotChat(chatID, botUserID int64) (*models.Chat, error) {
+	chat, err := m.getChat(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	chatMember, err := m.getMemberRecord(chatID, botUserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrChatNotFound
+		}
+		return nil, err
+	}
+	if chatMember.Status == models.ChatMemberStatusKicked {
+		if chat.IsPrivate() {
+			return nil, models.ErrBotBlocked
+		}
+		return nil, models.ErrBotKicked
+	}
+	if !chatMember.IsActive() {
+		return nil, models.ErrChatNotFound
+	}
+
+	return chat, nil
+}
+
+// GetTelegramChatMember возвращает участника чата в формате Telegram Bot API с точки зрения пользователя viewerID.
+// Пользователь, который никогда не состоял в чате, возвращается со статусом left
+func (m *ChatManager) GetTelegramChatMember(chatID, userID, viewerID int64) (*models.TelegramChatMember, error) {
+	user, err := m.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	chatMember, err := m.getMemberRecord(chatID, userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		chatMember = &models.ChatMember{ChatID: chatID, UserID: userID, Status: models.ChatMemberStatusLeft}
+	}
+
+	telegramMember := chatMember.ToTelegramChatMember(user)
+	telegramMember.CanBeEdited = chatMember.CanBeEditedBy(viewerID)
+	return &telegramMember, nil
+}
+
+// GetAdministrators возвращает создателя и администраторов группы в формате Telegram Bot API
+// с точки зрения пользователя viewerID
+func (m *ChatManager) GetAdministrators(chatID, viewerID int64) ([]models.TelegramChatMember, error) {
+	chat, err := m.getChat(chatID)
+	if err != nil {
+		return nil, err
+	}
+	if chat.IsPrivate() {
+		return nil, models.ErrNoPrivateChatAdmins
+	}
+
+	chatMembers, err := m.chatRepo.GetMemberRecords(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	administrators := make([]models.TelegramChatMember, 0)
+	for i := range chatMembers {
+		if !chatMembers[i].IsAdministrator() {
+			continue
+		}
+		for j := range chat.Members {
+			if chat.Members[j].ID == chatMembers[i].UserID {
+				administrator := chatMembers[i].ToTelegramChatMember(&chat.Members[j])
+				administrator.CanBeEdited = chatMembers[i].CanBeEditedBy(viewerID)
+				administrators = append(administrators, administrator)
+				break
+			}
+		}
+	}
+
+	return administrators, nil
+}
+
+// GetMemberCount возвращает количество участников чата
+func (m *ChatManager) GetMemberCount(chatID int64) (int64, error) {
+	return m.chatRepo.CountMembers(chatID)
+}
+
+// getChat получает чат, преобразуя отсутствие чата в ошибку Telegram
+func (m *ChatManager) getChat(chatID int64) (*models.Chat, error) {
+	chat, err := m.chatRepo.GetByID(chatID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrChatNotFound
+		}
+		return nil, err
+	}
+	return chat, nil
+}
+
+// getUser получает пользователя, преобразуя отсутствие пользователя в ошибку Telegram
+func (m *ChatManager) getUser(userID int64) (*models.User, error) {
+	user, err := m.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// getMemberRecord получает участие пользователя в чате, снимая истекшие ограничения и блокировку
+func (m *ChatManager) getMemberRecord(chatID, userID int64) (*models.ChatMember, error) {
+	chatMember, err := m.chatRepo.GetMember(chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if chatMember.Expire(time.Now()) {
+		m.saveExpiredMember(chatMember)
+	}
+	return chatMember, nil
+}
+
+// saveExpiredMember сохраняет участника, у которого истек срок ограничения или блокировки
+func (m *ChatManager) saveExpiredMember(chatMember *models.ChatMember) {
+	if err := m.chatRepo.SaveMember(chatMember); err != nil {
+		m.logger.Error("Ошибка снятия истекшего ограничения участника",
+			zap.Int64("chat_id", chatMember.ChatID),
+			zap.Int64("user_id", chatMember.UserID),
+			zap.Error(err))
+		return
+	}
+	m.logger.Info("Истек срок ограничения участника",
+		zap.Int64("chat_id", chatMember.ChatID),
+		zap.Int64("user_id", chatMember.UserID),
+		zap.String("status", chatMember.Status))
+}
+
+// getActiveMember получает участника, который состоит в чате
+func (m *ChatManager) getActiveMember(chatID, userID int64) (*models.ChatMember, error) {
+	chatMember, err := m.getMemberRecord(chatID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrUserNotParticipant
+		}
+		return nil, err
+	}
+	if !chatMember.IsActive() {
+		return nil, models.ErrUserNotParticipant
+	}
+	return chatMember, nil
+}
+
+// resetChatMember устанавливает статус участника, сбрасывая права администратора и ограничения
+func resetChatMember(chatMember *models.ChatMember, status string) {
+	chatMember.Status = status
+	chatMember.ChatAdministratorRights = models.ChatAdministratorRights{}
+	chatMember.PermissionsJSON = ""
+	chatMember.UntilDate = nil
+	chatMember.PromotedBy = 0
+	chatMember.CustomTitle = ""
+}
+
+// setAdministratorRights назначает участника администратором с правами rights от имени promotedBy.
+// Пустые права делают его обычным участником, а администратор при изменении прав сохраняет звание
+func setAdministratorRights(chatMember *models.ChatMember, rights models.ChatAdministratorRights, promotedBy int64) {
+	if rights.IsEmpty() {
+		resetChatMember(chatMember, models.ChatMemberStatusMember)
+		return
+	}
+	var customTitle string
+	if chatMember.Status == models.ChatMemberStatusAdministrator {
+		customTitle = chatMember.CustomTitle
+	}
+	resetChatMember(chatMember, models.ChatMemberStatusAdministrator)
+	chatMember.CustomTitle = customTitle
+	chatMember.ChatAdministratorRights = rights
+	chatMember.PromotedBy = promotedBy
+}
+
 // GetChatMembers получает участников чата
 func (m *ChatManager) GetChatMembers(chatID int64) ([]models.User, error) {
 	return m.chatRepo.GetMembers(chatID)
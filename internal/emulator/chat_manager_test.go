@@ -0,0 +1,221 @@
+package emulator
+
+import (
+	"errors"
+	"testing"
+
+	"telegram-emulator/internal/models"
+	"telegram-emulator/internal/repository"
+)
+
+func TestChatManager_CreateChatRoles(t *testing.T) {
+	db := SetupTestDB(t)
+	userRepo := repository.NewUserRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	chatManager := NewChatManager(chatRepo, repository.NewMessageRepository(db), userRepo)
+	userManager := NewUserManager(userRepo, repository.NewBotRepository(db))
+
+	owner, _ := userManager.CreateUser("owner", "Owner", "", false)
+	member, _ := userManager.CreateUser("member", "Member", "", false)
+
+	group, err := chatManager.CreateChat("group", "Group", "", "", []int64{owner.ID, member.ID})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+
+	// The first user of a group becomes its creator
+	ownerRecord, err := chatManager.GetMember(group.ID, owner.ID)
+	if err != nil || ownerRecord.Status != models.ChatMemberStatusCreator {
+		t.Errorf("Expected owner to be creator, got %+v (%v)", ownerRecord, err)
+	}
+	memberRecord, err := chatManager.GetMember(group.ID, member.ID)
+	if err != nil || memberRecord.Status != models.ChatMemberStatusMember {
+		t.Errorf("Expected member status, got %+v (%v)", memberRecord, err)
+	}
+
+	private, err := chatManager.CreatePrivateChat(owner.ID, member.ID)
+	if err != nil {
+		t.Fatalf("Failed to create private chat: %v", err)
+	}
+	records, _ := chatManager.GetMemberRecords(private.ID)
+	for _, record := range records {
+		if record.Status != models.ChatMemberStatusMember {
+			t.Errorf("Expected only members in a private chat, got %+v", record)
+		}
+	}
+}
+
+func TestChatManager_AddRemoveMember(t *testing.T) {
+	db := SetupTestDB(t)
+	userRepo := repository.NewUserRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	chatManager := NewChatManager(chatRepo, repository.NewMessageRepository(db), userRepo)
+	userManager := NewUserManager(userRepo, repository.NewBotRepository(db))
+
+	owner, _ := userManager.CreateUser("owner", "Owner", "", false)
+	member, _ := userManager.CreateUser("member", "Member", "", false)
+	group, _ := chatManager.CreateChat("group", "Group", "", "", []int64{owner.ID})
+
+	if err := chatManager.AddMember(group.ID, member.ID, 0); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+	if err := chatManager.AddMember(group.ID, member.ID, 0); !errors.Is(err, models.ErrUserAlreadyParticipant) {
+		t.Errorf("Expected ErrUserAlreadyParticipant, got %v", err)
+	}
+	if err := chatManager.AddMember(999, member.ID, 0); !errors.Is(err, models.ErrChatNotFound) {
+		t.Errorf("Expected ErrChatNotFound, got %v", err)
+	}
+
+	if _, err := chatManager.PromoteMember(group.ID, member.ID, models.ChatAdministratorRights{CanPinMessages: true}); err != nil {
+		t.Fatalf("Failed to promote member: %v", err)
+	}
+
+	// Leaving the chat drops admin rights; rejoining makes the user a regular member
+	if err := chatManager.RemoveMember(group.ID, member.ID, 0); err != nil {
+		t.Fatalf("Failed to remove member: %v", err)
+	}
+	record, _ := chatManager.GetMember(group.ID, member.ID)
+	if record.Status != models.ChatMemberStatusLeft || !record.Rights().IsEmpty() || record.CanPinMessages {
+		t.Errorf("Expected left member without rights, got %+v", record)
+	}
+	chat, _ := chatManager.GetChat(group.ID)
+	if chat.IsUserMember(member.ID) {
+		t.Error("Expected user who left not to be listed as a member")
+	}
+	if err := chatManager.RemoveMember(group.ID, member.ID, 0); !errors.Is(err, models.ErrUserNotParticipant) {
+		t.Errorf("Expected ErrUserNotParticipant, got %v", err)
+	}
+
+	if err := chatManager.AddMember(group.ID, member.ID, 0); err != nil {
+		t.Fatalf("Failed to add member again: %v", err)
+	}
+	record, _ = chatManager.GetMember(group.ID, member.ID)
+	if record.Status != models.ChatMemberStatusMember {
+		t.Errorf("Expected member status after rejoining, got %q", record.Status)
+	}
+
+	if err := chatManager.RemoveMember(group.ID, owner.ID, 0); !errors.Is(err, models.ErrCantRemoveChatOwner) {
+		t.Errorf("Expected ErrCantRemoveChatOwner, got %v", err)
+	}
+
+	// Kicked users can't be added back
+	record.Status = models.ChatMemberStatusKicked
+	if err := chatRepo.SaveMember(record); err != nil {
+		t.Fatalf("Failed to save member: %v", err)
+	}
+	if err := chatManager.AddMember(group.ID, member.ID, 0); !errors.Is(err, models.ErrUserKicked) {
+		t.Errorf("Expected ErrUserKicked, got %v", err)
+	}
+}
+
+func TestChatManager_PromoteMember(t *testing.T) {
+	db := SetupTestDB(t)
+	userRepo := repository.NewUserRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	chatManager := NewChatManager(chatRepo, repository.NewMessageRepository(db), userRepo)
+	userManager := NewUserManager(userRepo, repository.NewBotRepository(db))
+
+	owner, _ := userManager.CreateUser("owner", "Owner", "", false)
+	member, _ := userManager.CreateUser("member", "Member", "", false)
+	outsider, _ := userManager.CreateUser("outsider", "Outsider", "", false)
+	group, _ := chatManager.CreateChat("group", "Group", "", "", []int64{owner.ID, member.ID})
+	private, _ := chatManager.CreatePrivateChat(owner.ID, member.ID)
+
+	rights := models.ChatAdministratorRights{CanDeleteMessages: true, CanRestrictMembers: true}
+	admin, err := chatManager.PromoteMember(group.ID, member.ID, rights)
+	if err != nil {
+		t.Fatalf("Failed to promote member: %v", err)
+	}
+	if admin.Status != models.ChatMemberStatusAdministrator || admin.Rights() != rights {
+		t.Errorf("Expected administrator with granted rights, got %+v", admin)
+	}
+
+	stored, _ := chatManager.GetMember(group.ID, member.ID)
+	if !stored.IsAdministrator() || !stored.CanRestrictMembers {
+		t.Errorf("Expected promotion to be persisted, got %+v", stored)
+	}
+
+	demoted, err := chatManager.DemoteMember(group.ID, member.ID)
+	if err != nil {
+		t.Fatalf("Failed to demote member: %v", err)
+	}
+	if demoted.Status != models.ChatMemberStatusMember || demoted.CanDeleteMessages {
+		t.Errorf("Expected regular member without rights, got %+v", demoted)
+	}
+
+	if _, err := chatManager.DemoteMember(group.ID, owner.ID); !errors.Is(err, models.ErrCantDemoteChatCreator) {
+		t.Errorf("Expected ErrCantDemoteChatCreator, got %v", err)
+	}
+	if _, err := chatManager.PromoteMember(group.ID, outsider.ID, rights); !errors.Is(err, models.ErrUserNotParticipant) {
+		t.Errorf("Expected ErrUserNotParticipant, got %v", err)
+	}
+	if _, err := chatManager.PromoteMember(private.ID, member.ID, rights); !errors.Is(err, models.ErrGroupChatsOnly) {
+		t.Errorf("Expected ErrGroupChatsOnly for a private chat, got %v", err)
+	}
+}
+
+func TestChatManager_BotChatInfo(t *testing.T) {
+	db := SetupTestDB(t)
+	userRepo := repository.NewUserRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	chatManager := NewChatManager(chatRepo, repository.NewMessageRepository(db), userRepo)
+	userManager := NewUserManager(userRepo, repository.NewBotRepository(db))
+
+	owner, _ := userManager.CreateUser("owner", "Owner", "", false)
+	bot, _ := userManager.CreateUser("test_bot", "Bot", "", true)
+	outsider, _ := userManager.CreateUser("outsider", "Outsider", "", false)
+	group, _ := chatManager.CreateChat("group", "Group", "", "", []int64{owner.ID, bot.ID})
+	private, _ := chatManager.CreatePrivateChat(owner.ID, bot.ID)
+	otherGroup, _ := chatManager.CreateChat("group", "Other", "", "", []int64{owner.ID})
+
+	if chat, err := chatManager.GetBotChat(group.ID, bot.ID); err != nil || chat.ID != group.ID {
+		t.Errorf("Expected group for its member bot, got %+v (%v)", chat, err)
+	}
+	// Chats without the bot don't exist for it
+	if _, err := chatManager.GetBotChat(otherGroup.ID, bot.ID); !errors.Is(err, models.ErrChatNotFound) {
+		t.Errorf("Expected ErrChatNotFound, got %v", err)
+	}
+	if _, err := chatManager.GetBotChat(-1, bot.ID); !errors.Is(err, models.ErrChatNotFound) {
+		t.Errorf("Expected ErrChatNotFound for an unknown chat, got %v", err)
+	}
+
+	chatMember, err := chatManager.GetTelegramChatMember(group.ID, owner.ID, bot.ID)
+	if err != nil || chatMember.Status != models.ChatMemberStatusCreator || !chatMember.IsMember {
+		t.Errorf("Expected creator, got %+v (%v)", chatMember, err)
+	}
+	chatMember, err = chatManager.GetTelegramChatMember(group.ID, outsider.ID, bot.ID)
+	if err != nil || chatMember.Status != models.ChatMemberStatusLeft || chatMember.IsMember {
+		t.Errorf("Expected a user outside the chat to have left, got %+v (%v)", chatMember, err)
+	}
+	if _, err := chatManager.GetTelegramChatMember(group.ID, -1, bot.ID); !errors.Is(err, models.ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	administrators, err := chatManager.GetAdministrators(group.ID, bot.ID)
+	if err != nil || len(administrators) != 1 || administrators[0].User.ID != owner.ID {
+		t.Errorf("Expected the creator as the only administrator, got %+v (%v)", administrators, err)
+	}
+	if _, err := chatManager.PromoteMember(group.ID, bot.ID, models.ChatAdministratorRights{CanDeleteMessages: true}); err != nil {
+		t.Fatalf("Failed to promote bot: %v", err)
+	}
+	if administrators, _ := chatManager.GetAdministrators(group.ID, bot.ID); len(administrators) != 2 {
+		t.Errorf("Expected 2 administrators after promotion, got %d", len(administrators))
+	}
+	if _, err := chatManager.GetAdministrators(private.ID, bot.ID); !errors.Is(err, models.ErrNoPrivateChatAdmins) {
+		t.Errorf("Expected ErrNoPrivateChatAdmins, got %v", err)
+	}
+
+	if count, err := chatManager.GetMemberCount(group.ID); err != nil || count != 2 {
+		t.Errorf("Expected 2 members, got %d (%v)", count, err)
+	}
+
+	// A kicked bot gets a Forbidden error
+	record, _ := chatManager.GetMember(group.ID, bot.ID)
+	record.Status = models.ChatMemberStatusKicked
+	if err := chatRepo.SaveMember(record); err != nil {
+		t.Fatalf("Failed to save member: %v", err)
+	}
+	if _, err := chatManager.GetBotChat(group.ID, bot.ID); !errors.Is(err, models.ErrBotKicked) {
+		t.Errorf("Expected ErrBotKicked, got %v", err)
+	}
+}
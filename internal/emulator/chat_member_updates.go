@@ -0,0 +1,151 @@
+package emulator
+
+import (
+	"time"
+
+	"telegram-emulator/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// NotifyMyChatMember отправляет боту обновление my_chat_member об изменении его собственного статуса в чате
+func (m *BotManager) NotifyMyChatMember(chatMemberUpdated *models.ChatMemberUpdated) {
+	// ID бота совпадает с ID его пользователя
+	bot, err := m.botRepo.GetByID(chatMemberUpdated.NewChatMember.User.ID)
+	if err != nil || !bot.IsActive {
+		return
+	}
+
+	if err := m.enqueue(bot, &models.Update{MyChatMember: chatMemberUpdated}); err != nil {
+		m.logger.Error("Ошибка добавления обновления my_chat_member",
+			zap.Int64("bot_id", bot.ID),
+			zap.Int64("chat_id", chatMemberUpdated.Chat.ID),
+			zap.Error(err))
+	}
+}
+
+// NotifyChatMember отправляет обновление chat_member ботам-администраторам чата.
+// Как и в Telegram, бот получает его только если запросил chat_member в allowed_updates
+func (m *BotManager) NotifyChatMember(chatMemberUpdated *models.ChatMemberUpdated) {
+	chatMembers, err := m.chatRepo.GetMemberRecords(chatMemberUpdated.Chat.ID)
+	if err != nil {
+		m.logger.Error("Ошибка получения участников чата для уведомления ботов",
+			zap.Int64("chat_id", chatMemberUpdated.Chat.ID),
+			zap.Error(err))
+		return
+	}
+
+	for i := range chatMembers {
+		// Об изменении своего статуса бот узнает из my_chat_member
+		if !chatMembers[i].IsAdministrator() || chatMembers[i].UserID == chatMemberUpdated.NewChatMember.User.ID {
+			continue
+		}
+
+		// ID бота совпадает с ID его пользователя, у обычных пользователей бота нет
+		bot, err := m.botRepo.GetByID(chatMembers[i].UserID)
+		if err != nil || !bot.IsActive {
+			continue
+		}
+
+		if err := m.enqueue(bot, &models.Update{ChatMember: chatMemberUpdated}); err != nil {
+			m.logger.Error("Ошибка добавления обновления chat_member",
+				zap.Int64("bot_id", bot.ID),
+				zap.Int64("chat_id", chatMemberUpdated.Chat.ID),
+				zap.Error(err))
+		}
+	}
+}
+
+// notifyMemberChange уведомляет об изменении участника user по инициативе actorID:
+// сам бот получает my_chat_member, боты-администраторы группы - chat_member,
+// а о входе и выходе участника группы отправляется служебное сообщение.
+// actorID 0 означает изменение через эмулятор: инициатором считается создатель группы
+func (m *ChatManager) notifyMemberChange(chat *models.Chat, actorID int64, user *models.User, oldChatMember, newChatMember *models.ChatMember) {
+	if actorID == 0 {
+		actorID = m.getCreatorID(chat.ID, user.ID)
+	}
+
+	actor, err := m.userRepo.GetByID(actorID)
+	if err != nil {
+		m.logger.Error("Ошибка получения инициатора изменения участника", zap.Int64("user_id", actorID), zap.Error(err))
+		return
+	}
+
+	if m.botManager != nil {
+		if user.IsBot {
+			m.botManager.NotifyMyChatMember(newChatMemberUpdated(chat, user.ID, actor, user, oldChatMember, newChatMember))
+		}
+		m.botManager.NotifyChatMember(newChatMemberUpdated(chat, actorID, actor, user, oldChatMember, newChatMember))
+	}
+
+	if chat.IsGroup() && m.messageManager != nil {
+		if err := m.sendMemberServiceMessage(chat.ID, actorID, user, oldChatMember, newChatMember); err != nil {
+			m.logger.Error("Ошибка отправки служебного сообщения об участнике",
+				zap.Int64("chat_id", chat.ID),
+				zap.Int64("user_id", user.ID),
+				zap.Error(err))
+		}
+	}
+}
+
+// getCreatorID возвращает ID создателя чата или fallbackID, если создателя нет
+func (m *ChatManager) getCreatorID(chatID, fallbackID int64) int64 {
+	chatMembers, err := m.chatRepo.GetMemberRecords(chatID)
+	if err != nil {
+		m.logger.Error("Ошибка получения создателя чата", zap.Int64("chat_id", chatID), zap.Error(err))
+		return fallbackID
+	}
+	for i := range chatMembers {
+		if chatMembers[i].Status == models.ChatMemberStatusCreator {
+			return chatMembers[i].UserID
+		}
+	}
+	return fallbackID
+}
+
+// sendMemberServiceMessage отправляет в группу служебное сообщение new_chat_members или left_chat_member,
+// если участник вошел в нее или вышел. Изменение прав и ограничений сообщений не создает
+func (m *ChatManager) sendMemberServiceMessage(chatID, actorID int64, user *models.User, oldChatMember, newChatMember *models.ChatMember) error {
+	var err error
+	switch {
+	case !oldChatMember.IsActive() && newChatMember.IsActive():
+		_, err = m.messageManager.SendNewChatMembersMessage(chatID, actorID, []models.User{*user})
+	case oldChatMember.IsActive() && !newChatMember.IsActive():
+		_, err = m.messageManager.SendLeftChatMemberMessage(chatID, actorID, user)
+	}
+	return err
+}
+
+// notifyNewGroupBots отправляет ботам, которых создатель creatorID добавил в новую группу, обновление my_chat_member.
+// Служебные сообщения о входе участников при создании группы не отправляются
+func (m *ChatManager) notifyNewGroupBots(chat *models.Chat, creatorID int64) {
+	if m.botManager == nil {
+		return
+	}
+
+	creator, err := m.userRepo.GetByID(creatorID)
+	if err != nil {
+		m.logger.Error("Ошибка получения создателя группы", zap.Int64("chat_id", chat.ID), zap.Error(err))
+		return
+	}
+
+	oldChatMember := &models.ChatMember{ChatID: chat.ID, Status: models.ChatMemberStatusLeft}
+	newChatMember := &models.ChatMember{ChatID: chat.ID, Status: models.ChatMemberStatusMember}
+	for i := range chat.Members {
+		if chat.Members[i].IsBot && chat.Members[i].ID != creatorID {
+			m.botManager.NotifyMyChatMember(newChatMemberUpdated(chat, chat.Members[i].ID, creator, &chat.Members[i], oldChatMember, newChatMember))
+		}
+	}
+}
+
+// newChatMemberUpdated описывает изменение участника user по инициативе actor.
+// Приватный чат описывается с точки зрения viewerID: с именем его собеседника
+func newChatMemberUpdated(chat *models.Chat, viewerID int64, actor, user *models.User, oldChatMember, newChatMember *models.ChatMember) *models.ChatMemberUpdated {
+	return &models.ChatMemberUpdated{
+		Chat:          chat.ToTelegramChat(viewerID),
+		From:          actor.ToTelegramUser(),
+		Date:          time.Now().Unix(),
+		OldChatMember: oldChatMember.ToTelegramChatMember(user),
+		NewChatMember: newChatMember.ToTelegramChatMember(user),
+	}
+}
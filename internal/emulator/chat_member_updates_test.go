@@ -0,0 +1,284 @@
+package emulator
+
+import (
+	"errors"
+	"testing"
+
+	"telegram-emulator/internal/models"
+)
+
+// newChatMemberUpdatesTestEnv создает окружение тестов сообщений, в котором ChatManager уведомляет ботов
+// и отправляет служебные сообщения, а также создателя групп
+func newChatMemberUpdatesTestEnv(t *testing.T) (*messageTestEnv, *models.User) {
+	env := newMessageTestEnv(t)
+	env.chatManager.SetBotManager(env.botManager)
+	env.chatManager.SetMessageManager(env.messageManager)
+
+	owner := &models.User{ID: 1, Username: "owner", FirstName: "Owner"}
+	if err := env.messageManager.userRepo.Create(owner); err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+	return env, owner
+}
+
+// nextBotUpdates возвращает обновления бота начиная с offset и offset для следующего вызова
+func nextBotUpdates(t *testing.T, env *messageTestEnv, offset int) ([]models.Update, int) {
+	t.Helper()
+	updates, err := env.botManager.GetBotUpdates(env.bot.ID, offset, 100)
+	if err != nil {
+		t.Fatalf("Failed to get updates: %v", err)
+	}
+	if len(updates) > 0 {
+		offset = int(updates[len(updates)-1].UpdateID) + 1
+	}
+	return updates, offset
+}
+
+func TestChatManager_MyChatMemberUpdates(t *testing.T) {
+	env, owner := newChatMemberUpdatesTestEnv(t)
+
+	// A bot included in a new group learns about it from my_chat_member
+	if _, err := env.chatManager.CreateChat("group", "Created", "", "", []int64{owner.ID, env.bot.ID}); err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	updates, offset := nextBotUpdates(t, env, 0)
+	if len(updates) != 1 || updates[0].MyChatMember == nil || updates[0].MyChatMember.From.ID != owner.ID ||
+		updates[0].MyChatMember.NewChatMember.Status != models.ChatMemberStatusMember {
+		t.Fatalf("Expected my_chat_member from the creator, got %+v", updates)
+	}
+
+	group, err := env.chatManager.CreateChat("group", "Team", "", "", []int64{owner.ID, env.user.ID})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+
+	// The added bot receives my_chat_member and then the new_chat_members service message
+	if err := env.chatManager.AddMember(group.ID, env.bot.ID, owner.ID); err != nil {
+		t.Fatalf("Failed to add bot: %v", err)
+	}
+	updates, offset = nextBotUpdates(t, env, offset)
+	if len(updates) != 2 || updates[0].MyChatMember == nil || updates[1].Message == nil {
+		t.Fatalf("Expected my_chat_member and a service message, got %+v", updates)
+	}
+	myChatMember := updates[0].MyChatMember
+	if myChatMember.Chat.ID != group.ID || myChatMember.Chat.Title != "Team" || myChatMember.From.ID != owner.ID ||
+		myChatMember.OldChatMember.Status != models.ChatMemberStatusLeft ||
+		myChatMember.NewChatMember.Status != models.ChatMemberStatusMember ||
+		myChatMember.NewChatMember.User.ID != env.bot.ID {
+		t.Errorf("Expected left -> member for the bot added by the owner, got %+v", myChatMember)
+	}
+	newChatMembers := updates[1].Message.GetNewChatMembers()
+	if updates[1].Message.FromID != owner.ID || len(newChatMembers) != 1 || newChatMembers[0].ID != env.bot.ID {
+		t.Errorf("Expected new_chat_members with the bot from the owner, got %+v", updates[1].Message)
+	}
+
+	// The removed bot receives my_chat_member but not the service message of the group it left
+	if err := env.chatManager.RemoveMember(group.ID, env.bot.ID, owner.ID); err != nil {
+		t.Fatalf("Failed to remove bot: %v", err)
+	}
+	updates, offset = nextBotUpdates(t, env, offset)
+	if len(updates) != 1 || updates[0].MyChatMember == nil ||
+		updates[0].MyChatMember.OldChatMember.Status != models.ChatMemberStatusMember ||
+		updates[0].MyChatMember.NewChatMember.Status != models.ChatMemberStatusLeft {
+		t.Fatalf("Expected member -> left my_chat_member, got %+v", updates)
+	}
+
+	// Blocking the bot in a private chat makes it kicked; the chat is shown with the user's name
+	if err := env.chatManager.BlockBot(env.chat.ID, env.user.ID); err != nil {
+		t.Fatalf("Failed to block bot: %v", err)
+	}
+	updates, offset = nextBotUpdates(t, env, offset)
+	if len(updates) != 1 || updates[0].MyChatMember == nil {
+		t.Fatalf("Expected my_chat_member after blocking, got %+v", updates)
+	}
+	myChatMember = updates[0].MyChatMember
+	if myChatMember.Chat.Type != "private" || myChatMember.Chat.FirstName != env.user.FirstName || myChatMember.From.ID != env.user.ID ||
+		myChatMember.NewChatMember.Status != models.ChatMemberStatusKicked {
+		t.Errorf("Expected the user to block the bot, got %+v", myChatMember)
+	}
+	if _, err := env.messageManager.SendMessage(env.chat.ID, env.bot.ID, "Hi", models.MessageTypeText, nil); !errors.Is(err, models.ErrBotBlocked) {
+		t.Errorf("Expected ErrBotBlocked when writing to the user, got %v", err)
+	}
+	if _, err := env.chatManager.GetBotChat(env.chat.ID, env.bot.ID); !errors.Is(err, models.ErrBotBlocked) {
+		t.Errorf("Expected ErrBotBlocked for the blocked chat, got %v", err)
+	}
+
+	// Blocking twice changes nothing, unblocking restores the bot
+	if err := env.chatManager.BlockBot(env.chat.ID, env.user.ID); err != nil {
+		t.Fatalf("Failed to block bot again: %v", err)
+	}
+	if err := env.chatManager.UnblockBot(env.chat.ID, env.user.ID); err != nil {
+		t.Fatalf("Failed to unblock bot: %v", err)
+	}
+	updates, _ = nextBotUpdates(t, env, offset)
+	if len(updates) != 1 || updates[0].MyChatMember == nil ||
+		updates[0].MyChatMember.OldChatMember.Status != models.ChatMemberStatusKicked ||
+		updates[0].MyChatMember.NewChatMember.Status != models.ChatMemberStatusMember {
+		t.Fatalf("Expected a single kicked -> member my_chat_member, got %+v", updates)
+	}
+	if _, err := env.messageManager.SendMessage(env.chat.ID, env.bot.ID, "Hi", models.MessageTypeText, nil); err != nil {
+		t.Errorf("Expected the unblocked bot to write to the user, got %v", err)
+	}
+
+	if err := env.chatManager.BlockBot(group.ID, env.user.ID); !errors.Is(err, models.ErrNotBotPrivateChat) {
+		t.Errorf("Expected ErrNotBotPrivateChat for a group, got %v", err)
+	}
+	if err := env.chatManager.BlockBot(env.chat.ID, owner.ID); !errors.Is(err, models.ErrUserNotParticipant) {
+		t.Errorf("Expected ErrUserNotParticipant for a stranger, got %v", err)
+	}
+}
+
+func TestChatManager_ChatMemberUpdatesAndServiceMessages(t *testing.T) {
+	env, owner := newChatMemberUpdatesTestEnv(t)
+
+	group, err := env.chatManager.CreateChat("group", "Team", "", "", []int64{owner.ID, env.bot.ID})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	if _, err := env.chatManager.PromoteMember(group.ID, env.bot.ID, models.ChatAdministratorRights{CanInviteUsers: true}); err != nil {
+		t.Fatalf("Failed to promote bot: %v", err)
+	}
+	if err := env.botManager.SetAllowedUpdates(env.bot.ID, []string{models.UpdateTypeMessage, models.UpdateTypeChatMember}); err != nil {
+		t.Fatalf("Failed to set allowed updates: %v", err)
+	}
+	_, offset := nextBotUpdates(t, env, 0)
+
+	// A user joining on their own is both the initiator and the new member
+	if err := env.chatManager.AddMember(group.ID, env.user.ID, 0); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+	updates, offset := nextBotUpdates(t, env, offset)
+	if len(updates) != 2 || updates[0].ChatMember == nil || updates[1].Message == nil {
+		t.Fatalf("Expected chat_member and a service message, got %+v", updates)
+	}
+	chatMember := updates[0].ChatMember
+	if chatMember.From.ID != env.user.ID || chatMember.NewChatMember.User.ID != env.user.ID ||
+		chatMember.OldChatMember.Status != models.ChatMemberStatusLeft ||
+		chatMember.NewChatMember.Status != models.ChatMemberStatusMember {
+		t.Errorf("Expected the user to join on their own, got %+v", chatMember)
+	}
+	joined := updates[1].Message.ToTelegramMessage(env.bot.ID)
+	if joined.From.ID != env.user.ID || len(joined.NewChatMembers) != 1 || joined.NewChatMembers[0].ID != env.user.ID || joined.Text != "" {
+		t.Errorf("Expected new_chat_members with the user, got %+v", joined)
+	}
+	if joined.Chat.ID != group.ID || joined.Chat.Type != "group" || joined.Chat.Title != "Team" {
+		t.Errorf("Expected the service message in group Team, got %+v", joined.Chat)
+	}
+
+	// The owner removes the user: left_chat_member is sent on behalf of the owner
+	if err := env.chatManager.RemoveMember(group.ID, env.user.ID, owner.ID); err != nil {
+		t.Fatalf("Failed to remove user: %v", err)
+	}
+	updates, offset = nextBotUpdates(t, env, offset)
+	if len(updates) != 2 || updates[0].ChatMember == nil || updates[1].Message == nil {
+		t.Fatalf("Expected chat_member and a service message, got %+v", updates)
+	}
+	if updates[0].ChatMember.From.ID != owner.ID || updates[0].ChatMember.NewChatMember.Status != models.ChatMemberStatusLeft {
+		t.Errorf("Expected the owner to remove the user, got %+v", updates[0].ChatMember)
+	}
+	left := updates[1].Message.ToTelegramMessage(env.bot.ID)
+	if left.From.ID != owner.ID || left.LeftChatMember == nil || left.LeftChatMember.ID != env.user.ID {
+		t.Errorf("Expected left_chat_member with the user, got %+v", left)
+	}
+
+	// Service messages are stored in the chat history
+	messages, err := env.messageRepo.GetByChatID(group.ID, 100, 0)
+	if err != nil || len(messages) != 2 {
+		t.Fatalf("Expected 2 service messages in the group, got %d (%v)", len(messages), err)
+	}
+	for _, message := range messages {
+		if !message.IsService() {
+			t.Errorf("Expected a service message, got type %q", message.Type)
+		}
+	}
+
+	// Demoting the bot through the emulator is reported on behalf of the group creator
+	if err := env.botManager.SetAllowedUpdates(env.bot.ID, []string{models.UpdateTypeMyChatMember}); err != nil {
+		t.Fatalf("Failed to set allowed updates: %v", err)
+	}
+	if _, err := env.chatManager.DemoteMember(group.ID, env.bot.ID); err != nil {
+		t.Fatalf("Failed to demote bot: %v", err)
+	}
+	updates, _ = nextBotUpdates(t, env, offset)
+	if len(updates) != 1 || updates[0].MyChatMember == nil || updates[0].MyChatMember.From.ID != owner.ID ||
+		updates[0].MyChatMember.OldChatMember.Status != models.ChatMemberStatusAdministrator ||
+		updates[0].MyChatMember.NewChatMember.Status != models.ChatMemberStatusMember {
+		t.Errorf("Expected administrator -> member my_chat_member from the owner, got %+v", updates)
+	}
+}
+
+func TestMessageManager_SendContentJoinsGroup(t *testing.T) {
+	env, owner := newChatMemberUpdatesTestEnv(t)
+
+	group, err := env.chatManager.CreateChat("group", "Team", "", "", []int64{owner.ID, env.bot.ID})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	_, offset := nextBotUpdates(t, env, 0)
+
+	// A user writing to a group they aren't in joins it like through AddMember
+	if _, err := env.messageManager.SendMessage(group.ID, env.user.ID, "Hi all", models.MessageTypeText, nil); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	updates, offset := nextBotUpdates(t, env, offset)
+	if len(updates) != 2 || updates[0].Message == nil || updates[1].Message == nil {
+		t.Fatalf("Expected a service message and the message, got %+v", updates)
+	}
+	newChatMembers := updates[0].Message.GetNewChatMembers()
+	if len(newChatMembers) != 1 || newChatMembers[0].ID != env.user.ID || updates[1].Message.Text != "Hi all" {
+		t.Errorf("Expected new_chat_members with the user before the message, got %+v", updates)
+	}
+
+	// A member who left rejoins the same way
+	if err := env.chatManager.RemoveMember(group.ID, env.user.ID, 0); err != nil {
+		t.Fatalf("Failed to remove user: %v", err)
+	}
+	if _, err := env.messageManager.SendMessage(group.ID, env.user.ID, "I'm back", models.MessageTypeText, nil); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	record, err := env.chatManager.GetMember(group.ID, env.user.ID)
+	if err != nil || record.Status != models.ChatMemberStatusMember {
+		t.Errorf("Expected the user to rejoin, got %+v (%v)", record, err)
+	}
+	updates, _ = nextBotUpdates(t, env, offset)
+	if len(updates) != 3 || len(updates[1].Message.GetNewChatMembers()) != 1 || updates[2].Message.Text != "I'm back" {
+		t.Errorf("Expected left_chat_member, new_chat_members and the message, got %+v", updates)
+	}
+}
+
+func TestMessageManager_SendContentRequiresBotMembership(t *testing.T) {
+	env, owner := newChatMemberUpdatesTestEnv(t)
+
+	// A bot that was never in the group can't write to it
+	stranger, err := env.chatManager.CreateChat("group", "Strangers", "", "", []int64{owner.ID, env.user.ID})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	if _, err := env.messageManager.SendMessage(stranger.ID, env.bot.ID, "Hello", models.MessageTypeText, nil); !errors.Is(err, models.ErrBotNotMember) {
+		t.Errorf("Expected ErrBotNotMember for a group without the bot, got %v", err)
+	}
+	if _, err := env.chatManager.GetMember(stranger.ID, env.bot.ID); err == nil {
+		t.Error("Expected the bot not to join the group")
+	}
+
+	// A bot removed from the group doesn't rejoin by sending a message
+	group, err := env.chatManager.CreateChat("group", "Team", "", "", []int64{owner.ID, env.bot.ID})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	if err := env.chatManager.RemoveMember(group.ID, env.bot.ID, owner.ID); err != nil {
+		t.Fatalf("Failed to remove bot: %v", err)
+	}
+	_, offset := nextBotUpdates(t, env, 0)
+
+	if _, err := env.messageManager.SendMessage(group.ID, env.bot.ID, "I'm back", models.MessageTypeText, nil); !errors.Is(err, models.ErrBotNotMember) {
+		t.Errorf("Expected ErrBotNotMember for a bot that left the group, got %v", err)
+	}
+	record, err := env.chatManager.GetMember(group.ID, env.bot.ID)
+	if err != nil || record.Status != models.ChatMemberStatusLeft {
+		t.Errorf("Expected the bot to stay out of the group, got %+v (%v)", record, err)
+	}
+	if updates, _ := nextBotUpdates(t, env, offset); len(updates) != 0 {
+		t.Errorf("Expected no updates, got %+v", updates)
+	}
+}
@@ -0,0 +1,250 @@
+package emulator
+
+import (
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"telegram-emulator/internal/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// BanChatMember блокирует пользователя userID в группе по запросу администратора actorID (banChatMember).
+// Заблокированный пользователь покидает чат и не может вернуться до untilDate, nil - бессрочно
+func (m *ChatManager) BanChatMember(actorID, chatID, userID int64, untilDate *time.Time) error {
+	chat, actor, err := m.getModerator(actorID, chatID)
+	if err != nil {
+		return err
+	}
+	if !actor.Rights().CanRestrictMembers {
+		return models.ErrNotEnoughRightsToRestrict
+	}
+	if userID == actorID {
+		return models.ErrCantRestrictSelf
+	}
+
+	user, chatMember, err := m.getModerationTarget(chatID, userID)
+	if err != nil {
+		return err
+	}
+	if err := checkRestrictable(chatMember, actorID); err != nil {
+		return err
+	}
+
+	oldChatMember := *chatMember
+	resetChatMember(chatMember, models.ChatMemberStatusKicked)
+	chatMember.UntilDate = untilDate
+	return m.saveModeratedMember(chat, actorID, user, &oldChatMember, chatMember)
+}
+
+// UnbanChatMember снимает блокировку пользователя userID (unbanChatMember): он сможет вернуться в чат сам.
+// Как и в Telegram, без onlyIfBanned участник, который состоит в чате, удаляется из него
+func (m *ChatManager) UnbanChatMember(actorID, chatID, userID int64, onlyIfBanned bool) error {
+	chat, actor, err := m.getModerator(actorID, chatID)
+	if err != nil {
+		return err
+	}
+	if !actor.Rights().CanRestrictMembers {
+		return models.ErrNotEnoughRightsToRestrict
+	}
+	if userID == actorID {
+		return models.ErrCantRestrictSelf
+	}
+
+	user, chatMember, err := m.getModerationTarget(chatID, userID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case chatMember.Status == models.ChatMemberStatusKicked:
+	case chatMember.IsActive() && !onlyIfBanned:
+		if err := checkRestrictable(chatMember, actorID); err != nil {
+			return err
+		}
+	default:
+		// Пользователь не заблокирован и остается в прежнем статусе
+		return nil
+	}
+
+	oldChatMember := *chatMember
+	resetChatMember(chatMember, models.ChatMemberStatusLeft)
+	return m.saveModeratedMember(chat, actorID, user, &oldChatMember, chatMember)
+}
+
+// RestrictChatMember ограничивает участника userID разрешениями permissions до untilDate (restrictChatMember).
+// Разрешения, совпадающие с разрешениями чата по умолчанию, снимают ограничения
+func (m *ChatManager) RestrictChatMember(actorID, chatID, userID int64, permissions models.ChatPermissions, untilDate *time.Time) error {
+	chat, actor, err := m.getModerator(actorID, chatID)
+	if err != nil {
+		return err
+	}
+	if !actor.Rights().CanRestrictMembers {
+		return models.ErrNotEnoughRightsToRestrict
+	}
+	if userID == actorID {
+		return models.ErrCantRestrictSelf
+	}
+
+	user, chatMember, err := m.getModerationTarget(chatID, userID)
+	if err != nil {
+		return err
+	}
+	if !chatMember.IsActive() {
+		return models.ErrUserNotParticipant
+	}
+	if err := checkRestrictable(chatMember, actorID); err != nil {
+		return err
+	}
+
+	oldChatMember := *chatMember
+	if permissions == models.DefaultChatPermissions() {
+		resetChatMember(chatMember, models.ChatMemberStatusMember)
+	} else {
+		resetChatMember(chatMember, models.ChatMemberStatusRestricted)
+		if err := chatMember.SetPermissions(&permissions); err != nil {
+			return err
+		}
+		chatMember.UntilDate = untilDate
+	}
+	return m.saveModeratedMember(chat, actorID, user, &oldChatMember, chatMember)
+}
+
+// PromoteChatMember назначает участника userID администратором с правами rights от имени actorID (promoteChatMember).
+// Выдать можно только права, которые есть у самого actorID, а менять - только назначенных им администраторов
+func (m *ChatManager) PromoteChatMember(actorID, chatID, userID int64, rights models.ChatAdministratorRights) error {
+	chat, actor, err := m.getModerator(actorID, chatID)
+	if err != nil {
+		return err
+	}
+	if !actor.Rights().CanPromoteMembers {
+		return models.ErrChatAdminRequired
+	}
+	if userID == actorID {
+		return models.ErrCantPromoteSelf
+	}
+	if !actor.Rights().Includes(rights) {
+		return models.ErrRightForbidden
+	}
+
+	user, chatMember, err := m.getModerationTarget(chatID, userID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !chatMember.IsActive():
+		return models.ErrUserNotParticipant
+	case chatMember.Status == models.ChatMemberStatusCreator:
+		return models.ErrCantDemoteChatCreator
+	case chatMember.Status == models.ChatMemberStatusAdministrator && !chatMember.CanBeEditedBy(actorID):
+		return models.ErrChatAdminRequired
+	}
+
+	oldChatMember := *chatMember
+	setAdministratorRights(chatMember, rights, actorID)
+	return m.saveModeratedMember(chat, actorID, user, &oldChatMember, chatMember)
+}
+
+// SetChatAdministratorCustomTitle задает звание customTitle администратору userID от имени actorID
+// (setChatAdministratorCustomTitle). Как и в Telegram, менять звание можно только назначенным actorID администраторам
+func (m *ChatManager) SetChatAdministratorCustomTitle(actorID, chatID, userID int64, customTitle string) error {
+	chat, actor, err := m.getModerator(actorID, chatID)
+	if err != nil {
+		return err
+	}
+	if !actor.IsAdministrator() {
+		return models.ErrChatAdminRequired
+	}
+	if utf8.RuneCountInString(customTitle) > models.MaxCustomTitleLength {
+		return models.ErrAdminRankInvalid
+	}
+
+	user, chatMember, err := m.getModerationTarget(chatID, userID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !chatMember.IsAdministrator():
+		return models.ErrUserNotAdministrator
+	case !chatMember.CanBeEditedBy(actorID):
+		return models.ErrNotEnoughRightsToSetTitle
+	}
+
+	oldChatMember := *chatMember
+	chatMember.CustomTitle = customTitle
+	return m.saveModeratedMember(chat, actorID, user, &oldChatMember, chatMember)
+}
+
+// getModerator получает группу и участие в ней администратора actorID
+func (m *ChatManager) getModerator(actorID, chatID int64) (*models.Chat, *models.ChatMember, error) {
+	chat, err := m.getChat(chatID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !chat.IsGroup() {
+		return nil, nil, models.ErrGroupChatsOnly
+	}
+
+	actor, err := m.getMemberRecord(chatID, actorID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, err
+		}
+		// Пользователь вне чата не имеет в нем прав
+		actor = &models.ChatMember{ChatID: chatID, UserID: actorID, Status: models.ChatMemberStatusLeft}
+	}
+	return chat, actor, nil
+}
+
+// getModerationTarget получает пользователя и его участие в чате.
+// Для пользователя, который никогда не состоял в чате, возвращается новая запись со статусом left
+func (m *ChatManager) getModerationTarget(chatID, userID int64) (*models.User, *models.ChatMember, error) {
+	user, err := m.getUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chatMember, err := m.getMemberRecord(chatID, userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, err
+		}
+		chatMember = &models.ChatMember{ChatID: chatID, UserID: userID, Status: models.ChatMemberStatusLeft, JoinedAt: time.Now()}
+	}
+	return user, chatMember, nil
+}
+
+// checkRestrictable проверяет, может ли actorID ограничить или удалить участника:
+// создателя - нельзя, администратора - только назначившему его
+func checkRestrictable(chatMember *models.ChatMember, actorID int64) error {
+	switch {
+	case chatMember.Status == models.ChatMemberStatusCreator:
+		return models.ErrCantRemoveChatOwner
+	case chatMember.Status == models.ChatMemberStatusAdministrator && !chatMember.CanBeEditedBy(actorID):
+		return models.ErrUserIsAdministrator
+	}
+	return nil
+}
+
+// saveModeratedMember сохраняет новое состояние участника и уведомляет об изменении
+func (m *ChatManager) saveModeratedMember(chat *models.Chat, actorID int64, user *models.User, oldChatMember, newChatMember *models.ChatMember) error {
+	if err := m.chatRepo.SaveMember(newChatMember); err != nil {
+		m.logger.Error("Ошибка изменения участника чата",
+			zap.Int64("chat_id", chat.ID),
+			zap.Int64("user_id", user.ID),
+			zap.Error(err))
+		return err
+	}
+
+	m.logger.Info("Участник чата изменен администратором",
+		zap.Int64("chat_id", chat.ID),
+		zap.Int64("user_id", user.ID),
+		zap.Int64("actor_id", actorID),
+		zap.String("old_status", oldChatMember.Status),
+		zap.String("new_status", newChatMember.Status))
+
+	m.notifyMemberChange(chat, actorID, user, oldChatMember, newChatMember)
+	return nil
+}
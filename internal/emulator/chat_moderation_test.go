@@ -0,0 +1,322 @@
+package emulator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"telegram-emulator/internal/models"
+	"telegram-emulator/internal/repository"
+)
+
+// moderationTestEnv содержит группу с создателем, ботом и участником для тестов модерации
+type moderationTestEnv struct {
+	chatManager *ChatManager
+	botManager  *BotManager
+	chatRepo    *repository.ChatRepository
+	owner       *models.User
+	member      *models.User
+	bot         *models.Bot
+	group       *models.Chat
+}
+
+func newModerationTestEnv(t *testing.T) *moderationTestEnv {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, repository.NewUpdateRepository(db))
+	chatManager := NewChatManager(chatRepo, messageRepo, userRepo)
+	chatManager.SetBotManager(botManager)
+	userManager := NewUserManager(userRepo, botRepo)
+
+	owner, _ := userManager.CreateUser("owner", "Owner", "", false)
+	member, _ := userManager.CreateUser("member", "Member", "", false)
+	bot, err := botManager.CreateBot("ModBot", "mod_bot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	group, err := chatManager.CreateChat("group", "Group", "", "", []int64{owner.ID, bot.ID, member.ID})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+
+	return &moderationTestEnv{
+		chatManager: chatManager,
+		botManager:  botManager,
+		chatRepo:    chatRepo,
+		owner:       owner,
+		member:      member,
+		bot:         bot,
+		group:       group,
+	}
+}
+
+// promoteBot назначает бота администратором группы через эмулятор
+func (env *moderationTestEnv) promoteBot(t *testing.T, rights models.ChatAdministratorRights) {
+	if _, err := env.chatManager.PromoteMember(env.group.ID, env.bot.ID, rights); err != nil {
+		t.Fatalf("Failed to promote bot: %v", err)
+	}
+}
+
+func TestChatManager_BanChatMember(t *testing.T) {
+	env := newModerationTestEnv(t)
+
+	if err := env.chatManager.BanChatMember(env.bot.ID, env.group.ID, env.member.ID, nil); !errors.Is(err, models.ErrNotEnoughRightsToRestrict) {
+		t.Fatalf("Expected ErrNotEnoughRightsToRestrict for a regular member, got %v", err)
+	}
+
+	env.promoteBot(t, models.ChatAdministratorRights{CanRestrictMembers: true})
+
+	until := time.Now().Add(time.Hour)
+	if err := env.chatManager.BanChatMember(env.bot.ID, env.group.ID, env.member.ID, &until); err != nil {
+		t.Fatalf("Failed to ban member: %v", err)
+	}
+	record, _ := env.chatManager.GetMember(env.group.ID, env.member.ID)
+	if record.Status != models.ChatMemberStatusKicked || record.UntilDate == nil {
+		t.Errorf("Expected kicked member with until_date, got %+v", record)
+	}
+	if count, _ := env.chatManager.GetMemberCount(env.group.ID); count != 2 {
+		t.Errorf("Expected banned member to leave the chat, got %d members", count)
+	}
+	if err := env.chatManager.AddMember(env.group.ID, env.member.ID, 0); !errors.Is(err, models.ErrUserKicked) {
+		t.Errorf("Expected ErrUserKicked, got %v", err)
+	}
+
+	// An expired ban turns into a regular leave, so the user may join again
+	past := time.Now().Add(-time.Second)
+	record.UntilDate = &past
+	if err := env.chatRepo.SaveMember(record); err != nil {
+		t.Fatalf("Failed to save member: %v", err)
+	}
+	if record, _ := env.chatManager.GetMember(env.group.ID, env.member.ID); record.Status != models.ChatMemberStatusLeft {
+		t.Errorf("Expected expired ban to become left, got %q", record.Status)
+	}
+	if err := env.chatManager.AddMember(env.group.ID, env.member.ID, 0); err != nil {
+		t.Errorf("Expected user to rejoin after the ban expired, got %v", err)
+	}
+
+	if err := env.chatManager.BanChatMember(env.bot.ID, env.group.ID, env.owner.ID, nil); !errors.Is(err, models.ErrCantRemoveChatOwner) {
+		t.Errorf("Expected ErrCantRemoveChatOwner, got %v", err)
+	}
+	if err := env.chatManager.BanChatMember(env.bot.ID, env.group.ID, env.bot.ID, nil); !errors.Is(err, models.ErrCantRestrictSelf) {
+		t.Errorf("Expected ErrCantRestrictSelf, got %v", err)
+	}
+	if err := env.chatManager.BanChatMember(env.bot.ID, env.group.ID, -1, nil); !errors.Is(err, models.ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	private, _ := env.chatManager.CreatePrivateChat(env.member.ID, env.bot.ID)
+	if err := env.chatManager.BanChatMember(env.bot.ID, private.ID, env.member.ID, nil); !errors.Is(err, models.ErrGroupChatsOnly) {
+		t.Errorf("Expected ErrGroupChatsOnly, got %v", err)
+	}
+}
+
+func TestChatManager_UnbanChatMember(t *testing.T) {
+	env := newModerationTestEnv(t)
+	env.promoteBot(t, models.ChatAdministratorRights{CanRestrictMembers: true})
+
+	if err := env.chatManager.BanChatMember(env.bot.ID, env.group.ID, env.member.ID, nil); err != nil {
+		t.Fatalf("Failed to ban member: %v", err)
+	}
+	if err := env.chatManager.UnbanChatMember(env.bot.ID, env.group.ID, env.member.ID, true); err != nil {
+		t.Fatalf("Failed to unban member: %v", err)
+	}
+	// Unbanned users don't return to the chat by themselves
+	if record, _ := env.chatManager.GetMember(env.group.ID, env.member.ID); record.Status != models.ChatMemberStatusLeft {
+		t.Errorf("Expected unbanned member to have left, got %q", record.Status)
+	}
+
+	if err := env.chatManager.AddMember(env.group.ID, env.member.ID, 0); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+	if err := env.chatManager.UnbanChatMember(env.bot.ID, env.group.ID, env.member.ID, true); err != nil {
+		t.Fatalf("Failed to unban member: %v", err)
+	}
+	if record, _ := env.chatManager.GetMember(env.group.ID, env.member.ID); record.Status != models.ChatMemberStatusMember {
+		t.Errorf("Expected only_if_banned to keep the member, got %q", record.Status)
+	}
+
+	// Without only_if_banned the member is removed from the chat
+	if err := env.chatManager.UnbanChatMember(env.bot.ID, env.group.ID, env.member.ID, false); err != nil {
+		t.Fatalf("Failed to unban member: %v", err)
+	}
+	if record, _ := env.chatManager.GetMember(env.group.ID, env.member.ID); record.Status != models.ChatMemberStatusLeft {
+		t.Errorf("Expected member to be removed, got %q", record.Status)
+	}
+
+	if err := env.chatManager.UnbanChatMember(env.bot.ID, env.group.ID, env.owner.ID, false); !errors.Is(err, models.ErrCantRemoveChatOwner) {
+		t.Errorf("Expected ErrCantRemoveChatOwner, got %v", err)
+	}
+}
+
+func TestChatManager_RestrictChatMember(t *testing.T) {
+	env := newModerationTestEnv(t)
+	env.promoteBot(t, models.ChatAdministratorRights{CanRestrictMembers: true})
+
+	until := time.Now().Add(time.Hour)
+	permissions := models.ChatPermissions{CanSendPhotos: true}
+	if err := env.chatManager.RestrictChatMember(env.bot.ID, env.group.ID, env.member.ID, permissions, &until); err != nil {
+		t.Fatalf("Failed to restrict member: %v", err)
+	}
+
+	chatMember, err := env.chatManager.GetTelegramChatMember(env.group.ID, env.member.ID, env.bot.ID)
+	if err != nil {
+		t.Fatalf("Failed to get chat member: %v", err)
+	}
+	if chatMember.Status != models.ChatMemberStatusRestricted || !chatMember.IsMember || chatMember.CanSendMessages || !chatMember.CanSendPhotos {
+		t.Errorf("Expected restricted member allowed to send only photos, got %+v", chatMember)
+	}
+	if chatMember.UntilDate == nil || *chatMember.UntilDate != until.Unix() {
+		t.Errorf("Expected until_date %d, got %v", until.Unix(), chatMember.UntilDate)
+	}
+
+	// Default permissions lift the restriction
+	if err := env.chatManager.RestrictChatMember(env.bot.ID, env.group.ID, env.member.ID, models.DefaultChatPermissions(), nil); err != nil {
+		t.Fatalf("Failed to lift restriction: %v", err)
+	}
+	if record, _ := env.chatManager.GetMember(env.group.ID, env.member.ID); record.Status != models.ChatMemberStatusMember {
+		t.Errorf("Expected restriction to be lifted, got %q", record.Status)
+	}
+
+	// Administrators promoted by someone else can't be restricted
+	if _, err := env.chatManager.PromoteMember(env.group.ID, env.member.ID, models.ChatAdministratorRights{CanPinMessages: true}); err != nil {
+		t.Fatalf("Failed to promote member: %v", err)
+	}
+	if err := env.chatManager.RestrictChatMember(env.bot.ID, env.group.ID, env.member.ID, permissions, nil); !errors.Is(err, models.ErrUserIsAdministrator) {
+		t.Errorf("Expected ErrUserIsAdministrator, got %v", err)
+	}
+}
+
+func TestChatManager_PromoteChatMember(t *testing.T) {
+	env := newModerationTestEnv(t)
+	rights := models.ChatAdministratorRights{CanDeleteMessages: true}
+
+	if err := env.chatManager.PromoteChatMember(env.bot.ID, env.group.ID, env.member.ID, rights); !errors.Is(err, models.ErrChatAdminRequired) {
+		t.Fatalf("Expected ErrChatAdminRequired for a regular member, got %v", err)
+	}
+
+	env.promoteBot(t, models.ChatAdministratorRights{CanPromoteMembers: true, CanDeleteMessages: true})
+
+	if err := env.chatManager.PromoteChatMember(env.bot.ID, env.group.ID, env.member.ID, models.ChatAdministratorRights{CanPinMessages: true}); !errors.Is(err, models.ErrRightForbidden) {
+		t.Errorf("Expected ErrRightForbidden for a right the bot lacks, got %v", err)
+	}
+
+	if err := env.chatManager.PromoteChatMember(env.bot.ID, env.group.ID, env.member.ID, rights); err != nil {
+		t.Fatalf("Failed to promote member: %v", err)
+	}
+	chatMember, _ := env.chatManager.GetTelegramChatMember(env.group.ID, env.member.ID, env.bot.ID)
+	if chatMember.Status != models.ChatMemberStatusAdministrator || !chatMember.CanDeleteMessages || !chatMember.CanBeEdited {
+		t.Errorf("Expected editable administrator, got %+v", chatMember)
+	}
+
+	// The bot may demote administrators it promoted
+	if err := env.chatManager.PromoteChatMember(env.bot.ID, env.group.ID, env.member.ID, models.ChatAdministratorRights{}); err != nil {
+		t.Fatalf("Failed to demote member: %v", err)
+	}
+	if record, _ := env.chatManager.GetMember(env.group.ID, env.member.ID); record.Status != models.ChatMemberStatusMember || record.PromotedBy != 0 {
+		t.Errorf("Expected regular member, got %+v", record)
+	}
+
+	if _, err := env.chatManager.PromoteMember(env.group.ID, env.member.ID, rights); err != nil {
+		t.Fatalf("Failed to promote member: %v", err)
+	}
+	if err := env.chatManager.PromoteChatMember(env.bot.ID, env.group.ID, env.member.ID, models.ChatAdministratorRights{}); !errors.Is(err, models.ErrChatAdminRequired) {
+		t.Errorf("Expected ErrChatAdminRequired for an administrator promoted by someone else, got %v", err)
+	}
+	if err := env.chatManager.PromoteChatMember(env.bot.ID, env.group.ID, env.bot.ID, rights); !errors.Is(err, models.ErrCantPromoteSelf) {
+		t.Errorf("Expected ErrCantPromoteSelf, got %v", err)
+	}
+	if err := env.chatManager.PromoteChatMember(env.bot.ID, env.group.ID, env.owner.ID, rights); !errors.Is(err, models.ErrCantDemoteChatCreator) {
+		t.Errorf("Expected ErrCantDemoteChatCreator, got %v", err)
+	}
+}
+
+func TestChatManager_SetChatAdministratorCustomTitle(t *testing.T) {
+	env := newModerationTestEnv(t)
+
+	if err := env.chatManager.SetChatAdministratorCustomTitle(env.bot.ID, env.group.ID, env.member.ID, "Helper"); !errors.Is(err, models.ErrChatAdminRequired) {
+		t.Fatalf("Expected ErrChatAdminRequired for a regular member, got %v", err)
+	}
+
+	env.promoteBot(t, models.ChatAdministratorRights{CanPromoteMembers: true, CanDeleteMessages: true})
+	if err := env.chatManager.SetChatAdministratorCustomTitle(env.bot.ID, env.group.ID, env.member.ID, "Helper"); !errors.Is(err, models.ErrUserNotAdministrator) {
+		t.Errorf("Expected ErrUserNotAdministrator for a regular member, got %v", err)
+	}
+	if err := env.chatManager.SetChatAdministratorCustomTitle(env.bot.ID, env.group.ID, env.owner.ID, "Boss"); !errors.Is(err, models.ErrNotEnoughRightsToSetTitle) {
+		t.Errorf("Expected ErrNotEnoughRightsToSetTitle for the creator, got %v", err)
+	}
+
+	rights := models.ChatAdministratorRights{CanDeleteMessages: true}
+	if err := env.chatManager.PromoteChatMember(env.bot.ID, env.group.ID, env.member.ID, rights); err != nil {
+		t.Fatalf("Failed to promote member: %v", err)
+	}
+	if err := env.chatManager.SetChatAdministratorCustomTitle(env.bot.ID, env.group.ID, env.member.ID, "Заместитель модератора"); !errors.Is(err, models.ErrAdminRankInvalid) {
+		t.Errorf("Expected ErrAdminRankInvalid for a title longer than 16 characters, got %v", err)
+	}
+	if err := env.chatManager.SetChatAdministratorCustomTitle(env.bot.ID, env.group.ID, env.member.ID, "Модератор"); err != nil {
+		t.Fatalf("Failed to set custom title: %v", err)
+	}
+	chatMember, _ := env.chatManager.GetTelegramChatMember(env.group.ID, env.member.ID, env.bot.ID)
+	if chatMember.CustomTitle != "Модератор" {
+		t.Errorf("Expected custom title in getChatMember, got %+v", chatMember)
+	}
+
+	// Changing the rights keeps the title, demotion drops it
+	if err := env.chatManager.PromoteChatMember(env.bot.ID, env.group.ID, env.member.ID, models.ChatAdministratorRights{CanPromoteMembers: true}); err != nil {
+		t.Fatalf("Failed to change rights: %v", err)
+	}
+	if record, _ := env.chatManager.GetMember(env.group.ID, env.member.ID); record.CustomTitle != "Модератор" {
+		t.Errorf("Expected the title to be kept, got %q", record.CustomTitle)
+	}
+	if _, err := env.chatManager.DemoteMember(env.group.ID, env.member.ID); err != nil {
+		t.Fatalf("Failed to demote member: %v", err)
+	}
+	if record, _ := env.chatManager.GetMember(env.group.ID, env.member.ID); record.CustomTitle != "" {
+		t.Errorf("Expected the title to be dropped on demotion, got %q", record.CustomTitle)
+	}
+}
+
+func TestChatManager_ModerationChatMemberUpdates(t *testing.T) {
+	env := newModerationTestEnv(t)
+	env.promoteBot(t, models.ChatAdministratorRights{CanRestrictMembers: true})
+
+	// chat_member updates are only sent to bots that requested them
+	if err := env.chatManager.BanChatMember(env.bot.ID, env.group.ID, env.member.ID, nil); err != nil {
+		t.Fatalf("Failed to ban member: %v", err)
+	}
+	// Only the my_chat_member updates about joining the group and the promotion are queued
+	updates, err := env.botManager.GetBotUpdates(env.bot.ID, 0, 100)
+	if err != nil || len(updates) != 2 || updates[0].MyChatMember == nil || updates[1].MyChatMember == nil {
+		t.Fatalf("Expected no chat_member updates without allowed_updates, got %+v (%v)", updates, err)
+	}
+	if promoted := updates[1].MyChatMember; promoted.From.ID != env.owner.ID ||
+		promoted.NewChatMember.Status != models.ChatMemberStatusAdministrator || !promoted.NewChatMember.CanRestrictMembers {
+		t.Errorf("Expected the owner to promote the bot, got %+v", promoted)
+	}
+	offset := int(updates[1].UpdateID) + 1
+
+	if err := env.botManager.SetAllowedUpdates(env.bot.ID, []string{models.UpdateTypeChatMember}); err != nil {
+		t.Fatalf("Failed to set allowed updates: %v", err)
+	}
+	if err := env.chatManager.UnbanChatMember(env.bot.ID, env.group.ID, env.member.ID, true); err != nil {
+		t.Fatalf("Failed to unban member: %v", err)
+	}
+
+	updates, err = env.botManager.GetBotUpdates(env.bot.ID, offset, 100)
+	if err != nil || len(updates) != 1 || updates[0].ChatMember == nil {
+		t.Fatalf("Expected one chat_member update, got %+v (%v)", updates, err)
+	}
+	chatMemberUpdated := updates[0].ChatMember
+	if chatMemberUpdated.Chat.ID != env.group.ID || chatMemberUpdated.Chat.Type != "group" || chatMemberUpdated.From.ID != env.bot.ID {
+		t.Errorf("Unexpected chat or initiator: %+v", chatMemberUpdated)
+	}
+	if chatMemberUpdated.OldChatMember.Status != models.ChatMemberStatusKicked ||
+		chatMemberUpdated.NewChatMember.Status != models.ChatMemberStatusLeft ||
+		chatMemberUpdated.NewChatMember.User.ID != env.member.ID {
+		t.Errorf("Expected kicked -> left for the member, got %+v", chatMemberUpdated)
+	}
+}
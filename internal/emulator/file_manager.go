@@ -0,0 +1,556 @@
+package emulator
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // Регистрация декодера GIF для фотографий
+	"image/jpeg"
+	_ "image/png" // Регистрация декодера PNG для фотографий
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"telegram-emulator/internal/models"
+	"telegram-emulator/internal/pkg/logger"
+	"telegram-emulator/internal/repository"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Ограничения Telegram Bot API на размер файлов
+const (
+	maxUploadPhotoSize   = 10 << 20 // Фотография, загруженная через multipart/form-data
+	maxUploadFileSize    = 50 << 20 // Остальные файлы, загруженные через multipart/form-data
+	maxDownloadPhotoSize = 5 << 20  // Фотография, переданная по HTTP URL
+	maxDownloadFileSize  = 20 << 20 // Остальные файлы, переданные по HTTP URL
+	maxGetFileSize       = 20 << 20 // Файл, который бот может скачать через getFile
+)
+
+// defaultFileDownloadTimeout ограничивает время загрузки файла по HTTP URL
+const defaultFileDownloadTimeout = 30 * time.Second
+
+// photoSizeLimits задает максимальную сторону уменьшенных копий фотографии (s, m, x, y)
+var photoSizeLimits = []int{90, 320, 800, 1280}
+
+// photoThumbnailQuality задает качество JPEG уменьшенных копий фотографии
+const photoThumbnailQuality = 87
+
+// fileFolders задает каталоги хранения файлов разных типов
+var fileFolders = map[string]string{
+	models.FileTypePhoto:    "photos",
+	models.FileTypeDocument: "documents",
+	models.FileTypeAudio:    "music",
+	models.FileTypeVoice:    "voice",
+	models.FileTypeVideo:    "videos",
+}
+
+// InputFile представляет файл, переданный боту в методе отправки:
+// загруженное содержимое, file_id ранее отправленного файла или HTTP URL
+type InputFile struct {
+	FileID   string // Идентификатор ранее отправленного файла
+	URL      string // HTTP URL, по которому эмулятор загрузит файл
+	FileName string // Имя загруженного файла
+	MimeType string // MIME тип загруженного файла
+	Data     []byte // Содержимое загруженного файла
+}
+
+// NewInputFileReference создает InputFile из строкового параметра метода: HTTP URL или file_id
+func NewInputFileReference(value string) *InputFile {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+		return &InputFile{URL: value}
+	}
+	return &InputFile{FileID: value}
+}
+
+// MediaAttributes содержит дополнительные параметры вложения, переданные в методе отправки
+type MediaAttributes struct {
+	Duration  int
+	Width     int
+	Height    int
+	Performer string
+	Title     string
+}
+
+// FileManager управляет файлами эмулятора: сохраняет их содержимое на диск и выдает file_id
+type FileManager struct {
+	fileRepo *repository.FileRepository
+	dir      string
+	client   *http.Client
+	logger   *zap.Logger
+}
+
+// NewFileManager создает новый экземпляр FileManager, хранящий файлы в каталоге dir
+func NewFileManager(fileRepo *repository.FileRepository, dir string) *FileManager {
+	return &FileManager{
+		fileRepo: fileRepo,
+		dir:      dir,
+		client:   &http.Client{Timeout: defaultFileDownloadTimeout},
+		logger:   logger.GetLogger(),
+	}
+}
+
+// BuildMedia сохраняет переданный файл и возвращает вложение сообщения типа fileType
+func (m *FileManager) BuildMedia(fileType string, input *InputFile, attrs MediaAttributes) (*models.MessageMedia, error) {
+	if _, ok := fileFolders[fileType]; !ok {
+		return nil, fmt.Errorf("неизвестный тип файла: %s", fileType)
+	}
+
+	var files []models.File
+	var err error
+	switch {
+	case input == nil:
+		return nil, models.ErrWrongFileIdentifier
+	case input.Data != nil:
+		if int64(len(input.Data)) > uploadLimit(fileType) {
+			return nil, models.ErrRequestEntityTooLarge
+		}
+		files, err = m.storeFile(fileType, input.Data, input.FileName, input.MimeType, attrs)
+	case input.URL != "":
+		var data []byte
+		var mimeType string
+		data, mimeType, err = m.download(input.URL, downloadLimit(fileType))
+		if err != nil {
+			return nil, err
+		}
+		files, err = m.storeFile(fileType, data, urlFileName(input.URL), mimeType, attrs)
+	default:
+		files, err = m.findFile(fileType, input.FileID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	media := &models.MessageMedia{}
+	switch fileType {
+	case models.FileTypePhoto:
+		for i := range files {
+			media.Photo = append(media.Photo, files[i].ToPhotoSize())
+		}
+	case models.FileTypeAudio:
+		media.Audio = files[0].ToAudio(attrs.Performer, attrs.Title)
+	case models.FileTypeVoice:
+		media.Voice = files[0].ToVoice()
+	case models.FileTypeVideo:
+		media.Video = files[0].ToVideo()
+	default:
+		media.Document = files[0].ToDocument()
+	}
+
+	return media, nil
+}
+
+// DiscardMedia удаляет файлы, сохраненные BuildMedia для input, если сообщение с ними не удалось отправить.
+// Файлы, переданные по file_id, принадлежат ранее отправленным сообщениям и не удаляются
+func (m *FileManager) DiscardMedia(input *InputFile, media *models.MessageMedia) {
+	if input == nil || media == nil || (input.Data == nil && input.URL == "") {
+		return
+	}
+
+	file, err := m.fileRepo.GetByFileID(media.FileID())
+	if err != nil {
+		m.logger.Warn("Ошибка получения неотправленного файла", zap.String("file_id", media.FileID()), zap.Error(err))
+		return
+	}
+
+	files := []models.File{*file}
+	if file.Type == models.FileTypePhoto && file.GroupID != "" {
+		// Вместе с фотографией удаляются ее уменьшенные копии
+		if files, err = m.fileRepo.GetPhotoSizes(file.GroupID); err != nil {
+			m.logger.Warn("Ошибка получения размеров неотправленной фотографии", zap.String("group_id", file.GroupID), zap.Error(err))
+			return
+		}
+	}
+
+	m.removeFiles(files)
+}
+
+// GetFile возвращает файл по file_id для скачивания ботом.
+// Как и Telegram, эмулятор не выдает ботам файлы больше 20 МБ
+func (m *FileManager) GetFile(fileID string) (*models.File, error) {
+	if fileID == "" {
+		return nil, models.ErrInvalidFileID
+	}
+
+	file, err := m.fileRepo.GetByFileID(fileID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrInvalidFileID
+		}
+		return nil, err
+	}
+
+	if file.FileSize > maxGetFileSize {
+		return nil, models.ErrFileTooBig
+	}
+
+	return file, nil
+}
+
+// ResolveFilePath находит файл по пути, выданному getFile, и возвращает путь к его содержимому на диске
+func (m *FileManager) ResolveFilePath(filePath string) (*models.File, string, error) {
+	filePath = strings.TrimPrefix(path.Clean("/"+filePath), "/")
+	if filePath == "" {
+		return nil, "", models.ErrFileNotFound
+	}
+
+	// Скачать можно только файлы, известные эмулятору, поэтому путь вне каталога файлов не найдется
+	file, err := m.fileRepo.GetByFilePath(filePath)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", models.ErrFileNotFound
+		}
+		return nil, "", err
+	}
+
+	if file.FileSize > maxGetFileSize {
+		return nil, "", models.ErrFileTooBig
+	}
+
+	fullPath, err := m.contentPath(file)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return file, fullPath, nil
+}
+
+// GetFileContent находит файл по file_id и возвращает путь к его содержимому на диске.
+// В отличие от getFile не ограничивает размер файла и используется веб-интерфейсом
+func (m *FileManager) GetFileContent(fileID string) (*models.File, string, error) {
+	file, err := m.fileRepo.GetByFileID(fileID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", models.ErrFileNotFound
+		}
+		return nil, "", err
+	}
+
+	fullPath, err := m.contentPath(file)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return file, fullPath, nil
+}
+
+// contentPath возвращает путь к содержимому файла на диске и проверяет, что оно существует
+func (m *FileManager) contentPath(file *models.File) (string, error) {
+	fullPath := filepath.Join(m.dir, filepath.FromSlash(file.FilePath))
+	if _, err := os.Stat(fullPath); err != nil || file.FilePath == "" {
+		m.logger.Warn("Содержимое файла отсутствует на диске", zap.String("path", fullPath), zap.Error(err))
+		return "", models.ErrFileNotFound
+	}
+
+	return fullPath, nil
+}
+
+// findFile находит ранее сохраненный файл по file_id и проверяет, что его можно отправить как fileType
+func (m *FileManager) findFile(fileType, fileID string) ([]models.File, error) {
+	if fileID == "" {
+		return nil, models.ErrWrongFileIdentifier
+	}
+
+	file, err := m.fileRepo.GetByFileID(fileID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrWrongFileIdentifier
+		}
+		return nil, err
+	}
+
+	if file.Type != fileType {
+		return nil, models.NewTelegramError(400, fmt.Sprintf("Bad Request: can't use file of type %s as %s",
+			models.FileTypeLabel(file.Type), models.FileTypeLabel(fileType)))
+	}
+
+	if fileType != models.FileTypePhoto || file.GroupID == "" {
+		return []models.File{*file}, nil
+	}
+
+	// Фотография отправляется во всех размерах
+	return m.fileRepo.GetPhotoSizes(file.GroupID)
+}
+
+// storeFile сохраняет содержимое файла. Для фотографии дополнительно создаются уменьшенные копии
+func (m *FileManager) storeFile(fileType string, data []byte, fileName, mimeType string, attrs MediaAttributes) ([]models.File, error) {
+	if fileType == models.FileTypePhoto {
+		return m.storePhoto(data)
+	}
+
+	file := &models.File{
+		Type:     fileType,
+		FileName: fileName,
+		MimeType: detectMimeType(fileName, mimeType, data),
+		Width:    attrs.Width,
+		Height:   attrs.Height,
+		Duration: attrs.Duration,
+	}
+	if fileType == models.FileTypeVoice {
+		// Telegram не передает имя файла голосового сообщения
+		file.FileName = ""
+	}
+
+	if err := m.saveFile(file, data, fileExtension(fileName, file.MimeType)); err != nil {
+		return nil, err
+	}
+
+	return []models.File{*file}, nil
+}
+
+// storePhoto сохраняет фотографию и ее уменьшенные копии, как это делает Telegram
+func (m *FileManager) storePhoto(data []byte) ([]models.File, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, models.ErrImageProcessFailed
+	}
+
+	groupID, err := randomFileID()
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	longSide := max(bounds.Dx(), bounds.Dy())
+
+	var files []models.File
+	for _, limit := range photoSizeLimits {
+		if limit >= longSide {
+			break
+		}
+
+		thumbnail := resizeImage(img, limit)
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: photoThumbnailQuality}); err != nil {
+			m.removeFiles(files)
+			return nil, models.ErrImageProcessFailed
+		}
+
+		file := &models.File{
+			Type:     models.FileTypePhoto,
+			MimeType: "image/jpeg",
+			Width:    thumbnail.Bounds().Dx(),
+			Height:   thumbnail.Bounds().Dy(),
+			GroupID:  groupID,
+		}
+		if err := m.saveFile(file, buf.Bytes(), ".jpg"); err != nil {
+			m.removeFiles(files)
+			return nil, err
+		}
+		files = append(files, *file)
+	}
+
+	// Самый крупный размер - исходная фотография
+	original := &models.File{
+		Type:     models.FileTypePhoto,
+		MimeType: "image/" + format,
+		Width:    bounds.Dx(),
+		Height:   bounds.Dy(),
+		GroupID:  groupID,
+	}
+	extension := "." + format
+	if format == "jpeg" {
+		extension = ".jpg"
+	}
+	if err := m.saveFile(original, data, extension); err != nil {
+		m.removeFiles(files)
+		return nil, err
+	}
+	files = append(files, *original)
+
+	return files, nil
+}
+
+// saveFile создает запись о файле и записывает его содержимое в каталог файлов
+func (m *FileManager) saveFile(file *models.File, data []byte, extension string) error {
+	fileID, err := randomFileID()
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(append([]byte(file.Type), data...))
+	file.FileID = fileID
+	file.FileUniqueID = base64.RawURLEncoding.EncodeToString(sum[:12])
+	file.FileSize = int64(len(data))
+	file.CreatedAt = time.Now()
+
+	if err := m.fileRepo.Create(file); err != nil {
+		m.logger.Error("Ошибка создания записи о файле", zap.Error(err))
+		return err
+	}
+
+	// Путь файла относительно каталога файлов, как его возвращает getFile
+	filePath := path.Join(fileFolders[file.Type], fmt.Sprintf("file_%d%s", file.ID, extension))
+	fullPath := filepath.Join(m.dir, filepath.FromSlash(filePath))
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		m.logger.Error("Ошибка создания каталога файлов", zap.String("dir", filepath.Dir(fullPath)), zap.Error(err))
+		_ = m.fileRepo.Delete(file.ID)
+		return err
+	}
+	if err := os.WriteFile(fullPath, data, 0644); err != nil {
+		m.logger.Error("Ошибка записи файла", zap.String("path", fullPath), zap.Error(err))
+		_ = m.fileRepo.Delete(file.ID)
+		return err
+	}
+
+	if err := m.fileRepo.SetFilePath(file.ID, filePath); err != nil {
+		return err
+	}
+	file.FilePath = filePath
+
+	m.logger.Info("Файл сохранен",
+		zap.Int64("id", file.ID),
+		zap.String("type", file.Type),
+		zap.String("file_path", filePath),
+		zap.Int64("file_size", file.FileSize))
+
+	return nil
+}
+
+// removeFiles удаляет записи о файлах и их содержимое на диске
+func (m *FileManager) removeFiles(files []models.File) {
+	if len(files) == 0 {
+		return
+	}
+
+	for i := range files {
+		if files[i].FilePath != "" {
+			fullPath := filepath.Join(m.dir, filepath.FromSlash(files[i].FilePath))
+			if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+				m.logger.Warn("Ошибка удаления файла", zap.String("path", fullPath), zap.Error(err))
+			}
+		}
+		if err := m.fileRepo.Delete(files[i].ID); err != nil {
+			m.logger.Warn("Ошибка удаления записи о файле", zap.Int64("id", files[i].ID), zap.Error(err))
+		}
+	}
+
+	m.logger.Info("Неиспользуемые файлы удалены", zap.Int("count", len(files)))
+}
+
+// download загружает файл по HTTP URL, не более limit байт
+func (m *FileManager) download(rawURL string, limit int64) ([]byte, string, error) {
+	resp, err := m.client.Get(rawURL)
+	if err != nil {
+		m.logger.Warn("Ошибка загрузки файла по URL", zap.String("url", rawURL), zap.Error(err))
+		return nil, "", models.ErrFailedToGetHTTPURLContent
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		m.logger.Warn("Файл по URL недоступен", zap.String("url", rawURL), zap.Int("status_code", resp.StatusCode))
+		return nil, "", models.ErrFailedToGetHTTPURLContent
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil || int64(len(data)) > limit {
+		m.logger.Warn("Не удалось загрузить файл по URL", zap.String("url", rawURL), zap.Int64("limit", limit), zap.Error(err))
+		return nil, "", models.ErrFailedToGetHTTPURLContent
+	}
+
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// uploadLimit возвращает максимальный размер загружаемого файла типа fileType
+func uploadLimit(fileType string) int64 {
+	if fileType == models.FileTypePhoto {
+		return maxUploadPhotoSize
+	}
+	return maxUploadFileSize
+}
+
+// downloadLimit возвращает максимальный размер файла типа fileType, загружаемого по HTTP URL
+func downloadLimit(fileType string) int64 {
+	if fileType == models.FileTypePhoto {
+		return maxDownloadPhotoSize
+	}
+	return maxDownloadFileSize
+}
+
+// randomFileID генерирует случайный идентификатор файла
+func randomFileID() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// detectMimeType определяет MIME тип файла по переданному типу, расширению имени или содержимому
+func detectMimeType(fileName, mimeType string, data []byte) string {
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		if byExtension := mime.TypeByExtension(filepath.Ext(fileName)); byExtension != "" {
+			mimeType = byExtension
+		} else if mimeType == "" {
+			mimeType = http.DetectContentType(data)
+		}
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		return mediaType
+	}
+	return mimeType
+}
+
+// fileExtension возвращает расширение для сохранения файла
+func fileExtension(fileName, mimeType string) string {
+	if extension := filepath.Ext(fileName); extension != "" {
+		return strings.ToLower(extension)
+	}
+	if extensions, err := mime.ExtensionsByType(mimeType); err == nil && len(extensions) > 0 {
+		return extensions[0]
+	}
+	return ""
+}
+
+// urlFileName возвращает имя файла из пути HTTP URL
+func urlFileName(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	name := path.Base(parsed.Path)
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// resizeImage уменьшает изображение так, чтобы большая сторона не превышала limit
+func resizeImage(img image.Image, limit int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width >= height {
+		height = max(1, height*limit/width)
+		width = limit
+	} else {
+		width = max(1, width*limit/height)
+		height = limit
+	}
+
+	// Метод ближайшего соседа достаточен для эмуляции уменьшенных копий
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		srcY := bounds.Min.Y + y*bounds.Dy()/height
+		for x := 0; x < width; x++ {
+			srcX := bounds.Min.X + x*bounds.Dx()/width
+			resized.Set(x, y, img.At(srcX, srcY))
+		}
+	}
+
+	return resized
+}
@@ -0,0 +1,278 @@
+package emulator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"telegram-emulator/internal/models"
+	"telegram-emulator/internal/repository"
+)
+
+func newTestFileManager(t *testing.T) *FileManager {
+	db := SetupTestDB(t)
+	return NewFileManager(repository.NewFileRepository(db), t.TempDir())
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestFileManager_BuildMediaPhoto(t *testing.T) {
+	fileManager := newTestFileManager(t)
+
+	media, err := fileManager.BuildMedia(models.FileTypePhoto, &InputFile{
+		FileName: "photo.png",
+		Data:     testPNG(t, 1000, 500),
+	}, MediaAttributes{})
+	if err != nil {
+		t.Fatalf("BuildMedia() error = %v", err)
+	}
+
+	// Thumbnails are generated for every size limit below the original
+	expected := [][2]int{{90, 45}, {320, 160}, {800, 400}, {1000, 500}}
+	if len(media.Photo) != len(expected) {
+		t.Fatalf("Expected %d photo sizes, got %d", len(expected), len(media.Photo))
+	}
+	for i, size := range media.Photo {
+		if size.Width != expected[i][0] || size.Height != expected[i][1] {
+			t.Errorf("Expected size %dx%d, got %dx%d", expected[i][0], expected[i][1], size.Width, size.Height)
+		}
+		if size.FileID == "" || size.FileUniqueID == "" || size.FileSize == 0 {
+			t.Errorf("Expected file_id, file_unique_id and file_size to be set, got %+v", size)
+		}
+	}
+
+	file, err := fileManager.fileRepo.GetByFileID(media.Photo[3].FileID)
+	if err != nil {
+		t.Fatalf("Failed to get stored file: %v", err)
+	}
+	if file.FilePath != fmt.Sprintf("photos/file_%d.png", file.ID) {
+		t.Errorf("Unexpected file path %s", file.FilePath)
+	}
+	if _, err := os.Stat(filepath.Join(fileManager.dir, file.FilePath)); err != nil {
+		t.Errorf("Expected file to be written to disk: %v", err)
+	}
+
+	// Resending by file_id returns all sizes without storing new files
+	resent, err := fileManager.BuildMedia(models.FileTypePhoto, &InputFile{FileID: media.Photo[0].FileID}, MediaAttributes{})
+	if err != nil {
+		t.Fatalf("BuildMedia() by file_id error = %v", err)
+	}
+	if len(resent.Photo) != len(media.Photo) || resent.Photo[3].FileID != media.Photo[3].FileID {
+		t.Errorf("Expected the same photo sizes, got %+v", resent.Photo)
+	}
+}
+
+func TestFileManager_BuildMediaDocument(t *testing.T) {
+	fileManager := newTestFileManager(t)
+
+	media, err := fileManager.BuildMedia(models.FileTypeDocument, &InputFile{
+		FileName: "report.pdf",
+		Data:     []byte("%PDF-1.4"),
+	}, MediaAttributes{})
+	if err != nil {
+		t.Fatalf("BuildMedia() error = %v", err)
+	}
+
+	document := media.Document
+	if document == nil {
+		t.Fatal("Expected document")
+	}
+	if document.FileName != "report.pdf" || document.MimeType != "application/pdf" || document.FileSize != 8 {
+		t.Errorf("Unexpected document %+v", document)
+	}
+
+	// Same content gets the same file_unique_id but a new file_id
+	again, err := fileManager.BuildMedia(models.FileTypeDocument, &InputFile{
+		FileName: "copy.pdf",
+		Data:     []byte("%PDF-1.4"),
+	}, MediaAttributes{})
+	if err != nil {
+		t.Fatalf("BuildMedia() error = %v", err)
+	}
+	if again.Document.FileUniqueID != document.FileUniqueID || again.Document.FileID == document.FileID {
+		t.Errorf("Expected equal file_unique_id and different file_id, got %+v and %+v", document, again.Document)
+	}
+
+	// A document can't be sent as a photo
+	_, err = fileManager.BuildMedia(models.FileTypePhoto, &InputFile{FileID: document.FileID}, MediaAttributes{})
+	var telegramErr *models.TelegramError
+	if !errors.As(err, &telegramErr) || telegramErr.Description != "Bad Request: can't use file of type Document as Photo" {
+		t.Errorf("Expected type mismatch error, got %v", err)
+	}
+}
+
+func TestFileManager_DiscardMedia(t *testing.T) {
+	fileManager := newTestFileManager(t)
+
+	input := &InputFile{FileName: "photo.png", Data: testPNG(t, 400, 200)}
+	media, err := fileManager.BuildMedia(models.FileTypePhoto, input, MediaAttributes{})
+	if err != nil {
+		t.Fatalf("BuildMedia() error = %v", err)
+	}
+	var paths []string
+	for _, size := range media.Photo {
+		file, err := fileManager.fileRepo.GetByFileID(size.FileID)
+		if err != nil {
+			t.Fatalf("Failed to get stored file: %v", err)
+		}
+		paths = append(paths, filepath.Join(fileManager.dir, file.FilePath))
+	}
+
+	// A file referenced by file_id belongs to an earlier message and is kept
+	fileManager.DiscardMedia(&InputFile{FileID: media.FileID()}, media)
+	if _, err := fileManager.fileRepo.GetByFileID(media.FileID()); err != nil {
+		t.Errorf("Expected file sent by file_id to be kept: %v", err)
+	}
+
+	// A new upload is removed together with its thumbnails
+	fileManager.DiscardMedia(input, media)
+	for i, size := range media.Photo {
+		if _, err := fileManager.fileRepo.GetByFileID(size.FileID); err == nil {
+			t.Errorf("Expected photo size %s to be deleted", size.FileID)
+		}
+		if _, err := os.Stat(paths[i]); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed from disk, got %v", paths[i], err)
+		}
+	}
+}
+
+func TestFileManager_BuildMediaFromURL(t *testing.T) {
+	fileManager := newTestFileManager(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/voice.ogg" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "audio/ogg")
+		w.Write([]byte("OggS"))
+	}))
+	defer server.Close()
+
+	media, err := fileManager.BuildMedia(models.FileTypeVoice, &InputFile{URL: server.URL + "/voice.ogg"}, MediaAttributes{Duration: 3})
+	if err != nil {
+		t.Fatalf("BuildMedia() error = %v", err)
+	}
+	if media.Voice == nil || media.Voice.Duration != 3 || media.Voice.MimeType != "audio/ogg" {
+		t.Errorf("Unexpected voice %+v", media.Voice)
+	}
+
+	_, err = fileManager.BuildMedia(models.FileTypeVoice, &InputFile{URL: server.URL + "/missing.ogg"}, MediaAttributes{})
+	if !errors.Is(err, models.ErrFailedToGetHTTPURLContent) {
+		t.Errorf("Expected ErrFailedToGetHTTPURLContent, got %v", err)
+	}
+}
+
+func TestFileManager_BuildMediaErrors(t *testing.T) {
+	fileManager := newTestFileManager(t)
+
+	tests := []struct {
+		name     string
+		fileType string
+		input    *InputFile
+		expected error
+	}{
+		{
+			name:     "unknown file_id",
+			fileType: models.FileTypeDocument,
+			input:    &InputFile{FileID: "missing"},
+			expected: models.ErrWrongFileIdentifier,
+		},
+		{
+			name:     "photo is not an image",
+			fileType: models.FileTypePhoto,
+			input:    &InputFile{FileName: "photo.jpg", Data: []byte("not an image")},
+			expected: models.ErrImageProcessFailed,
+		},
+		{
+			name:     "photo exceeds upload limit",
+			fileType: models.FileTypePhoto,
+			input:    &InputFile{Data: make([]byte, maxUploadPhotoSize+1)},
+			expected: models.ErrRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := fileManager.BuildMedia(tt.fileType, tt.input, MediaAttributes{})
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestFileManager_GetFileAndResolveFilePath(t *testing.T) {
+	fileManager := newTestFileManager(t)
+
+	media, err := fileManager.BuildMedia(models.FileTypeDocument, &InputFile{
+		FileName: "notes.txt",
+		Data:     []byte("hello"),
+	}, MediaAttributes{})
+	if err != nil {
+		t.Fatalf("BuildMedia() error = %v", err)
+	}
+
+	file, err := fileManager.GetFile(media.Document.FileID)
+	if err != nil {
+		t.Fatalf("GetFile() error = %v", err)
+	}
+	if file.FilePath != fmt.Sprintf("documents/file_%d.txt", file.ID) {
+		t.Errorf("Unexpected file path %s", file.FilePath)
+	}
+
+	resolved, fullPath, err := fileManager.ResolveFilePath("/" + file.FilePath)
+	if err != nil {
+		t.Fatalf("ResolveFilePath() error = %v", err)
+	}
+	if resolved.FileID != file.FileID {
+		t.Errorf("Expected file %s, got %s", file.FileID, resolved.FileID)
+	}
+	if content, err := os.ReadFile(fullPath); err != nil || string(content) != "hello" {
+		t.Errorf("Expected file content 'hello', got '%s' (%v)", content, err)
+	}
+
+	if _, err := fileManager.GetFile("missing"); !errors.Is(err, models.ErrInvalidFileID) {
+		t.Errorf("Expected ErrInvalidFileID, got %v", err)
+	}
+	if _, _, err := fileManager.ResolveFilePath("../" + file.FilePath + "x"); !errors.Is(err, models.ErrFileNotFound) {
+		t.Errorf("Expected ErrFileNotFound, got %v", err)
+	}
+
+	// Files over 20MB are not handed out to bots
+	if err := fileManager.fileRepo.Create(&models.File{
+		FileID:   "big",
+		Type:     models.FileTypeVideo,
+		FileSize: maxGetFileSize + 1,
+		FilePath: "videos/big.mp4",
+	}); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if _, err := fileManager.GetFile("big"); !errors.Is(err, models.ErrFileTooBig) {
+		t.Errorf("Expected ErrFileTooBig, got %v", err)
+	}
+	if _, _, err := fileManager.ResolveFilePath("videos/big.mp4"); !errors.Is(err, models.ErrFileTooBig) {
+		t.Errorf("Expected ErrFileTooBig, got %v", err)
+	}
+}
@@ -1,12 +1,9 @@
 package emulator
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"math/rand"
-	"net/http"
 	"time"
 
 	"telegram-emulator/internal/models"
@@ -15,14 +12,21 @@ import (
 	"telegram-emulator/internal/websocket"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
+// botDeleteMessageWindow - время, в течение которого бот может удалить сообщение
+const botDeleteMessageWindow = 48 * time.Hour
+
 // MessageManager управляет сообщениями в эмуляторе
 type MessageManager struct {
 	messageRepo *repository.MessageRepository
 	chatRepo    *repository.ChatRepository
 	userRepo    *repository.UserRepository
 	botManager  *BotManager
+	chatManager *ChatManager
+	fileManager *FileManager
+	chatActions *ChatActionTracker
 	wsServer    *websocket.Server
 	logger      *zap.Logger
 }
@@ -34,13 +38,106 @@ func NewMessageManager(messageRepo *repository.MessageRepository, chatRepo *repo
 		chatRepo:    chatRepo,
 		userRepo:    userRepo,
 		botManager:  botManager,
+		chatActions: NewChatActionTracker(),
 		wsServer:    wsServer,
 		logger:      logger.GetLogger(),
 	}
 }
 
+// SetFileManager устанавливает FileManager для сообщений пользователей с вложениями
+func (m *MessageManager) SetFileManager(fileManager *FileManager) {
+	m.fileManager = fileManager
+}
+
+// SetChatManager устанавливает ChatManager для проверки участия ботов в чатах
+func (m *MessageManager) SetChatManager(chatManager *ChatManager) {
+	m.chatManager = chatManager
+}
+
+// MessageContent описывает содержимое отправляемого сообщения
+type MessageContent struct {
+	Type        string                 // Тип сообщения (text, photo, file, audio, voice, video)
+	Text        string                 // Текст сообщения или подпись к вложению
+	ParseMode   string                 // Режим разметки текста (HTML, Markdown, MarkdownV2)
+	Entities    []models.MessageEntity // Явно заданные сущности текста, используются вместо ParseMode
+	Media       *models.MessageMedia   // Вложение сообщения
+	ReplyMarkup interface{}            // Клавиатура
+
+	ForwardOrigin *models.MessageOrigin // Источник пересланного сообщения
+
+	ReplyToMessageID         int64 // Сообщение того же чата, на которое отвечает новое сообщение
+	AllowSendingWithoutReply bool  // Отправить сообщение без ответа, если исходное сообщение не найдено
+}
+
 // SendMessage отправляет сообщение в чат
 func (m *MessageManager) SendMessage(chatID int64, fromUserID int64, text, messageType string, replyMarkup interface{}) (*models.Message, error) {
+	return m.SendContent(chatID, fromUserID, MessageContent{
+		Type:        messageType,
+		Text:        text,
+		ReplyMarkup: replyMarkup,
+	})
+}
+
+// SendFormattedMessage отправляет в чат сообщение с разметкой в режиме parseMode (HTML, Markdown, MarkdownV2).
+// Разметка удаляется из текста и превращается в сущности сообщения
+func (m *MessageManager) SendFormattedMessage(chatID int64, fromUserID int64, text, parseMode, messageType string, replyMarkup interface{}) (*models.Message, error) {
+	return m.SendContent(chatID, fromUserID, MessageContent{
+		Type:        messageType,
+		Text:        text,
+		ParseMode:   parseMode,
+		ReplyMarkup: replyMarkup,
+	})
+}
+
+// SendMedia отправляет в чат сообщение с вложением типа fileType и подписью caption.
+// Ненулевой replyToMessageID делает сообщение ответом
+func (m *MessageManager) SendMedia(chatID int64, fromUserID int64, fileType string, input *InputFile, caption string, replyToMessageID int64) (*models.Message, error) {
+	if m.fileManager == nil {
+		return nil, fmt.Errorf("хранилище файлов не настроено")
+	}
+
+	media, err := m.fileManager.BuildMedia(fileType, input, MediaAttributes{})
+	if err != nil {
+		m.logger.Warn("Ошибка обработки вложения сообщения", zap.String("type", fileType), zap.Error(err))
+		return nil, err
+	}
+
+	message, err := m.SendContent(chatID, fromUserID, MessageContent{
+		Type:             models.MessageTypeForFile(fileType),
+		Text:             caption,
+		Media:            media,
+		ReplyToMessageID: replyToMessageID,
+	})
+	if err != nil {
+		// Загруженный файл не попал в сообщение и больше не нужен
+		m.fileManager.DiscardMedia(input, media)
+		return nil, err
+	}
+	return message, nil
+}
+
+// SendFileMessage отправляет в чат сообщение типа messageType с ранее загруженным файлом fileID.
+// Ненулевой replyToMessageID делает сообщение ответом
+func (m *MessageManager) SendFileMessage(chatID int64, fromUserID int64, messageType, fileID, caption string, replyToMessageID int64) (*models.Message, error) {
+	fileType, ok := models.FileTypeForMessage(messageType)
+	if !ok {
+		return nil, fmt.Errorf("тип сообщения %s не поддерживает вложения", messageType)
+	}
+
+	return m.SendMedia(chatID, fromUserID, fileType, &InputFile{FileID: fileID}, caption, replyToMessageID)
+}
+
+// SendReply отправляет в чат текстовый ответ на сообщение replyToMessageID
+func (m *MessageManager) SendReply(chatID int64, fromUserID int64, text string, replyToMessageID int64) (*models.Message, error) {
+	return m.SendContent(chatID, fromUserID, MessageContent{
+		Type:             models.MessageTypeText,
+		Text:             text,
+		ReplyToMessageID: replyToMessageID,
+	})
+}
+
+// SendContent отправляет в чат сообщение с текстом, вложением и клавиатурой
+func (m *MessageManager) SendContent(chatID int64, fromUserID int64, content MessageContent) (*models.Message, error) {
 	// Генерируем уникальный ID
 	id, err := m.generateID()
 	if err != nil {
@@ -54,6 +151,59 @@ func (m *MessageManager) SendMessage(chatID int64, fromUserID int64, text, messa
 		return nil, err
 	}
 
+	// Создаем сообщение
+	message := &models.Message{
+		ID:         id,
+		ChatID:     chatID,
+		FromID:     fromUserID,
+		From:       *fromUser,
+		Type:       content.Type,
+		Status:     models.MessageStatusSending,
+		IsOutgoing: false,
+		Timestamp:  time.Now(),
+		CreatedAt:  time.Now(),
+	}
+
+	// Устанавливаем клавиатуру, если она есть
+	if content.ReplyMarkup != nil {
+		if err := message.SetReplyMarkup(content.ReplyMarkup); err != nil {
+			m.logger.Error("Ошибка установки клавиатуры", zap.Error(err))
+			// Не прерываем отправку сообщения, просто логируем ошибку
+		}
+	}
+
+	if err := message.SetMedia(content.Media); err != nil {
+		return nil, err
+	}
+
+	if err := message.SetForwardOrigin(content.ForwardOrigin); err != nil {
+		return nil, err
+	}
+
+	if content.ReplyToMessageID != 0 {
+		reply, err := m.getChatMessage(chatID, content.ReplyToMessageID, models.ErrReplyMessageNotFound)
+		if err != nil && !(errors.Is(err, models.ErrReplyMessageNotFound) && content.AllowSendingWithoutReply) {
+			return nil, err
+		}
+		message.SetReplyTo(reply)
+	}
+
+	// Разбираем разметку и устанавливаем сущности (форматирование, команды, упоминания, хештеги, URL)
+	if content.Entities != nil {
+		err = message.SetTextWithEntities(content.Text, content.Entities)
+	} else {
+		err = message.SetFormattedText(content.Text, content.ParseMode)
+	}
+	if err != nil {
+		m.logger.Warn("Ошибка разбора разметки сообщения",
+			zap.String("parse_mode", content.ParseMode),
+			zap.Error(err))
+		return nil, err
+	}
+	if content.Media == nil && content.ParseMode != "" && message.Text == "" {
+		return nil, models.ErrMessageTextEmpty
+	}
+
 	// Проверяем, является ли пользователь участником чата
 	chat, err := m.chatRepo.GetByID(chatID)
 	if err != nil {
@@ -85,6 +235,12 @@ func (m *MessageManager) SendMessage(chatID int64, fromUserID int64, text, messa
 		}
 	}
 
+	// Заблокированные участники группы и заблокированные пользователем боты не могут писать,
+	// ограниченные участники - только разрешенные типы сообщений
+	if err := m.checkSendPermission(chat, fromUser, content.Type); err != nil {
+		return nil, err
+	}
+
 	// Проверяем, является ли пользователь участником
 	isMember := false
 	for _, member := range chat.Members {
@@ -94,9 +250,15 @@ func (m *MessageManager) SendMessage(chatID int64, fromUserID int64, text, messa
 		}
 	}
 
-	// Если пользователь не участник, добавляем его (кроме приватных чатов)
-	if !isMember && chat.Type != "private" {
-		if err := m.chatRepo.AddMember(chatID, fromUserID); err != nil {
+	// Если пользователь не участник, добавляем его (кроме приватных чатов).
+	// Участники группы и боты узнают о нем из служебного сообщения и обновлений chat_member.
+	// Боты сами в группы не вступают: писать можно только в чат, в котором бот состоит
+	if fromUser.IsBot {
+		if err := m.checkBotMember(chatID, fromUserID); err != nil {
+			return nil, err
+		}
+	} else if !isMember && chat.Type != "private" {
+		if err := m.addChatMember(chatID, fromUserID); err != nil {
 			m.logger.Error("Ошибка добавления пользователя в чат", zap.Int64("chat_id", chatID), zap.Int64("user_id", fromUserID), zap.Error(err))
 			// Не прерываем отправку сообщения, просто логируем ошибку
 		} else {
@@ -104,39 +266,17 @@ func (m *MessageManager) SendMessage(chatID int64, fromUserID int64, text, messa
 		}
 	}
 
-	// Создаем сообщение
-	message := &models.Message{
-		ID:         id,
-		ChatID:     chatID,
-		FromID:     fromUserID,
-		From:       *fromUser,
-		Text:       text,
-		Type:       messageType,
-		Status:     models.MessageStatusSending,
-		IsOutgoing: false,
-		Timestamp:  time.Now(),
-		CreatedAt:  time.Now(),
-	}
-
-	// Устанавливаем клавиатуру, если она есть
-	if replyMarkup != nil {
-		if err := message.SetReplyMarkup(replyMarkup); err != nil {
-			m.logger.Error("Ошибка установки клавиатуры", zap.Error(err))
-			// Не прерываем отправку сообщения, просто логируем ошибку
-		}
-	}
-
-	// Парсим и устанавливаем сущности (команды, упоминания, хештеги, URL)
-	if err := message.ParseAndSetEntities(); err != nil {
-		m.logger.Error("Ошибка парсинга сущностей", zap.Error(err))
-		// Не прерываем отправку сообщения, просто логируем ошибку
-	}
-
 	// Сохраняем сообщение
 	if err := m.messageRepo.Create(message); err != nil {
 		m.logger.Error("Ошибка создания сообщения", zap.Error(err))
 		return nil, err
 	}
+	setMessageChat(message, chat)
+
+	// Отправленное сообщение завершает действие отправителя (например, набор текста)
+	if m.chatActions.Clear(chatID, fromUserID) {
+		m.broadcastChatActionCancel(chat, fromUserID)
+	}
 
 	// Обновляем счетчик непрочитанных сообщений
 	// Получаем количество непрочитанных сообщений
@@ -166,6 +306,254 @@ func (m *MessageManager) SendMessage(chatID int64, fromUserID int64, text, messa
 	return message, nil
 }
 
+// checkSendPermission проверяет, может ли пользователь отправить в чат сообщение типа messageType.
+// Пользователи, которые не состоят в группе, добавляются в нее при отправке, а боты - нет
+func (m *MessageManager) checkSendPermission(chat *models.Chat, user *models.User, messageType string) error {
+	chatMember, err := m.chatRepo.GetMember(chat.ID, user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// Истекшее ограничение уже не действует, даже если еще не снято в базе данных
+	chatMember.Expire(time.Now())
+
+	switch chatMember.Status {
+	case models.ChatMemberStatusKicked:
+		switch {
+		case user.IsBot && chat.IsPrivate():
+			return models.ErrBotBlocked
+		case user.IsBot:
+			return models.ErrBotKicked
+		}
+		return models.ErrUserKicked
+	case models.ChatMemberStatusRestricted:
+		if !chatMember.EffectivePermissions().CanSend(messageType) {
+			return models.NotEnoughRightsToSend(messageType)
+		}
+	}
+	return nil
+}
+
+// SendNewChatMembersMessage отправляет в группу служебное сообщение new_chat_members о входе участников users.
+// fromUserID - пользователь, который добавил участников, или сам вступивший участник
+func (m *MessageManager) SendNewChatMembersMessage(chatID, fromUserID int64, users []models.User) (*models.Message, error) {
+	newChatMembers := make([]models.TelegramUser, 0, len(users))
+	for i := range users {
+		newChatMembers = append(newChatMembers, users[i].ToTelegramUser())
+	}
+
+	return m.sendServiceMessage(chatID, fromUserID, func(message *models.Message) error {
+		return message.SetNewChatMembers(newChatMembers)
+	})
+}
+
+// SendLeftChatMemberMessage отправляет в группу служебное сообщение left_chat_member о выходе участника user.
+// fromUserID - пользователь, который удалил участника, или сам вышедший участник
+func (m *MessageManager) SendLeftChatMemberMessage(chatID, fromUserID int64, user *models.User) (*models.Message, error) {
+	return m.sendServiceMessage(chatID, fromUserID, func(message *models.Message) error {
+		return message.SetLeftChatMember(user.ToTelegramUser())
+	})
+}
+
+// sendServiceMessage сохраняет служебное сообщение, заполненное setService, и уведомляет о нем участников и ботов.
+// Служебные сообщения не проверяют права отправителя и не влияют на счетчик непрочитанных
+func (m *MessageManager) sendServiceMessage(chatID, fromUserID int64, setService func(message *models.Message) error) (*models.Message, error) {
+	id, err := m.generateID()
+	if err != nil {
+		return nil, err
+	}
+
+	fromUser, err := m.userRepo.GetByID(fromUserID)
+	if err != nil {
+		m.logger.Error("Ошибка получения пользователя", zap.Int64("user_id", fromUserID), zap.Error(err))
+		return nil, err
+	}
+
+	message := &models.Message{
+		ID:        id,
+		ChatID:    chatID,
+		FromID:    fromUserID,
+		From:      *fromUser,
+		Status:    models.MessageStatusSent,
+		Timestamp: time.Now(),
+		CreatedAt: time.Now(),
+	}
+	if err := setService(message); err != nil {
+		return nil, err
+	}
+
+	if err := m.messageRepo.Create(message); err != nil {
+		m.logger.Error("Ошибка создания служебного сообщения", zap.Error(err))
+		return nil, err
+	}
+	m.loadMessageChat(message)
+
+	m.broadcastMessage(message)
+	m.notifyBots(message)
+
+	m.logger.Info("Служебное сообщение отправлено",
+		zap.Int64("message_id", message.ID),
+		zap.Int64("chat_id", chatID),
+		zap.String("type", message.Type))
+
+	return message, nil
+}
+
+// SendChatAction показывает участникам чата действие пользователя userID (набор текста, загрузка файла и т.д.).
+// Действие отображается models.ChatActionDuration или до следующего сообщения пользователя.
+// Отправить действие может только участник чата
+func (m *MessageManager) SendChatAction(chatID, userID int64, action string) error {
+	if !models.IsValidChatAction(action) {
+		return models.ErrWrongChatAction
+	}
+
+	chat, err := m.chatRepo.GetByID(chatID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrChatNotFound
+		}
+		return err
+	}
+
+	chatMember, err := m.chatRepo.GetMember(chatID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrUserNotParticipant
+		}
+		return err
+	}
+	chatMember.Expire(time.Now())
+	if !chatMember.IsActive() {
+		return models.ErrUserNotParticipant
+	}
+
+	m.setChatAction(chat, userID, action)
+	return nil
+}
+
+// SendBotChatAction показывает участникам чата действие бота по запросу sendChatAction.
+// Как и в Telegram, бот, который не состоит в чате, исключен из него или заблокирован пользователем,
+// получает ошибку вместо отображения действия
+func (m *MessageManager) SendBotChatAction(chatID, botUserID int64, action string) error {
+	if !models.IsValidChatAction(action) {
+		return models.ErrWrongChatAction
+	}
+
+	chat, err := m.getBotChat(chatID, botUserID)
+	if err != nil {
+		return err
+	}
+
+	m.setChatAction(chat, botUserID, action)
+	return nil
+}
+
+// setChatAction запоминает действие пользователя и рассылает его участникам чата
+func (m *MessageManager) setChatAction(chat *models.Chat, userID int64, action string) {
+	chatAction := m.chatActions.Set(chat.ID, userID, action)
+	m.broadcastChatAction(chat, chatAction)
+
+	m.logger.Debug("Действие в чате",
+		zap.Int64("chat_id", chat.ID),
+		zap.Int64("user_id", userID),
+		zap.String("action", action))
+}
+
+// GetChatActions возвращает действия, которые сейчас отображаются в чате
+func (m *MessageManager) GetChatActions(chatID int64) []models.ChatAction {
+	return m.chatActions.GetChatActions(chatID)
+}
+
+// EditMessageText изменяет текст сообщения бота.
+// Как и в Telegram, клавиатура сообщения заменяется переданной (nil удаляет ее),
+// а подпись к вложению этим методом не изменить
+func (m *MessageManager) EditMessageText(botUserID, chatID, messageID int64, text, parseMode string, replyMarkup interface{}) (*models.Message, error) {
+	if text == "" {
+		return nil, models.ErrMessageTextEmpty
+	}
+
+	message, err := m.getMessageForEdit(botUserID, chatID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if !message.IsText() {
+		return nil, models.ErrNoTextToEdit
+	}
+
+	edited := *message
+	if err := edited.SetFormattedText(text, parseMode); err != nil {
+		return nil, err
+	}
+	if edited.Text == "" {
+		return nil, models.ErrMessageTextEmpty
+	}
+	if err := edited.SetReplyMarkup(replyMarkup); err != nil {
+		return nil, err
+	}
+
+	return m.saveEditedMessage(message, &edited)
+}
+
+// EditMessageReplyMarkup изменяет клавиатуру сообщения бота (nil удаляет ее)
+func (m *MessageManager) EditMessageReplyMarkup(botUserID, chatID, messageID int64, replyMarkup interface{}) (*models.Message, error) {
+	message, err := m.getMessageForEdit(botUserID, chatID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	edited := *message
+	if err := edited.SetReplyMarkup(replyMarkup); err != nil {
+		return nil, err
+	}
+
+	return m.saveEditedMessage(message, &edited)
+}
+
+// getMessageForEdit загружает сообщение и проверяет, что бот может его редактировать
+func (m *MessageManager) getMessageForEdit(botUserID, chatID, messageID int64) (*models.Message, error) {
+	message, err := m.getChatMessage(chatID, messageID, models.ErrMessageToEditNotFound)
+	if err != nil {
+		return nil, err
+	}
+
+	// Бот может редактировать только свои сообщения
+	if message.FromID != botUserID {
+		return nil, models.ErrMessageCantBeEdited
+	}
+
+	return message, nil
+}
+
+// saveEditedMessage сохраняет отредактированное сообщение и уведомляет участников чата.
+// Сообщение считается измененным, если изменились текст (у вложений - подпись), его сущности или клавиатура
+func (m *MessageManager) saveEditedMessage(original, edited *models.Message) (*models.Message, error) {
+	if original.Text == edited.Text && original.EntitiesJSON == edited.EntitiesJSON &&
+		original.ReplyMarkupJSON == edited.ReplyMarkupJSON {
+		return nil, models.ErrMessageNotModified
+	}
+
+	editDate := time.Now()
+	edited.EditDate = &editDate
+
+	if err := m.messageRepo.UpdateContent(edited); err != nil {
+		m.logger.Error("Ошибка сохранения отредактированного сообщения",
+			zap.Int64("message_id", edited.ID),
+			zap.Error(err))
+		return nil, err
+	}
+
+	m.broadcastMessageEdited(edited)
+
+	m.logger.Info("Сообщение отредактировано",
+		zap.Int64("message_id", edited.ID),
+		zap.Int64("chat_id", edited.ChatID))
+
+	return edited, nil
+}
+
 // GetChatMessages получает сообщения чата
 func (m *MessageManager) GetChatMessages(chatID int64, limit, offset int) ([]models.Message, error) {
 	messages, err := m.messageRepo.GetByChatID(chatID, limit, offset)
@@ -256,6 +644,200 @@ func (m *MessageManager) DeleteMessage(id int64) error {
 	return nil
 }
 
+// DeleteBotMessage удаляет сообщение по запросу бота с учетом ограничений Telegram:
+// удалить можно только сообщение младше 48 часов из чата, в котором состоит бот,
+// а чужие сообщения в группах - только администратору
+func (m *MessageManager) DeleteBotMessage(botUserID, chatID, messageID int64) error {
+	chat, err := m.getBotChat(chatID, botUserID)
+	if err != nil {
+		if errors.Is(err, models.ErrChatNotFound) {
+			return models.ErrMessageToDeleteNotFound
+		}
+		return err
+	}
+
+	message, err := m.getChatMessage(chatID, messageID, models.ErrMessageToDeleteNotFound)
+	if err != nil {
+		return err
+	}
+
+	if time.Since(message.Timestamp) > botDeleteMessageWindow {
+		return models.ErrMessageCantBeDeleted
+	}
+
+	// В личном чате бот - собеседник пользователя и может удалять и его сообщения
+	if message.FromID != botUserID && !chat.IsPrivate() && !m.canDeleteMessages(chat, botUserID) {
+		return models.ErrMessageCantBeDeleted
+	}
+
+	return m.DeleteMessage(messageID)
+}
+
+// canDeleteMessages проверяет, может ли пользователь удалять чужие сообщения в групповом чате.
+// Это могут создатель чата и администраторы с правом can_delete_messages
+func (m *MessageManager) canDeleteMessages(chat *models.Chat, userID int64) bool {
+	chatMember, err := m.chatRepo.GetMember(chat.ID, userID)
+	if err != nil {
+		return false
+	}
+	return chatMember.Rights().CanDeleteMessages
+}
+
+// ForwardMessage пересылает сообщение в чат chatID от имени бота.
+// Пересланное сообщение сохраняет автора и дату оригинала в forward_origin
+func (m *MessageManager) ForwardMessage(botUserID, chatID, fromChatID, messageID int64) (*models.Message, error) {
+	if err := m.checkBotChats(botUserID, chatID, fromChatID); err != nil {
+		return nil, err
+	}
+
+	source, err := m.getChatMessage(fromChatID, messageID, models.ErrMessageToForwardNotFound)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.SendContent(chatID, botUserID, MessageContent{
+		Type:          source.Type,
+		Text:          source.Text,
+		Entities:      source.GetEntities(),
+		Media:         source.GetMedia(),
+		ForwardOrigin: source.NewForwardOrigin(),
+	})
+}
+
+// CopyOptions задает изменения, применяемые к копии сообщения
+type CopyOptions struct {
+	Caption         *string                // Новая подпись к вложению, nil сохраняет подпись оригинала
+	ParseMode       string                 // Режим разметки новой подписи
+	CaptionEntities []models.MessageEntity // Сущности новой подписи, используются вместо ParseMode
+	ReplyMarkup     interface{}            // Клавиатура копии
+
+	ReplyToMessageID         int64 // Сообщение чата назначения, на которое отвечает копия
+	AllowSendingWithoutReply bool  // Отправить копию без ответа, если исходное сообщение не найдено
+}
+
+// CopyMessage копирует сообщение в чат chatID от имени бота.
+// В отличие от пересылки, копия не содержит ссылки на оригинал
+func (m *MessageManager) CopyMessage(botUserID, chatID, fromChatID, messageID int64, options CopyOptions) (*models.Message, error) {
+	if err := m.checkBotChats(botUserID, chatID, fromChatID); err != nil {
+		return nil, err
+	}
+
+	source, err := m.getChatMessage(fromChatID, messageID, models.ErrMessageToCopyNotFound)
+	if err != nil {
+		return nil, err
+	}
+
+	content := MessageContent{
+		Type:        source.Type,
+		Text:        source.Text,
+		Entities:    source.GetEntities(),
+		Media:       source.GetMedia(),
+		ReplyMarkup: options.ReplyMarkup,
+
+		ReplyToMessageID:         options.ReplyToMessageID,
+		AllowSendingWithoutReply: options.AllowSendingWithoutReply,
+	}
+
+	// Подпись можно заменить только у сообщения с вложением
+	if content.Media != nil && options.Caption != nil {
+		content.Text = *options.Caption
+		content.ParseMode = options.ParseMode
+		content.Entities = options.CaptionEntities
+	}
+
+	return m.SendContent(chatID, botUserID, content)
+}
+
+// checkBotChats проверяет, что бот состоит в исходном чате fromChatID и может писать в чат chatID.
+// Сообщения чатов, из которых бот вышел или был исключен, ему не видны
+func (m *MessageManager) checkBotChats(botUserID, chatID, fromChatID int64) error {
+	if _, err := m.getBotChat(fromChatID, botUserID); err != nil {
+		if errors.Is(err, models.ErrBotKicked) || errors.Is(err, models.ErrBotBlocked) {
+			return models.ErrChatNotFound
+		}
+		return err
+	}
+
+	return m.checkBotMember(chatID, botUserID)
+}
+
+// checkBotMember проверяет, что бот состоит в чате chatID и может писать в него.
+// Как и в Telegram, бот, который не состоит в группе, получает ошибку "bot is not a member"
+func (m *MessageManager) checkBotMember(chatID, botUserID int64) error {
+	_, err := m.getBotChat(chatID, botUserID)
+	if errors.Is(err, models.ErrChatNotFound) {
+		if chat, chatErr := m.chatRepo.GetByID(chatID); chatErr == nil && !chat.IsPrivate() {
+			return models.ErrBotNotMember
+		}
+	}
+	return err
+}
+
+// getBotChat возвращает чат chatID, если бот botUserID состоит в нем.
+// Без ChatManager участие бота не проверяется и возвращается любой существующий чат
+func (m *MessageManager) getBotChat(chatID, botUserID int64) (*models.Chat, error) {
+	if m.chatManager == nil {
+		m.logger.Error("chatManager равен nil - проверка участия ботов в чатах отключена")
+		chat, err := m.chatRepo.GetByID(chatID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, models.ErrChatNotFound
+			}
+			return nil, err
+		}
+		return chat, nil
+	}
+
+	return m.chatManager.GetBotChat(chatID, botUserID)
+}
+
+// addChatMember добавляет пользователя в групповой чат, в который он пишет.
+// Без ChatManager участники чата и боты не получают уведомлений о вступлении
+func (m *MessageManager) addChatMember(chatID, userID int64) error {
+	if m.chatManager == nil {
+		m.logger.Error("chatManager равен nil - уведомления о вступлении в чат отключены")
+		return m.chatRepo.AddMember(chatID, userID)
+	}
+
+	return m.chatManager.AddMember(chatID, userID, 0)
+}
+
+// getChatMessage возвращает сообщение messageID из чата chatID или notFound, если его нет
+func (m *MessageManager) getChatMessage(chatID, messageID int64, notFound error) (*models.Message, error) {
+	message, err := m.messageRepo.GetByID(messageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound
+		}
+		return nil, err
+	}
+
+	if message.ChatID != chatID {
+		return nil, notFound
+	}
+
+	m.loadMessageChat(message)
+	return message, nil
+}
+
+// loadMessageChat загружает чат сообщения, чтобы боты получили его тип, название и собеседника
+func (m *MessageManager) loadMessageChat(message *models.Message) {
+	chat, err := m.chatRepo.GetByID(message.ChatID)
+	if err != nil {
+		m.logger.Warn("Ошибка получения чата сообщения", zap.Int64("chat_id", message.ChatID), zap.Error(err))
+		return
+	}
+	setMessageChat(message, chat)
+}
+
+// setMessageChat прикрепляет к сообщению чат. Последнее сообщение чата для конвертации не нужно
+// и не сохраняется вместе с сообщением в очереди обновлений
+func setMessageChat(message *models.Message, chat *models.Chat) {
+	messageChat := *chat
+	messageChat.LastMessage = nil
+	message.Chat = &messageChat
+}
+
 // SearchMessages ищет сообщения по тексту
 func (m *MessageManager) SearchMessages(chatID int64, query string) ([]models.Message, error) {
 	messages, err := m.messageRepo.SearchByText(chatID, query)
@@ -274,6 +856,7 @@ func (m *MessageManager) HandleCallbackQuery(userID int64, messageID int64, call
 		m.logger.Error("Ошибка получения сообщения для callback query", zap.Int64("message_id", messageID), zap.Error(err))
 		return nil, err
 	}
+	m.loadMessageChat(message)
 
 	// Получаем пользователя
 	user, err := m.userRepo.GetByID(userID)
@@ -352,11 +935,6 @@ func (m *MessageManager) notifyBotsCallbackQuery(callbackQuery *models.CallbackQ
 					zap.Int64("bot_id", bot.ID),
 					zap.Error(err))
 			}
-
-			// Если у бота есть webhook URL, отправляем обновление в webhook
-			if bot.WebhookURL != "" {
-				go m.sendWebhookUpdate(&bot, update)
-			}
 		}
 	}
 
@@ -365,6 +943,30 @@ func (m *MessageManager) notifyBotsCallbackQuery(callbackQuery *models.CallbackQ
 		zap.Int("bots_count", len(bots)))
 }
 
+// AnswerCallbackQuery проверяет ответ бота на callback query и доставляет его пользователю, нажавшему кнопку
+func (m *MessageManager) AnswerCallbackQuery(botID int64, answer *models.CallbackQueryAnswer) error {
+	userID, err := m.botManager.AnswerCallbackQuery(botID, answer)
+	if err != nil {
+		return err
+	}
+
+	if m.wsServer == nil {
+		m.logger.Error("wsServer равен nil - ответ на callback query не доставлен")
+		return nil
+	}
+
+	m.wsServer.SendCallbackAnswer(answer.CallbackQueryID, userID, map[string]interface{}{
+		"callback_query_id": answer.CallbackQueryID,
+		"bot_id":            botID,
+		"text":              answer.Text,
+		"show_alert":        answer.ShowAlert,
+		"url":               answer.URL,
+		"cache_time":        answer.CacheTime,
+	})
+
+	return nil
+}
+
 // simulateMessageDelivery эмулирует доставку сообщения
 func (m *MessageManager) simulateMessageDelivery(message *models.Message) {
 	// Эмулируем задержку сети
@@ -411,28 +1013,85 @@ func (m *MessageManager) broadcastMessage(message *models.Message) {
 				zap.Int64("member_id", member.ID),
 				zap.String("member_username", member.Username))
 
-			messageData := map[string]interface{}{
-				"id":        message.ID,
-				"chat_id":   message.ChatID,
-				"from":      message.From,
-				"text":      message.Text,
-				"type":      message.Type,
-				"timestamp": message.Timestamp,
-				"status":    message.Status,
-			}
-
-			// Добавляем клавиатуру, если она есть
-			if replyMarkup := message.GetReplyMarkup(); replyMarkup != nil {
-				messageData["reply_markup"] = replyMarkup
-			}
-
-			m.wsServer.BroadcastToUser(member.ID, "message", messageData)
+			m.wsServer.BroadcastToUser(member.ID, "message", m.messageEventData(message))
 		}
 	} else {
 		m.logger.Error("wsServer равен nil - broadcast отключен")
 	}
 }
 
+// broadcastMessageEdited отправляет WebSocket уведомление об изменении сообщения участникам чата
+func (m *MessageManager) broadcastMessageEdited(message *models.Message) {
+	if m.wsServer == nil {
+		return
+	}
+
+	chat, err := m.chatRepo.GetByID(message.ChatID)
+	if err != nil {
+		m.logger.Error("Ошибка получения чата для broadcast", zap.Int64("chat_id", message.ChatID), zap.Error(err))
+		return
+	}
+
+	messageData := m.messageEventData(message)
+	for _, member := range chat.Members {
+		m.wsServer.BroadcastToUser(member.ID, "message_edited", messageData)
+	}
+}
+
+// messageEventData формирует данные сообщения для WebSocket уведомлений
+func (m *MessageManager) messageEventData(message *models.Message) map[string]interface{} {
+	messageData := map[string]interface{}{
+		"id":        message.ID,
+		"chat_id":   message.ChatID,
+		"from":      message.From,
+		"text":      message.Text,
+		"type":      message.Type,
+		"timestamp": message.Timestamp,
+		"status":    message.Status,
+	}
+
+	// Добавляем клавиатуру, если она есть
+	if replyMarkup := message.GetReplyMarkup(); replyMarkup != nil {
+		messageData["reply_markup"] = replyMarkup
+	}
+
+	if entities := message.GetEntities(); len(entities) > 0 {
+		messageData["entities"] = entities
+	}
+
+	if message.EditDate != nil {
+		messageData["edit_date"] = message.EditDate
+	}
+
+	if media := message.GetMedia(); media != nil {
+		messageData["media"] = media
+	}
+
+	if origin := message.GetForwardOrigin(); origin != nil {
+		messageData["forward_origin"] = origin
+	}
+
+	if newChatMembers := message.GetNewChatMembers(); len(newChatMembers) > 0 {
+		messageData["new_chat_members"] = newChatMembers
+	}
+
+	if leftChatMember := message.GetLeftChatMember(); leftChatMember != nil {
+		messageData["left_chat_member"] = leftChatMember
+	}
+
+	if message.ReplyToMessage != nil {
+		messageData["reply_to_message"] = map[string]interface{}{
+			"id":    message.ReplyToMessage.ID,
+			"from":  message.ReplyToMessage.From,
+			"text":  message.ReplyToMessage.Text,
+			"type":  message.ReplyToMessage.Type,
+			"media": message.ReplyToMessage.GetMedia(),
+		}
+	}
+
+	return messageData
+}
+
 // broadcastMessageStatusUpdate отправляет WebSocket уведомление об обновлении статуса
 func (m *MessageManager) broadcastMessageStatusUpdate(messageID int64, status string) {
 	if m.wsServer != nil {
@@ -452,6 +1111,47 @@ func (m *MessageManager) broadcastMessageDelete(messageID int64) {
 	}
 }
 
+// broadcastChatAction отправляет участникам чата, кроме автора действия, WebSocket уведомление о действии
+func (m *MessageManager) broadcastChatAction(chat *models.Chat, chatAction models.ChatAction) {
+	if m.wsServer == nil {
+		return
+	}
+
+	data := map[string]interface{}{
+		"chat_id":    chatAction.ChatID,
+		"user_id":    chatAction.UserID,
+		"action":     chatAction.Action,
+		"expires_at": chatAction.ExpiresAt,
+	}
+	for _, member := range chat.Members {
+		if member.ID == chatAction.UserID {
+			data["user"] = member
+		}
+	}
+
+	for _, member := range chat.Members {
+		if member.ID != chatAction.UserID {
+			m.wsServer.BroadcastToUser(member.ID, "chat_action", data)
+		}
+	}
+}
+
+// broadcastChatActionCancel отправляет участникам чата WebSocket уведомление о завершении действия пользователя
+func (m *MessageManager) broadcastChatActionCancel(chat *models.Chat, userID int64) {
+	if m.wsServer == nil {
+		return
+	}
+
+	for _, member := range chat.Members {
+		if member.ID != userID {
+			m.wsServer.BroadcastToUser(member.ID, "chat_action_cancel", map[string]interface{}{
+				"chat_id": chat.ID,
+				"user_id": userID,
+			})
+		}
+	}
+}
+
 // broadcastChatRead отправляет WebSocket уведомление о прочтении чата
 func (m *MessageManager) broadcastChatRead(chatID int64, userID int64) {
 	if m.wsServer != nil {
@@ -559,10 +1259,7 @@ func (m *MessageManager) notifyBots(message *models.Message) {
 				zap.Error(err))
 		}
 
-		// Если у бота есть webhook URL, отправляем обновление в webhook
-		if bot.WebhookURL != "" {
-			go m.sendWebhookUpdate(&bot, update)
-		}
+		m.replyWithStubRule(&bot, botUser, message)
 	}
 
 	m.logger.Info("Боты уведомлены о новом сообщении",
@@ -570,54 +1267,46 @@ func (m *MessageManager) notifyBots(message *models.Message) {
 		zap.Int("bots_count", len(bots)))
 }
 
-// sendWebhookUpdate отправляет обновление в webhook бота
-func (m *MessageManager) sendWebhookUpdate(bot *models.Bot, update *models.Update) {
-	// Конвертируем обновление в формат Telegram Bot API
-	webhookUpdate := map[string]interface{}{
-		"update_id": update.UpdateID,
-	}
-
-	if update.Message != nil {
-		webhookUpdate["message"] = update.Message.ToTelegramMessage()
-	}
-	if update.EditedMessage != nil {
-		webhookUpdate["edited_message"] = update.EditedMessage.ToTelegramMessage()
-	}
-	if update.CallbackQuery != nil {
-		webhookUpdate["callback_query"] = update.CallbackQuery.ToTelegramCallbackQuery()
+// replyWithStubRule отвечает на сообщение от имени бота по первому подходящему правилу бота-заглушки.
+// Заглушки отвечают только на обычные сообщения пользователей, чтобы боты не отвечали друг другу бесконечно
+func (m *MessageManager) replyWithStubRule(bot *models.Bot, botUser *models.User, message *models.Message) {
+	if message.From.IsBot || message.IsService() {
+		return
 	}
 
-	// Отправляем POST запрос в webhook
-	jsonData, err := json.Marshal(webhookUpdate)
+	rule, err := m.botManager.MatchStubRule(bot, message)
 	if err != nil {
-		m.logger.Error("Ошибка сериализации webhook обновления",
-			zap.Int64("bot_id", bot.ID),
-			zap.Error(err))
+		m.logger.Error("Ошибка получения правил бота-заглушки", zap.Int64("bot_id", bot.ID), zap.Error(err))
 		return
 	}
-
-	resp, err := http.Post(bot.WebhookURL, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		m.logger.Error("Ошибка отправки webhook",
-			zap.Int64("bot_id", bot.ID),
-			zap.String("webhook_url", bot.WebhookURL),
-			zap.Error(err))
+	if rule == nil {
 		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		m.logger.Error("Webhook вернул ошибку",
-			zap.Int64("bot_id", bot.ID),
-			zap.String("webhook_url", bot.WebhookURL),
-			zap.Int("status_code", resp.StatusCode),
-			zap.String("response", string(body)))
-		return
+	content := MessageContent{
+		Type:        models.MessageTypeText,
+		Text:        rule.Text,
+		ParseMode:   rule.ParseMode,
+		ReplyMarkup: rule.GetReplyMarkup(),
+	}
+	if rule.Reply {
+		content.ReplyToMessageID = message.ID
+		content.AllowSendingWithoutReply = true
 	}
 
-	m.logger.Info("Webhook обновление отправлено",
-		zap.Int64("bot_id", bot.ID),
-		zap.String("webhook_url", bot.WebhookURL),
-		zap.Int64("update_id", update.UpdateID))
+	time.AfterFunc(rule.Delay(), func() {
+		reply, err := m.SendContent(message.ChatID, botUser.ID, content)
+		if err != nil {
+			m.logger.Error("Ошибка отправки ответа бота-заглушки",
+				zap.Int64("bot_id", bot.ID),
+				zap.Int64("rule_id", rule.ID),
+				zap.Error(err))
+			return
+		}
+
+		m.logger.Info("Бот-заглушка ответил на сообщение",
+			zap.Int64("bot_id", bot.ID),
+			zap.Int64("rule_id", rule.ID),
+			zap.Int64("message_id", reply.ID))
+	})
 }
@@ -0,0 +1,903 @@
+package emulator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram-emulator/internal/models"
+	"telegram-emulator/internal/repository"
+)
+
+// messageTestEnv содержит менеджеры и данные для тестов MessageManager
+type messageTestEnv struct {
+	messageManager *MessageManager
+	botManager     *BotManager
+	chatManager    *ChatManager
+	messageRepo    *repository.MessageRepository
+	bot            *models.Bot
+	user           *models.User
+	chat           *models.Chat
+}
+
+func newMessageTestEnv(t *testing.T) *messageTestEnv {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+	userManager := NewUserManager(userRepo, botRepo)
+	chatManager := NewChatManager(chatRepo, messageRepo, userRepo)
+	messageManager := NewMessageManager(messageRepo, chatRepo, userRepo, botManager, nil)
+	messageManager.SetChatManager(chatManager)
+
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	user, err := userManager.CreateUser("testuser", "Test", "User", false)
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	chat, err := chatManager.CreatePrivateChat(user.ID, bot.ID)
+	if err != nil {
+		t.Fatalf("Failed to create chat: %v", err)
+	}
+
+	return &messageTestEnv{
+		messageManager: messageManager,
+		botManager:     botManager,
+		chatManager:    chatManager,
+		messageRepo:    messageRepo,
+		bot:            bot,
+		user:           user,
+		chat:           chat,
+	}
+}
+
+func inlineKeyboard(text, data string) map[string]interface{} {
+	return map[string]interface{}{
+		"inline_keyboard": []interface{}{
+			[]interface{}{
+				map[string]interface{}{"text": text, "callback_data": data},
+			},
+		},
+	}
+}
+
+func TestMessageManager_EditMessageText(t *testing.T) {
+	env := newMessageTestEnv(t)
+
+	message, err := env.messageManager.SendMessage(env.chat.ID, env.bot.ID, "Hello", models.MessageTypeText, inlineKeyboard("Yes", "yes"))
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	edited, err := env.messageManager.EditMessageText(env.bot.ID, env.chat.ID, message.ID, "Hello /start", "", inlineKeyboard("No", "no"))
+	if err != nil {
+		t.Fatalf("Failed to edit message: %v", err)
+	}
+
+	if edited.EditDate == nil {
+		t.Error("Expected edit_date to be set")
+	}
+
+	stored, err := env.messageRepo.GetByID(message.ID)
+	if err != nil {
+		t.Fatalf("Failed to get message: %v", err)
+	}
+
+	if stored.Text != "Hello /start" {
+		t.Errorf("Expected stored text 'Hello /start', got '%s'", stored.Text)
+	}
+	if !stored.IsEdited() {
+		t.Error("Expected stored message to have edit_date")
+	}
+	if !stored.IsCommand() {
+		t.Error("Expected entities to be parsed for the new text")
+	}
+	if stored.ReplyMarkupJSON != edited.ReplyMarkupJSON || stored.ReplyMarkupJSON == message.ReplyMarkupJSON {
+		t.Errorf("Expected new reply markup to be stored, got %s", stored.ReplyMarkupJSON)
+	}
+	if telegramMessage := stored.ToTelegramMessage(env.bot.ID); telegramMessage.EditDate == 0 {
+		t.Error("Expected edit_date in Telegram message")
+	}
+}
+
+func TestMessageManager_EditMessageNotModified(t *testing.T) {
+	env := newMessageTestEnv(t)
+
+	markup := inlineKeyboard("Yes", "yes")
+	message, err := env.messageManager.SendMessage(env.chat.ID, env.bot.ID, "Hello", models.MessageTypeText, markup)
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	_, err = env.messageManager.EditMessageText(env.bot.ID, env.chat.ID, message.ID, "Hello", "", markup)
+	if !errors.Is(err, models.ErrMessageNotModified) {
+		t.Errorf("Expected ErrMessageNotModified, got %v", err)
+	}
+
+	_, err = env.messageManager.EditMessageReplyMarkup(env.bot.ID, env.chat.ID, message.ID, markup)
+	if !errors.Is(err, models.ErrMessageNotModified) {
+		t.Errorf("Expected ErrMessageNotModified for reply markup, got %v", err)
+	}
+
+	// Formatting the same text changes its entities
+	edited, err := env.messageManager.EditMessageText(env.bot.ID, env.chat.ID, message.ID, "<b>Hello</b>", models.ParseModeHTML, markup)
+	if err != nil {
+		t.Fatalf("Expected formatting-only edit to succeed, got %v", err)
+	}
+	if edited.Text != "Hello" || len(edited.GetEntities()) != 1 {
+		t.Errorf("Expected bold Hello, got text %q entities %+v", edited.Text, edited.GetEntities())
+	}
+	_, err = env.messageManager.EditMessageText(env.bot.ID, env.chat.ID, message.ID, "<b>Hello</b>", models.ParseModeHTML, markup)
+	if !errors.Is(err, models.ErrMessageNotModified) {
+		t.Errorf("Expected ErrMessageNotModified for the same formatting, got %v", err)
+	}
+
+}
+
+func TestMessageManager_EditMessageTextRejectsMedia(t *testing.T) {
+	env := newMessageTestEnv(t)
+
+	media, err := env.messageManager.SendContent(env.chat.ID, env.bot.ID, MessageContent{
+		Type:  models.MessageTypeFile,
+		Text:  "Report",
+		Media: &models.MessageMedia{Document: &models.Document{FileID: "file-id", FileUniqueID: "unique-id"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to send media message: %v", err)
+	}
+
+	if _, err := env.messageManager.EditMessageText(env.bot.ID, env.chat.ID, media.ID, "Final report", "", nil); !errors.Is(err, models.ErrNoTextToEdit) {
+		t.Errorf("Expected ErrNoTextToEdit for a media message, got %v", err)
+	}
+
+	// The keyboard of a media message can still be edited, the caption stays the same
+	edited, err := env.messageManager.EditMessageReplyMarkup(env.bot.ID, env.chat.ID, media.ID, inlineKeyboard("Yes", "yes"))
+	if err != nil {
+		t.Fatalf("Failed to edit reply markup: %v", err)
+	}
+	if edited.Text != "Report" {
+		t.Errorf("Expected caption to stay 'Report', got '%s'", edited.Text)
+	}
+}
+
+func TestMessageManager_EditMessageReplyMarkupRemovesKeyboard(t *testing.T) {
+	env := newMessageTestEnv(t)
+
+	message, err := env.messageManager.SendMessage(env.chat.ID, env.bot.ID, "Hello", models.MessageTypeText, inlineKeyboard("Yes", "yes"))
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	edited, err := env.messageManager.EditMessageReplyMarkup(env.bot.ID, env.chat.ID, message.ID, nil)
+	if err != nil {
+		t.Fatalf("Failed to edit reply markup: %v", err)
+	}
+
+	if edited.GetReplyMarkup() != nil {
+		t.Error("Expected keyboard to be removed")
+	}
+	if edited.Text != "Hello" {
+		t.Errorf("Expected text to stay 'Hello', got '%s'", edited.Text)
+	}
+}
+
+func TestMessageManager_EditMessageChecksOwnership(t *testing.T) {
+	env := newMessageTestEnv(t)
+
+	botMessage, err := env.messageManager.SendMessage(env.chat.ID, env.bot.ID, "Hello", models.MessageTypeText, nil)
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	userMessage, err := env.messageManager.SendMessage(env.chat.ID, env.user.ID, "Hi", models.MessageTypeText, nil)
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	_, err = env.messageManager.EditMessageText(env.bot.ID, env.chat.ID, 123456789, "Edited", "", nil)
+	if !errors.Is(err, models.ErrMessageToEditNotFound) {
+		t.Errorf("Expected ErrMessageToEditNotFound for unknown message, got %v", err)
+	}
+
+	_, err = env.messageManager.EditMessageText(env.bot.ID, env.chat.ID+1, botMessage.ID, "Edited", "", nil)
+	if !errors.Is(err, models.ErrMessageToEditNotFound) {
+		t.Errorf("Expected ErrMessageToEditNotFound for another chat, got %v", err)
+	}
+
+	_, err = env.messageManager.EditMessageText(env.bot.ID, env.chat.ID, userMessage.ID, "Edited", "", nil)
+	if !errors.Is(err, models.ErrMessageCantBeEdited) {
+		t.Errorf("Expected ErrMessageCantBeEdited for user's message, got %v", err)
+	}
+
+	_, err = env.messageManager.EditMessageText(env.bot.ID, env.chat.ID, botMessage.ID, "", "", nil)
+	if !errors.Is(err, models.ErrMessageTextEmpty) {
+		t.Errorf("Expected ErrMessageTextEmpty, got %v", err)
+	}
+}
+
+func TestMessageManager_SendFormattedMessage(t *testing.T) {
+	env := newMessageTestEnv(t)
+
+	message, err := env.messageManager.SendFormattedMessage(env.chat.ID, env.bot.ID, "<b>Hello</b>, <i>world</i>", models.ParseModeHTML, models.MessageTypeText, nil)
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	if message.Text != "Hello, world" {
+		t.Errorf("Expected markup to be stripped, got '%s'", message.Text)
+	}
+	entities := message.GetEntities()
+	if len(entities) != 2 || entities[0].Type != models.EntityTypeBold || entities[1].Type != models.EntityTypeItalic {
+		t.Errorf("Expected bold and italic entities, got %+v", entities)
+	}
+
+	_, err = env.messageManager.SendFormattedMessage(env.chat.ID, env.bot.ID, "*unclosed", models.ParseModeMarkdownV2, models.MessageTypeText, nil)
+	var telegramErr *models.TelegramError
+	if !errors.As(err, &telegramErr) || !strings.HasPrefix(telegramErr.Description, "Bad Request: can't parse entities:") {
+		t.Errorf("Expected can't parse entities error, got %v", err)
+	}
+
+	edited, err := env.messageManager.EditMessageText(env.bot.ID, env.chat.ID, message.ID, "*Bye*", models.ParseModeMarkdownV2, nil)
+	if err != nil {
+		t.Fatalf("Failed to edit message: %v", err)
+	}
+	if edited.Text != "Bye" {
+		t.Errorf("Expected edited text 'Bye', got '%s'", edited.Text)
+	}
+	if entities := edited.GetEntities(); len(entities) != 1 || entities[0].Type != models.EntityTypeBold {
+		t.Errorf("Expected bold entity after edit, got %+v", entities)
+	}
+}
+
+func TestMessageManager_SendContentWithMedia(t *testing.T) {
+	env := newMessageTestEnv(t)
+
+	media := &models.MessageMedia{
+		Document: &models.Document{FileID: "file-id", FileUniqueID: "unique-id", FileName: "report.pdf"},
+	}
+	message, err := env.messageManager.SendContent(env.chat.ID, env.bot.ID, MessageContent{
+		Type:     models.MessageTypeFile,
+		Text:     "Report for /today",
+		Entities: []models.MessageEntity{{Type: models.EntityTypeBold, Offset: 0, Length: 6}},
+		Media:    media,
+	})
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	telegramMessage := message.ToTelegramMessage(env.bot.ID)
+	if telegramMessage.Text != "" || telegramMessage.Caption != "Report for /today" {
+		t.Errorf("Expected text to be sent as caption, got text '%s' caption '%s'", telegramMessage.Text, telegramMessage.Caption)
+	}
+	if len(telegramMessage.CaptionEntities) != 2 ||
+		telegramMessage.CaptionEntities[0].Type != models.EntityTypeBold ||
+		telegramMessage.CaptionEntities[1].Type != models.EntityTypeBotCommand {
+		t.Errorf("Expected bold and bot_command caption entities, got %+v", telegramMessage.CaptionEntities)
+	}
+	if document, ok := telegramMessage.Document.(*models.Document); !ok || document.FileID != "file-id" {
+		t.Errorf("Expected document to be attached, got %+v", telegramMessage.Document)
+	}
+
+	// Media survives a round trip through the database
+	stored, err := env.messageRepo.GetByID(message.ID)
+	if err != nil {
+		t.Fatalf("Failed to load message: %v", err)
+	}
+	if stored.GetMedia() == nil || stored.GetMedia().Document.FileName != "report.pdf" {
+		t.Errorf("Expected stored media, got %+v", stored.GetMedia())
+	}
+}
+
+func TestMessageManager_SendMediaDiscardsFileOnError(t *testing.T) {
+	env := newMessageTestEnv(t)
+	db := SetupTestDB(t)
+	env.messageManager.SetFileManager(NewFileManager(repository.NewFileRepository(db), t.TempDir()))
+
+	input := &InputFile{FileName: "report.pdf", Data: []byte("%PDF-1.4")}
+	if _, err := env.messageManager.SendMedia(env.chat.ID, env.user.ID, models.FileTypeDocument, input, "", 123456789); !errors.Is(err, models.ErrReplyMessageNotFound) {
+		t.Fatalf("Expected ErrReplyMessageNotFound, got %v", err)
+	}
+
+	var count int64
+	if err := db.Model(&models.File{}).Count(&count).Error; err != nil {
+		t.Fatalf("Failed to count files: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected the uploaded file to be discarded, got %d files", count)
+	}
+}
+
+func TestMessageManager_SendFileMessage(t *testing.T) {
+	env := newMessageTestEnv(t)
+	fileManager := NewFileManager(repository.NewFileRepository(SetupTestDB(t)), t.TempDir())
+
+	// Without a file store user media can't be sent
+	if _, err := env.messageManager.SendFileMessage(env.chat.ID, env.user.ID, models.MessageTypeVoice, "id", "", 0); err == nil {
+		t.Error("Expected error without file manager")
+	}
+
+	env.messageManager.SetFileManager(fileManager)
+
+	uploaded, err := fileManager.BuildMedia(models.FileTypeVoice, &InputFile{FileName: "note.ogg", Data: []byte("OggS")}, MediaAttributes{})
+	if err != nil {
+		t.Fatalf("Failed to upload file: %v", err)
+	}
+
+	message, err := env.messageManager.SendFileMessage(env.chat.ID, env.user.ID, models.MessageTypeVoice, uploaded.FileID(), "listen", 0)
+	if err != nil {
+		t.Fatalf("Failed to send file message: %v", err)
+	}
+	if message.Type != models.MessageTypeVoice || message.Text != "listen" {
+		t.Errorf("Expected voice message with caption, got type '%s' text '%s'", message.Type, message.Text)
+	}
+
+	// The bot receives the voice note in its update
+	updates, err := env.botManager.WaitForUpdates(context.Background(), env.bot.ID, 0, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get updates: %v", err)
+	}
+	if len(updates) != 1 || updates[0].Message == nil {
+		t.Fatalf("Expected one message update, got %+v", updates)
+	}
+	telegramMessage := updates[0].Message.ToTelegramMessage(env.bot.ID)
+	if voice, ok := telegramMessage.Voice.(*models.Voice); !ok || voice.FileID != uploaded.FileID() || voice.MimeType != "audio/ogg" {
+		t.Errorf("Expected voice in update, got %+v", telegramMessage.Voice)
+	}
+	if telegramMessage.Caption != "listen" {
+		t.Errorf("Expected caption 'listen', got '%s'", telegramMessage.Caption)
+	}
+
+	if _, err := env.messageManager.SendFileMessage(env.chat.ID, env.user.ID, models.MessageTypeText, uploaded.FileID(), "", 0); err == nil {
+		t.Error("Expected error for text message with file")
+	}
+}
+
+func TestMessageManager_DeleteBotMessage(t *testing.T) {
+	env := newMessageTestEnv(t)
+
+	userMessage, err := env.messageManager.SendMessage(env.chat.ID, env.user.ID, "Hi", models.MessageTypeText, nil)
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	if err := env.messageManager.DeleteBotMessage(env.bot.ID, env.chat.ID+1, userMessage.ID); !errors.Is(err, models.ErrMessageToDeleteNotFound) {
+		t.Errorf("Expected ErrMessageToDeleteNotFound for another chat, got %v", err)
+	}
+
+	// In a private chat the bot may delete the user's messages
+	if err := env.messageManager.DeleteBotMessage(env.bot.ID, env.chat.ID, userMessage.ID); err != nil {
+		t.Fatalf("Failed to delete user's message in private chat: %v", err)
+	}
+	if _, err := env.messageRepo.GetByID(userMessage.ID); err == nil {
+		t.Error("Expected message to be deleted")
+	}
+	if err := env.messageManager.DeleteBotMessage(env.bot.ID, env.chat.ID, userMessage.ID); !errors.Is(err, models.ErrMessageToDeleteNotFound) {
+		t.Errorf("Expected ErrMessageToDeleteNotFound for deleted message, got %v", err)
+	}
+
+	// Messages older than 48 hours can't be deleted
+	oldMessage, err := env.messageManager.SendMessage(env.chat.ID, env.bot.ID, "Old", models.MessageTypeText, nil)
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	oldMessage.Timestamp = time.Now().Add(-49 * time.Hour)
+	if err := env.messageRepo.Update(oldMessage); err != nil {
+		t.Fatalf("Failed to update message: %v", err)
+	}
+	if err := env.messageManager.DeleteBotMessage(env.bot.ID, env.chat.ID, oldMessage.ID); !errors.Is(err, models.ErrMessageCantBeDeleted) {
+		t.Errorf("Expected ErrMessageCantBeDeleted for old message, got %v", err)
+	}
+}
+
+func TestMessageManager_DeleteBotMessageInGroup(t *testing.T) {
+	env := newMessageTestEnv(t)
+
+	group, err := env.chatManager.CreateChat("group", "Team", "", "", []int64{env.user.ID, env.bot.ID})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+
+	userMessage, err := env.messageManager.SendMessage(group.ID, env.user.ID, "Hi", models.MessageTypeText, nil)
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	botMessage, err := env.messageManager.SendMessage(group.ID, env.bot.ID, "Hello", models.MessageTypeText, nil)
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	// Only admins may delete other members' messages in groups
+	if err := env.messageManager.DeleteBotMessage(env.bot.ID, group.ID, userMessage.ID); !errors.Is(err, models.ErrMessageCantBeDeleted) {
+		t.Errorf("Expected ErrMessageCantBeDeleted for user's message, got %v", err)
+	}
+
+	if err := env.messageManager.DeleteBotMessage(env.bot.ID, group.ID, botMessage.ID); err != nil {
+		t.Errorf("Failed to delete own message: %v", err)
+	}
+
+	// An administrator with can_delete_messages may delete anyone's messages
+	if _, err := env.chatManager.PromoteMember(group.ID, env.bot.ID, models.ChatAdministratorRights{CanDeleteMessages: true}); err != nil {
+		t.Fatalf("Failed to promote bot: %v", err)
+	}
+	if err := env.messageManager.DeleteBotMessage(env.bot.ID, group.ID, userMessage.ID); err != nil {
+		t.Errorf("Expected administrator to delete user's message, got %v", err)
+	}
+}
+
+func TestMessageManager_ForwardMessage(t *testing.T) {
+	env := newMessageTestEnv(t)
+
+	original, err := env.messageManager.SendMessage(env.chat.ID, env.user.ID, "Look at /start", models.MessageTypeText, nil)
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	forwarded, err := env.messageManager.ForwardMessage(env.bot.ID, env.chat.ID, env.chat.ID, original.ID)
+	if err != nil {
+		t.Fatalf("Failed to forward message: %v", err)
+	}
+	if forwarded.ID == original.ID || forwarded.Text != original.Text || forwarded.FromID != env.bot.ID {
+		t.Errorf("Expected new message from bot with the same text, got %+v", forwarded)
+	}
+
+	telegramMessage := forwarded.ToTelegramMessage(env.bot.ID)
+	if telegramMessage.ForwardOrigin == nil || telegramMessage.ForwardOrigin.Type != models.MessageOriginUser {
+		t.Fatalf("Expected user forward origin, got %+v", telegramMessage.ForwardOrigin)
+	}
+	if telegramMessage.ForwardFrom == nil || telegramMessage.ForwardFrom.ID != env.user.ID || telegramMessage.ForwardFrom.Username != "testuser" {
+		t.Errorf("Expected forward_from to be the original sender, got %+v", telegramMessage.ForwardFrom)
+	}
+	if telegramMessage.ForwardDate != original.Timestamp.Unix() {
+		t.Errorf("Expected forward_date %d, got %d", original.Timestamp.Unix(), telegramMessage.ForwardDate)
+	}
+	if len(telegramMessage.Entities) != 1 || telegramMessage.Entities[0].Type != models.EntityTypeBotCommand {
+		t.Errorf("Expected single bot_command entity, got %+v", telegramMessage.Entities)
+	}
+
+	// Forwarding a forwarded message keeps the original sender
+	again, err := env.messageManager.ForwardMessage(env.bot.ID, env.chat.ID, env.chat.ID, forwarded.ID)
+	if err != nil {
+		t.Fatalf("Failed to forward message: %v", err)
+	}
+	if origin := again.GetForwardOrigin(); origin == nil || origin.SenderUser.ID != env.user.ID {
+		t.Errorf("Expected original sender in forward origin, got %+v", origin)
+	}
+
+	if _, err := env.messageManager.ForwardMessage(env.bot.ID, env.chat.ID, env.chat.ID+1, original.ID); !errors.Is(err, models.ErrChatNotFound) {
+		t.Errorf("Expected ErrChatNotFound for an unknown chat, got %v", err)
+	}
+	if _, err := env.messageManager.ForwardMessage(env.bot.ID, env.chat.ID, env.chat.ID, 123456789); !errors.Is(err, models.ErrMessageToForwardNotFound) {
+		t.Errorf("Expected ErrMessageToForwardNotFound, got %v", err)
+	}
+}
+
+// newForeignChat creates a private chat between the test user and another user without the bot
+func newForeignChat(t *testing.T, env *messageTestEnv) *models.Chat {
+	t.Helper()
+	stranger := &models.User{ID: 2, Username: "stranger", FirstName: "Stranger"}
+	if err := env.messageManager.userRepo.Create(stranger); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	chat, err := env.chatManager.CreatePrivateChat(env.user.ID, stranger.ID)
+	if err != nil {
+		t.Fatalf("Failed to create chat: %v", err)
+	}
+	return chat
+}
+
+func TestMessageManager_BotMessagesRequireMembership(t *testing.T) {
+	env := newMessageTestEnv(t)
+	foreign := newForeignChat(t, env)
+
+	secret, err := env.messageManager.SendMessage(foreign.ID, env.user.ID, "Secret", models.MessageTypeText, nil)
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	// A bot outside the chat can neither delete nor read its messages
+	if err := env.messageManager.DeleteBotMessage(env.bot.ID, foreign.ID, secret.ID); !errors.Is(err, models.ErrMessageToDeleteNotFound) {
+		t.Errorf("Expected ErrMessageToDeleteNotFound outside the chat, got %v", err)
+	}
+	if _, err := env.messageRepo.GetByID(secret.ID); err != nil {
+		t.Errorf("Expected the message to be kept, got %v", err)
+	}
+	if _, err := env.messageManager.ForwardMessage(env.bot.ID, env.chat.ID, foreign.ID, secret.ID); !errors.Is(err, models.ErrChatNotFound) {
+		t.Errorf("Expected ErrChatNotFound when forwarding from a foreign chat, got %v", err)
+	}
+	if _, err := env.messageManager.CopyMessage(env.bot.ID, env.chat.ID, foreign.ID, secret.ID, CopyOptions{}); !errors.Is(err, models.ErrChatNotFound) {
+		t.Errorf("Expected ErrChatNotFound when copying from a foreign chat, got %v", err)
+	}
+
+	// Nor can it forward or copy into a chat it isn't a member of
+	original, err := env.messageManager.SendMessage(env.chat.ID, env.user.ID, "Hi", models.MessageTypeText, nil)
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	if _, err := env.messageManager.ForwardMessage(env.bot.ID, foreign.ID, env.chat.ID, original.ID); !errors.Is(err, models.ErrChatNotFound) {
+		t.Errorf("Expected ErrChatNotFound when forwarding to a foreign chat, got %v", err)
+	}
+
+	// A bot kicked from the target group gets ErrBotKicked, and the group's messages are hidden from it
+	group, err := env.chatManager.CreateChat("group", "Team", "", "", []int64{env.user.ID, env.bot.ID})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	groupMessage, err := env.messageManager.SendMessage(group.ID, env.user.ID, "Hello", models.MessageTypeText, nil)
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	if err := env.chatManager.BanChatMember(env.user.ID, group.ID, env.bot.ID, nil); err != nil {
+		t.Fatalf("Failed to ban bot: %v", err)
+	}
+	if _, err := env.messageManager.CopyMessage(env.bot.ID, group.ID, env.chat.ID, original.ID, CopyOptions{}); !errors.Is(err, models.ErrBotKicked) {
+		t.Errorf("Expected ErrBotKicked when copying to the group, got %v", err)
+	}
+	if _, err := env.messageManager.ForwardMessage(env.bot.ID, env.chat.ID, group.ID, groupMessage.ID); !errors.Is(err, models.ErrChatNotFound) {
+		t.Errorf("Expected ErrChatNotFound when forwarding from the group, got %v", err)
+	}
+}
+
+func TestMessageManager_CopyMessage(t *testing.T) {
+	env := newMessageTestEnv(t)
+
+	media := &models.MessageMedia{
+		Document: &models.Document{FileID: "file-id", FileUniqueID: "unique-id", FileName: "report.pdf"},
+	}
+	original, err := env.messageManager.SendContent(env.chat.ID, env.user.ID, MessageContent{
+		Type:  models.MessageTypeFile,
+		Text:  "Report",
+		Media: media,
+	})
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	caption := "<b>Copy</b>"
+	copied, err := env.messageManager.CopyMessage(env.bot.ID, env.chat.ID, env.chat.ID, original.ID, CopyOptions{
+		Caption:     &caption,
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: inlineKeyboard("Open", "open"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to copy message: %v", err)
+	}
+	if copied.ID == original.ID {
+		t.Error("Expected copy to get a new message ID")
+	}
+	if copied.IsForwarded() {
+		t.Error("Expected copy to have no forward origin")
+	}
+	if copied.Text != "Copy" || copied.GetMedia() == nil || copied.GetMedia().Document.FileID != "file-id" {
+		t.Errorf("Expected document with new caption, got text '%s' media %+v", copied.Text, copied.GetMedia())
+	}
+	if copied.GetReplyMarkup() == nil {
+		t.Error("Expected reply markup on copy")
+	}
+
+	if _, err := env.messageManager.CopyMessage(env.bot.ID, env.chat.ID, env.chat.ID, 123456789, CopyOptions{}); !errors.Is(err, models.ErrMessageToCopyNotFound) {
+		t.Errorf("Expected ErrMessageToCopyNotFound, got %v", err)
+	}
+}
+
+func TestMessageManager_Replies(t *testing.T) {
+	env := newMessageTestEnv(t)
+
+	question, err := env.messageManager.SendMessage(env.chat.ID, env.bot.ID, "How are you?", models.MessageTypeText, nil)
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	answer, err := env.messageManager.SendReply(env.chat.ID, env.user.ID, "Fine", question.ID)
+	if err != nil {
+		t.Fatalf("Failed to send reply: %v", err)
+	}
+
+	// The bot sees the nested message the user replied to
+	updates, err := env.botManager.WaitForUpdates(context.Background(), env.bot.ID, 0, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get updates: %v", err)
+	}
+	if len(updates) != 1 || updates[0].Message == nil {
+		t.Fatalf("Expected one message update, got %+v", updates)
+	}
+	telegramMessage := updates[0].Message.ToTelegramMessage(env.bot.ID)
+	if telegramMessage.MessageID != answer.ID || telegramMessage.ReplyToMessage == nil {
+		t.Fatalf("Expected reply_to_message in update, got %+v", telegramMessage)
+	}
+	if telegramMessage.ReplyToMessage.MessageID != question.ID || telegramMessage.ReplyToMessage.Text != "How are you?" {
+		t.Errorf("Expected reply to the question, got %+v", telegramMessage.ReplyToMessage)
+	}
+	if chat := telegramMessage.Chat; chat.ID != env.chat.ID || chat.Type != "private" || chat.FirstName != env.user.FirstName {
+		t.Errorf("Expected the private chat with the user, got %+v", chat)
+	}
+
+	// Replying to a reply nests only one level
+	followUp, err := env.messageManager.SendContent(env.chat.ID, env.bot.ID, MessageContent{
+		Type:             models.MessageTypeText,
+		Text:             "Glad to hear",
+		ReplyToMessageID: answer.ID,
+	})
+	if err != nil {
+		t.Fatalf("Failed to send reply: %v", err)
+	}
+	nested := followUp.ToTelegramMessage(env.bot.ID).ReplyToMessage
+	if nested == nil || nested.MessageID != answer.ID || nested.ReplyToMessage != nil {
+		t.Errorf("Expected single level reply_to_message, got %+v", nested)
+	}
+
+	_, err = env.messageManager.SendContent(env.chat.ID, env.bot.ID, MessageContent{
+		Type:             models.MessageTypeText,
+		Text:             "Hello",
+		ReplyToMessageID: 123456789,
+	})
+	if !errors.Is(err, models.ErrReplyMessageNotFound) {
+		t.Errorf("Expected ErrReplyMessageNotFound, got %v", err)
+	}
+
+	message, err := env.messageManager.SendContent(env.chat.ID, env.bot.ID, MessageContent{
+		Type:                     models.MessageTypeText,
+		Text:                     "Hello",
+		ReplyToMessageID:         123456789,
+		AllowSendingWithoutReply: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected message to be sent without reply: %v", err)
+	}
+	if message.IsReply() {
+		t.Error("Expected message not to be a reply")
+	}
+}
+
+func TestMessageManager_WithoutChatManager(t *testing.T) {
+	env := newMessageTestEnv(t)
+
+	group, err := env.chatManager.CreateChat("group", "Group", "", "", []int64{env.bot.ID})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+
+	messageManager := NewMessageManager(env.messageRepo, env.chatManager.chatRepo, env.chatManager.userRepo, env.botManager, nil)
+
+	// A user joins the group without service messages and the bot can still delete its own message
+	if _, err := messageManager.SendMessage(group.ID, env.user.ID, "Hello", models.MessageTypeText, nil); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	if _, err := env.chatManager.GetMember(group.ID, env.user.ID); err != nil {
+		t.Errorf("Expected user to join the group: %v", err)
+	}
+
+	message, err := messageManager.SendMessage(group.ID, env.bot.ID, "Hi", models.MessageTypeText, nil)
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	if err := messageManager.DeleteBotMessage(env.bot.ID, group.ID, message.ID); err != nil {
+		t.Errorf("Failed to delete message: %v", err)
+	}
+}
+
+func TestMessageManager_SendChatAction(t *testing.T) {
+	env := newMessageTestEnv(t)
+
+	if err := env.messageManager.SendBotChatAction(env.chat.ID, env.bot.ID, "dancing"); !errors.Is(err, models.ErrWrongChatAction) {
+		t.Errorf("Expected ErrWrongChatAction, got %v", err)
+	}
+	if err := env.messageManager.SendBotChatAction(123456789, env.bot.ID, models.ChatActionTyping); !errors.Is(err, models.ErrChatNotFound) {
+		t.Errorf("Expected ErrChatNotFound, got %v", err)
+	}
+	if err := env.messageManager.SendChatAction(123456789, env.user.ID, models.ChatActionTyping); !errors.Is(err, models.ErrChatNotFound) {
+		t.Errorf("Expected ErrChatNotFound, got %v", err)
+	}
+
+	if err := env.messageManager.SendBotChatAction(env.chat.ID, env.bot.ID, models.ChatActionTyping); err != nil {
+		t.Fatalf("Failed to send chat action: %v", err)
+	}
+	if err := env.messageManager.SendChatAction(env.chat.ID, env.user.ID, models.ChatActionTyping); err != nil {
+		t.Fatalf("Failed to send chat action: %v", err)
+	}
+	if actions := env.messageManager.GetChatActions(env.chat.ID); len(actions) != 2 {
+		t.Fatalf("Expected actions of bot and user, got %+v", actions)
+	}
+
+	// The bot's next message ends its action
+	if _, err := env.messageManager.SendMessage(env.chat.ID, env.bot.ID, "Done", models.MessageTypeText, nil); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	actions := env.messageManager.GetChatActions(env.chat.ID)
+	if len(actions) != 1 || actions[0].UserID != env.user.ID {
+		t.Errorf("Expected only the user's action to remain, got %+v", actions)
+	}
+}
+
+func TestMessageManager_SendChatActionRequiresMembership(t *testing.T) {
+	env := newMessageTestEnv(t)
+
+	stranger, err := NewUserManager(env.chatManager.userRepo, nil).CreateUser("stranger", "Stranger", "", false)
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if err := env.messageManager.SendChatAction(env.chat.ID, stranger.ID, models.ChatActionTyping); !errors.Is(err, models.ErrUserNotParticipant) {
+		t.Errorf("Expected ErrUserNotParticipant for a stranger, got %v", err)
+	}
+
+	if err := env.chatManager.BlockBot(env.chat.ID, env.user.ID); err != nil {
+		t.Fatalf("Failed to block bot: %v", err)
+	}
+	if err := env.messageManager.SendBotChatAction(env.chat.ID, env.bot.ID, models.ChatActionTyping); !errors.Is(err, models.ErrBotBlocked) {
+		t.Errorf("Expected ErrBotBlocked, got %v", err)
+	}
+
+	group, err := env.chatManager.CreateChat("group", "Group", "", "", []int64{env.user.ID, env.bot.ID})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	if err := env.chatManager.RemoveMember(group.ID, env.bot.ID, env.user.ID); err != nil {
+		t.Fatalf("Failed to remove bot: %v", err)
+	}
+	if err := env.messageManager.SendBotChatAction(group.ID, env.bot.ID, models.ChatActionTyping); !errors.Is(err, models.ErrChatNotFound) {
+		t.Errorf("Expected ErrChatNotFound for a bot that left the group, got %v", err)
+	}
+
+	if actions := env.messageManager.GetChatActions(env.chat.ID); len(actions) != 0 {
+		t.Errorf("Expected no actions, got %+v", actions)
+	}
+}
+
+func TestMessageManager_SendContentChecksRestrictions(t *testing.T) {
+	env := newMessageTestEnv(t)
+	env.chatManager.SetBotManager(env.botManager)
+
+	owner := &models.User{ID: 1, Username: "owner", FirstName: "Owner"}
+	if err := env.messageManager.userRepo.Create(owner); err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+	group, err := env.chatManager.CreateChat("group", "Team", "", "", []int64{owner.ID, env.bot.ID, env.user.ID})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	if _, err := env.chatManager.PromoteMember(group.ID, env.bot.ID, models.ChatAdministratorRights{CanRestrictMembers: true}); err != nil {
+		t.Fatalf("Failed to promote bot: %v", err)
+	}
+
+	// A restricted member may send only the allowed message types
+	if err := env.chatManager.RestrictChatMember(env.bot.ID, group.ID, env.user.ID, models.ChatPermissions{CanSendPhotos: true}, nil); err != nil {
+		t.Fatalf("Failed to restrict user: %v", err)
+	}
+	if _, err := env.messageManager.SendMessage(group.ID, env.user.ID, "Hi", models.MessageTypeText, nil); !errors.Is(err, models.ErrNotEnoughRightsToSendText) {
+		t.Errorf("Expected ErrNotEnoughRightsToSendText, got %v", err)
+	}
+	if _, err := env.messageManager.SendContent(group.ID, env.user.ID, MessageContent{Type: models.MessageTypePhoto, Text: "Photo"}); err != nil {
+		t.Errorf("Expected photo to be allowed, got %v", err)
+	}
+
+	// The restriction no longer applies once it expires
+	record, _ := env.chatManager.GetMember(group.ID, env.user.ID)
+	past := time.Now().Add(-time.Second)
+	record.UntilDate = &past
+	if err := env.chatManager.chatRepo.SaveMember(record); err != nil {
+		t.Fatalf("Failed to save member: %v", err)
+	}
+	if _, err := env.messageManager.SendMessage(group.ID, env.user.ID, "Hi", models.MessageTypeText, nil); err != nil {
+		t.Errorf("Expected expired restriction to be ignored, got %v", err)
+	}
+
+	// Banned users can't write to the group and aren't added back automatically
+	if err := env.chatManager.BanChatMember(env.bot.ID, group.ID, env.user.ID, nil); err != nil {
+		t.Fatalf("Failed to ban user: %v", err)
+	}
+	if _, err := env.messageManager.SendMessage(group.ID, env.user.ID, "Hi", models.MessageTypeText, nil); !errors.Is(err, models.ErrUserKicked) {
+		t.Errorf("Expected ErrUserKicked, got %v", err)
+	}
+	if record, _ := env.chatManager.GetMember(group.ID, env.user.ID); record.Status != models.ChatMemberStatusKicked {
+		t.Errorf("Expected user to stay banned, got %q", record.Status)
+	}
+
+	// After the ban is lifted the user rejoins the group by writing to it
+	if err := env.chatManager.UnbanChatMember(env.bot.ID, group.ID, env.user.ID, true); err != nil {
+		t.Fatalf("Failed to unban user: %v", err)
+	}
+	if _, err := env.messageManager.SendMessage(group.ID, env.user.ID, "Back", models.MessageTypeText, nil); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	if record, _ := env.chatManager.GetMember(group.ID, env.user.ID); record.Status != models.ChatMemberStatusMember {
+		t.Errorf("Expected user to rejoin, got %q", record.Status)
+	}
+}
+
+// waitForChatMessages ждет, пока в чате появится count сообщений
+func waitForChatMessages(t *testing.T, env *messageTestEnv, count int) []models.Message {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		messages, err := env.messageRepo.GetByChatID(env.chat.ID, 100, 0)
+		if err != nil {
+			t.Fatalf("Failed to get messages: %v", err)
+		}
+		if len(messages) >= count || time.Now().After(deadline) {
+			return messages
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMessageManager_StubRuleReply(t *testing.T) {
+	env := newMessageTestEnv(t)
+
+	// Without rules the bot stays silent: the old canned /start reply is gone
+	if _, err := env.messageManager.SendMessage(env.chat.ID, env.user.ID, "/start", models.MessageTypeText, nil); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if messages := waitForChatMessages(t, env, 1); len(messages) != 1 {
+		t.Fatalf("Expected no reply without stub rules, got %d messages", len(messages))
+	}
+
+	_, err := env.botManager.SetStubRules(env.bot.ID, []models.StubRuleConfig{
+		{Command: "start", Text: "*Welcome*", ParseMode: "Markdown", ReplyMarkup: inlineKeyboard("Go", "go")},
+		{Pattern: "(?i)weather", Text: "Sunny", DelayMS: 20, Reply: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to set stub rules: %v", err)
+	}
+
+	if _, err := env.messageManager.SendMessage(env.chat.ID, env.user.ID, "/start", models.MessageTypeText, nil); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	messages := waitForChatMessages(t, env, 3)
+	if len(messages) != 3 {
+		t.Fatalf("Expected stub reply to /start, got %d messages", len(messages))
+	}
+
+	var welcome *models.Message
+	for i := range messages {
+		if messages[i].FromID == env.bot.ID {
+			welcome = &messages[i]
+		}
+	}
+	if welcome == nil {
+		t.Fatal("Expected a message from the bot")
+	}
+	// The reply goes through SendContent, so formatting and keyboard are applied
+	if welcome.Text != "Welcome" || len(welcome.GetEntities()) == 0 {
+		t.Errorf("Expected formatted reply, got %q with entities %+v", welcome.Text, welcome.GetEntities())
+	}
+	if welcome.GetReplyMarkup() == nil {
+		t.Error("Expected reply keyboard")
+	}
+
+	question, err := env.messageManager.SendMessage(env.chat.ID, env.user.ID, "What's the Weather?", models.MessageTypeText, nil)
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	messages = waitForChatMessages(t, env, 5)
+	if len(messages) != 5 {
+		t.Fatalf("Expected stub reply to the pattern, got %d messages", len(messages))
+	}
+	var sunny *models.Message
+	for i := range messages {
+		if messages[i].Text == "Sunny" {
+			sunny = &messages[i]
+		}
+	}
+	if sunny == nil || sunny.ReplyToMessageID == nil || *sunny.ReplyToMessageID != question.ID {
+		t.Errorf("Expected reply to the question, got %+v", sunny)
+	}
+
+	// Bot messages never trigger stub rules
+	if _, err := env.messageManager.SendMessage(env.chat.ID, env.bot.ID, "/start", models.MessageTypeText, nil); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if messages := waitForChatMessages(t, env, 6); len(messages) != 6 {
+		t.Errorf("Expected no stub reply to a bot message, got %d messages", len(messages))
+	}
+}
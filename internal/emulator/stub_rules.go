@@ -0,0 +1,134 @@
+package emulator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"telegram-emulator/internal/models"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// stubRulesFile представляет YAML файл с правилами ботов-заглушек: username бота -> правила
+type stubRulesFile struct {
+	Bots map[string][]models.StubRuleConfig `yaml:"bots"`
+}
+
+// AddStubRule добавляет боту правило-заглушку. Правила проверяются в порядке добавления
+func (m *BotManager) AddStubRule(botID int64, config models.StubRuleConfig) (*models.StubRule, error) {
+	if _, err := m.GetBot(botID); err != nil {
+		return nil, err
+	}
+
+	rule, err := models.NewStubRule(botID, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.botRepo.CreateStubRule(rule); err != nil {
+		m.logger.Error("Ошибка сохранения правила бота-заглушки", zap.Int64("bot_id", botID), zap.Error(err))
+		return nil, err
+	}
+
+	m.logger.Info("Добавлено правило бота-заглушки",
+		zap.Int64("bot_id", botID),
+		zap.Int64("rule_id", rule.ID),
+		zap.String("command", rule.Command),
+		zap.String("pattern", rule.Pattern))
+	return rule, nil
+}
+
+// SetStubRules заменяет все правила бота-заглушки. Пустой список отключает заглушку
+func (m *BotManager) SetStubRules(botID int64, configs []models.StubRuleConfig) ([]models.StubRule, error) {
+	if _, err := m.GetBot(botID); err != nil {
+		return nil, err
+	}
+
+	rules := make([]*models.StubRule, 0, len(configs))
+	for i, config := range configs {
+		rule, err := models.NewStubRule(botID, config)
+		if err != nil {
+			return nil, fmt.Errorf("правило %d: %w", i+1, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := m.botRepo.ReplaceStubRules(botID, rules); err != nil {
+		m.logger.Error("Ошибка сохранения правил бота-заглушки", zap.Int64("bot_id", botID), zap.Error(err))
+		return nil, err
+	}
+
+	m.logger.Info("Правила бота-заглушки заменены", zap.Int64("bot_id", botID), zap.Int("rules", len(rules)))
+	return m.botRepo.GetStubRules(botID)
+}
+
+// GetStubRules возвращает правила бота-заглушки
+func (m *BotManager) GetStubRules(botID int64) ([]models.StubRule, error) {
+	if _, err := m.GetBot(botID); err != nil {
+		return nil, err
+	}
+	return m.botRepo.GetStubRules(botID)
+}
+
+// DeleteStubRule удаляет правило бота-заглушки
+func (m *BotManager) DeleteStubRule(botID, ruleID int64) error {
+	if err := m.botRepo.DeleteStubRule(botID, ruleID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrStubRuleNotFound
+		}
+		return err
+	}
+
+	m.logger.Info("Удалено правило бота-заглушки", zap.Int64("bot_id", botID), zap.Int64("rule_id", ruleID))
+	return nil
+}
+
+// MatchStubRule возвращает первое правило бота-заглушки, срабатывающее на сообщение, или nil
+func (m *BotManager) MatchStubRule(bot *models.Bot, message *models.Message) (*models.StubRule, error) {
+	rules, err := m.botRepo.GetStubRules(bot.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rules {
+		if rules[i].Matches(message, bot.Username) {
+			return &rules[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// LoadStubRules загружает правила ботов-заглушек из YAML файла.
+// Правила каждого бота из файла заменяют сохраненные, боты без правил в файле не меняются
+func (m *BotManager) LoadStubRules(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения файла правил: %w", err)
+	}
+
+	var file stubRulesFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("ошибка разбора файла правил: %w", err)
+	}
+
+	for username, configs := range file.Bots {
+		bot, err := m.botRepo.GetByUsername(username)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				m.logger.Warn("Бот из файла правил не найден", zap.String("username", username))
+				continue
+			}
+			return err
+		}
+
+		if _, err := m.SetStubRules(bot.ID, configs); err != nil {
+			return fmt.Errorf("бот %s: %w", username, err)
+		}
+	}
+
+	m.logger.Info("Правила ботов-заглушек загружены", zap.String("path", path), zap.Int("bots", len(file.Bots)))
+	return nil
+}
@@ -17,10 +17,17 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 	}
 
 	// Auto migrate the schema
-	err = db.AutoMigrate(&models.Bot{}, &models.User{}, &models.Chat{}, &models.Message{})
+	err = db.AutoMigrate(&models.Bot{}, &models.User{}, &models.Chat{}, &models.Message{}, &models.ChatMember{}, &models.UpdateRecord{}, &models.UpdateSequence{}, &models.File{}, &models.BotCommandSet{}, &models.StubRule{})
 	if err != nil {
 		t.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	// База в памяти живет в рамках одного соединения, поэтому ограничиваем пул
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to get database handle: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
 	return db
 }
@@ -0,0 +1,168 @@
+package emulator
+
+import (
+	"sync"
+	"time"
+
+	"telegram-emulator/internal/models"
+	"telegram-emulator/internal/repository"
+)
+
+// maxQueuedUpdates ограничивает размер очереди обновлений одного бота
+const maxQueuedUpdates = 1000
+
+// updateQueue представляет потокобезопасную очередь обновлений одного бота.
+// Сами обновления и счетчик update_id хранятся в базе данных и переживают перезапуск эмулятора.
+type updateQueue struct {
+	mu     sync.Mutex
+	botID  int64
+	repo   *repository.UpdateRepository
+	signal chan struct{} // Закрывается при появлении новых обновлений
+	poll   chan struct{} // Закрывается, когда активный getUpdates вытесняется новым запросом
+}
+
+// newUpdateQueue создает очередь обновлений бота поверх репозитория
+func newUpdateQueue(botID int64, repo *repository.UpdateRepository) *updateQueue {
+	return &updateQueue{
+		botID:  botID,
+		repo:   repo,
+		signal: make(chan struct{}),
+	}
+}
+
+// push присваивает обновлению update_id (не меньше minID), сохраняет его в очереди и будит ожидающих получателей
+func (q *updateQueue) push(update *models.Update, minID int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	update.Timestamp = time.Now()
+	if err := q.repo.Append(q.botID, update, minID); err != nil {
+		return err
+	}
+
+	// Ограничиваем размер очереди, отбрасывая самые старые обновления
+	if err := q.repo.KeepLast(q.botID, maxQueuedUpdates); err != nil {
+		return err
+	}
+
+	close(q.signal)
+	q.signal = make(chan struct{})
+	return nil
+}
+
+// get возвращает обновления с update_id >= offset (не больше limit) и канал,
+// который будет закрыт при следующем добавлении обновления
+func (q *updateQueue) get(offset int64, limit int) ([]models.Update, <-chan struct{}, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	updates, err := q.repo.GetFrom(q.botID, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return updates, q.signal, nil
+}
+
+// confirm удаляет из очереди подтвержденные обновления с update_id < offset
+func (q *updateQueue) confirm(offset int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.repo.DeleteBefore(q.botID, offset)
+}
+
+// remove удаляет из очереди одно обновление
+func (q *updateQueue) remove(updateID int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.repo.Delete(q.botID, updateID)
+}
+
+// keepLast оставляет в очереди только последние count обновлений и возвращает
+// update_id первого оставшегося обновления (или следующий update_id, если очередь пуста)
+func (q *updateQueue) keepLast(count int) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.repo.KeepLast(q.botID, count); err != nil {
+		return 0, err
+	}
+
+	first, err := q.repo.GetFrom(q.botID, 0, 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(first) == 0 {
+		return q.repo.GetNextUpdateID(q.botID)
+	}
+	return first[0].UpdateID, nil
+}
+
+// clear удаляет все обновления из очереди, сохраняя счетчик update_id
+func (q *updateQueue) clear() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.repo.DeleteByBotID(q.botID)
+}
+
+// size возвращает количество обновлений в очереди
+func (q *updateQueue) size() (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	count, err := q.repo.Count(q.botID)
+	return int(count), err
+}
+
+// beginPoll регистрирует новый запрос getUpdates, вытесняя активный.
+// Возвращает канал, который будет закрыт при вытеснении, и функцию завершения запроса
+func (q *updateQueue) beginPoll() (<-chan struct{}, func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.poll != nil {
+		close(q.poll)
+	}
+
+	poll := make(chan struct{})
+	q.poll = poll
+
+	return poll, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+
+		if q.poll == poll {
+			q.poll = nil
+		}
+	}
+}
+
+// updateQueues представляет потокобезопасный реестр очередей обновлений по ботам
+type updateQueues struct {
+	mu     sync.Mutex
+	repo   *repository.UpdateRepository
+	queues map[int64]*updateQueue
+}
+
+// newUpdateQueues создает пустой реестр очередей
+func newUpdateQueues(repo *repository.UpdateRepository) *updateQueues {
+	return &updateQueues{
+		repo:   repo,
+		queues: make(map[int64]*updateQueue),
+	}
+}
+
+// get возвращает очередь бота, создавая её при первом обращении
+func (r *updateQueues) get(botID int64) *updateQueue {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	queue, exists := r.queues[botID]
+	if !exists {
+		queue = newUpdateQueue(botID, r.repo)
+		r.queues[botID] = queue
+	}
+	return queue
+}
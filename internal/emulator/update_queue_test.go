@@ -0,0 +1,189 @@
+package emulator
+
+import (
+	"sync"
+	"testing"
+
+	"telegram-emulator/internal/models"
+	"telegram-emulator/internal/repository"
+)
+
+func TestUpdateQueue_PushAssignsSequentialIDs(t *testing.T) {
+	queue := newUpdateQueue(1, repository.NewUpdateRepository(SetupTestDB(t)))
+
+	for i := 0; i < 3; i++ {
+		if err := queue.push(&models.Update{}, 0); err != nil {
+			t.Fatalf("Failed to push update: %v", err)
+		}
+	}
+
+	updates, _, err := queue.get(0, 10)
+	if err != nil {
+		t.Fatalf("Failed to get updates: %v", err)
+	}
+	if len(updates) != 3 {
+		t.Fatalf("Expected 3 updates, got %d", len(updates))
+	}
+
+	for i, update := range updates {
+		if update.UpdateID != int64(i+1) {
+			t.Errorf("Expected update_id %d, got %d", i+1, update.UpdateID)
+		}
+	}
+}
+
+func TestUpdateQueue_PushSignalsWaiters(t *testing.T) {
+	queue := newUpdateQueue(1, repository.NewUpdateRepository(SetupTestDB(t)))
+
+	_, signal, err := queue.get(0, 10)
+	if err != nil {
+		t.Fatalf("Failed to get updates: %v", err)
+	}
+	select {
+	case <-signal:
+		t.Fatal("Expected signal to stay open before push")
+	default:
+	}
+
+	if err := queue.push(&models.Update{}, 0); err != nil {
+		t.Fatalf("Failed to push update: %v", err)
+	}
+
+	select {
+	case <-signal:
+	default:
+		t.Fatal("Expected signal to be closed after push")
+	}
+}
+
+func TestUpdateQueue_TrimsToMaxSize(t *testing.T) {
+	queue := newUpdateQueue(1, repository.NewUpdateRepository(SetupTestDB(t)))
+
+	for i := 0; i < maxQueuedUpdates+10; i++ {
+		if err := queue.push(&models.Update{}, 0); err != nil {
+			t.Fatalf("Failed to push update: %v", err)
+		}
+	}
+
+	size, err := queue.size()
+	if err != nil {
+		t.Fatalf("Failed to get queue size: %v", err)
+	}
+	if size != maxQueuedUpdates {
+		t.Errorf("Expected queue size %d, got %d", maxQueuedUpdates, size)
+	}
+
+	updates, _, err := queue.get(0, 1)
+	if err != nil {
+		t.Fatalf("Failed to get updates: %v", err)
+	}
+	if len(updates) != 1 || updates[0].UpdateID != 11 {
+		t.Errorf("Expected oldest update_id 11 after trimming, got %+v", updates)
+	}
+}
+
+func TestUpdateQueue_ClearKeepsCounter(t *testing.T) {
+	queue := newUpdateQueue(1, repository.NewUpdateRepository(SetupTestDB(t)))
+	if err := queue.push(&models.Update{}, 0); err != nil {
+		t.Fatalf("Failed to push update: %v", err)
+	}
+	if err := queue.push(&models.Update{}, 0); err != nil {
+		t.Fatalf("Failed to push update: %v", err)
+	}
+	if err := queue.clear(); err != nil {
+		t.Fatalf("Failed to clear queue: %v", err)
+	}
+
+	size, err := queue.size()
+	if err != nil {
+		t.Fatalf("Failed to get queue size: %v", err)
+	}
+	if size != 0 {
+		t.Errorf("Expected empty queue after clear, got %d", size)
+	}
+
+	update := &models.Update{}
+	if err := queue.push(update, 0); err != nil {
+		t.Fatalf("Failed to push update: %v", err)
+	}
+	if update.UpdateID != 3 {
+		t.Errorf("Expected update_id 3 after clear, got %d", update.UpdateID)
+	}
+}
+
+func TestBotManager_ConcurrentQueueAccess(t *testing.T) {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+
+	bot1, err := botManager.CreateBot("Bot1", "bot1", "token1", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot1: %v", err)
+	}
+	bot2, err := botManager.CreateBot("Bot2", "bot2", "token2", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot2: %v", err)
+	}
+
+	const writers = 8
+	const perWriter = 24
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(3)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perWriter; j++ {
+				botID := bot1.ID
+				if j%2 == 0 {
+					botID = bot2.ID
+				}
+				update := &models.Update{
+					Message: &models.Message{ID: int64(i*perWriter + j), ChatID: 1, FromID: 1, Text: "hello", Type: "text"},
+				}
+				if err := botManager.AddUpdate(botID, update); err != nil {
+					t.Errorf("Failed to add update: %v", err)
+				}
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWriter; j++ {
+				if _, err := botManager.GetBotUpdates(bot1.ID, 0, 100); err != nil {
+					t.Errorf("Failed to get updates: %v", err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWriter/5; j++ {
+				if err := botManager.ClearUpdates(bot2.ID); err != nil {
+					t.Errorf("Failed to clear updates: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	updates, err := botManager.GetBotUpdates(bot1.ID, 0, 1000)
+	if err != nil {
+		t.Fatalf("Failed to get updates: %v", err)
+	}
+
+	expected := writers * perWriter / 2
+	if len(updates) != expected {
+		t.Fatalf("Expected %d updates for bot1, got %d", expected, len(updates))
+	}
+
+	seen := make(map[int64]bool)
+	for _, update := range updates {
+		if seen[update.UpdateID] {
+			t.Errorf("Duplicate update_id %d", update.UpdateID)
+		}
+		seen[update.UpdateID] = true
+	}
+}
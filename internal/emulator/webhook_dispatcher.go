@@ -0,0 +1,316 @@
+package emulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"telegram-emulator/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// Параметры доставки обновлений через webhook по умолчанию
+const (
+	defaultWebhookTimeout    = 30 * time.Second
+	defaultWebhookRetryDelay = time.Second
+	maxWebhookRetryDelay     = time.Minute
+
+	// webhookScanLimit ограничивает количество обновлений, просматриваемых за одну итерацию
+	webhookScanLimit = 100
+
+	// webhookSecretTokenHeader содержит secret_token, указанный в setWebhook
+	webhookSecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+)
+
+// webhookStats хранит статистику ошибок доставки webhook бота
+type webhookStats struct {
+	lastErrorDate    int64
+	lastErrorMessage string
+}
+
+// webhookWorker представляет обработчик доставки обновлений одного бота
+type webhookWorker struct {
+	wake chan struct{} // Сигнал о появлении новых обновлений
+}
+
+// webhookDelivery представляет результат доставки одного обновления
+type webhookDelivery struct {
+	update    models.Update
+	delivered bool
+}
+
+// webhookDispatcher доставляет обновления ботам через webhook.
+// Для каждого бота работает один обработчик, который распределяет обновления по доставкам.
+// Доставленные обновления удаляются из очереди бота, недоставленные повторяются с нарастающей задержкой.
+// Порядок update_id соблюдается внутри чата, а строгий порядок для всего бота - при max_connections = 1 (см. run)
+type webhookDispatcher struct {
+	manager *BotManager
+	logger  *zap.Logger
+
+	mu            sync.Mutex
+	client        *http.Client
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+	workers       map[int64]*webhookWorker
+	stats         map[int64]webhookStats
+}
+
+// newWebhookDispatcher создает диспетчер webhook для менеджера ботов
+func newWebhookDispatcher(manager *BotManager) *webhookDispatcher {
+	return &webhookDispatcher{
+		manager:       manager,
+		logger:        manager.logger,
+		client:        &http.Client{Timeout: defaultWebhookTimeout},
+		retryDelay:    defaultWebhookRetryDelay,
+		maxRetryDelay: maxWebhookRetryDelay,
+		workers:       make(map[int64]*webhookWorker),
+		stats:         make(map[int64]webhookStats),
+	}
+}
+
+// setTimeout устанавливает таймаут одного запроса к webhook
+func (d *webhookDispatcher) setTimeout(timeout time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.client = &http.Client{Timeout: timeout}
+}
+
+// notify будит обработчик бота, запуская его при необходимости
+func (d *webhookDispatcher) notify(botID int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	worker, exists := d.workers[botID]
+	if !exists {
+		worker = &webhookWorker{wake: make(chan struct{}, 1)}
+		d.workers[botID] = worker
+		go d.run(botID, worker)
+	}
+
+	select {
+	case worker.wake <- struct{}{}:
+	default:
+	}
+}
+
+// retire останавливает обработчик бота, если за время последней итерации его не будили
+func (d *webhookDispatcher) retire(botID int64, worker *webhookWorker) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	select {
+	case <-worker.wake:
+		return false
+	default:
+	}
+
+	delete(d.workers, botID)
+	return true
+}
+
+// run распределяет обновления бота по доставкам, пока у бота установлен webhook.
+// Одновременно выполняется не больше max_connections доставок, а обновления одного чата
+// доставляются строго по порядку update_id. Обновления разных чатов доставляются
+// параллельно и могут прийти не по порядку update_id, а обновление, доставка которого не удается,
+// задерживает только свой чат. Для строгого порядка всех обновлений бота нужен max_connections = 1
+func (d *webhookDispatcher) run(botID int64, worker *webhookWorker) {
+	inFlight := make(map[int64]int64) // update_id -> ID чата доставляемого обновления
+	results := make(chan webhookDelivery)
+
+	for {
+		bot, err := d.manager.GetBot(botID)
+		active := err == nil && bot.IsActive && bot.WebhookURL != ""
+
+		if !active && len(inFlight) == 0 {
+			if d.retire(botID, worker) {
+				return
+			}
+			continue
+		}
+
+		if active {
+			d.startDeliveries(bot, inFlight, results)
+		}
+
+		select {
+		case <-worker.wake:
+		case result := <-results:
+			delete(inFlight, result.update.UpdateID)
+			if !result.delivered {
+				continue
+			}
+
+			// Доставленное обновление удаляется из очереди
+			if err := d.manager.updates.get(botID).remove(result.update.UpdateID); err != nil {
+				d.logger.Error("Ошибка удаления доставленного обновления",
+					zap.Int64("bot_id", botID),
+					zap.Int64("update_id", result.update.UpdateID),
+					zap.Error(err))
+			}
+
+			d.logger.Info("Обновление успешно отправлено через webhook",
+				zap.Int64("bot_id", botID),
+				zap.Int64("update_id", result.update.UpdateID))
+		}
+	}
+}
+
+// startDeliveries запускает доставку ожидающих обновлений в пределах max_connections
+func (d *webhookDispatcher) startDeliveries(bot *models.Bot, inFlight map[int64]int64, results chan<- webhookDelivery) {
+	maxConnections := bot.GetWebhookMaxConnections()
+	if len(inFlight) >= maxConnections {
+		return
+	}
+
+	pending, _, err := d.manager.updates.get(bot.ID).get(0, webhookScanLimit)
+	if err != nil {
+		d.logger.Error("Ошибка получения обновлений для webhook",
+			zap.Int64("bot_id", bot.ID),
+			zap.Error(err))
+		return
+	}
+
+	// Чаты, обновления которых уже доставляются или ждут своей очереди
+	busy := make(map[int64]bool)
+	for _, chatID := range inFlight {
+		busy[chatID] = true
+	}
+
+	for i := range pending {
+		if len(inFlight) >= maxConnections {
+			break
+		}
+
+		update := pending[i]
+		if _, exists := inFlight[update.UpdateID]; exists {
+			continue
+		}
+
+		chatID := update.ChatID()
+		if busy[chatID] {
+			continue
+		}
+		busy[chatID] = true
+		inFlight[update.UpdateID] = chatID
+
+		go func() {
+			results <- d.deliverWithRetry(bot.ID, update)
+		}()
+	}
+}
+
+// deliverWithRetry доставляет обновление, повторяя попытки с нарастающей задержкой,
+// пока доставка не удастся или webhook не будет удален
+func (d *webhookDispatcher) deliverWithRetry(botID int64, update models.Update) webhookDelivery {
+	for attempt := 0; ; attempt++ {
+		bot, err := d.manager.GetBot(botID)
+		if err != nil || !bot.IsActive || bot.WebhookURL == "" {
+			return webhookDelivery{update: update}
+		}
+
+		err = d.deliver(bot, &update)
+		if err == nil {
+			return webhookDelivery{update: update, delivered: true}
+		}
+
+		d.recordError(botID, err)
+
+		delay := d.backoff(attempt)
+		d.logger.Warn("Ошибка доставки обновления через webhook, повтор позже",
+			zap.Int64("bot_id", botID),
+			zap.Int64("update_id", update.UpdateID),
+			zap.String("webhook_url", bot.WebhookURL),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("retry_in", delay),
+			zap.Error(err))
+		time.Sleep(delay)
+	}
+}
+
+// deliver отправляет одно обновление в webhook бота
+func (d *webhookDispatcher) deliver(bot *models.Bot, update *models.Update) error {
+	jsonData, err := json.Marshal(update.ToTelegramUpdate(bot.ID))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, bot.WebhookURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if bot.WebhookSecretToken != "" {
+		req.Header.Set(webhookSecretTokenHeader, bot.WebhookSecretToken)
+	}
+
+	d.mu.Lock()
+	client := d.client
+	d.mu.Unlock()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return errors.New("Connection timed out")
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Wrong response from the webhook: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// backoff возвращает задержку перед очередной попыткой доставки
+func (d *webhookDispatcher) backoff(attempt int) time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delay := d.retryDelay
+	for i := 0; i < attempt && delay < d.maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.maxRetryDelay {
+		delay = d.maxRetryDelay
+	}
+	return delay
+}
+
+// recordError запоминает последнюю ошибку доставки для getWebhookInfo
+func (d *webhookDispatcher) recordError(botID int64, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.stats[botID] = webhookStats{
+		lastErrorDate:    time.Now().Unix(),
+		lastErrorMessage: err.Error(),
+	}
+}
+
+// resetStats забывает последнюю ошибку доставки после смены или удаления webhook
+func (d *webhookDispatcher) resetStats(botID int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.stats, botID)
+}
+
+// getStats возвращает статистику ошибок доставки webhook бота
+func (d *webhookDispatcher) getStats(botID int64) webhookStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.stats[botID]
+}
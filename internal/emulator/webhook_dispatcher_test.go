@@ -0,0 +1,376 @@
+package emulator
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"telegram-emulator/internal/models"
+	"telegram-emulator/internal/repository"
+)
+
+func newWebhookTestManager(t *testing.T) *BotManager {
+	db := SetupTestDB(t)
+	botRepo := repository.NewBotRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	updateRepo := repository.NewUpdateRepository(db)
+	botManager := NewBotManager(botRepo, userRepo, messageRepo, chatRepo, updateRepo)
+	botManager.webhooks.retryDelay = 10 * time.Millisecond
+	botManager.webhooks.maxRetryDelay = 20 * time.Millisecond
+	return botManager
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Condition was not met in time")
+}
+
+func TestWebhookDispatcher_DeliversInOrder(t *testing.T) {
+	var mu sync.Mutex
+	var received []int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var update map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&update)
+		mu.Lock()
+		received = append(received, int64(update["update_id"].(float64)))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	botManager := newWebhookTestManager(t)
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		update := &models.Update{
+			Message: &models.Message{ID: int64(i + 1), ChatID: 1, FromID: 1, Text: "Test message", Type: "text"},
+		}
+		if err := botManager.AddUpdate(bot.ID, update); err != nil {
+			t.Fatalf("Failed to add update: %v", err)
+		}
+	}
+
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 5
+	})
+
+	for i, updateID := range received {
+		if updateID != int64(i+1) {
+			t.Errorf("Expected update_id %d at position %d, got %d", i+1, i, updateID)
+		}
+	}
+
+	waitFor(t, func() bool {
+		info, err := botManager.GetWebhookInfo(bot.ID)
+		return err == nil && info.PendingUpdateCount == 0
+	})
+}
+
+func TestWebhookDispatcher_RetriesFailedDelivery(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts <= 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	botManager := newWebhookTestManager(t)
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	update := &models.Update{
+		Message: &models.Message{ID: 1, ChatID: 1, FromID: 1, Text: "Test message", Type: "text"},
+	}
+	if err := botManager.AddUpdate(bot.ID, update); err != nil {
+		t.Fatalf("Failed to add update: %v", err)
+	}
+
+	waitFor(t, func() bool {
+		info, err := botManager.GetWebhookInfo(bot.ID)
+		return err == nil && info.PendingUpdateCount == 0
+	})
+
+	mu.Lock()
+	if attempts != 3 {
+		t.Errorf("Expected 3 delivery attempts, got %d", attempts)
+	}
+	mu.Unlock()
+
+	info, err := botManager.GetWebhookInfo(bot.ID)
+	if err != nil {
+		t.Fatalf("Failed to get webhook info: %v", err)
+	}
+	if info.LastErrorDate == 0 {
+		t.Error("Expected last_error_date to be set")
+	}
+	if !strings.Contains(info.LastErrorMessage, "500") {
+		t.Errorf("Expected last_error_message to mention status 500, got %q", info.LastErrorMessage)
+	}
+}
+
+func TestWebhookDispatcher_HonoursTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	botManager := newWebhookTestManager(t)
+	botManager.SetWebhookTimeout(50 * time.Millisecond)
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	update := &models.Update{
+		Message: &models.Message{ID: 1, ChatID: 1, FromID: 1, Text: "Test message", Type: "text"},
+	}
+	if err := botManager.AddUpdate(bot.ID, update); err != nil {
+		t.Fatalf("Failed to add update: %v", err)
+	}
+
+	waitFor(t, func() bool {
+		info, err := botManager.GetWebhookInfo(bot.ID)
+		return err == nil && info.LastErrorMessage == "Connection timed out"
+	})
+
+	info, err := botManager.GetWebhookInfo(bot.ID)
+	if err != nil {
+		t.Fatalf("Failed to get webhook info: %v", err)
+	}
+	if info.PendingUpdateCount != 1 {
+		t.Errorf("Expected 1 pending update, got %d", info.PendingUpdateCount)
+	}
+}
+
+func TestWebhookDispatcher_SendsSecretToken(t *testing.T) {
+	headers := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+	}))
+	defer server.Close()
+
+	botManager := newWebhookTestManager(t)
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	bot.WebhookURL = server.URL
+	bot.WebhookSecretToken = "my_secret-token"
+	if err := botManager.UpdateBot(bot); err != nil {
+		t.Fatalf("Failed to update bot: %v", err)
+	}
+
+	update := &models.Update{
+		Message: &models.Message{ID: 1, ChatID: 1, FromID: 1, Text: "Test message", Type: "text"},
+	}
+	if err := botManager.AddUpdate(bot.ID, update); err != nil {
+		t.Fatalf("Failed to add update: %v", err)
+	}
+
+	select {
+	case header := <-headers:
+		if header != "my_secret-token" {
+			t.Errorf("Expected secret token header 'my_secret-token', got %q", header)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Webhook was not called")
+	}
+}
+
+func TestWebhookDispatcher_MaxConnectionsKeepsChatOrder(t *testing.T) {
+	var mu sync.Mutex
+	active := 0
+	maxActive := 0
+	activeChats := make(map[int64]bool)
+	chatOverlap := false
+	received := make(map[int64][]int64)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var update struct {
+			UpdateID int64 `json:"update_id"`
+			Message  struct {
+				Chat struct {
+					ID int64 `json:"id"`
+				} `json:"chat"`
+			} `json:"message"`
+		}
+		json.NewDecoder(r.Body).Decode(&update)
+		chatID := update.Message.Chat.ID
+
+		mu.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		if activeChats[chatID] {
+			chatOverlap = true
+		}
+		activeChats[chatID] = true
+		received[chatID] = append(received[chatID], update.UpdateID)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		activeChats[chatID] = false
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	botManager := newWebhookTestManager(t)
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	// Queue updates before the webhook is set so that delivery starts for all of them at once
+	for i := 0; i < 12; i++ {
+		update := &models.Update{
+			Message: &models.Message{ID: int64(i + 1), ChatID: int64(i%4 + 1), FromID: 1, Text: "Test message", Type: "text"},
+		}
+		if err := botManager.AddUpdate(bot.ID, update); err != nil {
+			t.Fatalf("Failed to add update: %v", err)
+		}
+	}
+
+	bot.WebhookURL = server.URL
+	bot.WebhookMaxConnections = 2
+	if err := botManager.UpdateBot(bot); err != nil {
+		t.Fatalf("Failed to update bot: %v", err)
+	}
+
+	waitFor(t, func() bool {
+		info, err := botManager.GetWebhookInfo(bot.ID)
+		return err == nil && info.PendingUpdateCount == 0
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if maxActive > 2 {
+		t.Errorf("Expected at most 2 concurrent deliveries, got %d", maxActive)
+	}
+	if maxActive < 2 {
+		t.Errorf("Expected deliveries to run concurrently, got %d", maxActive)
+	}
+	if chatOverlap {
+		t.Error("Expected updates of one chat to be delivered sequentially")
+	}
+	for chatID, updateIDs := range received {
+		for i := 1; i < len(updateIDs); i++ {
+			if updateIDs[i] <= updateIDs[i-1] {
+				t.Errorf("Expected ordered delivery for chat %d, got %v", chatID, updateIDs)
+			}
+		}
+	}
+}
+
+func TestWebhookDispatcher_SingleConnectionKeepsBotOrder(t *testing.T) {
+	var mu sync.Mutex
+	var received []int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var update map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&update)
+		mu.Lock()
+		received = append(received, int64(update["update_id"].(float64)))
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	botManager := newWebhookTestManager(t)
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	// Updates of different chats are queued up front so the dispatcher could reorder them
+	for i := 0; i < 8; i++ {
+		update := &models.Update{
+			Message: &models.Message{ID: int64(i + 1), ChatID: int64(i%4 + 1), FromID: 1, Text: "Test message", Type: "text"},
+		}
+		if err := botManager.AddUpdate(bot.ID, update); err != nil {
+			t.Fatalf("Failed to add update: %v", err)
+		}
+	}
+
+	bot.WebhookURL = server.URL
+	bot.WebhookMaxConnections = 1
+	if err := botManager.UpdateBot(bot); err != nil {
+		t.Fatalf("Failed to update bot: %v", err)
+	}
+
+	waitFor(t, func() bool {
+		info, err := botManager.GetWebhookInfo(bot.ID)
+		return err == nil && info.PendingUpdateCount == 0
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(received) != 8 {
+		t.Fatalf("Expected 8 delivered updates, got %d", len(received))
+	}
+	for i := 1; i < len(received); i++ {
+		if received[i] <= received[i-1] {
+			t.Errorf("Expected ordered delivery across chats, got %v", received)
+			break
+		}
+	}
+}
+
+func TestBotManager_ResetWebhookStats(t *testing.T) {
+	botManager := newWebhookTestManager(t)
+	bot, err := botManager.CreateBot("TestBot", "testbot", "token", "")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	botManager.webhooks.recordError(bot.ID, errors.New("webhook responded with status 500"))
+
+	bot.WebhookURL = "https://example.com/webhook"
+	botManager.ResetWebhookStats(bot.ID)
+	if err := botManager.botRepo.Update(bot); err != nil {
+		t.Fatalf("Failed to update bot: %v", err)
+	}
+
+	info, err := botManager.GetWebhookInfo(bot.ID)
+	if err != nil {
+		t.Fatalf("Failed to get webhook info: %v", err)
+	}
+	if info.LastErrorDate != 0 || info.LastErrorMessage != "" {
+		t.Errorf("Expected last error to be reset, got %d %q", info.LastErrorDate, info.LastErrorMessage)
+	}
+}
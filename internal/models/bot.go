@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -12,11 +13,23 @@ type Bot struct {
 	Token            string    `json:"token"`
 	WebhookURL       string    `json:"webhook_url"`
 	IsActive         bool      `json:"is_active"`
-	LastUpdateOffset int64     `json:"last_update_offset" gorm:"default:0"`
+	LastUpdateOffset int64     `json:"last_update_offset" gorm:"default:0"` // Последний подтвержденный offset getUpdates
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at"`
+
+	// Параметры setWebhook
+	WebhookSecretToken    string `json:"webhook_secret_token,omitempty"`                          // Значение заголовка X-Telegram-Bot-Api-Secret-Token
+	WebhookIPAddress      string `json:"webhook_ip_address,omitempty"`                            // IP адрес, указанный при установке webhook
+	WebhookMaxConnections int    `json:"webhook_max_connections" gorm:"default:40"`               // Максимум одновременных доставок
+	AllowedUpdatesJSON    string `json:"allowed_updates,omitempty" gorm:"column:allowed_updates"` // Типы получаемых обновлений в JSON формате
 }
 
+// Значения max_connections для webhook
+const (
+	DefaultWebhookMaxConnections = 40
+	MaxWebhookMaxConnections     = 100
+)
+
 // TableName возвращает имя таблицы для модели Bot
 func (Bot) TableName() string {
 	return "bots"
@@ -37,11 +50,88 @@ func (b *Bot) SetWebhook(url string) {
 	b.WebhookURL = url
 }
 
+// GetWebhookMaxConnections возвращает максимальное количество одновременных доставок webhook
+func (b *Bot) GetWebhookMaxConnections() int {
+	if b.WebhookMaxConnections <= 0 {
+		return DefaultWebhookMaxConnections
+	}
+	if b.WebhookMaxConnections > MaxWebhookMaxConnections {
+		return MaxWebhookMaxConnections
+	}
+	return b.WebhookMaxConnections
+}
+
+// SetAllowedUpdates устанавливает типы получаемых обновлений и сериализует их в JSON.
+// Пустой список означает набор обновлений по умолчанию
+func (b *Bot) SetAllowedUpdates(allowedUpdates []string) error {
+	if len(allowedUpdates) == 0 {
+		b.AllowedUpdatesJSON = ""
+		return nil
+	}
+
+	jsonData, err := json.Marshal(allowedUpdates)
+	if err != nil {
+		return err
+	}
+
+	b.AllowedUpdatesJSON = string(jsonData)
+	return nil
+}
+
+// GetAllowedUpdates десериализует типы получаемых обновлений из JSON
+func (b *Bot) GetAllowedUpdates() []string {
+	if b.AllowedUpdatesJSON == "" {
+		return nil
+	}
+
+	var allowedUpdates []string
+	if err := json.Unmarshal([]byte(b.AllowedUpdatesJSON), &allowedUpdates); err != nil {
+		return nil
+	}
+
+	return allowedUpdates
+}
+
+// AcceptsUpdate проверяет, подписан ли бот на обновления данного типа
+func (b *Bot) AcceptsUpdate(update *Update) bool {
+	updateType := update.Type()
+
+	allowedUpdates := b.GetAllowedUpdates()
+	if len(allowedUpdates) == 0 {
+		// По умолчанию Telegram не присылает chat_member и реакции на сообщения
+		switch updateType {
+		case UpdateTypeChatMember, UpdateTypeMessageReaction, UpdateTypeMessageReactionCount:
+			return false
+		}
+		return true
+	}
+
+	for _, allowed := range allowedUpdates {
+		if allowed == updateType {
+			return true
+		}
+	}
+	return false
+}
+
 // UpdateToken обновляет токен бота
 func (b *Bot) UpdateToken(token string) {
 	b.Token = token
 }
 
+// WebhookInfo представляет состояние webhook бота в формате Telegram Bot API
+type WebhookInfo struct {
+	URL                          string   `json:"url"`
+	HasCustomCertificate         bool     `json:"has_custom_certificate"`
+	PendingUpdateCount           int      `json:"pending_update_count"`
+	IPAddress                    string   `json:"ip_address,omitempty"`
+	LastErrorDate                int64    `json:"last_error_date,omitempty"`
+	LastErrorMessage             string   `json:"last_error_message,omitempty"`
+	LastSynchronizationErrorDate int64    `json:"last_synchronization_error_date,omitempty"`
+	MaxConnections               int      `json:"max_connections,omitempty"`
+	AllowedUpdates               []string `json:"allowed_updates,omitempty"`
+}
+
 // BotNotFoundError представляет ошибку "бот не найден"
 type BotNotFoundError struct{}
 
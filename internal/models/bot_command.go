@@ -0,0 +1,233 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Области видимости команд бота (BotCommandScope)
+const (
+	BotCommandScopeDefault         = "default"
+	BotCommandScopeAllPrivateChats = "all_private_chats"
+	BotCommandScopeAllGroupChats   = "all_group_chats"
+	BotCommandScopeChat            = "chat"
+	BotCommandScopeChatMember      = "chat_member"
+)
+
+// Ограничения списка команд бота
+const (
+	MaxBotCommands                 = 100
+	MaxBotCommandLength            = 32
+	MaxBotCommandDescriptionLength = 256
+)
+
+// BotCommand представляет команду бота в формате Telegram Bot API
+type BotCommand struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+}
+
+// BotCommandScope описывает, для каких чатов и пользователей действует список команд
+type BotCommandScope struct {
+	Type   string `json:"type"`
+	ChatID int64  `json:"chat_id,omitempty"`
+	UserID int64  `json:"user_id,omitempty"`
+}
+
+// Validate проверяет тип области и наличие обязательных для него полей
+func (s BotCommandScope) Validate() error {
+	switch s.Type {
+	case BotCommandScopeDefault, BotCommandScopeAllPrivateChats, BotCommandScopeAllGroupChats:
+		return nil
+	case BotCommandScopeChat:
+		if s.ChatID == 0 {
+			return ErrChatNotFound
+		}
+		return nil
+	case BotCommandScopeChatMember:
+		if s.ChatID == 0 {
+			return ErrChatNotFound
+		}
+		if s.UserID == 0 {
+			return ErrUserNotFound
+		}
+		return nil
+	default:
+		return ErrWrongBotCommandScope
+	}
+}
+
+// Normalize возвращает область без полей, не относящихся к ее типу.
+// Пустой тип означает область default
+func (s BotCommandScope) Normalize() BotCommandScope {
+	switch s.Type {
+	case "":
+		return BotCommandScope{Type: BotCommandScopeDefault}
+	case BotCommandScopeChat:
+		return BotCommandScope{Type: s.Type, ChatID: s.ChatID}
+	case BotCommandScopeChatMember:
+		return s
+	default:
+		return BotCommandScope{Type: s.Type}
+	}
+}
+
+// BotCommandScopesFor возвращает области, из которых Telegram выбирает меню команд
+// пользователя userID в чате chat, в порядке убывания приоритета
+func BotCommandScopesFor(chat *Chat, userID int64) []BotCommandScope {
+	if chat.IsPrivate() {
+		return []BotCommandScope{
+			{Type: BotCommandScopeChat, ChatID: chat.ID},
+			{Type: BotCommandScopeAllPrivateChats},
+			{Type: BotCommandScopeDefault},
+		}
+	}
+
+	return []BotCommandScope{
+		{Type: BotCommandScopeChatMember, ChatID: chat.ID, UserID: userID},
+		{Type: BotCommandScopeChat, ChatID: chat.ID},
+		{Type: BotCommandScopeAllGroupChats},
+		{Type: BotCommandScopeDefault},
+	}
+}
+
+// ValidateBotCommands проверяет список команд по правилам Telegram
+// и возвращает его с командами без ведущего "/"
+func ValidateBotCommands(commands []BotCommand) ([]BotCommand, error) {
+	if len(commands) > MaxBotCommands {
+		return nil, ErrBotCommandsTooMuch
+	}
+
+	validated := make([]BotCommand, 0, len(commands))
+	for _, command := range commands {
+		name := strings.TrimPrefix(command.Command, "/")
+		if !isValidBotCommand(name) {
+			return nil, ErrBotCommandInvalid
+		}
+
+		description := strings.TrimSpace(command.Description)
+		if description == "" || len([]rune(description)) > MaxBotCommandDescriptionLength {
+			return nil, ErrBotCommandDescriptionInvalid
+		}
+
+		validated = append(validated, BotCommand{Command: name, Description: description})
+	}
+
+	return validated, nil
+}
+
+// isValidBotCommand проверяет, что команда состоит из 1-32 строчных латинских букв, цифр и подчеркиваний
+func isValidBotCommand(command string) bool {
+	if command == "" || len(command) > MaxBotCommandLength {
+		return false
+	}
+	for _, r := range command {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// IsValidLanguageCode проверяет код языка IETF: пустая строка или две строчные латинские буквы
+func IsValidLanguageCode(languageCode string) bool {
+	if languageCode == "" {
+		return true
+	}
+	if len(languageCode) != 2 {
+		return false
+	}
+	for _, r := range languageCode {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// BotCommandSet представляет список команд бота для одной области и языка
+type BotCommandSet struct {
+	ID           int64     `json:"id" gorm:"primaryKey"`
+	BotID        int64     `json:"bot_id" gorm:"uniqueIndex:idx_bot_commands_scope"`
+	ScopeType    string    `json:"-" gorm:"uniqueIndex:idx_bot_commands_scope"`
+	ScopeChatID  int64     `json:"-" gorm:"uniqueIndex:idx_bot_commands_scope"`
+	ScopeUserID  int64     `json:"-" gorm:"uniqueIndex:idx_bot_commands_scope"`
+	LanguageCode string    `json:"language_code" gorm:"uniqueIndex:idx_bot_commands_scope"`
+	CommandsJSON string    `json:"-" gorm:"column:commands"` // Команды в JSON формате
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName возвращает имя таблицы для модели BotCommandSet
+func (BotCommandSet) TableName() string {
+	return "bot_commands"
+}
+
+// NewBotCommandSet создает список команд бота для области scope и языка languageCode
+func NewBotCommandSet(botID int64, scope BotCommandScope, languageCode string, commands []BotCommand) (*BotCommandSet, error) {
+	scope = scope.Normalize()
+	set := &BotCommandSet{
+		BotID:        botID,
+		ScopeType:    scope.Type,
+		ScopeChatID:  scope.ChatID,
+		ScopeUserID:  scope.UserID,
+		LanguageCode: languageCode,
+	}
+	if err := set.SetCommands(commands); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// Scope возвращает область видимости списка команд
+func (s *BotCommandSet) Scope() BotCommandScope {
+	return BotCommandScope{Type: s.ScopeType, ChatID: s.ScopeChatID, UserID: s.ScopeUserID}
+}
+
+// SetCommands устанавливает команды и сериализует их в JSON
+func (s *BotCommandSet) SetCommands(commands []BotCommand) error {
+	if commands == nil {
+		commands = []BotCommand{}
+	}
+
+	jsonData, err := json.Marshal(commands)
+	if err != nil {
+		return err
+	}
+
+	s.CommandsJSON = string(jsonData)
+	return nil
+}
+
+// GetCommands десериализует команды из JSON
+func (s *BotCommandSet) GetCommands() []BotCommand {
+	commands := []BotCommand{}
+	if s.CommandsJSON == "" {
+		return commands
+	}
+
+	if err := json.Unmarshal([]byte(s.CommandsJSON), &commands); err != nil {
+		return []BotCommand{}
+	}
+
+	return commands
+}
+
+// ToBotCommandList конвертирует список команд в представление для REST API
+func (s *BotCommandSet) ToBotCommandList() BotCommandList {
+	return BotCommandList{
+		Scope:        s.Scope(),
+		LanguageCode: s.LanguageCode,
+		Commands:     s.GetCommands(),
+		UpdatedAt:    s.UpdatedAt,
+	}
+}
+
+// BotCommandList представляет список команд бота вместе с его областью видимости и языком
+type BotCommandList struct {
+	Scope        BotCommandScope `json:"scope"`
+	LanguageCode string          `json:"language_code"`
+	Commands     []BotCommand    `json:"commands"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
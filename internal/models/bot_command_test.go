@@ -0,0 +1,145 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateBotCommands(t *testing.T) {
+	tooMany := make([]BotCommand, MaxBotCommands+1)
+	for i := range tooMany {
+		tooMany[i] = BotCommand{Command: "cmd", Description: "Команда"}
+	}
+
+	tests := []struct {
+		name     string
+		commands []BotCommand
+		want     string
+		err      error
+	}{
+		{name: "Обычная команда", commands: []BotCommand{{Command: "start", Description: "Начать"}}, want: "start"},
+		{name: "Ведущий слеш убирается", commands: []BotCommand{{Command: "/help", Description: "Помощь"}}, want: "help"},
+		{name: "Цифры и подчеркивания", commands: []BotCommand{{Command: "set_lang2", Description: "Язык"}}, want: "set_lang2"},
+		{name: "Пустая команда", commands: []BotCommand{{Command: "", Description: "Пусто"}}, err: ErrBotCommandInvalid},
+		{name: "Заглавные буквы", commands: []BotCommand{{Command: "Start", Description: "Начать"}}, err: ErrBotCommandInvalid},
+		{name: "Кириллица", commands: []BotCommand{{Command: "старт", Description: "Начать"}}, err: ErrBotCommandInvalid},
+		{name: "Слишком длинная команда", commands: []BotCommand{{Command: strings.Repeat("a", MaxBotCommandLength+1), Description: "Длинная"}}, err: ErrBotCommandInvalid},
+		{name: "Пустое описание", commands: []BotCommand{{Command: "start", Description: "  "}}, err: ErrBotCommandDescriptionInvalid},
+		{name: "Слишком длинное описание", commands: []BotCommand{{Command: "start", Description: strings.Repeat("я", MaxBotCommandDescriptionLength+1)}}, err: ErrBotCommandDescriptionInvalid},
+		{name: "Слишком много команд", commands: tooMany, err: ErrBotCommandsTooMuch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands, err := ValidateBotCommands(tt.commands)
+			if err != tt.err {
+				t.Fatalf("ValidateBotCommands() error = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && commands[0].Command != tt.want {
+				t.Errorf("ValidateBotCommands() command = %q, want %q", commands[0].Command, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsValidLanguageCode(t *testing.T) {
+	tests := []struct {
+		name         string
+		languageCode string
+		valid        bool
+	}{
+		{name: "Все языки", languageCode: "", valid: true},
+		{name: "Русский", languageCode: "ru", valid: true},
+		{name: "Заглавные буквы", languageCode: "EN", valid: false},
+		{name: "Код с регионом", languageCode: "en-US", valid: false},
+		{name: "Одна буква", languageCode: "e", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsValidLanguageCode(tt.languageCode); got != tt.valid {
+				t.Errorf("IsValidLanguageCode(%q) = %v, want %v", tt.languageCode, got, tt.valid)
+			}
+		})
+	}
+}
+
+func TestBotCommandScope_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		scope BotCommandScope
+		err   error
+	}{
+		{name: "По умолчанию", scope: BotCommandScope{Type: BotCommandScopeDefault}},
+		{name: "Все приватные чаты", scope: BotCommandScope{Type: BotCommandScopeAllPrivateChats}},
+		{name: "Все группы", scope: BotCommandScope{Type: BotCommandScopeAllGroupChats}},
+		{name: "Чат", scope: BotCommandScope{Type: BotCommandScopeChat, ChatID: 1}},
+		{name: "Чат без chat_id", scope: BotCommandScope{Type: BotCommandScopeChat}, err: ErrChatNotFound},
+		{name: "Участник чата", scope: BotCommandScope{Type: BotCommandScopeChatMember, ChatID: 1, UserID: 2}},
+		{name: "Участник без user_id", scope: BotCommandScope{Type: BotCommandScopeChatMember, ChatID: 1}, err: ErrUserNotFound},
+		{name: "Неизвестный тип", scope: BotCommandScope{Type: "everyone"}, err: ErrWrongBotCommandScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.scope.Validate(); err != tt.err {
+				t.Errorf("Validate() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestBotCommandScopesFor(t *testing.T) {
+	private := BotCommandScopesFor(&Chat{ID: 10, Type: "private"}, 5)
+	expectedPrivate := []BotCommandScope{
+		{Type: BotCommandScopeChat, ChatID: 10},
+		{Type: BotCommandScopeAllPrivateChats},
+		{Type: BotCommandScopeDefault},
+	}
+	if len(private) != len(expectedPrivate) {
+		t.Fatalf("Expected %d scopes for private chat, got %v", len(expectedPrivate), private)
+	}
+	for i := range expectedPrivate {
+		if private[i] != expectedPrivate[i] {
+			t.Errorf("Private scope %d = %+v, want %+v", i, private[i], expectedPrivate[i])
+		}
+	}
+
+	group := BotCommandScopesFor(&Chat{ID: 20, Type: "group"}, 5)
+	expectedGroup := []BotCommandScope{
+		{Type: BotCommandScopeChatMember, ChatID: 20, UserID: 5},
+		{Type: BotCommandScopeChat, ChatID: 20},
+		{Type: BotCommandScopeAllGroupChats},
+		{Type: BotCommandScopeDefault},
+	}
+	if len(group) != len(expectedGroup) {
+		t.Fatalf("Expected %d scopes for group, got %v", len(expectedGroup), group)
+	}
+	for i := range expectedGroup {
+		if group[i] != expectedGroup[i] {
+			t.Errorf("Group scope %d = %+v, want %+v", i, group[i], expectedGroup[i])
+		}
+	}
+}
+
+func TestBotCommandSet_Commands(t *testing.T) {
+	// Fields unrelated to the scope type are dropped so lookups match
+	set, err := NewBotCommandSet(1, BotCommandScope{Type: BotCommandScopeAllGroupChats, ChatID: 7, UserID: 8}, "en",
+		[]BotCommand{{Command: "start", Description: "Start"}})
+	if err != nil {
+		t.Fatalf("NewBotCommandSet() error = %v", err)
+	}
+	if set.Scope() != (BotCommandScope{Type: BotCommandScopeAllGroupChats}) {
+		t.Errorf("Expected normalized scope, got %+v", set.Scope())
+	}
+
+	commands := set.GetCommands()
+	if len(commands) != 1 || commands[0].Command != "start" {
+		t.Errorf("Expected commands to round-trip through JSON, got %+v", commands)
+	}
+
+	list := set.ToBotCommandList()
+	if list.LanguageCode != "en" || len(list.Commands) != 1 {
+		t.Errorf("Unexpected command list: %+v", list)
+	}
+}
@@ -77,3 +77,62 @@ func TestBot_Validation(t *testing.T) {
 	minimalBot.SetWebhook("https://example.com/webhook")
 	minimalBot.UpdateToken("new-token")
 }
+
+func TestBot_AllowedUpdates(t *testing.T) {
+	bot := &Bot{}
+
+	if err := bot.SetAllowedUpdates([]string{UpdateTypeCallbackQuery}); err != nil {
+		t.Fatalf("Failed to set allowed updates: %v", err)
+	}
+
+	allowed := bot.GetAllowedUpdates()
+	if len(allowed) != 1 || allowed[0] != UpdateTypeCallbackQuery {
+		t.Errorf("Expected allowed updates [callback_query], got %v", allowed)
+	}
+
+	if bot.AcceptsUpdate(&Update{Message: &Message{}}) {
+		t.Error("Expected message update to be filtered out")
+	}
+
+	if !bot.AcceptsUpdate(&Update{CallbackQuery: &CallbackQuery{}}) {
+		t.Error("Expected callback query update to be accepted")
+	}
+}
+
+func TestBot_AcceptsUpdateByDefault(t *testing.T) {
+	bot := &Bot{}
+
+	if !bot.AcceptsUpdate(&Update{Message: &Message{}}) {
+		t.Error("Expected message update to be accepted by default")
+	}
+
+	if bot.AcceptsUpdate(&Update{ChatMember: &ChatMemberUpdated{}}) {
+		t.Error("Expected chat_member update to be filtered out by default")
+	}
+
+	if err := bot.SetAllowedUpdates([]string{}); err != nil {
+		t.Fatalf("Failed to set allowed updates: %v", err)
+	}
+
+	if bot.AllowedUpdatesJSON != "" {
+		t.Errorf("Expected empty allowed updates to reset to default, got %s", bot.AllowedUpdatesJSON)
+	}
+}
+
+func TestBot_GetWebhookMaxConnections(t *testing.T) {
+	tests := []struct {
+		value    int
+		expected int
+	}{
+		{0, DefaultWebhookMaxConnections},
+		{5, 5},
+		{1000, MaxWebhookMaxConnections},
+	}
+
+	for _, tt := range tests {
+		bot := &Bot{WebhookMaxConnections: tt.value}
+		if got := bot.GetWebhookMaxConnections(); got != tt.expected {
+			t.Errorf("WebhookMaxConnections %d: expected %d, got %d", tt.value, tt.expected, got)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -11,7 +12,7 @@ type Chat struct {
 	Title       string    `json:"title"`
 	Username    string    `json:"username"`
 	Description string    `json:"description"`
-	Members     []User    `json:"members" gorm:"many2many:chat_members;"`
+	Members     []User    `json:"members" gorm:"many2many:chat_members;"` // Только участники, которые состоят в чате
 	LastMessage *Message  `json:"last_message" gorm:"foreignKey:ChatID"`
 	UnreadCount int       `json:"unread_count"`
 	CreatedAt   time.Time `json:"created_at"`
@@ -23,11 +24,79 @@ func (Chat) TableName() string {
 	return "chats"
 }
 
-// ChatMember представляет связь между чатом и пользователем
+// Статусы участника чата
+const (
+	ChatMemberStatusCreator       = "creator"
+	ChatMemberStatusAdministrator = "administrator"
+	ChatMemberStatusMember        = "member"
+	ChatMemberStatusRestricted    = "restricted"
+	ChatMemberStatusLeft          = "left"
+	ChatMemberStatusKicked        = "kicked"
+)
+
+// ChatAdministratorRights представляет права администратора чата
+type ChatAdministratorRights struct {
+	IsAnonymous         bool `json:"is_anonymous"`
+	CanManageChat       bool `json:"can_manage_chat"`
+	CanDeleteMessages   bool `json:"can_delete_messages"`
+	CanManageVideoChats bool `json:"can_manage_video_chats"`
+	CanRestrictMembers  bool `json:"can_restrict_members"`
+	CanPromoteMembers   bool `json:"can_promote_members"`
+	CanChangeInfo       bool `json:"can_change_info"`
+	CanInviteUsers      bool `json:"can_invite_users"`
+	CanPinMessages      bool `json:"can_pin_messages"`
+}
+
+// FullAdministratorRights возвращает все права администратора, которые есть у создателя чата
+func FullAdministratorRights() ChatAdministratorRights {
+	return ChatAdministratorRights{
+		CanManageChat:       true,
+		CanDeleteMessages:   true,
+		CanManageVideoChats: true,
+		CanRestrictMembers:  true,
+		CanPromoteMembers:   true,
+		CanChangeInfo:       true,
+		CanInviteUsers:      true,
+		CanPinMessages:      true,
+	}
+}
+
+// IsEmpty проверяет, что не выдано ни одного права. Назначение без прав снимает администратора
+func (r ChatAdministratorRights) IsEmpty() bool {
+	return r == ChatAdministratorRights{}
+}
+
+// Includes проверяет, что все права other, кроме анонимности, есть среди прав r.
+// Администратор может выдать другому только те права, которые есть у него самого
+func (r ChatAdministratorRights) Includes(other ChatAdministratorRights) bool {
+	r.IsAnonymous, other.IsAnonymous = false, false
+	return r == ChatAdministratorRights{
+		CanManageChat:       r.CanManageChat || other.CanManageChat,
+		CanDeleteMessages:   r.CanDeleteMessages || other.CanDeleteMessages,
+		CanManageVideoChats: r.CanManageVideoChats || other.CanManageVideoChats,
+		CanRestrictMembers:  r.CanRestrictMembers || other.CanRestrictMembers,
+		CanPromoteMembers:   r.CanPromoteMembers || other.CanPromoteMembers,
+		CanChangeInfo:       r.CanChangeInfo || other.CanChangeInfo,
+		CanInviteUsers:      r.CanInviteUsers || other.CanInviteUsers,
+		CanPinMessages:      r.CanPinMessages || other.CanPinMessages,
+	}
+}
+
+// MaxCustomTitleLength - максимальная длина звания администратора в символах
+const MaxCustomTitleLength = 16
+
+// ChatMember представляет участие пользователя в чате: статус, права администратора и ограничения.
+// Вышедшие (left) и заблокированные (kicked) пользователи сохраняются, но не входят в Chat.Members
 type ChatMember struct {
-	ChatID   int64     `json:"chat_id" gorm:"primaryKey"`
-	UserID   int64     `json:"user_id" gorm:"primaryKey"`
-	JoinedAt time.Time `json:"joined_at"`
+	ChatID                  int64  `json:"chat_id" gorm:"primaryKey"`
+	UserID                  int64  `json:"user_id" gorm:"primaryKey"`
+	Status                  string `json:"status" gorm:"default:member"`
+	CustomTitle             string `json:"custom_title,omitempty"` // Звание создателя или администратора
+	ChatAdministratorRights `gorm:"embedded"`
+	PermissionsJSON         string     `json:"-" gorm:"column:permissions"` // Ограничения участника со статусом restricted
+	UntilDate               *time.Time `json:"until_date,omitempty"`        // Срок ограничения или блокировки, nil - бессрочно
+	PromotedBy              int64      `json:"promoted_by,omitempty"`       // Кто назначил администратора, 0 - назначен через эмулятор
+	JoinedAt                time.Time  `json:"joined_at"`
 }
 
 // TableName возвращает имя таблицы для модели ChatMember
@@ -35,6 +104,174 @@ func (ChatMember) TableName() string {
 	return "chat_members"
 }
 
+// IsActive проверяет, состоит ли пользователь в чате
+func (m *ChatMember) IsActive() bool {
+	switch m.Status {
+	case ChatMemberStatusCreator, ChatMemberStatusAdministrator, ChatMemberStatusMember, ChatMemberStatusRestricted:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsAdministrator проверяет, является ли участник создателем или администратором чата
+func (m *ChatMember) IsAdministrator() bool {
+	return m.Status == ChatMemberStatusCreator || m.Status == ChatMemberStatusAdministrator
+}
+
+// Rights возвращает действующие права участника: у создателя есть все права, у остальных участников - никаких
+func (m *ChatMember) Rights() ChatAdministratorRights {
+	switch m.Status {
+	case ChatMemberStatusCreator:
+		rights := FullAdministratorRights()
+		rights.IsAnonymous = m.IsAnonymous
+		return rights
+	case ChatMemberStatusAdministrator:
+		return m.ChatAdministratorRights
+	default:
+		return ChatAdministratorRights{}
+	}
+}
+
+// SetPermissions сохраняет ограничения участника в JSON формате
+func (m *ChatMember) SetPermissions(permissions *ChatPermissions) error {
+	if permissions == nil {
+		m.PermissionsJSON = ""
+		return nil
+	}
+
+	jsonData, err := json.Marshal(permissions)
+	if err != nil {
+		return err
+	}
+	m.PermissionsJSON = string(jsonData)
+	return nil
+}
+
+// GetPermissions возвращает ограничения участника или nil, если их нет
+func (m *ChatMember) GetPermissions() *ChatPermissions {
+	if m.PermissionsJSON == "" {
+		return nil
+	}
+
+	var permissions ChatPermissions
+	if err := json.Unmarshal([]byte(m.PermissionsJSON), &permissions); err != nil {
+		return nil
+	}
+	return &permissions
+}
+
+// EffectivePermissions возвращает разрешения, действующие для участника: у ограниченного участника -
+// его ограничения, у вышедших и заблокированных - никаких, у остальных - разрешения чата по умолчанию
+func (m *ChatMember) EffectivePermissions() ChatPermissions {
+	switch m.Status {
+	case ChatMemberStatusRestricted:
+		if permissions := m.GetPermissions(); permissions != nil {
+			return *permissions
+		}
+		return ChatPermissions{}
+	case ChatMemberStatusLeft, ChatMemberStatusKicked:
+		return ChatPermissions{}
+	default:
+		return DefaultChatPermissions()
+	}
+}
+
+// ToTelegramChatMember конвертирует участие пользователя user в чате в формат Telegram Bot API
+func (m *ChatMember) ToTelegramChatMember(user *User) TelegramChatMember {
+	rights := m.Rights()
+	permissions := m.EffectivePermissions()
+
+	telegramMember := TelegramChatMember{
+		User:                  user.ToTelegramUser(),
+		Status:                m.Status,
+		IsAnonymous:           rights.IsAnonymous,
+		CanManageChat:         rights.CanManageChat,
+		CanDeleteMessages:     rights.CanDeleteMessages,
+		CanManageVideoChats:   rights.CanManageVideoChats,
+		CanRestrictMembers:    rights.CanRestrictMembers,
+		CanPromoteMembers:     rights.CanPromoteMembers,
+		CanChangeInfo:         permissions.CanChangeInfo,
+		CanInviteUsers:        permissions.CanInviteUsers,
+		CanPinMessages:        permissions.CanPinMessages,
+		IsMember:              m.IsActive(),
+		CanSendMessages:       permissions.CanSendMessages,
+		CanSendAudios:         permissions.CanSendAudios,
+		CanSendDocuments:      permissions.CanSendDocuments,
+		CanSendPhotos:         permissions.CanSendPhotos,
+		CanSendVideos:         permissions.CanSendVideos,
+		CanSendVideoNotes:     permissions.CanSendVideoNotes,
+		CanSendVoiceNotes:     permissions.CanSendVoiceNotes,
+		CanSendPolls:          permissions.CanSendPolls,
+		CanSendOtherMessages:  permissions.CanSendOtherMessages,
+		CanAddWebPagePreviews: permissions.CanAddWebPagePreviews,
+		CanManageTopics:       permissions.CanManageTopics,
+	}
+
+	// У администраторов can_change_info, can_invite_users и can_pin_messages - права, а не разрешения чата
+	if m.IsAdministrator() {
+		telegramMember.CanChangeInfo = rights.CanChangeInfo
+		telegramMember.CanInviteUsers = rights.CanInviteUsers
+		telegramMember.CanPinMessages = rights.CanPinMessages
+		telegramMember.CustomTitle = m.CustomTitle
+	}
+
+	if m.Status == ChatMemberStatusRestricted || m.Status == ChatMemberStatusKicked {
+		var untilDate int64
+		if m.UntilDate != nil {
+			untilDate = m.UntilDate.Unix()
+		}
+		telegramMember.UntilDate = &untilDate
+	}
+
+	return telegramMember
+}
+
+// CanBeEditedBy проверяет, может ли пользователь userID менять права администратора:
+// это может только тот, кто его назначил
+func (m *ChatMember) CanBeEditedBy(userID int64) bool {
+	return m.Status == ChatMemberStatusAdministrator && m.PromotedBy != 0 && m.PromotedBy == userID
+}
+
+// Expire снимает истекшее ограничение или блокировку: ограниченный участник становится обычным,
+// заблокированный пользователь - вышедшим из чата. Возвращает true, если статус изменился
+func (m *ChatMember) Expire(now time.Time) bool {
+	if m.UntilDate == nil || m.UntilDate.After(now) {
+		return false
+	}
+
+	switch m.Status {
+	case ChatMemberStatusRestricted:
+		m.Status = ChatMemberStatusMember
+	case ChatMemberStatusKicked:
+		m.Status = ChatMemberStatusLeft
+	default:
+		return false
+	}
+	m.PermissionsJSON = ""
+	m.UntilDate = nil
+	return true
+}
+
+// UntilDateFromUnix конвертирует until_date Bot API в срок ограничения.
+// Как и в Telegram, 0 и сроки меньше 30 секунд или больше 366 дней от now означают бессрочное ограничение (nil)
+func UntilDateFromUnix(untilDate int64, now time.Time) *time.Time {
+	if untilDate <= 0 {
+		return nil
+	}
+
+	until := time.Unix(untilDate, 0)
+	if until.Sub(now) < 30*time.Second || until.Sub(now) > 366*24*time.Hour {
+		return nil
+	}
+	return &until
+}
+
+// ActiveChatMemberStatuses возвращает статусы участников, которые состоят в чате
+func ActiveChatMemberStatuses() []string {
+	return []string{ChatMemberStatusCreator, ChatMemberStatusAdministrator, ChatMemberStatusMember, ChatMemberStatusRestricted}
+}
+
 // IsPrivate проверяет, является ли чат приватным
 func (c *Chat) IsPrivate() bool {
 	return c.Type == "private"
@@ -45,6 +282,45 @@ func (c *Chat) IsGroup() bool {
 	return c.Type == "group"
 }
 
+// ToTelegramChat конвертирует чат в формат Telegram Bot API с точки зрения пользователя viewerID.
+// Приватный чат представляется собеседником: его именем, фамилией и username
+func (c *Chat) ToTelegramChat(viewerID int64) TelegramChat {
+	telegramChat := TelegramChat{
+		ID:   c.ID,
+		Type: c.Type,
+	}
+
+	if c.IsPrivate() {
+		// Без загруженного собеседника используется название чата
+		telegramChat.FirstName = c.Title
+		for i := range c.Members {
+			if c.Members[i].ID != viewerID {
+				telegramChat.FirstName = c.Members[i].FirstName
+				telegramChat.LastName = c.Members[i].LastName
+				telegramChat.Username = c.Members[i].Username
+				break
+			}
+		}
+		return telegramChat
+	}
+
+	telegramChat.Title = c.Title
+	telegramChat.Username = c.Username
+	return telegramChat
+}
+
+// ToTelegramChatFullInfo конвертирует чат в полную информацию о чате (getChat):
+// дополнительно передаются описание и разрешения участников группы
+func (c *Chat) ToTelegramChatFullInfo(viewerID int64) TelegramChat {
+	telegramChat := c.ToTelegramChat(viewerID)
+	if c.IsGroup() {
+		telegramChat.Description = c.Description
+		permissions := DefaultChatPermissions()
+		telegramChat.Permissions = &permissions
+	}
+	return telegramChat
+}
+
 // GetChatIcon возвращает иконку для типа чата
 func (c *Chat) GetChatIcon() string {
 	switch c.Type {
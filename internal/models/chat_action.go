@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Действия пользователя в чате, передаваемые через sendChatAction
+const (
+	ChatActionTyping          = "typing"
+	ChatActionUploadPhoto     = "upload_photo"
+	ChatActionRecordVideo     = "record_video"
+	ChatActionUploadVideo     = "upload_video"
+	ChatActionRecordVoice     = "record_voice"
+	ChatActionUploadVoice     = "upload_voice"
+	ChatActionUploadDocument  = "upload_document"
+	ChatActionChooseSticker   = "choose_sticker"
+	ChatActionFindLocation    = "find_location"
+	ChatActionRecordVideoNote = "record_video_note"
+	ChatActionUploadVideoNote = "upload_video_note"
+)
+
+// ChatActionDuration - время, в течение которого действие отображается в чате
+const ChatActionDuration = 5 * time.Second
+
+// ChatAction представляет действие пользователя в чате, например набор текста
+type ChatAction struct {
+	ChatID    int64     `json:"chat_id"`
+	UserID    int64     `json:"user_id"`
+	Action    string    `json:"action"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// IsValidChatAction проверяет, поддерживается ли действие Telegram Bot API
+func IsValidChatAction(action string) bool {
+	switch action {
+	case ChatActionTyping, ChatActionUploadPhoto, ChatActionRecordVideo, ChatActionUploadVideo,
+		ChatActionRecordVoice, ChatActionUploadVoice, ChatActionUploadDocument, ChatActionChooseSticker,
+		ChatActionFindLocation, ChatActionRecordVideoNote, ChatActionUploadVideoNote:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsExpired проверяет, закончилось ли отображение действия к моменту now
+func (a *ChatAction) IsExpired(now time.Time) bool {
+	return !now.Before(a.ExpiresAt)
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsValidChatAction(t *testing.T) {
+	tests := []struct {
+		name   string
+		action string
+		valid  bool
+	}{
+		{name: "Набор текста", action: ChatActionTyping, valid: true},
+		{name: "Загрузка фото", action: ChatActionUploadPhoto, valid: true},
+		{name: "Запись голосового", action: ChatActionRecordVoice, valid: true},
+		{name: "Видеосообщение", action: ChatActionUploadVideoNote, valid: true},
+		{name: "Пустое действие", action: "", valid: false},
+		{name: "Неизвестное действие", action: "dancing", valid: false},
+		{name: "Регистр важен", action: "TYPING", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsValidChatAction(tt.action); got != tt.valid {
+				t.Errorf("IsValidChatAction(%q) = %v, want %v", tt.action, got, tt.valid)
+			}
+		})
+	}
+}
+
+func TestChatAction_IsExpired(t *testing.T) {
+	now := time.Now()
+	action := ChatAction{Action: ChatActionTyping, ExpiresAt: now.Add(ChatActionDuration)}
+
+	if action.IsExpired(now) {
+		t.Error("Expected action to be active right after it was sent")
+	}
+	if !action.IsExpired(now.Add(ChatActionDuration)) {
+		t.Error("Expected action to expire after ChatActionDuration")
+	}
+}
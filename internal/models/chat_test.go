@@ -1,7 +1,9 @@
 package models
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 )
 
 func TestChat_TableName(t *testing.T) {
@@ -222,3 +224,371 @@ func TestChat_RemoveMember(t *testing.T) {
 		t.Errorf("Expected 0 members after removing all, got %d", len(chat.Members))
 	}
 }
+
+func TestChatMember_Status(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        string
+		active        bool
+		administrator bool
+	}{
+		{name: "Создатель", status: ChatMemberStatusCreator, active: true, administrator: true},
+		{name: "Администратор", status: ChatMemberStatusAdministrator, active: true, administrator: true},
+		{name: "Участник", status: ChatMemberStatusMember, active: true},
+		{name: "Ограниченный участник", status: ChatMemberStatusRestricted, active: true},
+		{name: "Вышедший", status: ChatMemberStatusLeft},
+		{name: "Заблокированный", status: ChatMemberStatusKicked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			member := &ChatMember{Status: tt.status}
+			if member.IsActive() != tt.active {
+				t.Errorf("IsActive() = %v, want %v", member.IsActive(), tt.active)
+			}
+			if member.IsAdministrator() != tt.administrator {
+				t.Errorf("IsAdministrator() = %v, want %v", member.IsAdministrator(), tt.administrator)
+			}
+		})
+	}
+}
+
+func TestChatMember_Rights(t *testing.T) {
+	creator := &ChatMember{Status: ChatMemberStatusCreator}
+	if creator.Rights() != FullAdministratorRights() {
+		t.Errorf("Expected creator to have all rights, got %+v", creator.Rights())
+	}
+
+	admin := &ChatMember{
+		Status:                  ChatMemberStatusAdministrator,
+		ChatAdministratorRights: ChatAdministratorRights{CanDeleteMessages: true},
+	}
+	if !admin.Rights().CanDeleteMessages || admin.Rights().CanRestrictMembers {
+		t.Errorf("Expected administrator to have only granted rights, got %+v", admin.Rights())
+	}
+
+	// Rights left over from a previous promotion do not apply to other statuses
+	member := &ChatMember{
+		Status:                  ChatMemberStatusMember,
+		ChatAdministratorRights: ChatAdministratorRights{CanDeleteMessages: true},
+	}
+	if !member.Rights().IsEmpty() {
+		t.Errorf("Expected member to have no rights, got %+v", member.Rights())
+	}
+}
+
+func TestChatMember_Permissions(t *testing.T) {
+	member := &ChatMember{Status: ChatMemberStatusRestricted}
+	if member.GetPermissions() != nil {
+		t.Error("Expected no permissions by default")
+	}
+
+	if err := member.SetPermissions(&ChatPermissions{CanSendMessages: true}); err != nil {
+		t.Fatalf("SetPermissions() error = %v", err)
+	}
+	permissions := member.GetPermissions()
+	if permissions == nil || !permissions.CanSendMessages || permissions.CanSendPolls {
+		t.Errorf("Expected permissions to round-trip through JSON, got %+v", permissions)
+	}
+
+	if err := member.SetPermissions(nil); err != nil || member.GetPermissions() != nil {
+		t.Errorf("Expected permissions to be cleared, got %+v", member.GetPermissions())
+	}
+}
+
+func TestChatMember_ToTelegramChatMember(t *testing.T) {
+	user := &User{ID: 1, Username: "user", FirstName: "User"}
+	until := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name        string
+		member      ChatMember
+		isMember    bool
+		canSend     bool
+		canPin      bool
+		canDelete   bool
+		untilDate   *int64
+		permissions *ChatPermissions
+	}{
+		{name: "Создатель", member: ChatMember{Status: ChatMemberStatusCreator}, isMember: true, canSend: true, canPin: true, canDelete: true},
+		{name: "Администратор без права закрепления", member: ChatMember{Status: ChatMemberStatusAdministrator, ChatAdministratorRights: ChatAdministratorRights{CanDeleteMessages: true}}, isMember: true, canSend: true, canDelete: true},
+		{name: "Участник", member: ChatMember{Status: ChatMemberStatusMember}, isMember: true, canSend: true, canPin: true},
+		{name: "Ограниченный участник", member: ChatMember{Status: ChatMemberStatusRestricted, UntilDate: &until}, isMember: true, untilDate: &[]int64{until.Unix()}[0], permissions: &ChatPermissions{CanPinMessages: true}, canPin: true},
+		{name: "Вышедший", member: ChatMember{Status: ChatMemberStatusLeft}},
+		{name: "Заблокированный навсегда", member: ChatMember{Status: ChatMemberStatusKicked}, untilDate: &[]int64{0}[0]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.permissions != nil {
+				if err := tt.member.SetPermissions(tt.permissions); err != nil {
+					t.Fatalf("SetPermissions() error = %v", err)
+				}
+			}
+
+			got := tt.member.ToTelegramChatMember(user)
+			if got.User.ID != user.ID || got.Status != tt.member.Status {
+				t.Errorf("Unexpected user or status: %+v", got)
+			}
+			if got.IsMember != tt.isMember {
+				t.Errorf("IsMember = %v, want %v", got.IsMember, tt.isMember)
+			}
+			if got.CanSendMessages != tt.canSend {
+				t.Errorf("CanSendMessages = %v, want %v", got.CanSendMessages, tt.canSend)
+			}
+			if got.CanPinMessages != tt.canPin {
+				t.Errorf("CanPinMessages = %v, want %v", got.CanPinMessages, tt.canPin)
+			}
+			if got.CanDeleteMessages != tt.canDelete {
+				t.Errorf("CanDeleteMessages = %v, want %v", got.CanDeleteMessages, tt.canDelete)
+			}
+			switch {
+			case tt.untilDate == nil && got.UntilDate != nil:
+				t.Errorf("Expected no until_date, got %d", *got.UntilDate)
+			case tt.untilDate != nil && (got.UntilDate == nil || *got.UntilDate != *tt.untilDate):
+				t.Errorf("UntilDate = %v, want %d", got.UntilDate, *tt.untilDate)
+			}
+		})
+	}
+}
+
+func TestTelegramChatMember_MarshalJSON(t *testing.T) {
+	user := &User{ID: 1, Username: "user", FirstName: "User"}
+
+	tests := []struct {
+		name    string
+		member  ChatMember
+		present []string
+		absent  []string
+	}{
+		{name: "Создатель", member: ChatMember{Status: ChatMemberStatusCreator},
+			present: []string{"is_anonymous"}, absent: []string{"can_send_messages", "is_member", "can_be_edited", "until_date"}},
+		{name: "Администратор", member: ChatMember{Status: ChatMemberStatusAdministrator, ChatAdministratorRights: ChatAdministratorRights{CanDeleteMessages: true}},
+			present: []string{"can_be_edited", "can_manage_chat", "can_delete_messages", "can_pin_messages", "can_post_stories"},
+			absent:  []string{"can_send_messages", "is_member", "can_post_messages", "can_edit_messages", "until_date"}},
+		{name: "Участник", member: ChatMember{Status: ChatMemberStatusMember},
+			absent: []string{"is_anonymous", "can_send_messages", "can_pin_messages", "is_member", "until_date"}},
+		{name: "Ограниченный участник", member: ChatMember{Status: ChatMemberStatusRestricted},
+			present: []string{"is_member", "can_send_messages", "can_send_polls", "can_manage_topics", "until_date"},
+			absent:  []string{"is_anonymous", "can_delete_messages", "can_be_edited"}},
+		{name: "Вышедший", member: ChatMember{Status: ChatMemberStatusLeft},
+			absent: []string{"is_member", "can_send_messages", "until_date"}},
+		{name: "Заблокированный", member: ChatMember{Status: ChatMemberStatusKicked},
+			present: []string{"until_date"}, absent: []string{"is_member", "can_send_messages"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.member.ToTelegramChatMember(user))
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			var fields map[string]interface{}
+			if err := json.Unmarshal(data, &fields); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+
+			if fields["status"] != tt.member.Status || fields["user"] == nil {
+				t.Errorf("Expected user and status %q, got %s", tt.member.Status, data)
+			}
+			for _, field := range tt.present {
+				if _, ok := fields[field]; !ok {
+					t.Errorf("Expected %s in %s", field, data)
+				}
+			}
+			for _, field := range tt.absent {
+				if _, ok := fields[field]; ok {
+					t.Errorf("Expected no %s in %s", field, data)
+				}
+			}
+		})
+	}
+
+	// A restricted member keeps its fields after a round trip through the update queue
+	restricted := ChatMember{Status: ChatMemberStatusRestricted}
+	if err := restricted.SetPermissions(&ChatPermissions{CanSendPhotos: true}); err != nil {
+		t.Fatalf("SetPermissions() error = %v", err)
+	}
+	data, err := json.Marshal(restricted.ToTelegramChatMember(user))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var decoded TelegramChatMember
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !decoded.IsMember || !decoded.CanSendPhotos || decoded.CanSendMessages || decoded.UntilDate == nil || *decoded.UntilDate != 0 {
+		t.Errorf("Expected restricted member to round-trip, got %+v", decoded)
+	}
+}
+
+func TestChat_ToTelegramChat(t *testing.T) {
+	bot := User{ID: 1, Username: "test_bot", FirstName: "Bot", IsBot: true}
+	user := User{ID: 2, Username: "user", FirstName: "Ivan", LastName: "Petrov"}
+
+	// A private chat looks like the other participant
+	private := &Chat{ID: 10, Type: "private", Title: "Ivan - Bot", Members: []User{bot, user}}
+	got := private.ToTelegramChat(bot.ID)
+	if got.Type != "private" || got.FirstName != "Ivan" || got.LastName != "Petrov" || got.Username != "user" || got.Title != "" {
+		t.Errorf("Unexpected private chat: %+v", got)
+	}
+	if got := private.ToTelegramChat(user.ID); got.FirstName != "Bot" || got.Username != "test_bot" {
+		t.Errorf("Expected the bot as counterpart, got %+v", got)
+	}
+
+	group := &Chat{ID: 20, Type: "group", Title: "Group", Username: "group_chat", Description: "About", Members: []User{bot, user}}
+	got = group.ToTelegramChat(bot.ID)
+	if got.Title != "Group" || got.Username != "group_chat" || got.FirstName != "" || got.Description != "" || got.Permissions != nil {
+		t.Errorf("Unexpected group chat: %+v", got)
+	}
+
+	full := group.ToTelegramChatFullInfo(bot.ID)
+	if full.Description != "About" || full.Permissions == nil || !full.Permissions.CanSendMessages {
+		t.Errorf("Expected description and default permissions, got %+v", full)
+	}
+}
+
+func TestChatMember_Expire(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	tests := []struct {
+		name      string
+		status    string
+		untilDate *time.Time
+		expired   bool
+		want      string
+	}{
+		{name: "Истекшее ограничение", status: ChatMemberStatusRestricted, untilDate: &past, expired: true, want: ChatMemberStatusMember},
+		{name: "Истекшая блокировка", status: ChatMemberStatusKicked, untilDate: &past, expired: true, want: ChatMemberStatusLeft},
+		{name: "Действующее ограничение", status: ChatMemberStatusRestricted, untilDate: &future, want: ChatMemberStatusRestricted},
+		{name: "Бессрочная блокировка", status: ChatMemberStatusKicked, want: ChatMemberStatusKicked},
+		{name: "Обычный участник", status: ChatMemberStatusMember, untilDate: &past, want: ChatMemberStatusMember},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			member := &ChatMember{Status: tt.status, UntilDate: tt.untilDate, PermissionsJSON: `{"can_send_messages":true}`}
+			if got := member.Expire(now); got != tt.expired {
+				t.Errorf("Expire() = %v, want %v", got, tt.expired)
+			}
+			if member.Status != tt.want {
+				t.Errorf("Status = %q, want %q", member.Status, tt.want)
+			}
+			if tt.expired && (member.UntilDate != nil || member.GetPermissions() != nil) {
+				t.Errorf("Expected restrictions to be cleared, got %+v", member)
+			}
+		})
+	}
+}
+
+func TestUntilDateFromUnix(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name      string
+		untilDate int64
+		forever   bool
+	}{
+		{name: "Не указан", untilDate: 0, forever: true},
+		{name: "Меньше 30 секунд", untilDate: now.Add(10 * time.Second).Unix(), forever: true},
+		{name: "Час", untilDate: now.Add(time.Hour).Unix()},
+		{name: "Больше 366 дней", untilDate: now.Add(400 * 24 * time.Hour).Unix(), forever: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := UntilDateFromUnix(tt.untilDate, now)
+			if tt.forever != (got == nil) {
+				t.Fatalf("UntilDateFromUnix(%d) = %v, forever = %v", tt.untilDate, got, tt.forever)
+			}
+			if got != nil && got.Unix() != tt.untilDate {
+				t.Errorf("UntilDateFromUnix(%d) = %d", tt.untilDate, got.Unix())
+			}
+		})
+	}
+}
+
+func TestChatAdministratorRights_Includes(t *testing.T) {
+	rights := ChatAdministratorRights{CanDeleteMessages: true, CanRestrictMembers: true, CanPromoteMembers: true}
+
+	if !rights.Includes(ChatAdministratorRights{CanDeleteMessages: true, IsAnonymous: true}) {
+		t.Error("Expected a subset of rights to be included")
+	}
+	if !rights.Includes(ChatAdministratorRights{}) {
+		t.Error("Expected empty rights to be included")
+	}
+	if rights.Includes(ChatAdministratorRights{CanDeleteMessages: true, CanPinMessages: true}) {
+		t.Error("Expected can_pin_messages not to be included")
+	}
+}
+
+func TestChatMember_CanBeEditedBy(t *testing.T) {
+	admin := &ChatMember{Status: ChatMemberStatusAdministrator, PromotedBy: 7}
+	if !admin.CanBeEditedBy(7) || admin.CanBeEditedBy(8) {
+		t.Errorf("Expected only the promoter to edit the administrator")
+	}
+
+	// Administrators promoted through the emulator can't be edited by bots
+	if (&ChatMember{Status: ChatMemberStatusAdministrator}).CanBeEditedBy(0) {
+		t.Error("Expected administrator without promoter not to be editable")
+	}
+	if (&ChatMember{Status: ChatMemberStatusCreator, PromotedBy: 7}).CanBeEditedBy(7) {
+		t.Error("Expected creator not to be editable")
+	}
+}
+
+func TestChatPermissions_Normalize(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions ChatPermissions
+		independent bool
+		want        ChatPermissions
+	}{
+		{
+			name:        "Медиа разрешает все виды медиа и текст",
+			permissions: ChatPermissions{CanSendMediaMessages: true},
+			want: ChatPermissions{CanSendMessages: true, CanSendMediaMessages: true, CanSendAudios: true, CanSendDocuments: true,
+				CanSendPhotos: true, CanSendVideos: true, CanSendVideoNotes: true, CanSendVoiceNotes: true},
+		},
+		{
+			name:        "Опросы разрешают текст",
+			permissions: ChatPermissions{CanSendPolls: true},
+			want:        ChatPermissions{CanSendMessages: true, CanSendPolls: true},
+		},
+		{
+			name:        "Независимые разрешения не меняются",
+			permissions: ChatPermissions{CanSendPolls: true, CanSendPhotos: true},
+			independent: true,
+			want:        ChatPermissions{CanSendPolls: true, CanSendPhotos: true},
+		},
+		{
+			name:        "Все запрещено",
+			permissions: ChatPermissions{},
+			want:        ChatPermissions{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.permissions.Normalize(tt.independent); got != tt.want {
+				t.Errorf("Normalize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatPermissions_CanSend(t *testing.T) {
+	permissions := ChatPermissions{CanSendMessages: true, CanSendPhotos: true}
+
+	if !permissions.CanSend(MessageTypeText) || !permissions.CanSend(MessageTypePhoto) {
+		t.Error("Expected text and photos to be allowed")
+	}
+	if permissions.CanSend(MessageTypeFile) || permissions.CanSend(MessageTypeVoice) {
+		t.Error("Expected documents and voice notes to be forbidden")
+	}
+	if NotEnoughRightsToSend(MessageTypeFile) != ErrNotEnoughRightsToSendDocuments {
+		t.Error("Expected the documents error for files")
+	}
+}
@@ -0,0 +1,116 @@
+package models
+
+import "errors"
+
+// TelegramError представляет ошибку Telegram Bot API с кодом и описанием
+type TelegramError struct {
+	ErrorCode   int
+	Description string
+}
+
+// NewTelegramError создает новую ошибку Telegram Bot API
+func NewTelegramError(errorCode int, description string) *TelegramError {
+	return &TelegramError{
+		ErrorCode:   errorCode,
+		Description: description,
+	}
+}
+
+func (e *TelegramError) Error() string {
+	return e.Description
+}
+
+// Ошибки Telegram Bot API, возвращаемые эмулятором
+var (
+	ErrWebhookActive = NewTelegramError(409,
+		"Conflict: can't use getUpdates method while webhook is active; use deleteWebhook to delete the webhook first")
+	ErrTerminatedByOtherGetUpdates = NewTelegramError(409,
+		"Conflict: terminated by other getUpdates request; make sure that only one bot instance is running")
+
+	ErrMessageToEditNotFound = NewTelegramError(400, "Bad Request: message to edit not found")
+	ErrMessageCantBeEdited   = NewTelegramError(400, "Bad Request: message can't be edited")
+	ErrMessageNotModified    = NewTelegramError(400,
+		"Bad Request: message is not modified: specified new message content and reply markup are exactly the same as a current content and reply markup of the message")
+	ErrMessageTextEmpty = NewTelegramError(400, "Bad Request: message text is empty")
+	ErrNoTextToEdit     = NewTelegramError(400, "Bad Request: there is no text in the message to edit")
+
+	ErrMessageToDeleteNotFound  = NewTelegramError(400, "Bad Request: message to delete not found")
+	ErrMessageCantBeDeleted     = NewTelegramError(400, "Bad Request: message can't be deleted")
+	ErrMessageToForwardNotFound = NewTelegramError(400, "Bad Request: message to forward not found")
+	ErrMessageToCopyNotFound    = NewTelegramError(400, "Bad Request: message to copy not found")
+	ErrReplyMessageNotFound     = NewTelegramError(400, "Bad Request: message to be replied not found")
+
+	ErrChatNotFound    = NewTelegramError(400, "Bad Request: chat not found")
+	ErrWrongChatAction = NewTelegramError(400, "Bad Request: wrong parameter action in request")
+	ErrUserNotFound    = NewTelegramError(400, "Bad Request: user not found")
+
+	ErrUserNotParticipant     = NewTelegramError(400, "Bad Request: USER_NOT_PARTICIPANT")
+	ErrUserAlreadyParticipant = NewTelegramError(400, "Bad Request: USER_ALREADY_PARTICIPANT")
+	ErrUserKicked             = NewTelegramError(400, "Bad Request: USER_KICKED")
+	ErrCantRemoveChatOwner    = NewTelegramError(400, "Bad Request: can't remove chat owner")
+	ErrCantDemoteChatCreator  = NewTelegramError(400, "Bad Request: can't demote chat creator")
+	ErrGroupChatsOnly         = NewTelegramError(400, "Bad Request: method is available for supergroup and channel chats only")
+	ErrNoPrivateChatAdmins    = NewTelegramError(400, "Bad Request: there are no administrators in the private chat")
+	ErrBotKicked              = NewTelegramError(403, "Forbidden: bot was kicked from the group chat")
+	ErrBotNotMember           = NewTelegramError(403, "Forbidden: bot is not a member of the group chat")
+	ErrBotBlocked             = NewTelegramError(403, "Forbidden: bot was blocked by the user")
+	ErrNotBotPrivateChat      = NewTelegramError(400, "Bad Request: chat is not a private chat with a bot")
+
+	ErrNotEnoughRightsToRestrict = NewTelegramError(400, "Bad Request: not enough rights to restrict/unrestrict chat member")
+	ErrChatAdminRequired         = NewTelegramError(400, "Bad Request: CHAT_ADMIN_REQUIRED")
+	ErrRightForbidden            = NewTelegramError(400, "Bad Request: RIGHT_FORBIDDEN")
+	ErrUserIsAdministrator       = NewTelegramError(400, "Bad Request: user is an administrator of the chat")
+	ErrCantRestrictSelf          = NewTelegramError(400, "Bad Request: can't restrict self")
+	ErrCantPromoteSelf           = NewTelegramError(400, "Bad Request: can't promote self")
+	ErrUserNotAdministrator      = NewTelegramError(400, "Bad Request: user is not an administrator")
+	ErrNotEnoughRightsToSetTitle = NewTelegramError(400, "Bad Request: not enough rights to change custom title of the user")
+	ErrAdminRankInvalid          = NewTelegramError(400, "Bad Request: ADMIN_RANK_INVALID")
+
+	ErrNotEnoughRightsToSendText      = NewTelegramError(400, "Bad Request: not enough rights to send text messages to the chat")
+	ErrNotEnoughRightsToSendPhotos    = NewTelegramError(400, "Bad Request: not enough rights to send photos to the chat")
+	ErrNotEnoughRightsToSendDocuments = NewTelegramError(400, "Bad Request: not enough rights to send documents to the chat")
+	ErrNotEnoughRightsToSendAudios    = NewTelegramError(400, "Bad Request: not enough rights to send audios to the chat")
+	ErrNotEnoughRightsToSendVoices    = NewTelegramError(400, "Bad Request: not enough rights to send voice notes to the chat")
+	ErrNotEnoughRightsToSendVideos    = NewTelegramError(400, "Bad Request: not enough rights to send videos to the chat")
+	ErrNotEnoughRightsToSendOther     = NewTelegramError(400, "Bad Request: not enough rights to send other messages to the chat")
+
+	ErrBotCommandsTooMuch           = NewTelegramError(400, "Bad Request: BOT_COMMANDS_TOO_MUCH")
+	ErrBotCommandInvalid            = NewTelegramError(400, "Bad Request: BOT_COMMAND_INVALID")
+	ErrBotCommandDescriptionInvalid = NewTelegramError(400, "Bad Request: BOT_COMMAND_DESCRIPTION_INVALID")
+	ErrWrongBotCommandScope         = NewTelegramError(400, "Bad Request: wrong bot command scope type specified")
+	ErrInvalidLanguageCode          = NewTelegramError(400, "Bad Request: invalid language code specified")
+
+	ErrCallbackQueryTooOld = NewTelegramError(400,
+		"Bad Request: query is too old and response timeout expired or query ID is invalid")
+
+	ErrWrongFileIdentifier       = NewTelegramError(400, "Bad Request: wrong file identifier/HTTP URL specified")
+	ErrFailedToGetHTTPURLContent = NewTelegramError(400, "Bad Request: failed to get HTTP URL content")
+	ErrImageProcessFailed        = NewTelegramError(400, "Bad Request: IMAGE_PROCESS_FAILED")
+	ErrRequestEntityTooLarge     = NewTelegramError(413, "Request Entity Too Large")
+	ErrInvalidFileID             = NewTelegramError(400, "Bad Request: invalid file_id")
+	ErrFileTooBig                = NewTelegramError(400, "Bad Request: file is too big")
+	ErrFileNotFound              = NewTelegramError(404, "Not Found")
+)
+
+// ErrStubRuleNotFound возвращается, если у бота нет правила бота-заглушки с указанным ID
+var ErrStubRuleNotFound = errors.New("правило бота-заглушки не найдено")
+
+// NotEnoughRightsToSend возвращает ошибку отправки сообщения типа messageType без соответствующего разрешения
+func NotEnoughRightsToSend(messageType string) *TelegramError {
+	switch messageType {
+	case MessageTypeText:
+		return ErrNotEnoughRightsToSendText
+	case MessageTypePhoto:
+		return ErrNotEnoughRightsToSendPhotos
+	case MessageTypeFile:
+		return ErrNotEnoughRightsToSendDocuments
+	case MessageTypeAudio:
+		return ErrNotEnoughRightsToSendAudios
+	case MessageTypeVoice:
+		return ErrNotEnoughRightsToSendVoices
+	case MessageTypeVideo:
+		return ErrNotEnoughRightsToSendVideos
+	default:
+		return ErrNotEnoughRightsToSendOther
+	}
+}
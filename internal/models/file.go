@@ -0,0 +1,281 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы файлов, хранящихся в эмуляторе
+const (
+	FileTypePhoto    = "photo"
+	FileTypeDocument = "document"
+	FileTypeAudio    = "audio"
+	FileTypeVoice    = "voice"
+	FileTypeVideo    = "video"
+)
+
+// File представляет файл, загруженный в эмулятор.
+// Содержимое файла хранится на диске в каталоге файлов по пути FilePath
+type File struct {
+	ID           int64     `json:"id" gorm:"primaryKey"`
+	FileID       string    `json:"file_id" gorm:"uniqueIndex"`
+	FileUniqueID string    `json:"file_unique_id" gorm:"index"`
+	Type         string    `json:"type"` // photo, document, audio, voice, video
+	FileName     string    `json:"file_name,omitempty"`
+	MimeType     string    `json:"mime_type,omitempty"`
+	FileSize     int64     `json:"file_size"`
+	FilePath     string    `json:"file_path"`
+	Width        int       `json:"width,omitempty"`
+	Height       int       `json:"height,omitempty"`
+	Duration     int       `json:"duration,omitempty"`
+	GroupID      string    `json:"-" gorm:"index"` // Общий идентификатор всех размеров одной фотографии
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName возвращает имя таблицы для модели File
+func (File) TableName() string {
+	return "files"
+}
+
+// FileTypeLabel возвращает название типа файла в сообщениях об ошибках Telegram
+func FileTypeLabel(fileType string) string {
+	switch fileType {
+	case FileTypePhoto:
+		return "Photo"
+	case FileTypeAudio:
+		return "Audio"
+	case FileTypeVoice:
+		return "Voice"
+	case FileTypeVideo:
+		return "Video"
+	default:
+		return "Document"
+	}
+}
+
+// MessageTypeForFile возвращает тип сообщения с вложением типа fileType
+func MessageTypeForFile(fileType string) string {
+	switch fileType {
+	case FileTypePhoto:
+		return MessageTypePhoto
+	case FileTypeAudio:
+		return MessageTypeAudio
+	case FileTypeVoice:
+		return MessageTypeVoice
+	case FileTypeVideo:
+		return MessageTypeVideo
+	default:
+		return MessageTypeFile
+	}
+}
+
+// FileTypeForMessage возвращает тип вложения сообщения типа messageType.
+// Для документов принимаются оба названия: file и document
+func FileTypeForMessage(messageType string) (string, bool) {
+	switch messageType {
+	case MessageTypePhoto:
+		return FileTypePhoto, true
+	case MessageTypeFile, FileTypeDocument:
+		return FileTypeDocument, true
+	case MessageTypeAudio:
+		return FileTypeAudio, true
+	case MessageTypeVoice:
+		return FileTypeVoice, true
+	case MessageTypeVideo:
+		return FileTypeVideo, true
+	default:
+		return "", false
+	}
+}
+
+// ToPhotoSize конвертирует файл в размер фотографии Telegram Bot API
+func (f *File) ToPhotoSize() PhotoSize {
+	return PhotoSize{
+		FileID:       f.FileID,
+		FileUniqueID: f.FileUniqueID,
+		Width:        f.Width,
+		Height:       f.Height,
+		FileSize:     f.FileSize,
+	}
+}
+
+// ToDocument конвертирует файл в документ Telegram Bot API
+func (f *File) ToDocument() *Document {
+	return &Document{
+		FileID:       f.FileID,
+		FileUniqueID: f.FileUniqueID,
+		FileName:     f.FileName,
+		MimeType:     f.MimeType,
+		FileSize:     f.FileSize,
+	}
+}
+
+// ToAudio конвертирует файл в аудио Telegram Bot API
+func (f *File) ToAudio(performer, title string) *Audio {
+	return &Audio{
+		FileID:       f.FileID,
+		FileUniqueID: f.FileUniqueID,
+		Duration:     f.Duration,
+		Performer:    performer,
+		Title:        title,
+		FileName:     f.FileName,
+		MimeType:     f.MimeType,
+		FileSize:     f.FileSize,
+	}
+}
+
+// ToVoice конвертирует файл в голосовое сообщение Telegram Bot API
+func (f *File) ToVoice() *Voice {
+	return &Voice{
+		FileID:       f.FileID,
+		FileUniqueID: f.FileUniqueID,
+		Duration:     f.Duration,
+		MimeType:     f.MimeType,
+		FileSize:     f.FileSize,
+	}
+}
+
+// ToVideo конвертирует файл в видео Telegram Bot API
+func (f *File) ToVideo() *Video {
+	return &Video{
+		FileID:       f.FileID,
+		FileUniqueID: f.FileUniqueID,
+		Width:        f.Width,
+		Height:       f.Height,
+		Duration:     f.Duration,
+		FileName:     f.FileName,
+		MimeType:     f.MimeType,
+		FileSize:     f.FileSize,
+	}
+}
+
+// ToTelegramFile конвертирует файл в объект File Telegram Bot API, возвращаемый getFile
+func (f *File) ToTelegramFile() TelegramFile {
+	return TelegramFile{
+		FileID:       f.FileID,
+		FileUniqueID: f.FileUniqueID,
+		FileSize:     f.FileSize,
+		FilePath:     f.FilePath,
+	}
+}
+
+// TelegramFile представляет файл, готовый к скачиванию, в формате Telegram Bot API
+type TelegramFile struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	FileSize     int64  `json:"file_size,omitempty"`
+	FilePath     string `json:"file_path,omitempty"`
+}
+
+// PhotoSize представляет один размер фотографии в формате Telegram Bot API
+type PhotoSize struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	FileSize     int64  `json:"file_size,omitempty"`
+}
+
+// Document представляет документ в формате Telegram Bot API
+type Document struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	FileName     string `json:"file_name,omitempty"`
+	MimeType     string `json:"mime_type,omitempty"`
+	FileSize     int64  `json:"file_size,omitempty"`
+}
+
+// Audio представляет аудиофайл в формате Telegram Bot API
+type Audio struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	Duration     int    `json:"duration"`
+	Performer    string `json:"performer,omitempty"`
+	Title        string `json:"title,omitempty"`
+	FileName     string `json:"file_name,omitempty"`
+	MimeType     string `json:"mime_type,omitempty"`
+	FileSize     int64  `json:"file_size,omitempty"`
+}
+
+// Voice представляет голосовое сообщение в формате Telegram Bot API
+type Voice struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	Duration     int    `json:"duration"`
+	MimeType     string `json:"mime_type,omitempty"`
+	FileSize     int64  `json:"file_size,omitempty"`
+}
+
+// Video представляет видео в формате Telegram Bot API
+type Video struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	Duration     int    `json:"duration"`
+	FileName     string `json:"file_name,omitempty"`
+	MimeType     string `json:"mime_type,omitempty"`
+	FileSize     int64  `json:"file_size,omitempty"`
+}
+
+// MessageMedia представляет вложение сообщения: фотографию (все размеры), документ, аудио, голосовое или видео
+type MessageMedia struct {
+	Photo    []PhotoSize `json:"photo,omitempty"`
+	Document *Document   `json:"document,omitempty"`
+	Audio    *Audio      `json:"audio,omitempty"`
+	Voice    *Voice      `json:"voice,omitempty"`
+	Video    *Video      `json:"video,omitempty"`
+}
+
+// FileID возвращает file_id вложения. Для фотографии возвращается самый крупный размер
+func (m *MessageMedia) FileID() string {
+	switch {
+	case len(m.Photo) > 0:
+		return m.Photo[len(m.Photo)-1].FileID
+	case m.Document != nil:
+		return m.Document.FileID
+	case m.Audio != nil:
+		return m.Audio.FileID
+	case m.Voice != nil:
+		return m.Voice.FileID
+	case m.Video != nil:
+		return m.Video.FileID
+	default:
+		return ""
+	}
+}
+
+// SetMedia устанавливает вложение сообщения и сериализует его в JSON
+func (m *Message) SetMedia(media *MessageMedia) error {
+	if media == nil {
+		m.MediaJSON = ""
+		return nil
+	}
+
+	jsonData, err := json.Marshal(media)
+	if err != nil {
+		return err
+	}
+
+	m.MediaJSON = string(jsonData)
+	return nil
+}
+
+// GetMedia десериализует вложение сообщения из JSON
+func (m *Message) GetMedia() *MessageMedia {
+	if m.MediaJSON == "" {
+		return nil
+	}
+
+	var media MessageMedia
+	if err := json.Unmarshal([]byte(m.MediaJSON), &media); err != nil {
+		return nil
+	}
+
+	return &media
+}
+
+// HasMedia проверяет, содержит ли сообщение вложение
+func (m *Message) HasMedia() bool {
+	return m.MediaJSON != ""
+}
@@ -0,0 +1,46 @@
+package models
+
+import "testing"
+
+func TestFileTypeForMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		messageType string
+		fileType    string
+		ok          bool
+	}{
+		{name: "Фотография", messageType: MessageTypePhoto, fileType: FileTypePhoto, ok: true},
+		{name: "Файл", messageType: MessageTypeFile, fileType: FileTypeDocument, ok: true},
+		{name: "Документ", messageType: "document", fileType: FileTypeDocument, ok: true},
+		{name: "Голосовое", messageType: MessageTypeVoice, fileType: FileTypeVoice, ok: true},
+		{name: "Текст", messageType: MessageTypeText, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileType, ok := FileTypeForMessage(tt.messageType)
+			if fileType != tt.fileType || ok != tt.ok {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", tt.fileType, tt.ok, fileType, ok)
+			}
+			if ok && MessageTypeForFile(fileType) != tt.messageType && tt.messageType != "document" {
+				t.Errorf("Expected message type %q for file type %q, got %q", tt.messageType, fileType, MessageTypeForFile(fileType))
+			}
+		})
+	}
+}
+
+func TestMessageMedia_FileID(t *testing.T) {
+	photo := &MessageMedia{Photo: []PhotoSize{{FileID: "small"}, {FileID: "large"}}}
+	if photo.FileID() != "large" {
+		t.Errorf("Expected the largest photo size, got %q", photo.FileID())
+	}
+
+	document := &MessageMedia{Document: &Document{FileID: "doc"}}
+	if document.FileID() != "doc" {
+		t.Errorf("Expected document file_id, got %q", document.FileID())
+	}
+
+	if (&MessageMedia{}).FileID() != "" {
+		t.Error("Expected empty file_id for empty media")
+	}
+}
@@ -0,0 +1,682 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Режимы форматирования текста (parse_mode)
+const (
+	ParseModeHTML       = "HTML"
+	ParseModeMarkdown   = "Markdown"
+	ParseModeMarkdownV2 = "MarkdownV2"
+)
+
+// Типы сущностей сообщения
+const (
+	EntityTypeMention              = "mention"
+	EntityTypeHashtag              = "hashtag"
+	EntityTypeBotCommand           = "bot_command"
+	EntityTypeURL                  = "url"
+	EntityTypeBold                 = "bold"
+	EntityTypeItalic               = "italic"
+	EntityTypeUnderline            = "underline"
+	EntityTypeStrikethrough        = "strikethrough"
+	EntityTypeSpoiler              = "spoiler"
+	EntityTypeCode                 = "code"
+	EntityTypePre                  = "pre"
+	EntityTypeTextLink             = "text_link"
+	EntityTypeTextMention          = "text_mention"
+	EntityTypeBlockquote           = "blockquote"
+	EntityTypeExpandableBlockquote = "expandable_blockquote"
+	EntityTypeCustomEmoji          = "custom_emoji"
+)
+
+// Ссылки, которые превращаются в text_mention и custom_emoji вместо text_link
+const (
+	userMentionURLPrefix = "tg://user?id="
+	customEmojiURLPrefix = "tg://emoji?id="
+)
+
+// ErrUnsupportedParseMode возвращается для неизвестного режима форматирования
+var ErrUnsupportedParseMode = NewTelegramError(400, "Bad Request: unsupported parse_mode")
+
+// ParseFormattedText разбирает разметку текста в режиме parseMode.
+// Возвращает текст без разметки и сущности форматирования со смещениями в UTF-16.
+// Пустой parseMode означает текст без разметки
+func ParseFormattedText(text, parseMode string) (string, []MessageEntity, error) {
+	if parseMode == "" {
+		return text, nil, nil
+	}
+
+	if !utf8.ValidString(text) {
+		return "", nil, NewTelegramError(400, "Bad Request: text must be encoded in UTF-8")
+	}
+
+	var (
+		plain    string
+		entities []MessageEntity
+		err      error
+	)
+
+	switch strings.ToLower(parseMode) {
+	case strings.ToLower(ParseModeHTML):
+		plain, entities, err = parseHTML(text)
+	case strings.ToLower(ParseModeMarkdownV2):
+		plain, entities, err = parseMarkdownV2(text)
+	case strings.ToLower(ParseModeMarkdown):
+		plain, entities, err = parseMarkdown(text)
+	default:
+		return "", nil, ErrUnsupportedParseMode
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	sortEntities(entities)
+	return plain, entities, nil
+}
+
+// entityParseError создает ошибку разбора разметки в формате Telegram
+func entityParseError(format string, args ...interface{}) error {
+	return NewTelegramError(400, "Bad Request: can't parse entities: "+fmt.Sprintf(format, args...))
+}
+
+// sortEntities упорядочивает сущности как Telegram: по смещению, внешние раньше вложенных
+func sortEntities(entities []MessageEntity) {
+	sort.SliceStable(entities, func(i, j int) bool {
+		if entities[i].Offset != entities[j].Offset {
+			return entities[i].Offset < entities[j].Offset
+		}
+		return entities[i].Length > entities[j].Length
+	})
+}
+
+// formattedTextBuilder собирает текст без разметки и отслеживает его длину в UTF-16
+type formattedTextBuilder struct {
+	text     strings.Builder
+	offset   int
+	entities []MessageEntity
+}
+
+// writeString добавляет текст
+func (b *formattedTextBuilder) writeString(s string) {
+	b.text.WriteString(s)
+	b.offset += UTF16Len(s)
+}
+
+// writeRune добавляет символ
+func (b *formattedTextBuilder) writeRune(r rune) {
+	b.writeString(string(r))
+}
+
+// addEntity добавляет сущность от смещения start до текущей позиции, пустые сущности отбрасываются
+func (b *formattedTextBuilder) addEntity(entity MessageEntity, start int) {
+	if b.offset <= start {
+		return
+	}
+	entity.Offset = start
+	entity.Length = b.offset - start
+	b.entities = append(b.entities, entity)
+}
+
+// linkEntity создает сущность ссылки, распознавая упоминания пользователей по tg://user?id=
+func linkEntity(url string) (MessageEntity, bool) {
+	if strings.HasPrefix(url, userMentionURLPrefix) {
+		userID, err := strconv.ParseInt(strings.TrimPrefix(url, userMentionURLPrefix), 10, 64)
+		if err != nil || userID <= 0 {
+			return MessageEntity{}, false
+		}
+		return MessageEntity{Type: EntityTypeTextMention, User: &User{ID: userID}}, true
+	}
+	if url == "" {
+		return MessageEntity{}, false
+	}
+	return MessageEntity{Type: EntityTypeTextLink, URL: url}, true
+}
+
+// customEmojiID извлекает идентификатор эмодзи из ссылки tg://emoji?id=
+func customEmojiID(url string) (string, bool) {
+	if !strings.HasPrefix(url, customEmojiURLPrefix) {
+		return "", false
+	}
+	id := strings.TrimPrefix(url, customEmojiURLPrefix)
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return "", false
+	}
+	return id, true
+}
+
+// htmlTag представляет открытый HTML тег
+type htmlTag struct {
+	name       string
+	entity     MessageEntity
+	start      int
+	language   string // Язык блока <code> внутри <pre>
+	skipEntity bool   // Тег не создает сущность (например, <a> без ссылки)
+}
+
+// parseHTML разбирает разметку HTML
+func parseHTML(text string) (string, []MessageEntity, error) {
+	var b formattedTextBuilder
+	var stack []*htmlTag
+
+	for i := 0; i < len(text); {
+		switch text[i] {
+		case '<':
+			if i+1 < len(text) && text[i+1] == '/' {
+				end := strings.IndexByte(text[i:], '>')
+				if end < 0 {
+					return "", nil, entityParseError("Unclosed end tag at byte offset %d", i)
+				}
+				name := strings.ToLower(strings.TrimSpace(text[i+2 : i+end]))
+				if len(stack) == 0 {
+					return "", nil, entityParseError("Unexpected end tag at byte offset %d", i)
+				}
+
+				tag := stack[len(stack)-1]
+				if name != tag.name {
+					return "", nil, entityParseError("Unmatched end tag at byte offset %d, expected \"</%s>\", found \"</%s>\"", i, tag.name, name)
+				}
+				stack = stack[:len(stack)-1]
+
+				// <pre><code class="language-x">...</code></pre> задает язык блока кода
+				if tag.language != "" && len(stack) > 0 && stack[len(stack)-1].name == "pre" {
+					stack[len(stack)-1].entity.Language = tag.language
+				} else if !tag.skipEntity {
+					b.addEntity(tag.entity, tag.start)
+				}
+				i += end + 1
+				continue
+			}
+
+			tag, length, err := parseHTMLStartTag(text, i)
+			if err != nil {
+				return "", nil, err
+			}
+			if tag.name == "code" && tag.language != "" && (len(stack) == 0 || stack[len(stack)-1].name != "pre") {
+				tag.language = ""
+			}
+			tag.start = b.offset
+			stack = append(stack, tag)
+			i += length
+
+		case '&':
+			r, length := decodeHTMLEntity(text[i:])
+			if length == 0 {
+				b.writeString("&")
+				i++
+				continue
+			}
+			b.writeRune(r)
+			i += length
+
+		default:
+			r, size := utf8.DecodeRuneInString(text[i:])
+			b.writeRune(r)
+			i += size
+		}
+	}
+
+	if len(stack) > 0 {
+		return "", nil, entityParseError("Can't find end tag corresponding to start tag \"%s\"", stack[len(stack)-1].name)
+	}
+
+	return b.text.String(), b.entities, nil
+}
+
+// parseHTMLStartTag разбирает открывающий тег, начинающийся в позиции offset.
+// Возвращает тег и длину разобранного фрагмента
+func parseHTMLStartTag(text string, offset int) (*htmlTag, int, error) {
+	i := offset + 1
+	nameStart := i
+	for i < len(text) && !isHTMLSpace(text[i]) && text[i] != '>' && text[i] != '/' {
+		i++
+	}
+	name := strings.ToLower(text[nameStart:i])
+
+	attributes := make(map[string]string)
+	for {
+		for i < len(text) && (isHTMLSpace(text[i]) || text[i] == '/') {
+			i++
+		}
+		if i >= len(text) {
+			return nil, 0, entityParseError("Unclosed start tag at byte offset %d", offset)
+		}
+		if text[i] == '>' {
+			i++
+			break
+		}
+
+		attrStart := i
+		for i < len(text) && !isHTMLSpace(text[i]) && text[i] != '=' && text[i] != '>' && text[i] != '/' {
+			i++
+		}
+		attrName := strings.ToLower(text[attrStart:i])
+		if attrName == "" {
+			return nil, 0, entityParseError("Empty attribute name in the tag \"%s\" at byte offset %d", name, offset)
+		}
+
+		value := ""
+		if i < len(text) && text[i] == '=' {
+			i++
+			if i >= len(text) {
+				return nil, 0, entityParseError("Unclosed start tag at byte offset %d", offset)
+			}
+
+			var raw string
+			if quote := text[i]; quote == '"' || quote == '\'' {
+				end := strings.IndexByte(text[i+1:], quote)
+				if end < 0 {
+					return nil, 0, entityParseError("Unclosed start tag at byte offset %d", offset)
+				}
+				raw = text[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				valueStart := i
+				for i < len(text) && !isHTMLSpace(text[i]) && text[i] != '>' {
+					i++
+				}
+				raw = text[valueStart:i]
+			}
+			value = decodeHTMLText(raw)
+		}
+		attributes[attrName] = value
+	}
+
+	tag := &htmlTag{name: name}
+	switch name {
+	case "b", "strong":
+		tag.entity = MessageEntity{Type: EntityTypeBold}
+	case "i", "em":
+		tag.entity = MessageEntity{Type: EntityTypeItalic}
+	case "u", "ins":
+		tag.entity = MessageEntity{Type: EntityTypeUnderline}
+	case "s", "strike", "del":
+		tag.entity = MessageEntity{Type: EntityTypeStrikethrough}
+	case "tg-spoiler":
+		tag.entity = MessageEntity{Type: EntityTypeSpoiler}
+	case "span":
+		if attributes["class"] != "tg-spoiler" {
+			return nil, 0, entityParseError("Tag \"span\" must have class \"tg-spoiler\" at byte offset %d", offset)
+		}
+		tag.entity = MessageEntity{Type: EntityTypeSpoiler}
+	case "a":
+		entity, ok := linkEntity(attributes["href"])
+		tag.entity = entity
+		tag.skipEntity = !ok
+	case "code":
+		tag.entity = MessageEntity{Type: EntityTypeCode}
+		if class := attributes["class"]; strings.HasPrefix(class, "language-") {
+			tag.language = strings.TrimPrefix(class, "language-")
+		}
+	case "pre":
+		tag.entity = MessageEntity{Type: EntityTypePre}
+	case "blockquote":
+		tag.entity = MessageEntity{Type: EntityTypeBlockquote}
+		if _, expandable := attributes["expandable"]; expandable {
+			tag.entity.Type = EntityTypeExpandableBlockquote
+		}
+	case "tg-emoji":
+		id := attributes["emoji-id"]
+		if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+			return nil, 0, entityParseError("Tag \"tg-emoji\" must have attribute \"emoji-id\" at byte offset %d", offset)
+		}
+		tag.entity = MessageEntity{Type: EntityTypeCustomEmoji, CustomEmojiID: id}
+	default:
+		return nil, 0, entityParseError("Unsupported start tag \"%s\" at byte offset %d", name, offset)
+	}
+
+	return tag, i - offset, nil
+}
+
+// isHTMLSpace проверяет, является ли байт пробельным символом HTML
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// decodeHTMLEntity декодирует ссылку на символ (&lt;, &#62; и т.п.) в начале строки.
+// Возвращает нулевую длину, если строка не начинается с поддерживаемой ссылки
+func decodeHTMLEntity(s string) (rune, int) {
+	end := strings.IndexByte(s, ';')
+	if end < 2 || end > 10 {
+		return 0, 0
+	}
+
+	name := s[1:end]
+	switch name {
+	case "lt":
+		return '<', end + 1
+	case "gt":
+		return '>', end + 1
+	case "amp":
+		return '&', end + 1
+	case "quot":
+		return '"', end + 1
+	}
+
+	if name[0] != '#' {
+		return 0, 0
+	}
+
+	var code int64
+	var err error
+	if len(name) > 1 && (name[1] == 'x' || name[1] == 'X') {
+		code, err = strconv.ParseInt(name[2:], 16, 32)
+	} else {
+		code, err = strconv.ParseInt(name[1:], 10, 32)
+	}
+	if err != nil || code <= 0 || code > utf8.MaxRune || !utf8.ValidRune(rune(code)) {
+		return 0, 0
+	}
+	return rune(code), end + 1
+}
+
+// decodeHTMLText декодирует ссылки на символы в значении атрибута
+func decodeHTMLText(s string) string {
+	if !strings.Contains(s, "&") {
+		return s
+	}
+
+	var result strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] == '&' {
+			if r, length := decodeHTMLEntity(s[i:]); length > 0 {
+				result.WriteRune(r)
+				i += length
+				continue
+			}
+		}
+		result.WriteByte(s[i])
+		i++
+	}
+	return result.String()
+}
+
+// markdownEntity представляет открытую сущность MarkdownV2
+type markdownEntity struct {
+	entity     MessageEntity
+	name       string // Название сущности для сообщений об ошибках
+	start      int
+	textStart  int // Позиция начала сущности в байтах текста без разметки
+	byteOffset int
+}
+
+// markdownV2Reserved содержит символы, которые в MarkdownV2 нужно экранировать
+const markdownV2Reserved = "_*[]()~`>#+-=|{}.!"
+
+// parseMarkdownV2 разбирает разметку MarkdownV2
+func parseMarkdownV2(text string) (string, []MessageEntity, error) {
+	var b formattedTextBuilder
+	var stack []markdownEntity
+
+	quoteStart := -1 // Начало текущей цитаты или -1
+	top := func() *markdownEntity {
+		if len(stack) == 0 {
+			return nil
+		}
+		return &stack[len(stack)-1]
+	}
+	open := func(entity MessageEntity, name string, byteOffset int) {
+		stack = append(stack, markdownEntity{entity: entity, name: name, start: b.offset, textStart: b.text.Len(), byteOffset: byteOffset})
+	}
+	closeTop := func() {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		b.addEntity(current.entity, current.start)
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+
+		// Экранированный символ выводится как есть
+		if c == '\\' && i+1 < len(text) && text[i+1] > 0 && text[i+1] <= 126 {
+			b.writeString(text[i+1 : i+2])
+			i += 2
+			continue
+		}
+
+		// Внутри code и pre значим только обратный апостроф
+		if current := top(); current != nil && (current.entity.Type == EntityTypeCode || current.entity.Type == EntityTypePre) {
+			if c != '`' {
+				r, size := utf8.DecodeRuneInString(text[i:])
+				b.writeRune(r)
+				i += size
+				continue
+			}
+			if current.entity.Type == EntityTypeCode {
+				closeTop()
+				i++
+				continue
+			}
+			if strings.HasPrefix(text[i:], "```") {
+				closeTop()
+				i += 3
+				continue
+			}
+			return "", nil, entityParseError("Character '%c' is reserved and must be escaped with the preceding '\\'", c)
+		}
+
+		// Цитата: строки, начинающиеся с '>'
+		if c == '>' && (i == 0 || text[i-1] == '\n') {
+			if quoteStart < 0 {
+				quoteStart = b.offset
+			}
+			i++
+			continue
+		}
+		if c == '\n' && quoteStart >= 0 && (i+1 >= len(text) || text[i+1] != '>') {
+			b.addEntity(MessageEntity{Type: EntityTypeBlockquote}, quoteStart)
+			quoteStart = -1
+		}
+
+		if !strings.ContainsRune(markdownV2Reserved, rune(c)) {
+			r, size := utf8.DecodeRuneInString(text[i:])
+			b.writeRune(r)
+			i += size
+			continue
+		}
+
+		current := top()
+		switch {
+		case c == '_' && current != nil && current.entity.Type == EntityTypeUnderline && strings.HasPrefix(text[i:], "__"):
+			closeTop()
+			i += 2
+		case c == '_' && current != nil && current.entity.Type == EntityTypeItalic:
+			closeTop()
+			i++
+		case c == '_' && strings.HasPrefix(text[i:], "__"):
+			open(MessageEntity{Type: EntityTypeUnderline}, "Underline", i)
+			i += 2
+		case c == '_':
+			open(MessageEntity{Type: EntityTypeItalic}, "Italic", i)
+			i++
+		case c == '*' && current != nil && current.entity.Type == EntityTypeBold:
+			closeTop()
+			i++
+		case c == '*':
+			open(MessageEntity{Type: EntityTypeBold}, "Bold", i)
+			i++
+		case c == '~' && current != nil && current.entity.Type == EntityTypeStrikethrough:
+			closeTop()
+			i++
+		case c == '~':
+			open(MessageEntity{Type: EntityTypeStrikethrough}, "Strikethrough", i)
+			i++
+		case c == '|' && strings.HasPrefix(text[i:], "||"):
+			if current != nil && current.entity.Type == EntityTypeSpoiler {
+				closeTop()
+			} else {
+				open(MessageEntity{Type: EntityTypeSpoiler}, "Spoiler", i)
+			}
+			i += 2
+		case c == '[':
+			open(MessageEntity{Type: EntityTypeTextLink}, "TextUrl", i)
+			i++
+		case c == '!' && strings.HasPrefix(text[i:], "!["):
+			open(MessageEntity{Type: EntityTypeCustomEmoji}, "CustomEmoji", i)
+			i += 2
+		case c == ']' && current != nil && (current.entity.Type == EntityTypeTextLink || current.entity.Type == EntityTypeCustomEmoji):
+			url, length, err := parseMarkdownV2URL(text, i+1)
+			if err != nil {
+				return "", nil, err
+			}
+			if length == 0 {
+				// Без явной ссылки адресом служит сам текст
+				url = b.text.String()[current.textStart:]
+			}
+			i += 1 + length
+
+			entity := *current
+			stack = stack[:len(stack)-1]
+			if entity.entity.Type == EntityTypeCustomEmoji {
+				id, ok := customEmojiID(url)
+				if !ok {
+					return "", nil, entityParseError("Custom emoji entity must contain a tg://emoji URL")
+				}
+				b.addEntity(MessageEntity{Type: EntityTypeCustomEmoji, CustomEmojiID: id}, entity.start)
+				continue
+			}
+			if link, ok := linkEntity(url); ok {
+				b.addEntity(link, entity.start)
+			}
+		case c == '`' && strings.HasPrefix(text[i:], "```"):
+			i += 3
+			entity := MessageEntity{Type: EntityTypePre}
+			byteOffset := i - 3
+			if newline := strings.IndexByte(text[i:], '\n'); newline >= 0 {
+				language := text[i : i+newline]
+				if !strings.ContainsAny(language, " \t`") {
+					entity.Language = language
+					i += newline + 1
+				}
+			}
+			open(entity, "Pre", byteOffset)
+		case c == '`':
+			open(MessageEntity{Type: EntityTypeCode}, "Code", i)
+			i++
+		default:
+			return "", nil, entityParseError("Character '%c' is reserved and must be escaped with the preceding '\\'", c)
+		}
+	}
+
+	if current := top(); current != nil {
+		return "", nil, entityParseError("Can't find end of %s entity at byte offset %d", current.name, current.byteOffset)
+	}
+	if quoteStart >= 0 {
+		b.addEntity(MessageEntity{Type: EntityTypeBlockquote}, quoteStart)
+	}
+
+	return b.text.String(), b.entities, nil
+}
+
+// parseMarkdownV2URL разбирает адрес ссылки "(url)", начинающийся в позиции offset.
+// Возвращает нулевую длину, если адрес не указан
+func parseMarkdownV2URL(text string, offset int) (string, int, error) {
+	if offset >= len(text) || text[offset] != '(' {
+		return "", 0, nil
+	}
+
+	var url strings.Builder
+	for i := offset + 1; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && i+1 < len(text) && (text[i+1] == ')' || text[i+1] == '\\'):
+			url.WriteByte(text[i+1])
+			i++
+		case text[i] == ')':
+			return url.String(), i - offset + 1, nil
+		default:
+			url.WriteByte(text[i])
+		}
+	}
+
+	return "", 0, entityParseError("Can't find end of a URL at byte offset %d", offset)
+}
+
+// parseMarkdown разбирает устаревшую разметку Markdown: без вложенности,
+// экранирование символов '_', '*', '`' и '[' обратной косой чертой
+func parseMarkdown(text string) (string, []MessageEntity, error) {
+	var b formattedTextBuilder
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		if c == '\\' && i+1 < len(text) && strings.IndexByte("_*`[", text[i+1]) >= 0 {
+			b.writeString(text[i+1 : i+2])
+			i += 2
+			continue
+		}
+		if strings.IndexByte("_*`[", c) < 0 {
+			r, size := utf8.DecodeRuneInString(text[i:])
+			b.writeRune(r)
+			i += size
+			continue
+		}
+
+		beginOffset := i
+		endMarker := string(c)
+		entity := MessageEntity{}
+		switch c {
+		case '_':
+			entity.Type = EntityTypeItalic
+		case '*':
+			entity.Type = EntityTypeBold
+		case '[':
+			entity.Type = EntityTypeTextLink
+			endMarker = "]"
+		case '`':
+			entity.Type = EntityTypeCode
+			if strings.HasPrefix(text[i:], "```") {
+				entity.Type = EntityTypePre
+				endMarker = "```"
+			}
+		}
+		i += len(endMarker)
+
+		if entity.Type == EntityTypePre {
+			// Язык указывается в первой строке блока
+			languageEnd := i
+			for languageEnd < len(text) && !isHTMLSpace(text[languageEnd]) && text[languageEnd] != '`' {
+				languageEnd++
+			}
+			if languageEnd < len(text) && text[languageEnd] == '\n' {
+				entity.Language = text[i:languageEnd]
+				i = languageEnd + 1
+			}
+		}
+
+		end := strings.Index(text[i:], endMarker)
+		if end < 0 {
+			return "", nil, entityParseError("Can't find end of the entity starting at byte offset %d", beginOffset)
+		}
+
+		start := b.offset
+		content := text[i : i+end]
+		b.writeString(content)
+		i += end + len(endMarker)
+
+		if entity.Type == EntityTypeTextLink {
+			url := content
+			if i < len(text) && text[i] == '(' {
+				urlEnd := strings.IndexByte(text[i:], ')')
+				if urlEnd < 0 {
+					return "", nil, entityParseError("Can't find end of the entity starting at byte offset %d", beginOffset)
+				}
+				url = text[i+1 : i+urlEnd]
+				i += urlEnd + 1
+			}
+
+			link, ok := linkEntity(url)
+			if !ok {
+				continue
+			}
+			entity = link
+		}
+
+		b.addEntity(entity, start)
+	}
+
+	return b.text.String(), b.entities, nil
+}
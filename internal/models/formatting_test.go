@@ -0,0 +1,247 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFormattedText(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		parseMode string
+		plain     string
+		expected  []MessageEntity
+	}{
+		{
+			name:      "Без разметки",
+			text:      "*not bold*",
+			parseMode: "",
+			plain:     "*not bold*",
+		},
+		{
+			name:      "HTML: вложенные теги",
+			text:      "<b>bold <i>italic</i></b> <u>u</u> <s>s</s> <tg-spoiler>x</tg-spoiler>",
+			parseMode: ParseModeHTML,
+			plain:     "bold italic u s x",
+			expected: []MessageEntity{
+				{Type: EntityTypeBold, Offset: 0, Length: 11},
+				{Type: EntityTypeItalic, Offset: 5, Length: 6},
+				{Type: EntityTypeUnderline, Offset: 12, Length: 1},
+				{Type: EntityTypeStrikethrough, Offset: 14, Length: 1},
+				{Type: EntityTypeSpoiler, Offset: 16, Length: 1},
+			},
+		},
+		{
+			name:      "HTML: ссылки и упоминания",
+			text:      `<a href="https://example.com/?a=1&amp;b=2">site</a> <a href="tg://user?id=42">user</a>`,
+			parseMode: ParseModeHTML,
+			plain:     "site user",
+			expected: []MessageEntity{
+				{Type: EntityTypeTextLink, Offset: 0, Length: 4, URL: "https://example.com/?a=1&b=2"},
+				{Type: EntityTypeTextMention, Offset: 5, Length: 4, User: &User{ID: 42}},
+			},
+		},
+		{
+			name:      "HTML: блок кода с языком",
+			text:      `<pre><code class="language-go">x &lt; 1</code></pre>`,
+			parseMode: ParseModeHTML,
+			plain:     "x < 1",
+			expected: []MessageEntity{
+				{Type: EntityTypePre, Offset: 0, Length: 5, Language: "go"},
+			},
+		},
+		{
+			name:      "HTML: цитата и спойлер span",
+			text:      `<blockquote expandable>quote</blockquote><span class="tg-spoiler">s</span>`,
+			parseMode: ParseModeHTML,
+			plain:     "quotes",
+			expected: []MessageEntity{
+				{Type: EntityTypeExpandableBlockquote, Offset: 0, Length: 5},
+				{Type: EntityTypeSpoiler, Offset: 5, Length: 1},
+			},
+		},
+		{
+			name:      "HTML: смещения в UTF-16",
+			text:      "😀 <b>Привет</b>",
+			parseMode: ParseModeHTML,
+			plain:     "😀 Привет",
+			expected: []MessageEntity{
+				{Type: EntityTypeBold, Offset: 3, Length: 6},
+			},
+		},
+		{
+			name:      "MarkdownV2: все виды форматирования",
+			text:      "*bold _italic_* __under__ ~strike~ ||spoiler|| `code`",
+			parseMode: ParseModeMarkdownV2,
+			plain:     "bold italic under strike spoiler code",
+			expected: []MessageEntity{
+				{Type: EntityTypeBold, Offset: 0, Length: 11},
+				{Type: EntityTypeItalic, Offset: 5, Length: 6},
+				{Type: EntityTypeUnderline, Offset: 12, Length: 5},
+				{Type: EntityTypeStrikethrough, Offset: 18, Length: 6},
+				{Type: EntityTypeSpoiler, Offset: 25, Length: 7},
+				{Type: EntityTypeCode, Offset: 33, Length: 4},
+			},
+		},
+		{
+			name:      "MarkdownV2: ссылки и экранирование",
+			text:      `[link](https://example.com/a_\)b) [user](tg://user?id=7) 1\.5\!`,
+			parseMode: ParseModeMarkdownV2,
+			plain:     "link user 1.5!",
+			expected: []MessageEntity{
+				{Type: EntityTypeTextLink, Offset: 0, Length: 4, URL: "https://example.com/a_)b"},
+				{Type: EntityTypeTextMention, Offset: 5, Length: 4, User: &User{ID: 7}},
+			},
+		},
+		{
+			name:      "MarkdownV2: блок кода и цитата",
+			text:      "```python\nprint(1)\n```\n>quote\n>line\nend",
+			parseMode: ParseModeMarkdownV2,
+			plain:     "print(1)\n\nquote\nline\nend",
+			expected: []MessageEntity{
+				{Type: EntityTypePre, Offset: 0, Length: 9, Language: "python"},
+				{Type: EntityTypeBlockquote, Offset: 10, Length: 10},
+			},
+		},
+		{
+			name:      "Markdown: устаревший синтаксис",
+			text:      "*bold* _italic_ `code` [link](https://example.com) \\*",
+			parseMode: ParseModeMarkdown,
+			plain:     "bold italic code link *",
+			expected: []MessageEntity{
+				{Type: EntityTypeBold, Offset: 0, Length: 4},
+				{Type: EntityTypeItalic, Offset: 5, Length: 6},
+				{Type: EntityTypeCode, Offset: 12, Length: 4},
+				{Type: EntityTypeTextLink, Offset: 17, Length: 4, URL: "https://example.com"},
+			},
+		},
+		{
+			name:      "Режим форматирования без учета регистра",
+			text:      "<b>x</b>",
+			parseMode: "html",
+			plain:     "x",
+			expected: []MessageEntity{
+				{Type: EntityTypeBold, Offset: 0, Length: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain, entities, err := ParseFormattedText(tt.text, tt.parseMode)
+			if err != nil {
+				t.Fatalf("ParseFormattedText() error = %v", err)
+			}
+			if plain != tt.plain {
+				t.Errorf("Expected text %q, got %q", tt.plain, plain)
+			}
+			if len(entities) != len(tt.expected) || (len(entities) > 0 && !reflect.DeepEqual(entities, tt.expected)) {
+				t.Errorf("Expected entities %+v, got %+v", tt.expected, entities)
+			}
+		})
+	}
+}
+
+func TestParseFormattedText_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		parseMode   string
+		description string
+	}{
+		{
+			name:        "HTML: незакрытый тег",
+			text:        "<b>bold",
+			parseMode:   ParseModeHTML,
+			description: `Bad Request: can't parse entities: Can't find end tag corresponding to start tag "b"`,
+		},
+		{
+			name:        "HTML: неподдерживаемый тег",
+			text:        "<div>x</div>",
+			parseMode:   ParseModeHTML,
+			description: `Bad Request: can't parse entities: Unsupported start tag "div" at byte offset 0`,
+		},
+		{
+			name:        "HTML: несовпадающий закрывающий тег",
+			text:        "<b><i>x</b></i>",
+			parseMode:   ParseModeHTML,
+			description: `Bad Request: can't parse entities: Unmatched end tag at byte offset 7, expected "</i>", found "</b>"`,
+		},
+		{
+			name:        "HTML: лишний закрывающий тег",
+			text:        "x</b>",
+			parseMode:   ParseModeHTML,
+			description: "Bad Request: can't parse entities: Unexpected end tag at byte offset 1",
+		},
+		{
+			name:        "MarkdownV2: неэкранированный символ",
+			text:        "Hello!",
+			parseMode:   ParseModeMarkdownV2,
+			description: `Bad Request: can't parse entities: Character '!' is reserved and must be escaped with the preceding '\'`,
+		},
+		{
+			name:        "MarkdownV2: незакрытая сущность",
+			text:        "ok *bold",
+			parseMode:   ParseModeMarkdownV2,
+			description: "Bad Request: can't parse entities: Can't find end of Bold entity at byte offset 3",
+		},
+		{
+			name:        "Markdown: незакрытая сущность",
+			text:        "snake_case",
+			parseMode:   ParseModeMarkdown,
+			description: "Bad Request: can't parse entities: Can't find end of the entity starting at byte offset 5",
+		},
+		{
+			name:        "Неизвестный режим",
+			text:        "x",
+			parseMode:   "BBCode",
+			description: "Bad Request: unsupported parse_mode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ParseFormattedText(tt.text, tt.parseMode)
+			var telegramErr *TelegramError
+			if !errors.As(err, &telegramErr) {
+				t.Fatalf("Expected TelegramError, got %v", err)
+			}
+			if telegramErr.ErrorCode != 400 {
+				t.Errorf("Expected error code 400, got %d", telegramErr.ErrorCode)
+			}
+			if telegramErr.Description != tt.description {
+				t.Errorf("Expected description %q, got %q", tt.description, telegramErr.Description)
+			}
+		})
+	}
+}
+
+func TestMessage_SetFormattedText(t *testing.T) {
+	message := &Message{}
+	if err := message.SetFormattedText("<b>Run</b> /start or <code>/help</code>", ParseModeHTML); err != nil {
+		t.Fatalf("SetFormattedText() error = %v", err)
+	}
+
+	if message.Text != "Run /start or /help" {
+		t.Errorf("Expected markup to be stripped, got %q", message.Text)
+	}
+
+	var types []string
+	for _, entity := range message.GetEntities() {
+		types = append(types, entity.Type)
+	}
+	if strings.Join(types, ",") != "bold,bot_command,code" {
+		t.Errorf("Expected bold, bot_command and code entities, got %v", types)
+	}
+
+	// Malformed markup leaves the message untouched
+	if err := message.SetFormattedText("<b>broken", ParseModeHTML); err == nil {
+		t.Error("Expected error for malformed markup")
+	}
+	if message.Text != "Run /start or /help" {
+		t.Errorf("Expected text to stay unchanged, got %q", message.Text)
+	}
+}
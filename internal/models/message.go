@@ -8,18 +8,27 @@ import (
 
 // Message представляет сообщение в эмуляторе
 type Message struct {
-	ID              int64     `json:"id" gorm:"primaryKey"`
-	ChatID          int64     `json:"chat_id"`
-	FromID          int64     `json:"from_id"`
-	From            User      `json:"from" gorm:"foreignKey:FromID"`
-	Text            string    `json:"text"`
-	Type            string    `json:"type"`   // text, file, voice, photo
-	Status          string    `json:"status"` // sending, sent, delivered, read
-	IsOutgoing      bool      `json:"is_outgoing"`
-	Timestamp       time.Time `json:"timestamp"`
-	CreatedAt       time.Time `json:"created_at"`
-	ReplyMarkupJSON string    `json:"reply_markup,omitempty" gorm:"column:reply_markup"` // Клавиатура в JSON формате
-	EntitiesJSON    string    `json:"entities,omitempty" gorm:"column:entities"`         // Сущности в JSON формате
+	ID                int64      `json:"id" gorm:"primaryKey"`
+	ChatID            int64      `json:"chat_id"`
+	Chat              *Chat      `json:"chat,omitempty" gorm:"-"` // Чат на момент отправки, нужен для конвертации в формат Bot API
+	FromID            int64      `json:"from_id"`
+	From              User       `json:"from" gorm:"foreignKey:FromID"`
+	Text              string     `json:"text"`
+	Type              string     `json:"type"`   // text, file, voice, photo
+	Status            string     `json:"status"` // sending, sent, delivered, read
+	IsOutgoing        bool       `json:"is_outgoing"`
+	Timestamp         time.Time  `json:"timestamp"`
+	CreatedAt         time.Time  `json:"created_at"`
+	ReplyMarkupJSON   string     `json:"reply_markup,omitempty" gorm:"column:reply_markup"`     // Клавиатура в JSON формате
+	EntitiesJSON      string     `json:"entities,omitempty" gorm:"column:entities"`             // Сущности в JSON формате
+	EditDate          *time.Time `json:"edit_date,omitempty"`                                   // Время последнего редактирования
+	MediaJSON         string     `json:"media,omitempty" gorm:"column:media"`                   // Вложение в JSON формате
+	ForwardOriginJSON string     `json:"forward_origin,omitempty" gorm:"column:forward_origin"` // Источник пересланного сообщения в JSON формате
+	ReplyToMessageID  *int64     `json:"reply_to_message_id,omitempty" gorm:"index"`            // Сообщение, на которое отвечает это сообщение
+	ReplyToMessage    *Message   `json:"reply_to_message,omitempty" gorm:"-"`                   // Загружается репозиторием, если сообщение не удалено
+
+	NewChatMembersJSON string `json:"new_chat_members,omitempty" gorm:"column:new_chat_members"` // Новые участники служебного сообщения в JSON формате
+	LeftChatMemberJSON string `json:"left_chat_member,omitempty" gorm:"column:left_chat_member"` // Вышедший участник служебного сообщения в JSON формате
 }
 
 // TableName возвращает имя таблицы для модели Message
@@ -41,6 +50,12 @@ const (
 	MessageTypeFile  = "file"
 	MessageTypeVoice = "voice"
 	MessageTypePhoto = "photo"
+	MessageTypeAudio = "audio"
+	MessageTypeVideo = "video"
+
+	// Служебные сообщения о входе и выходе участников группы
+	MessageTypeNewChatMembers = "new_chat_members"
+	MessageTypeLeftChatMember = "left_chat_member"
 )
 
 // SetStatus устанавливает статус сообщения
@@ -73,6 +88,16 @@ func (m *Message) IsPhoto() bool {
 	return m.Type == MessageTypePhoto
 }
 
+// IsService проверяет, является ли сообщение служебным (вход или выход участников)
+func (m *Message) IsService() bool {
+	return m.Type == MessageTypeNewChatMembers || m.Type == MessageTypeLeftChatMember
+}
+
+// IsEdited проверяет, редактировалось ли сообщение
+func (m *Message) IsEdited() bool {
+	return m.EditDate != nil
+}
+
 // SetReplyMarkup устанавливает клавиатуру и сериализует её в JSON
 func (m *Message) SetReplyMarkup(replyMarkup interface{}) error {
 	if replyMarkup == nil {
@@ -135,31 +160,223 @@ func (m *Message) GetEntities() []MessageEntity {
 	return entities
 }
 
+// SetForwardOrigin устанавливает источник пересланного сообщения и сериализует его в JSON
+func (m *Message) SetForwardOrigin(origin *MessageOrigin) error {
+	if origin == nil {
+		m.ForwardOriginJSON = ""
+		return nil
+	}
+
+	jsonData, err := json.Marshal(origin)
+	if err != nil {
+		return err
+	}
+
+	m.ForwardOriginJSON = string(jsonData)
+	return nil
+}
+
+// GetForwardOrigin десериализует источник пересланного сообщения из JSON
+func (m *Message) GetForwardOrigin() *MessageOrigin {
+	if m.ForwardOriginJSON == "" {
+		return nil
+	}
+
+	var origin MessageOrigin
+	if err := json.Unmarshal([]byte(m.ForwardOriginJSON), &origin); err != nil {
+		return nil
+	}
+
+	return &origin
+}
+
+// SetNewChatMembers делает сообщение служебным сообщением о входе участников users
+func (m *Message) SetNewChatMembers(users []TelegramUser) error {
+	jsonData, err := json.Marshal(users)
+	if err != nil {
+		return err
+	}
+
+	m.Type = MessageTypeNewChatMembers
+	m.NewChatMembersJSON = string(jsonData)
+	return nil
+}
+
+// GetNewChatMembers десериализует новых участников служебного сообщения из JSON
+func (m *Message) GetNewChatMembers() []TelegramUser {
+	if m.NewChatMembersJSON == "" {
+		return nil
+	}
+
+	var users []TelegramUser
+	if err := json.Unmarshal([]byte(m.NewChatMembersJSON), &users); err != nil {
+		return nil
+	}
+
+	return users
+}
+
+// SetLeftChatMember делает сообщение служебным сообщением о выходе участника user
+func (m *Message) SetLeftChatMember(user TelegramUser) error {
+	jsonData, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+
+	m.Type = MessageTypeLeftChatMember
+	m.LeftChatMemberJSON = string(jsonData)
+	return nil
+}
+
+// GetLeftChatMember десериализует вышедшего участника служебного сообщения из JSON
+func (m *Message) GetLeftChatMember() *TelegramUser {
+	if m.LeftChatMemberJSON == "" {
+		return nil
+	}
+
+	var user TelegramUser
+	if err := json.Unmarshal([]byte(m.LeftChatMemberJSON), &user); err != nil {
+		return nil
+	}
+
+	return &user
+}
+
+// NewForwardOrigin возвращает источник для пересылки сообщения.
+// Повторно пересылаемое сообщение сохраняет источник оригинала
+func (m *Message) NewForwardOrigin() *MessageOrigin {
+	if origin := m.GetForwardOrigin(); origin != nil {
+		return origin
+	}
+
+	sender := m.From.ToTelegramUser()
+	sender.ID = m.FromID
+	return &MessageOrigin{
+		Type:       MessageOriginUser,
+		Date:       m.Timestamp.Unix(),
+		SenderUser: &sender,
+	}
+}
+
+// SetReplyTo делает сообщение ответом на reply
+func (m *Message) SetReplyTo(reply *Message) {
+	if reply == nil {
+		m.ReplyToMessageID = nil
+		m.ReplyToMessage = nil
+		return
+	}
+
+	replyID := reply.ID
+	m.ReplyToMessageID = &replyID
+	m.ReplyToMessage = reply
+}
+
+// IsReply проверяет, является ли сообщение ответом на другое сообщение
+func (m *Message) IsReply() bool {
+	return m.ReplyToMessageID != nil
+}
+
+// IsForwarded проверяет, является ли сообщение пересланным
+func (m *Message) IsForwarded() bool {
+	return m.ForwardOriginJSON != ""
+}
+
 // ParseAndSetEntities парсит текст сообщения и устанавливает сущности
 func (m *Message) ParseAndSetEntities() error {
 	if m.Text == "" {
 		return nil
 	}
 
+	return m.SetEntities(detectEntities(m.Text))
+}
+
+// SetFormattedText разбирает разметку текста в режиме parseMode (HTML, Markdown, MarkdownV2),
+// сохраняет текст без разметки и устанавливает сущности форматирования вместе с найденными
+// в тексте командами, упоминаниями, хештегами и URL
+func (m *Message) SetFormattedText(text, parseMode string) error {
+	plain, formatting, err := ParseFormattedText(text, parseMode)
+	if err != nil {
+		return err
+	}
+
+	return m.SetTextWithEntities(plain, formatting)
+}
+
+// SetTextWithEntities устанавливает текст с явно заданными сущностями форматирования
+// (entities, caption_entities) и дополняет их найденными в тексте командами, упоминаниями, хештегами и URL.
+// Сущности, выходящие за границы текста, отбрасываются
+func (m *Message) SetTextWithEntities(text string, formatting []MessageEntity) error {
+	m.Text = text
+	if text == "" {
+		return m.SetEntities(nil)
+	}
+
+	var entities []MessageEntity
+	for _, entity := range formatting {
+		if entity.Length > 0 {
+			if _, ok := ExtractEntityText(text, entity); ok {
+				entities = append(entities, entity)
+			}
+		}
+	}
+
+	for _, entity := range detectEntities(text) {
+		// Внутри кода и ссылок Telegram не распознает сущности.
+		// Сущности скопированного сообщения уже содержат найденные ранее команды и ссылки
+		if !overlapsVerbatimEntity(entity, entities) && !containsEntity(entities, entity) {
+			entities = append(entities, entity)
+		}
+	}
+	sortEntities(entities)
+
+	return m.SetEntities(entities)
+}
+
+// detectEntities находит в тексте команды, упоминания, URL и хештеги
+func detectEntities(text string) []MessageEntity {
 	var entities []MessageEntity
 
 	// Парсим команды
-	commandEntities := parseCommands(m.Text)
+	commandEntities := parseCommands(text)
 	entities = append(entities, commandEntities...)
 
 	// Парсим упоминания
-	mentionEntities := parseMentions(m.Text)
+	mentionEntities := parseMentions(text)
 	entities = append(entities, mentionEntities...)
 
 	// Парсим URL (до хештегов, чтобы избежать конфликтов)
-	urlEntities := parseURLs(m.Text)
+	urlEntities := parseURLs(text)
 	entities = append(entities, urlEntities...)
 
 	// Парсим хештеги (после URL)
-	hashtagEntities := parseHashtags(m.Text)
+	hashtagEntities := parseHashtags(text)
 	entities = append(entities, hashtagEntities...)
 
-	return m.SetEntities(entities)
+	sortEntities(entities)
+	return entities
+}
+
+// containsEntity проверяет, есть ли в списке сущность того же типа на том же месте
+func containsEntity(entities []MessageEntity, entity MessageEntity) bool {
+	for _, other := range entities {
+		if other.Type == entity.Type && other.Offset == entity.Offset && other.Length == entity.Length {
+			return true
+		}
+	}
+	return false
+}
+
+// overlapsVerbatimEntity проверяет, пересекается ли сущность с кодом или ссылкой
+func overlapsVerbatimEntity(entity MessageEntity, formatting []MessageEntity) bool {
+	for _, other := range formatting {
+		switch other.Type {
+		case EntityTypeCode, EntityTypePre, EntityTypeTextLink, EntityTypeTextMention:
+			if entity.Offset < other.Offset+other.Length && other.Offset < entity.Offset+entity.Length {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // IsCommand проверяет, является ли сообщение командой
@@ -178,14 +395,19 @@ func (m *Message) GetCommand() string {
 	entities := m.GetEntities()
 	for _, entity := range entities {
 		if entity.Type == "bot_command" {
-			if entity.Offset < len(m.Text) && entity.Offset+entity.Length <= len(m.Text) {
-				return m.Text[entity.Offset : entity.Offset+entity.Length]
+			if command, ok := m.EntityText(entity); ok {
+				return command
 			}
 		}
 	}
 	return ""
 }
 
+// EntityText возвращает фрагмент текста сообщения, который занимает сущность
+func (m *Message) EntityText(entity MessageEntity) (string, bool) {
+	return ExtractEntityText(m.Text, entity)
+}
+
 // parseCommands парсит команды в тексте
 func parseCommands(text string) []MessageEntity {
 	var entities []MessageEntity
@@ -193,11 +415,7 @@ func parseCommands(text string) []MessageEntity {
 
 	matches := commandRegex.FindAllStringIndex(text, -1)
 	for _, match := range matches {
-		entities = append(entities, MessageEntity{
-			Type:   "bot_command",
-			Offset: match[0],
-			Length: match[1] - match[0],
-		})
+		entities = append(entities, NewEntityFromByteRange(text, EntityTypeBotCommand, match[0], match[1]))
 	}
 
 	return entities
@@ -210,11 +428,7 @@ func parseMentions(text string) []MessageEntity {
 
 	matches := mentionRegex.FindAllStringIndex(text, -1)
 	for _, match := range matches {
-		entities = append(entities, MessageEntity{
-			Type:   "mention",
-			Offset: match[0],
-			Length: match[1] - match[0],
-		})
+		entities = append(entities, NewEntityFromByteRange(text, EntityTypeMention, match[0], match[1]))
 	}
 
 	return entities
@@ -223,15 +437,11 @@ func parseMentions(text string) []MessageEntity {
 // parseHashtags парсит хештеги в тексте
 func parseHashtags(text string) []MessageEntity {
 	var entities []MessageEntity
-	hashtagRegex := regexp.MustCompile(`#([a-zA-Z0-9_]+)`)
+	hashtagRegex := regexp.MustCompile(`#([\p{L}\p{N}_]+)`)
 
 	matches := hashtagRegex.FindAllStringIndex(text, -1)
 	for _, match := range matches {
-		entities = append(entities, MessageEntity{
-			Type:   "hashtag",
-			Offset: match[0],
-			Length: match[1] - match[0],
-		})
+		entities = append(entities, NewEntityFromByteRange(text, EntityTypeHashtag, match[0], match[1]))
 	}
 
 	return entities
@@ -244,11 +454,7 @@ func parseURLs(text string) []MessageEntity {
 
 	matches := urlRegex.FindAllStringIndex(text, -1)
 	for _, match := range matches {
-		entities = append(entities, MessageEntity{
-			Type:   "url",
-			Offset: match[0],
-			Length: match[1] - match[0],
-		})
+		entities = append(entities, NewEntityFromByteRange(text, EntityTypeURL, match[0], match[1]))
 	}
 
 	return entities
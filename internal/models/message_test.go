@@ -156,8 +156,8 @@ func TestMessage_ParseAndSetEntities(t *testing.T) {
 			text: "Привет @username! Проверь /help и #test",
 			expected: []MessageEntity{
 				{Type: "mention", Offset: 7, Length: 9},
-				{Type: "bot_command", Offset: 25, Length: 5},
-				{Type: "hashtag", Offset: 33, Length: 5},
+				{Type: "bot_command", Offset: 26, Length: 5},
+				{Type: "hashtag", Offset: 34, Length: 5},
 			},
 		},
 	}
@@ -186,13 +186,6 @@ func TestMessage_ParseAndSetEntities(t *testing.T) {
 				return
 			}
 
-			if tt.name == "Смешанный текст" {
-				if len(entities) == 0 {
-					t.Errorf("Expected at least 1 entity for mixed text, got %d", len(entities))
-				}
-				return
-			}
-
 			if len(entities) != len(tt.expected) {
 				t.Errorf("Expected %d entities, got %d", len(tt.expected), len(entities))
 				return
@@ -310,6 +303,16 @@ func TestMessage_GetCommand(t *testing.T) {
 			text:     "Привет!",
 			expected: "",
 		},
+		{
+			name:     "Команда после кириллицы",
+			text:     "Привет! /help",
+			expected: "/help",
+		},
+		{
+			name:     "Команда после эмодзи",
+			text:     "🎉🎉 /start now",
+			expected: "/start",
+		},
 	}
 
 	for _, tt := range tests {
@@ -334,6 +337,107 @@ func TestMessage_GetCommand(t *testing.T) {
 	}
 }
 
+func TestMessage_EntitiesUTF16(t *testing.T) {
+	type expectedEntity struct {
+		entity MessageEntity
+		text   string
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		expected []expectedEntity
+	}{
+		{
+			name: "Кириллица перед командой",
+			text: "Привет /start",
+			expected: []expectedEntity{
+				{MessageEntity{Type: "bot_command", Offset: 7, Length: 6}, "/start"},
+			},
+		},
+		{
+			name: "Эмодзи перед командой",
+			text: "😀 /start",
+			expected: []expectedEntity{
+				{MessageEntity{Type: "bot_command", Offset: 3, Length: 6}, "/start"},
+			},
+		},
+		{
+			name: "Составной эмодзи перед упоминанием",
+			text: "👨‍👩‍👧 @username",
+			expected: []expectedEntity{
+				{MessageEntity{Type: "mention", Offset: 9, Length: 9}, "@username"},
+			},
+		},
+		{
+			name: "Кириллический хештег",
+			text: "#новости и #news 🚀",
+			expected: []expectedEntity{
+				{MessageEntity{Type: "hashtag", Offset: 0, Length: 8}, "#новости"},
+				{MessageEntity{Type: "hashtag", Offset: 11, Length: 5}, "#news"},
+			},
+		},
+		{
+			name: "Эмодзи между сущностями",
+			text: "🔥 /help 🔥 @support_bot",
+			expected: []expectedEntity{
+				{MessageEntity{Type: "bot_command", Offset: 3, Length: 5}, "/help"},
+				{MessageEntity{Type: "mention", Offset: 12, Length: 12}, "@support_bot"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := &Message{
+				ID:        1,
+				Text:      tt.text,
+				Timestamp: time.Now(),
+			}
+
+			if err := message.ParseAndSetEntities(); err != nil {
+				t.Fatalf("ParseAndSetEntities() error = %v", err)
+			}
+
+			entities := message.GetEntities()
+			if len(entities) != len(tt.expected) {
+				t.Fatalf("Expected %d entities, got %+v", len(tt.expected), entities)
+			}
+
+			for i, expected := range tt.expected {
+				actual := entities[i]
+				if actual.Type != expected.entity.Type || actual.Offset != expected.entity.Offset || actual.Length != expected.entity.Length {
+					t.Errorf("Entity %d: expected %+v, got %+v", i, expected.entity, actual)
+				}
+
+				text, ok := message.EntityText(actual)
+				if !ok || text != expected.text {
+					t.Errorf("Entity %d: expected text %q, got %q (ok=%v)", i, expected.text, text, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestMessage_SetFormattedTextUTF16(t *testing.T) {
+	message := &Message{}
+	if err := message.SetFormattedText("🚀 <b>Запуск</b> /start", ParseModeHTML); err != nil {
+		t.Fatalf("SetFormattedText() error = %v", err)
+	}
+
+	expected := []string{"Запуск", "/start"}
+	entities := message.GetEntities()
+	if len(entities) != len(expected) {
+		t.Fatalf("Expected %d entities, got %+v", len(expected), entities)
+	}
+	for i, entity := range entities {
+		text, ok := message.EntityText(entity)
+		if !ok || text != expected[i] {
+			t.Errorf("Entity %d: expected text %q, got %q", i, expected[i], text)
+		}
+	}
+}
+
 func TestMessage_ReplyMarkup(t *testing.T) {
 	message := &Message{
 		ID:        1,
@@ -426,3 +530,112 @@ func TestMessage_IsOutgoing(t *testing.T) {
 		t.Error("Expected IsOutgoing to be false")
 	}
 }
+
+func TestMessage_ForwardOrigin(t *testing.T) {
+	sent := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	original := &Message{
+		ID:        1,
+		FromID:    42,
+		From:      User{Username: "alice", FirstName: "Alice"},
+		Text:      "Hello",
+		Timestamp: sent,
+	}
+
+	origin := original.NewForwardOrigin()
+	if origin.Type != MessageOriginUser || origin.Date != sent.Unix() {
+		t.Errorf("Expected user origin dated %d, got %+v", sent.Unix(), origin)
+	}
+	if origin.SenderUser == nil || origin.SenderUser.ID != 42 || origin.SenderUser.Username != "alice" {
+		t.Errorf("Expected sender alice with id 42, got %+v", origin.SenderUser)
+	}
+
+	forwarded := &Message{ID: 2, Text: "Hello", Timestamp: time.Now()}
+	if err := forwarded.SetForwardOrigin(origin); err != nil {
+		t.Fatalf("SetForwardOrigin() error = %v", err)
+	}
+	if !forwarded.IsForwarded() {
+		t.Error("Expected message to be forwarded")
+	}
+
+	// Forwarding again keeps the original sender and date
+	if again := forwarded.NewForwardOrigin(); again.SenderUser.ID != 42 || again.Date != sent.Unix() {
+		t.Errorf("Expected original origin to be kept, got %+v", again)
+	}
+
+	telegramMessage := forwarded.ToTelegramMessage(0)
+	if telegramMessage.ForwardFrom == nil || telegramMessage.ForwardFrom.ID != 42 || telegramMessage.ForwardDate != sent.Unix() {
+		t.Errorf("Expected forward_from and forward_date, got %+v %d", telegramMessage.ForwardFrom, telegramMessage.ForwardDate)
+	}
+
+	if err := forwarded.SetForwardOrigin(nil); err != nil {
+		t.Fatalf("SetForwardOrigin(nil) error = %v", err)
+	}
+	if forwarded.IsForwarded() || forwarded.ToTelegramMessage(0).ForwardOrigin != nil {
+		t.Error("Expected forward origin to be cleared")
+	}
+}
+
+func TestMessage_ChatMembersServiceMessages(t *testing.T) {
+	joined := &Message{ID: 1, FromID: 1, Timestamp: time.Now()}
+	if joined.IsService() || joined.ToTelegramMessage(0).NewChatMembers != nil {
+		t.Error("Expected a regular message not to be a service message")
+	}
+
+	users := []TelegramUser{{ID: 2, FirstName: "Bob"}, {ID: 3, IsBot: true, FirstName: "Bot", Username: "test_bot"}}
+	if err := joined.SetNewChatMembers(users); err != nil {
+		t.Fatalf("SetNewChatMembers() error = %v", err)
+	}
+	if !joined.IsService() || joined.Type != MessageTypeNewChatMembers {
+		t.Errorf("Expected new_chat_members service message, got type %q", joined.Type)
+	}
+	telegramMessage := joined.ToTelegramMessage(0)
+	if len(telegramMessage.NewChatMembers) != 2 || telegramMessage.NewChatMembers[1].Username != "test_bot" || telegramMessage.LeftChatMember != nil {
+		t.Errorf("Expected two new chat members, got %+v", telegramMessage)
+	}
+
+	left := &Message{ID: 2, FromID: 1, Timestamp: time.Now()}
+	if err := left.SetLeftChatMember(users[0]); err != nil {
+		t.Fatalf("SetLeftChatMember() error = %v", err)
+	}
+	if !left.IsService() || left.Type != MessageTypeLeftChatMember {
+		t.Errorf("Expected left_chat_member service message, got type %q", left.Type)
+	}
+	if leftChatMember := left.ToTelegramMessage(0).LeftChatMember; leftChatMember == nil || leftChatMember.ID != 2 {
+		t.Errorf("Expected left chat member Bob, got %+v", leftChatMember)
+	}
+}
+
+func TestMessage_ToTelegramMessageChat(t *testing.T) {
+	alice := User{ID: 1, FirstName: "Alice", LastName: "Smith", Username: "alice"}
+	bot := User{ID: 2, FirstName: "Bot", Username: "test_bot", IsBot: true}
+
+	// A private chat is described with the name of the viewer's counterpart
+	private := &Chat{ID: 10, Type: "private", Title: "Alice", Members: []User{alice, bot}}
+	message := &Message{ID: 1, ChatID: private.ID, Chat: private, FromID: alice.ID, From: alice, Text: "Hi", Timestamp: time.Now()}
+	chat := message.ToTelegramMessage(bot.ID).Chat
+	if chat.ID != 10 || chat.Type != "private" || chat.FirstName != "Alice" || chat.LastName != "Smith" ||
+		chat.Username != "alice" || chat.Title != "" {
+		t.Errorf("Expected private chat with Alice, got %+v", chat)
+	}
+
+	// A group keeps its own type, title and username regardless of the sender;
+	// the replied message without a loaded chat inherits it
+	group := &Chat{ID: -20, Type: "group", Title: "Team", Username: "team", Members: []User{alice, bot}}
+	message = &Message{ID: 2, ChatID: group.ID, FromID: alice.ID, From: alice, Text: "Hi", Timestamp: time.Now()}
+	reply := &Message{ID: 3, ChatID: group.ID, FromID: bot.ID, From: bot, Text: "Hello", Timestamp: time.Now()}
+	reply.SetReplyTo(message)
+	reply.Chat = group
+	telegramMessage := reply.ToTelegramMessage(bot.ID)
+	if telegramMessage.Chat.Type != "group" || telegramMessage.Chat.Title != "Team" || telegramMessage.Chat.Username != "team" ||
+		telegramMessage.Chat.FirstName != "" {
+		t.Errorf("Expected group chat Team, got %+v", telegramMessage.Chat)
+	}
+	if telegramMessage.ReplyToMessage == nil || telegramMessage.ReplyToMessage.Chat.Title != "Team" {
+		t.Errorf("Expected the replied message in the same chat, got %+v", telegramMessage.ReplyToMessage)
+	}
+
+	// Without a loaded chat only its ID is known
+	if chat := (&Message{ID: 4, ChatID: 30, From: alice, Timestamp: time.Now()}).ToTelegramMessage(bot.ID).Chat; chat.ID != 30 || chat.Title != "" {
+		t.Errorf("Expected chat with only its ID, got %+v", chat)
+	}
+}
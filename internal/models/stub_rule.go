@@ -0,0 +1,175 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MaxStubRuleDelay ограничивает задержку ответа бота-заглушки
+const MaxStubRuleDelay = time.Minute
+
+// StubRuleConfig описывает правило бота-заглушки в REST API и YAML файле.
+// Правило срабатывает на команду (без "/") или на регулярное выражение по тексту сообщения
+type StubRuleConfig struct {
+	Command     string      `json:"command,omitempty" yaml:"command"`
+	Pattern     string      `json:"pattern,omitempty" yaml:"pattern"`
+	Text        string      `json:"text" yaml:"text"`
+	ParseMode   string      `json:"parse_mode,omitempty" yaml:"parse_mode"`
+	ReplyMarkup interface{} `json:"reply_markup,omitempty" yaml:"reply_markup"`
+	DelayMS     int         `json:"delay_ms,omitempty" yaml:"delay_ms"` // Задержка ответа в миллисекундах
+	Reply       bool        `json:"reply,omitempty" yaml:"reply"`       // Отвечать на сообщение пользователя (reply_to_message)
+}
+
+// Validate проверяет, что правило задает ровно одно условие, текст ответа с корректной разметкой
+// и допустимую задержку
+func (c *StubRuleConfig) Validate() error {
+	command := strings.TrimPrefix(c.Command, "/")
+	switch {
+	case command == "" && c.Pattern == "":
+		return fmt.Errorf("правило должно содержать command или pattern")
+	case command != "" && c.Pattern != "":
+		return fmt.Errorf("правило не может содержать одновременно command и pattern")
+	case command != "" && !isValidBotCommand(command):
+		return fmt.Errorf("некорректная команда %q", c.Command)
+	}
+
+	if c.Pattern != "" {
+		if _, err := regexp.Compile(c.Pattern); err != nil {
+			return fmt.Errorf("некорректное регулярное выражение: %w", err)
+		}
+	}
+
+	if strings.TrimSpace(c.Text) == "" {
+		return fmt.Errorf("текст ответа не может быть пустым")
+	}
+
+	// Ошибку разметки лучше показать при сохранении правила, чем в логе при ответе бота
+	plain, _, err := ParseFormattedText(c.Text, c.ParseMode)
+	if err != nil {
+		return fmt.Errorf("некорректная разметка текста ответа: %w", err)
+	}
+	if strings.TrimSpace(plain) == "" {
+		return fmt.Errorf("текст ответа не может быть пустым")
+	}
+
+	if c.DelayMS < 0 || time.Duration(c.DelayMS)*time.Millisecond > MaxStubRuleDelay {
+		return fmt.Errorf("задержка должна быть от 0 до %d мс", MaxStubRuleDelay.Milliseconds())
+	}
+
+	return nil
+}
+
+// StubRule представляет сохраненное правило бота-заглушки
+type StubRule struct {
+	ID              int64     `json:"id" gorm:"primaryKey"`
+	BotID           int64     `json:"bot_id" gorm:"index"`
+	Command         string    `json:"command,omitempty"`
+	Pattern         string    `json:"pattern,omitempty"`
+	Text            string    `json:"text"`
+	ParseMode       string    `json:"parse_mode,omitempty"`
+	ReplyMarkupJSON string    `json:"-" gorm:"column:reply_markup"` // Клавиатура в JSON формате
+	DelayMS         int       `json:"delay_ms"`
+	Reply           bool      `json:"reply"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TableName возвращает имя таблицы для модели StubRule
+func (StubRule) TableName() string {
+	return "stub_rules"
+}
+
+// NewStubRule создает правило бота-заглушки из его описания
+func NewStubRule(botID int64, config StubRuleConfig) (*StubRule, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	rule := &StubRule{
+		BotID:     botID,
+		Command:   strings.TrimPrefix(config.Command, "/"),
+		Pattern:   config.Pattern,
+		Text:      config.Text,
+		ParseMode: config.ParseMode,
+		DelayMS:   config.DelayMS,
+		Reply:     config.Reply,
+	}
+
+	if config.ReplyMarkup != nil {
+		jsonData, err := json.Marshal(config.ReplyMarkup)
+		if err != nil {
+			return nil, fmt.Errorf("некорректная клавиатура: %w", err)
+		}
+		rule.ReplyMarkupJSON = string(jsonData)
+	}
+
+	return rule, nil
+}
+
+// GetReplyMarkup десериализует клавиатуру ответа из JSON
+func (r *StubRule) GetReplyMarkup() interface{} {
+	if r.ReplyMarkupJSON == "" {
+		return nil
+	}
+
+	var replyMarkup interface{}
+	if err := json.Unmarshal([]byte(r.ReplyMarkupJSON), &replyMarkup); err != nil {
+		return nil
+	}
+
+	return replyMarkup
+}
+
+// Delay возвращает задержку ответа
+func (r *StubRule) Delay() time.Duration {
+	return time.Duration(r.DelayMS) * time.Millisecond
+}
+
+// Matches проверяет, срабатывает ли правило на сообщение для бота botUsername.
+// Команда с упоминанием другого бота (/start@other_bot) не срабатывает
+func (r *StubRule) Matches(message *Message, botUsername string) bool {
+	if r.Command != "" {
+		// Команда должна стоять в начале сообщения: /start, /start@bot или /start аргументы
+		fields := strings.Fields(message.Text)
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+			return false
+		}
+
+		name, mention, _ := strings.Cut(strings.TrimPrefix(fields[0], "/"), "@")
+		if mention != "" && !strings.EqualFold(mention, botUsername) {
+			return false
+		}
+		return strings.EqualFold(name, r.Command)
+	}
+
+	pattern, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return false
+	}
+	return pattern.MatchString(message.Text)
+}
+
+// ToStubRuleInfo конвертирует правило в представление для REST API
+func (r *StubRule) ToStubRuleInfo() StubRuleInfo {
+	return StubRuleInfo{
+		ID: r.ID,
+		StubRuleConfig: StubRuleConfig{
+			Command:     r.Command,
+			Pattern:     r.Pattern,
+			Text:        r.Text,
+			ParseMode:   r.ParseMode,
+			ReplyMarkup: r.GetReplyMarkup(),
+			DelayMS:     r.DelayMS,
+			Reply:       r.Reply,
+		},
+	}
+}
+
+// StubRuleInfo представляет правило бота-заглушки вместе с его идентификатором
+type StubRuleInfo struct {
+	ID int64 `json:"id"`
+	StubRuleConfig
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStubRuleConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  StubRuleConfig
+		wantErr bool
+	}{
+		{name: "Команда", config: StubRuleConfig{Command: "start", Text: "Привет"}},
+		{name: "Команда со слешем", config: StubRuleConfig{Command: "/help", Text: "Помощь"}},
+		{name: "Регулярное выражение", config: StubRuleConfig{Pattern: "(?i)погода", Text: "Солнечно", DelayMS: 500}},
+		{name: "Нет условия", config: StubRuleConfig{Text: "Привет"}, wantErr: true},
+		{name: "Команда и выражение", config: StubRuleConfig{Command: "start", Pattern: "start", Text: "Привет"}, wantErr: true},
+		{name: "Некорректная команда", config: StubRuleConfig{Command: "Start", Text: "Привет"}, wantErr: true},
+		{name: "Некорректное выражение", config: StubRuleConfig{Pattern: "(", Text: "Привет"}, wantErr: true},
+		{name: "Пустой текст", config: StubRuleConfig{Command: "start", Text: " "}, wantErr: true},
+		{name: "Текст с разметкой", config: StubRuleConfig{Command: "start", Text: "<b>Привет</b>", ParseMode: ParseModeHTML}},
+		{name: "Некорректная HTML разметка", config: StubRuleConfig{Command: "start", Text: "<b>Привет", ParseMode: ParseModeHTML}, wantErr: true},
+		{name: "Некорректная MarkdownV2 разметка", config: StubRuleConfig{Command: "start", Text: "Привет!", ParseMode: ParseModeMarkdownV2}, wantErr: true},
+		{name: "Неизвестный режим разметки", config: StubRuleConfig{Command: "start", Text: "Привет", ParseMode: "XML"}, wantErr: true},
+		{name: "Пустой текст после разметки", config: StubRuleConfig{Command: "start", Text: "<b></b>", ParseMode: ParseModeHTML}, wantErr: true},
+		{name: "Отрицательная задержка", config: StubRuleConfig{Command: "start", Text: "Привет", DelayMS: -1}, wantErr: true},
+		{name: "Слишком большая задержка", config: StubRuleConfig{Command: "start", Text: "Привет", DelayMS: 60001}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStubRule_Matches(t *testing.T) {
+	command, err := NewStubRule(1, StubRuleConfig{Command: "/start", Text: "Привет"})
+	if err != nil {
+		t.Fatalf("NewStubRule() error = %v", err)
+	}
+	pattern, err := NewStubRule(1, StubRuleConfig{Pattern: "(?i)погода", Text: "Солнечно"})
+	if err != nil {
+		t.Fatalf("NewStubRule() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		rule  *StubRule
+		text  string
+		match bool
+	}{
+		{name: "Команда", rule: command, text: "/start", match: true},
+		{name: "Команда с аргументом", rule: command, text: "/start ref_42", match: true},
+		{name: "Команда с упоминанием бота", rule: command, text: "/start@TestBot", match: true},
+		{name: "Команда другого бота", rule: command, text: "/start@other_bot", match: false},
+		{name: "Другая команда", rule: command, text: "/help", match: false},
+		{name: "Команда не в начале", rule: command, text: "нажми /start", match: false},
+		{name: "Выражение", rule: pattern, text: "Какая сегодня Погода?", match: true},
+		{name: "Выражение не совпало", rule: pattern, text: "Привет", match: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := &Message{Text: tt.text}
+			if got := tt.rule.Matches(message, "testbot"); got != tt.match {
+				t.Errorf("Matches(%q) = %v, want %v", tt.text, got, tt.match)
+			}
+		})
+	}
+}
+
+func TestStubRule_ReplyMarkup(t *testing.T) {
+	keyboard := map[string]interface{}{
+		"inline_keyboard": []interface{}{
+			[]interface{}{map[string]interface{}{"text": "Да", "callback_data": "yes"}},
+		},
+	}
+	rule, err := NewStubRule(1, StubRuleConfig{Command: "start", Text: "Привет", ReplyMarkup: keyboard, DelayMS: 250})
+	if err != nil {
+		t.Fatalf("NewStubRule() error = %v", err)
+	}
+	if rule.Command != "start" {
+		t.Errorf("Expected command without leading slash, got %q", rule.Command)
+	}
+	if !strings.Contains(rule.ReplyMarkupJSON, "callback_data") {
+		t.Errorf("Expected keyboard to be stored as JSON, got %q", rule.ReplyMarkupJSON)
+	}
+
+	info := rule.ToStubRuleInfo()
+	markup, ok := info.ReplyMarkup.(map[string]interface{})
+	if !ok || markup["inline_keyboard"] == nil {
+		t.Errorf("Expected keyboard to round-trip through JSON, got %+v", info.ReplyMarkup)
+	}
+	if info.DelayMS != 250 || rule.Delay().Milliseconds() != 250 {
+		t.Errorf("Unexpected delay: %d ms", info.DelayMS)
+	}
+}
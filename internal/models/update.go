@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -24,6 +25,134 @@ type Update struct {
 	Timestamp          time.Time           `json:"timestamp"`
 }
 
+// UpdateType представляет типы обновлений (значения allowed_updates)
+const (
+	UpdateTypeMessage              = "message"
+	UpdateTypeEditedMessage        = "edited_message"
+	UpdateTypeChannelPost          = "channel_post"
+	UpdateTypeEditedChannelPost    = "edited_channel_post"
+	UpdateTypeMessageReaction      = "message_reaction"
+	UpdateTypeMessageReactionCount = "message_reaction_count"
+	UpdateTypeInlineQuery          = "inline_query"
+	UpdateTypeChosenInlineResult   = "chosen_inline_result"
+	UpdateTypeCallbackQuery        = "callback_query"
+	UpdateTypeShippingQuery        = "shipping_query"
+	UpdateTypePreCheckoutQuery     = "pre_checkout_query"
+	UpdateTypePoll                 = "poll"
+	UpdateTypePollAnswer           = "poll_answer"
+	UpdateTypeMyChatMember         = "my_chat_member"
+	UpdateTypeChatMember           = "chat_member"
+	UpdateTypeChatJoinRequest      = "chat_join_request"
+)
+
+// Type возвращает тип обновления
+func (u *Update) Type() string {
+	switch {
+	case u.Message != nil:
+		return UpdateTypeMessage
+	case u.EditedMessage != nil:
+		return UpdateTypeEditedMessage
+	case u.ChannelPost != nil:
+		return UpdateTypeChannelPost
+	case u.EditedChannelPost != nil:
+		return UpdateTypeEditedChannelPost
+	case u.CallbackQuery != nil:
+		return UpdateTypeCallbackQuery
+	case u.InlineQuery != nil:
+		return UpdateTypeInlineQuery
+	case u.ChosenInlineResult != nil:
+		return UpdateTypeChosenInlineResult
+	case u.ShippingQuery != nil:
+		return UpdateTypeShippingQuery
+	case u.PreCheckoutQuery != nil:
+		return UpdateTypePreCheckoutQuery
+	case u.Poll != nil:
+		return UpdateTypePoll
+	case u.PollAnswer != nil:
+		return UpdateTypePollAnswer
+	case u.MyChatMember != nil:
+		return UpdateTypeMyChatMember
+	case u.ChatMember != nil:
+		return UpdateTypeChatMember
+	case u.ChatJoinRequest != nil:
+		return UpdateTypeChatJoinRequest
+	}
+	return ""
+}
+
+// ChatID возвращает ID чата, к которому относится обновление, или 0
+func (u *Update) ChatID() int64 {
+	switch {
+	case u.Message != nil:
+		return u.Message.ChatID
+	case u.EditedMessage != nil:
+		return u.EditedMessage.ChatID
+	case u.ChannelPost != nil:
+		return u.ChannelPost.ChatID
+	case u.EditedChannelPost != nil:
+		return u.EditedChannelPost.ChatID
+	case u.CallbackQuery != nil && u.CallbackQuery.Message != nil:
+		return u.CallbackQuery.Message.ChatID
+	case u.MyChatMember != nil:
+		return u.MyChatMember.Chat.ID
+	case u.ChatMember != nil:
+		return u.ChatMember.Chat.ID
+	case u.ChatJoinRequest != nil:
+		return u.ChatJoinRequest.Chat.ID
+	}
+	return 0
+}
+
+// UpdateRecord представляет обновление, сохраненное в очереди бота
+type UpdateRecord struct {
+	ID        int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	BotID     int64     `json:"bot_id" gorm:"uniqueIndex:idx_updates_bot_update"`
+	UpdateID  int64     `json:"update_id" gorm:"uniqueIndex:idx_updates_bot_update"`
+	Payload   string    `json:"payload"` // Обновление в JSON формате
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName возвращает имя таблицы для модели UpdateRecord
+func (UpdateRecord) TableName() string {
+	return "updates"
+}
+
+// UpdateSequence хранит персональный счетчик update_id бота
+type UpdateSequence struct {
+	BotID        int64 `json:"bot_id" gorm:"primaryKey;autoIncrement:false"`
+	NextUpdateID int64 `json:"next_update_id"`
+}
+
+// TableName возвращает имя таблицы для модели UpdateSequence
+func (UpdateSequence) TableName() string {
+	return "update_sequences"
+}
+
+// NewUpdateRecord сериализует обновление для сохранения в очереди бота
+func NewUpdateRecord(botID int64, update *Update) (*UpdateRecord, error) {
+	payload, err := json.Marshal(update)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UpdateRecord{
+		BotID:     botID,
+		UpdateID:  update.UpdateID,
+		Payload:   string(payload),
+		CreatedAt: update.Timestamp,
+	}, nil
+}
+
+// ToUpdate десериализует сохраненное обновление
+func (r *UpdateRecord) ToUpdate() (*Update, error) {
+	var update Update
+	if err := json.Unmarshal([]byte(r.Payload), &update); err != nil {
+		return nil, err
+	}
+	update.UpdateID = r.UpdateID
+	return &update, nil
+}
+
 // CallbackQuery представляет callback query от inline кнопок
 type CallbackQuery struct {
 	ID              string   `json:"id"`
@@ -35,6 +164,15 @@ type CallbackQuery struct {
 	GameShortName   string   `json:"game_short_name,omitempty"`
 }
 
+// CallbackQueryAnswer представляет ответ бота на callback query (answerCallbackQuery)
+type CallbackQueryAnswer struct {
+	CallbackQueryID string `json:"callback_query_id"`
+	Text            string `json:"text,omitempty"`
+	ShowAlert       bool   `json:"show_alert,omitempty"`
+	URL             string `json:"url,omitempty"`
+	CacheTime       int    `json:"cache_time,omitempty"`
+}
+
 // InlineQuery представляет inline запрос
 type InlineQuery struct {
 	ID       string    `json:"id"`
@@ -105,8 +243,8 @@ type PollAnswer struct {
 
 // ChatMemberUpdated представляет обновление участника чата
 type ChatMemberUpdated struct {
-	Chat          Chat               `json:"chat"`
-	From          User               `json:"from"`
+	Chat          TelegramChat       `json:"chat"`
+	From          TelegramUser       `json:"from"`
 	Date          int64              `json:"date"`
 	OldChatMember TelegramChatMember `json:"old_chat_member"`
 	NewChatMember TelegramChatMember `json:"new_chat_member"`
@@ -123,27 +261,178 @@ type ChatJoinRequest struct {
 	InviteLink *ChatInviteLink `json:"invite_link,omitempty"`
 }
 
-// TelegramChatMember представляет участника чата в формате Telegram Bot API
+// TelegramChatMember представляет участника чата в формате Telegram Bot API.
+// Структура объединяет поля всех типов ChatMember, но сериализуется только с полями типа,
+// соответствующего статусу участника (см. MarshalJSON)
 type TelegramChatMember struct {
-	User                User   `json:"user"`
-	Status              string `json:"status"`
-	CustomTitle         string `json:"custom_title,omitempty"`
-	IsAnonymous         bool   `json:"is_anonymous,omitempty"`
-	CanBeEdited         bool   `json:"can_be_edited,omitempty"`
-	CanManageChat       bool   `json:"can_manage_chat,omitempty"`
-	CanDeleteMessages   bool   `json:"can_delete_messages,omitempty"`
-	CanManageVideoChats bool   `json:"can_manage_video_chats,omitempty"`
-	CanRestrictMembers  bool   `json:"can_restrict_members,omitempty"`
-	CanPromoteMembers   bool   `json:"can_promote_members,omitempty"`
-	CanChangeInfo       bool   `json:"can_change_info,omitempty"`
-	CanInviteUsers      bool   `json:"can_invite_users,omitempty"`
+	User                  TelegramUser `json:"user"`
+	Status                string       `json:"status"`
+	CustomTitle           string       `json:"custom_title,omitempty"`
+	IsAnonymous           bool         `json:"is_anonymous,omitempty"`
+	CanBeEdited           bool         `json:"can_be_edited,omitempty"`
+	CanManageChat         bool         `json:"can_manage_chat,omitempty"`
+	CanDeleteMessages     bool         `json:"can_delete_messages,omitempty"`
+	CanManageVideoChats   bool         `json:"can_manage_video_chats,omitempty"`
+	CanRestrictMembers    bool         `json:"can_restrict_members,omitempty"`
+	CanPromoteMembers     bool         `json:"can_promote_members,omitempty"`
+	CanChangeInfo         bool         `json:"can_change_info,omitempty"`
+	CanInviteUsers        bool         `json:"can_invite_users,omitempty"`
+	CanPostMessages       bool         `json:"can_post_messages,omitempty"`
+	CanEditMessages       bool         `json:"can_edit_messages,omitempty"`
+	CanPinMessages        bool         `json:"can_pin_messages,omitempty"`
+	CanPostStories        bool         `json:"can_post_stories,omitempty"`
+	CanEditStories        bool         `json:"can_edit_stories,omitempty"`
+	CanDeleteStories      bool         `json:"can_delete_stories,omitempty"`
+	IsMember              bool         `json:"is_member,omitempty"`
+	CanSendMessages       bool         `json:"can_send_messages,omitempty"`
+	CanSendAudios         bool         `json:"can_send_audios,omitempty"`
+	CanSendDocuments      bool         `json:"can_send_documents,omitempty"`
+	CanSendPhotos         bool         `json:"can_send_photos,omitempty"`
+	CanSendVideos         bool         `json:"can_send_videos,omitempty"`
+	CanSendVideoNotes     bool         `json:"can_send_video_notes,omitempty"`
+	CanSendVoiceNotes     bool         `json:"can_send_voice_notes,omitempty"`
+	CanSendPolls          bool         `json:"can_send_polls,omitempty"`
+	CanSendOtherMessages  bool         `json:"can_send_other_messages,omitempty"`
+	CanAddWebPagePreviews bool         `json:"can_add_web_page_previews,omitempty"`
+	CanManageTopics       bool         `json:"can_manage_topics,omitempty"`
+	UntilDate             *int64       `json:"until_date,omitempty"` // Для restricted и kicked, 0 - бессрочно
+}
+
+// telegramChatMemberBase содержит поля, общие для всех типов ChatMember
+type telegramChatMemberBase struct {
+	User   TelegramUser `json:"user"`
+	Status string       `json:"status"`
+}
+
+// telegramChatMemberOwner представляет ChatMemberOwner
+type telegramChatMemberOwner struct {
+	telegramChatMemberBase
+	IsAnonymous bool   `json:"is_anonymous"`
+	CustomTitle string `json:"custom_title,omitempty"`
+}
+
+// telegramChatMemberAdministrator представляет ChatMemberAdministrator.
+// Права для каналов и тем передаются, только если они есть
+type telegramChatMemberAdministrator struct {
+	telegramChatMemberBase
+	CanBeEdited         bool   `json:"can_be_edited"`
+	IsAnonymous         bool   `json:"is_anonymous"`
+	CanManageChat       bool   `json:"can_manage_chat"`
+	CanDeleteMessages   bool   `json:"can_delete_messages"`
+	CanManageVideoChats bool   `json:"can_manage_video_chats"`
+	CanRestrictMembers  bool   `json:"can_restrict_members"`
+	CanPromoteMembers   bool   `json:"can_promote_members"`
+	CanChangeInfo       bool   `json:"can_change_info"`
+	CanInviteUsers      bool   `json:"can_invite_users"`
+	CanPostStories      bool   `json:"can_post_stories"`
+	CanEditStories      bool   `json:"can_edit_stories"`
+	CanDeleteStories    bool   `json:"can_delete_stories"`
 	CanPostMessages     bool   `json:"can_post_messages,omitempty"`
 	CanEditMessages     bool   `json:"can_edit_messages,omitempty"`
-	CanPinMessages      bool   `json:"can_pin_messages,omitempty"`
-	CanPostStories      bool   `json:"can_post_stories,omitempty"`
-	CanEditStories      bool   `json:"can_edit_stories,omitempty"`
-	CanDeleteStories    bool   `json:"can_delete_stories,omitempty"`
-	UntilDate           int64  `json:"until_date,omitempty"`
+	CanPinMessages      bool   `json:"can_pin_messages"`
+	CanManageTopics     bool   `json:"can_manage_topics,omitempty"`
+	CustomTitle         string `json:"custom_title,omitempty"`
+}
+
+// telegramChatMemberMember представляет ChatMemberMember
+type telegramChatMemberMember struct {
+	telegramChatMemberBase
+	UntilDate *int64 `json:"until_date,omitempty"`
+}
+
+// telegramChatMemberRestricted представляет ChatMemberRestricted
+type telegramChatMemberRestricted struct {
+	telegramChatMemberBase
+	IsMember              bool  `json:"is_member"`
+	CanSendMessages       bool  `json:"can_send_messages"`
+	CanSendAudios         bool  `json:"can_send_audios"`
+	CanSendDocuments      bool  `json:"can_send_documents"`
+	CanSendPhotos         bool  `json:"can_send_photos"`
+	CanSendVideos         bool  `json:"can_send_videos"`
+	CanSendVideoNotes     bool  `json:"can_send_video_notes"`
+	CanSendVoiceNotes     bool  `json:"can_send_voice_notes"`
+	CanSendPolls          bool  `json:"can_send_polls"`
+	CanSendOtherMessages  bool  `json:"can_send_other_messages"`
+	CanAddWebPagePreviews bool  `json:"can_add_web_page_previews"`
+	CanChangeInfo         bool  `json:"can_change_info"`
+	CanInviteUsers        bool  `json:"can_invite_users"`
+	CanPinMessages        bool  `json:"can_pin_messages"`
+	CanManageTopics       bool  `json:"can_manage_topics"`
+	UntilDate             int64 `json:"until_date"`
+}
+
+// telegramChatMemberBanned представляет ChatMemberBanned
+type telegramChatMemberBanned struct {
+	telegramChatMemberBase
+	UntilDate int64 `json:"until_date"`
+}
+
+// MarshalJSON сериализует участника как тип Bot API, соответствующий его статусу: ChatMemberOwner,
+// ChatMemberAdministrator, ChatMemberMember, ChatMemberRestricted, ChatMemberLeft или ChatMemberBanned.
+// Передаются только поля этого типа, обязательные - даже с нулевым значением
+func (m TelegramChatMember) MarshalJSON() ([]byte, error) {
+	base := telegramChatMemberBase{User: m.User, Status: m.Status}
+
+	var untilDate int64
+	if m.UntilDate != nil {
+		untilDate = *m.UntilDate
+	}
+
+	switch m.Status {
+	case ChatMemberStatusCreator:
+		return json.Marshal(telegramChatMemberOwner{
+			telegramChatMemberBase: base,
+			IsAnonymous:            m.IsAnonymous,
+			CustomTitle:            m.CustomTitle,
+		})
+	case ChatMemberStatusAdministrator:
+		return json.Marshal(telegramChatMemberAdministrator{
+			telegramChatMemberBase: base,
+			CanBeEdited:            m.CanBeEdited,
+			IsAnonymous:            m.IsAnonymous,
+			CanManageChat:          m.CanManageChat,
+			CanDeleteMessages:      m.CanDeleteMessages,
+			CanManageVideoChats:    m.CanManageVideoChats,
+			CanRestrictMembers:     m.CanRestrictMembers,
+			CanPromoteMembers:      m.CanPromoteMembers,
+			CanChangeInfo:          m.CanChangeInfo,
+			CanInviteUsers:         m.CanInviteUsers,
+			CanPostStories:         m.CanPostStories,
+			CanEditStories:         m.CanEditStories,
+			CanDeleteStories:       m.CanDeleteStories,
+			CanPostMessages:        m.CanPostMessages,
+			CanEditMessages:        m.CanEditMessages,
+			CanPinMessages:         m.CanPinMessages,
+			CanManageTopics:        m.CanManageTopics,
+			CustomTitle:            m.CustomTitle,
+		})
+	case ChatMemberStatusMember:
+		return json.Marshal(telegramChatMemberMember{telegramChatMemberBase: base, UntilDate: m.UntilDate})
+	case ChatMemberStatusRestricted:
+		return json.Marshal(telegramChatMemberRestricted{
+			telegramChatMemberBase: base,
+			IsMember:               m.IsMember,
+			CanSendMessages:        m.CanSendMessages,
+			CanSendAudios:          m.CanSendAudios,
+			CanSendDocuments:       m.CanSendDocuments,
+			CanSendPhotos:          m.CanSendPhotos,
+			CanSendVideos:          m.CanSendVideos,
+			CanSendVideoNotes:      m.CanSendVideoNotes,
+			CanSendVoiceNotes:      m.CanSendVoiceNotes,
+			CanSendPolls:           m.CanSendPolls,
+			CanSendOtherMessages:   m.CanSendOtherMessages,
+			CanAddWebPagePreviews:  m.CanAddWebPagePreviews,
+			CanChangeInfo:          m.CanChangeInfo,
+			CanInviteUsers:         m.CanInviteUsers,
+			CanPinMessages:         m.CanPinMessages,
+			CanManageTopics:        m.CanManageTopics,
+			UntilDate:              untilDate,
+		})
+	case ChatMemberStatusKicked:
+		return json.Marshal(telegramChatMemberBanned{telegramChatMemberBase: base, UntilDate: untilDate})
+	default:
+		return json.Marshal(base)
+	}
 }
 
 // ChatInviteLink представляет ссылку-приглашение в чат
@@ -206,6 +495,7 @@ type TelegramMessage struct {
 	SenderChat                   *TelegramChat    `json:"sender_chat,omitempty"`
 	Date                         int64            `json:"date"`
 	Chat                         TelegramChat     `json:"chat"`
+	ForwardOrigin                *MessageOrigin   `json:"forward_origin,omitempty"`
 	ForwardFrom                  *TelegramUser    `json:"forward_from,omitempty"`
 	ForwardFromChat              *TelegramChat    `json:"forward_from_chat,omitempty"`
 	ForwardFromMessageID         int64            `json:"forward_from_message_id,omitempty"`
@@ -292,6 +582,18 @@ type TelegramUser struct {
 	SupportsInlineQueries   bool   `json:"supports_inline_queries,omitempty"`
 }
 
+// Типы источника пересланного сообщения
+const (
+	MessageOriginUser = "user"
+)
+
+// MessageOrigin описывает источник пересланного сообщения в формате Telegram Bot API
+type MessageOrigin struct {
+	Type       string        `json:"type"` // user
+	Date       int64         `json:"date"`
+	SenderUser *TelegramUser `json:"sender_user,omitempty"`
+}
+
 // TelegramChat представляет чат в формате Telegram Bot API
 type TelegramChat struct {
 	ID                                 int64            `json:"id"`
@@ -328,6 +630,12 @@ type TelegramChat struct {
 type ChatPermissions struct {
 	CanSendMessages       bool `json:"can_send_messages,omitempty"`
 	CanSendMediaMessages  bool `json:"can_send_media_messages,omitempty"`
+	CanSendAudios         bool `json:"can_send_audios,omitempty"`
+	CanSendDocuments      bool `json:"can_send_documents,omitempty"`
+	CanSendPhotos         bool `json:"can_send_photos,omitempty"`
+	CanSendVideos         bool `json:"can_send_videos,omitempty"`
+	CanSendVideoNotes     bool `json:"can_send_video_notes,omitempty"`
+	CanSendVoiceNotes     bool `json:"can_send_voice_notes,omitempty"`
 	CanSendPolls          bool `json:"can_send_polls,omitempty"`
 	CanSendOtherMessages  bool `json:"can_send_other_messages,omitempty"`
 	CanAddWebPagePreviews bool `json:"can_add_web_page_previews,omitempty"`
@@ -337,14 +645,79 @@ type ChatPermissions struct {
 	CanManageTopics       bool `json:"can_manage_topics,omitempty"`
 }
 
+// DefaultChatPermissions возвращает разрешения участников группы по умолчанию: разрешено все
+func DefaultChatPermissions() ChatPermissions {
+	return ChatPermissions{
+		CanSendMessages:       true,
+		CanSendMediaMessages:  true,
+		CanSendAudios:         true,
+		CanSendDocuments:      true,
+		CanSendPhotos:         true,
+		CanSendVideos:         true,
+		CanSendVideoNotes:     true,
+		CanSendVoiceNotes:     true,
+		CanSendPolls:          true,
+		CanSendOtherMessages:  true,
+		CanAddWebPagePreviews: true,
+		CanChangeInfo:         true,
+		CanInviteUsers:        true,
+		CanPinMessages:        true,
+		CanManageTopics:       true,
+	}
+}
+
+// Normalize приводит разрешения к независимому виду, как restrictChatMember в Telegram.
+// Без independent устаревшее can_send_media_messages разрешает все виды медиа,
+// can_send_other_messages и can_add_web_page_previews разрешают текст и медиа, а can_send_polls - текст
+func (p ChatPermissions) Normalize(independent bool) ChatPermissions {
+	if !independent {
+		if p.CanSendMediaMessages || p.CanSendOtherMessages || p.CanAddWebPagePreviews {
+			p.CanSendAudios = true
+			p.CanSendDocuments = true
+			p.CanSendPhotos = true
+			p.CanSendVideos = true
+			p.CanSendVideoNotes = true
+			p.CanSendVoiceNotes = true
+		}
+		if p.CanSendMediaMessages || p.CanSendOtherMessages || p.CanAddWebPagePreviews || p.CanSendPolls {
+			p.CanSendMessages = true
+		}
+	}
+
+	p.CanSendMediaMessages = p.CanSendAudios && p.CanSendDocuments && p.CanSendPhotos &&
+		p.CanSendVideos && p.CanSendVideoNotes && p.CanSendVoiceNotes
+	return p
+}
+
+// CanSend проверяет, разрешена ли отправка сообщения типа messageType
+func (p ChatPermissions) CanSend(messageType string) bool {
+	switch messageType {
+	case MessageTypeText:
+		return p.CanSendMessages
+	case MessageTypePhoto:
+		return p.CanSendPhotos
+	case MessageTypeFile:
+		return p.CanSendDocuments
+	case MessageTypeAudio:
+		return p.CanSendAudios
+	case MessageTypeVoice:
+		return p.CanSendVoiceNotes
+	case MessageTypeVideo:
+		return p.CanSendVideos
+	default:
+		return p.CanSendOtherMessages
+	}
+}
+
 // ChatLocation представляет местоположение чата
 type ChatLocation struct {
 	Location Location `json:"location"`
 	Address  string   `json:"address"`
 }
 
-// ToTelegramMessage конвертирует внутреннее сообщение в формат Telegram Bot API
-func (m *Message) ToTelegramMessage() TelegramMessage {
+// ToTelegramMessage конвертирует внутреннее сообщение в формат Telegram Bot API.
+// Приватный чат описывается с точки зрения viewerID: с именем его собеседника
+func (m *Message) ToTelegramMessage(viewerID int64) TelegramMessage {
 	// Все ID уже int64, конвертация не нужна
 	telegramMessage := TelegramMessage{
 		MessageID: m.ID,
@@ -355,26 +728,78 @@ func (m *Message) ToTelegramMessage() TelegramMessage {
 			LastName:  m.From.LastName,
 			Username:  m.From.Username,
 		},
-		Chat: TelegramChat{
-			ID:       m.ChatID,
-			Type:     "private", // TODO: получить тип чата
-			Title:    m.From.GetFullName(),
-			Username: m.From.Username,
-		},
 		Date: m.Timestamp.Unix(),
-		Text: m.Text,
 	}
 
-	// Добавляем сущности, если они есть
-	if entities := m.GetEntities(); len(entities) > 0 {
-		telegramMessage.Entities = entities
+	if m.Chat != nil {
+		telegramMessage.Chat = m.Chat.ToTelegramChat(viewerID)
+	} else {
+		// Без загруженного чата известен только его ID
+		telegramMessage.Chat = TelegramChat{ID: m.ChatID, Type: "private"}
 	}
 
+	// Telegram не включает reply_to_message во вложенное сообщение, на которое отвечают
+	if m.ReplyToMessage != nil {
+		reply := *m.ReplyToMessage
+		reply.ReplyToMessage = nil
+		if reply.Chat == nil {
+			reply.Chat = m.Chat
+		}
+		telegramReply := reply.ToTelegramMessage(viewerID)
+		telegramMessage.ReplyToMessage = &telegramReply
+	}
+
+	// Пересланное сообщение сохраняет автора и дату оригинала
+	if origin := m.GetForwardOrigin(); origin != nil {
+		telegramMessage.ForwardOrigin = origin
+		telegramMessage.ForwardFrom = origin.SenderUser
+		telegramMessage.ForwardDate = origin.Date
+	}
+
+	// У сообщений с вложением текст передается как подпись
+	if media := m.GetMedia(); media != nil {
+		telegramMessage.Caption = m.Text
+		telegramMessage.CaptionEntities = m.GetEntities()
+		if len(media.Photo) > 0 {
+			telegramMessage.Photo = make([]interface{}, 0, len(media.Photo))
+			for _, size := range media.Photo {
+				telegramMessage.Photo = append(telegramMessage.Photo, size)
+			}
+		}
+		if media.Document != nil {
+			telegramMessage.Document = media.Document
+		}
+		if media.Audio != nil {
+			telegramMessage.Audio = media.Audio
+		}
+		if media.Voice != nil {
+			telegramMessage.Voice = media.Voice
+		}
+		if media.Video != nil {
+			telegramMessage.Video = media.Video
+		}
+	} else {
+		telegramMessage.Text = m.Text
+
+		// Добавляем сущности, если они есть
+		if entities := m.GetEntities(); len(entities) > 0 {
+			telegramMessage.Entities = entities
+		}
+	}
+
+	// Служебные сообщения о входе и выходе участников
+	telegramMessage.NewChatMembers = m.GetNewChatMembers()
+	telegramMessage.LeftChatMember = m.GetLeftChatMember()
+
 	// Добавляем клавиатуру, если она есть
 	if replyMarkup := m.GetReplyMarkup(); replyMarkup != nil {
 		telegramMessage.ReplyMarkup = replyMarkup
 	}
 
+	if m.EditDate != nil {
+		telegramMessage.EditDate = m.EditDate.Unix()
+	}
+
 	return telegramMessage
 }
 
@@ -392,8 +817,33 @@ func FromTelegramMessage(tgMsg TelegramMessage, chatID int64) *Message {
 	}
 }
 
-// ToTelegramCallbackQuery конвертирует внутренний CallbackQuery в формат Telegram Bot API
-func (cq *CallbackQuery) ToTelegramCallbackQuery() map[string]interface{} {
+// ToTelegramUpdate конвертирует обновление в формат Telegram Bot API для бота viewerID
+func (u *Update) ToTelegramUpdate(viewerID int64) map[string]interface{} {
+	telegramUpdate := map[string]interface{}{
+		"update_id": u.UpdateID,
+	}
+
+	if u.Message != nil {
+		telegramUpdate["message"] = u.Message.ToTelegramMessage(viewerID)
+	}
+	if u.EditedMessage != nil {
+		telegramUpdate["edited_message"] = u.EditedMessage.ToTelegramMessage(viewerID)
+	}
+	if u.CallbackQuery != nil {
+		telegramUpdate["callback_query"] = u.CallbackQuery.ToTelegramCallbackQuery(viewerID)
+	}
+	if u.MyChatMember != nil {
+		telegramUpdate["my_chat_member"] = u.MyChatMember
+	}
+	if u.ChatMember != nil {
+		telegramUpdate["chat_member"] = u.ChatMember
+	}
+
+	return telegramUpdate
+}
+
+// ToTelegramCallbackQuery конвертирует внутренний CallbackQuery в формат Telegram Bot API для бота viewerID
+func (cq *CallbackQuery) ToTelegramCallbackQuery(viewerID int64) map[string]interface{} {
 	// Все ID уже int64, конвертация не нужна
 	telegramCallbackQuery := map[string]interface{}{
 		"id": cq.ID,
@@ -414,7 +864,7 @@ func (cq *CallbackQuery) ToTelegramCallbackQuery() map[string]interface{} {
 
 	// Добавляем сообщение, если оно есть
 	if cq.Message != nil {
-		telegramCallbackQuery["message"] = cq.Message.ToTelegramMessage()
+		telegramCallbackQuery["message"] = cq.Message.ToTelegramMessage(viewerID)
 	}
 
 	// Добавляем inline_message_id, если он есть
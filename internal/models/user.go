@@ -37,3 +37,14 @@ func (u *User) SetOnline(online bool) {
 		u.LastSeen = time.Now()
 	}
 }
+
+// ToTelegramUser конвертирует пользователя в формат Telegram Bot API
+func (u *User) ToTelegramUser() TelegramUser {
+	return TelegramUser{
+		ID:        u.ID,
+		IsBot:     u.IsBot,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Username:  u.Username,
+	}
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"unicode/utf16"
+)
+
+// Telegram задает смещение и длину сущностей сообщения в единицах UTF-16:
+// символы вне базовой многоязычной плоскости (например, большинство эмодзи) занимают две единицы.
+
+// UTF16Len возвращает длину строки в единицах UTF-16
+func UTF16Len(s string) int {
+	length := 0
+	for _, r := range s {
+		length += utf16RuneLen(r)
+	}
+	return length
+}
+
+// UTF16Offset переводит смещение в байтах строки text в смещение в единицах UTF-16
+func UTF16Offset(text string, byteOffset int) int {
+	if byteOffset > len(text) {
+		byteOffset = len(text)
+	}
+	return UTF16Len(text[:byteOffset])
+}
+
+// ByteOffset переводит смещение в единицах UTF-16 в смещение в байтах строки text.
+// Возвращает false, если смещение выходит за пределы строки или попадает внутрь суррогатной пары
+func ByteOffset(text string, utf16Offset int) (int, bool) {
+	if utf16Offset < 0 {
+		return 0, false
+	}
+
+	units := 0
+	for i, r := range text {
+		if units == utf16Offset {
+			return i, true
+		}
+		units += utf16RuneLen(r)
+		if units > utf16Offset {
+			return 0, false
+		}
+	}
+
+	if units == utf16Offset {
+		return len(text), true
+	}
+	return 0, false
+}
+
+// NewEntityFromByteRange создает сущность для фрагмента text[start:end], заданного в байтах
+func NewEntityFromByteRange(text, entityType string, start, end int) MessageEntity {
+	offset := UTF16Offset(text, start)
+	return MessageEntity{
+		Type:   entityType,
+		Offset: offset,
+		Length: UTF16Offset(text, end) - offset,
+	}
+}
+
+// ExtractEntityText возвращает фрагмент text, который занимает сущность.
+// Возвращает false, если границы сущности не совпадают с границами символов текста
+func ExtractEntityText(text string, entity MessageEntity) (string, bool) {
+	if entity.Length < 0 {
+		return "", false
+	}
+
+	start, ok := ByteOffset(text, entity.Offset)
+	if !ok {
+		return "", false
+	}
+	length, ok := ByteOffset(text[start:], entity.Length)
+	if !ok {
+		return "", false
+	}
+	return text[start : start+length], true
+}
+
+// utf16RuneLen возвращает количество единиц UTF-16, которое занимает символ
+func utf16RuneLen(r rune) int {
+	if length := utf16.RuneLen(r); length > 0 {
+		return length
+	}
+	return 1
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestUTF16Len(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"Привет", 6},
+		{"😀", 2},
+		{"👨‍👩‍👧", 8},
+		{"a😀b", 4},
+	}
+
+	for _, tt := range tests {
+		if length := UTF16Len(tt.text); length != tt.expected {
+			t.Errorf("UTF16Len(%q) = %d, expected %d", tt.text, length, tt.expected)
+		}
+	}
+}
+
+func TestByteOffset(t *testing.T) {
+	text := "a😀Б"
+
+	tests := []struct {
+		utf16Offset int
+		byteOffset  int
+		ok          bool
+	}{
+		{0, 0, true},
+		{1, 1, true},
+		{2, 0, false}, // Inside the surrogate pair
+		{3, 5, true},
+		{4, 7, true},
+		{5, 0, false},
+		{-1, 0, false},
+	}
+
+	for _, tt := range tests {
+		byteOffset, ok := ByteOffset(text, tt.utf16Offset)
+		if ok != tt.ok || (ok && byteOffset != tt.byteOffset) {
+			t.Errorf("ByteOffset(%d) = (%d, %v), expected (%d, %v)", tt.utf16Offset, byteOffset, ok, tt.byteOffset, tt.ok)
+		}
+		if ok && UTF16Offset(text, byteOffset) != tt.utf16Offset {
+			t.Errorf("UTF16Offset(%d) did not round-trip to %d", byteOffset, tt.utf16Offset)
+		}
+	}
+}
+
+func TestExtractEntityText(t *testing.T) {
+	text := "Жми 👉 /start"
+
+	entity := NewEntityFromByteRange(text, EntityTypeBotCommand, len("Жми 👉 "), len(text))
+	if entity.Offset != 7 || entity.Length != 6 {
+		t.Errorf("Expected offset 7 and length 6, got %+v", entity)
+	}
+
+	extracted, ok := ExtractEntityText(text, entity)
+	if !ok || extracted != "/start" {
+		t.Errorf("Expected '/start', got %q (ok=%v)", extracted, ok)
+	}
+
+	// Entities splitting a surrogate pair or running past the text are rejected
+	if _, ok := ExtractEntityText(text, MessageEntity{Offset: 5, Length: 2}); ok {
+		t.Error("Expected entity starting inside a surrogate pair to be rejected")
+	}
+	if _, ok := ExtractEntityText(text, MessageEntity{Offset: 7, Length: 10}); ok {
+		t.Error("Expected entity running past the text to be rejected")
+	}
+}
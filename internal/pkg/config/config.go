@@ -13,6 +13,7 @@ type Config struct {
 	Database  DatabaseConfig  `mapstructure:"database"`
 	WebSocket WebSocketConfig `mapstructure:"websocket"`
 	Bots      BotsConfig      `mapstructure:"bots"`
+	Files     FilesConfig     `mapstructure:"files"`
 	Logging   LoggingConfig   `mapstructure:"logging"`
 }
 
@@ -39,6 +40,12 @@ type WebSocketConfig struct {
 type BotsConfig struct {
 	WebhookTimeout string `mapstructure:"webhook_timeout"`
 	MaxConnections int    `mapstructure:"max_connections"`
+	StubRulesFile  string `mapstructure:"stub_rules_file"` // YAML файл с правилами ботов-заглушек
+}
+
+// FilesConfig конфигурация хранилища файлов
+type FilesConfig struct {
+	Dir string `mapstructure:"dir"`
 }
 
 // LoggingConfig конфигурация логирования
@@ -91,6 +98,9 @@ func setDefaults() {
 
 	viper.SetDefault("bots.webhook_timeout", "30s")
 	viper.SetDefault("bots.max_connections", 100)
+	viper.SetDefault("bots.stub_rules_file", "")
+
+	viper.SetDefault("files.dir", "data/files")
 
 	viper.SetDefault("logging.level", "debug")
 	viper.SetDefault("logging.format", "console")
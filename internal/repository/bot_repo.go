@@ -56,8 +56,9 @@ func (r *BotRepository) GetActive() ([]models.Bot, error) {
 }
 
 // Update обновляет бота
+// Подтвержденный offset getUpdates обновляется только через SetLastUpdateOffset
 func (r *BotRepository) Update(bot *models.Bot) error {
-	return r.db.Save(bot).Error
+	return r.db.Omit("last_update_offset").Save(bot).Error
 }
 
 // Delete удаляет бота
@@ -75,7 +76,116 @@ func (r *BotRepository) SetWebhookURL(id int64, webhookURL string) error {
 	return r.db.Model(&models.Bot{}).Where("id = ?", id).Update("webhook_url", webhookURL).Error
 }
 
+// SetLastUpdateOffset сохраняет последний подтвержденный ботом offset getUpdates.
+// Offset может только расти, поэтому устаревшие значения игнорируются
+func (r *BotRepository) SetLastUpdateOffset(id int64, offset int64) error {
+	return r.db.Model(&models.Bot{}).
+		Where("id = ? AND last_update_offset < ?", id, offset).
+		Update("last_update_offset", offset).Error
+}
+
+// SetAllowedUpdates сохраняет типы получаемых ботом обновлений в JSON формате
+func (r *BotRepository) SetAllowedUpdates(id int64, allowedUpdatesJSON string) error {
+	return r.db.Model(&models.Bot{}).Where("id = ?", id).Update("allowed_updates", allowedUpdatesJSON).Error
+}
+
 // UpdateToken обновляет токен бота
 func (r *BotRepository) UpdateToken(id int64, token string) error {
 	return r.db.Model(&models.Bot{}).Where("id = ?", id).Update("token", token).Error
 }
+
+// SetCommands сохраняет список команд бота, заменяя список с той же областью и языком
+func (r *BotRepository) SetCommands(set *models.BotCommandSet) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.BotCommandSet
+		err := commandSetQuery(tx, set.BotID, set.Scope(), set.LanguageCode).First(&existing).Error
+		if err == gorm.ErrRecordNotFound {
+			return tx.Create(set).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		set.ID = existing.ID
+		set.CreatedAt = existing.CreatedAt
+		return tx.Save(set).Error
+	})
+}
+
+// GetCommands получает список команд бота для области scope и языка languageCode
+func (r *BotRepository) GetCommands(botID int64, scope models.BotCommandScope, languageCode string) (*models.BotCommandSet, error) {
+	var set models.BotCommandSet
+	err := commandSetQuery(r.db, botID, scope, languageCode).First(&set).Error
+	if err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+// GetAllCommands получает все списки команд бота
+func (r *BotRepository) GetAllCommands(botID int64) ([]models.BotCommandSet, error) {
+	var sets []models.BotCommandSet
+	err := r.db.Where("bot_id = ?", botID).Order("id ASC").Find(&sets).Error
+	return sets, err
+}
+
+// DeleteCommands удаляет список команд бота для области scope и языка languageCode
+func (r *BotRepository) DeleteCommands(botID int64, scope models.BotCommandScope, languageCode string) error {
+	return commandSetQuery(r.db, botID, scope, languageCode).Delete(&models.BotCommandSet{}).Error
+}
+
+// DeleteAllCommands удаляет все списки команд бота
+func (r *BotRepository) DeleteAllCommands(botID int64) error {
+	return r.db.Where("bot_id = ?", botID).Delete(&models.BotCommandSet{}).Error
+}
+
+// commandSetQuery выбирает список команд бота по области и языку
+func commandSetQuery(db *gorm.DB, botID int64, scope models.BotCommandScope, languageCode string) *gorm.DB {
+	return db.Model(&models.BotCommandSet{}).Where(
+		"bot_id = ? AND scope_type = ? AND scope_chat_id = ? AND scope_user_id = ? AND language_code = ?",
+		botID, scope.Type, scope.ChatID, scope.UserID, languageCode)
+}
+
+// CreateStubRule создает правило бота-заглушки
+func (r *BotRepository) CreateStubRule(rule *models.StubRule) error {
+	return r.db.Create(rule).Error
+}
+
+// GetStubRules получает правила бота-заглушки в порядке добавления
+func (r *BotRepository) GetStubRules(botID int64) ([]models.StubRule, error) {
+	var rules []models.StubRule
+	err := r.db.Where("bot_id = ?", botID).Order("id ASC").Find(&rules).Error
+	return rules, err
+}
+
+// ReplaceStubRules заменяет все правила бота-заглушки
+func (r *BotRepository) ReplaceStubRules(botID int64, rules []*models.StubRule) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("bot_id = ?", botID).Delete(&models.StubRule{}).Error; err != nil {
+			return err
+		}
+		for _, rule := range rules {
+			if err := tx.Create(rule).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteStubRule удаляет правило бота-заглушки. Возвращает gorm.ErrRecordNotFound, если правила нет
+func (r *BotRepository) DeleteStubRule(botID, ruleID int64) error {
+	result := r.db.Where("id = ? AND bot_id = ?", ruleID, botID).Delete(&models.StubRule{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteStubRules удаляет все правила бота-заглушки
+func (r *BotRepository) DeleteStubRules(botID int64) error {
+	return r.db.Where("bot_id = ?", botID).Delete(&models.StubRule{}).Error
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"telegram-emulator/internal/models"
+
+	"gorm.io/gorm"
 )
 
 func TestBotRepository_Create(t *testing.T) {
@@ -380,6 +382,39 @@ func TestBotRepository_SetWebhookURL(t *testing.T) {
 	}
 }
 
+func TestBotRepository_SetLastUpdateOffset(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBotRepository(db)
+
+	bot := &models.Bot{
+		Name:      "Test Bot",
+		Username:  "testbot",
+		Token:     "1234567890:ABCdefGHIjklMNOpqrsTUVwxyz",
+		IsActive:  true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	err := repo.Create(bot)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	err = repo.SetLastUpdateOffset(bot.ID, 42)
+	if err != nil {
+		t.Fatalf("Failed to set last update offset: %v", err)
+	}
+
+	retrievedBot, err := repo.GetByID(bot.ID)
+	if err != nil {
+		t.Fatalf("Failed to get bot: %v", err)
+	}
+
+	if retrievedBot.LastUpdateOffset != 42 {
+		t.Errorf("Expected last update offset 42, got %d", retrievedBot.LastUpdateOffset)
+	}
+}
+
 func TestBotRepository_SetActiveStatus(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewBotRepository(db)
@@ -416,3 +451,161 @@ func TestBotRepository_SetActiveStatus(t *testing.T) {
 		t.Error("Expected IsActive to be false")
 	}
 }
+
+func TestBotRepository_SetAllowedUpdates(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBotRepository(db)
+
+	bot := &models.Bot{
+		Name:      "Test Bot",
+		Username:  "testbot",
+		Token:     "1234567890:ABCdefGHIjklMNOpqrsTUVwxyz",
+		IsActive:  true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := repo.Create(bot); err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	if err := repo.SetAllowedUpdates(bot.ID, `["message"]`); err != nil {
+		t.Fatalf("Failed to set allowed updates: %v", err)
+	}
+
+	retrievedBot, err := repo.GetByID(bot.ID)
+	if err != nil {
+		t.Fatalf("Failed to get bot: %v", err)
+	}
+
+	allowed := retrievedBot.GetAllowedUpdates()
+	if len(allowed) != 1 || allowed[0] != "message" {
+		t.Errorf("Expected allowed updates [message], got %v", allowed)
+	}
+}
+
+func TestBotRepository_Commands(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBotRepository(db)
+
+	defaultScope := models.BotCommandScope{Type: models.BotCommandScopeDefault}
+	chatScope := models.BotCommandScope{Type: models.BotCommandScopeChat, ChatID: 42}
+
+	set, err := models.NewBotCommandSet(1, defaultScope, "", []models.BotCommand{{Command: "start", Description: "Start"}})
+	if err != nil {
+		t.Fatalf("Failed to build command set: %v", err)
+	}
+	if err := repo.SetCommands(set); err != nil {
+		t.Fatalf("Failed to set commands: %v", err)
+	}
+
+	// Setting the same scope and language again replaces the list
+	replacement, _ := models.NewBotCommandSet(1, defaultScope, "", []models.BotCommand{{Command: "help", Description: "Help"}})
+	if err := repo.SetCommands(replacement); err != nil {
+		t.Fatalf("Failed to replace commands: %v", err)
+	}
+	if replacement.ID != set.ID {
+		t.Errorf("Expected replacement to reuse row %d, got %d", set.ID, replacement.ID)
+	}
+
+	localized, _ := models.NewBotCommandSet(1, defaultScope, "ru", []models.BotCommand{{Command: "help", Description: "Помощь"}})
+	chatSet, _ := models.NewBotCommandSet(1, chatScope, "", []models.BotCommand{{Command: "stats", Description: "Stats"}})
+	otherBot, _ := models.NewBotCommandSet(2, defaultScope, "", []models.BotCommand{{Command: "start", Description: "Start"}})
+	for _, s := range []*models.BotCommandSet{localized, chatSet, otherBot} {
+		if err := repo.SetCommands(s); err != nil {
+			t.Fatalf("Failed to set commands: %v", err)
+		}
+	}
+
+	retrieved, err := repo.GetCommands(1, defaultScope, "")
+	if err != nil {
+		t.Fatalf("Failed to get commands: %v", err)
+	}
+	commands := retrieved.GetCommands()
+	if len(commands) != 1 || commands[0].Command != "help" {
+		t.Errorf("Expected replaced commands [help], got %+v", commands)
+	}
+
+	if _, err := repo.GetCommands(1, models.BotCommandScope{Type: models.BotCommandScopeChat, ChatID: 43}, ""); err != gorm.ErrRecordNotFound {
+		t.Errorf("Expected ErrRecordNotFound for another chat, got %v", err)
+	}
+
+	all, err := repo.GetAllCommands(1)
+	if err != nil {
+		t.Fatalf("Failed to get all commands: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("Expected 3 command sets for bot 1, got %d", len(all))
+	}
+
+	if err := repo.DeleteCommands(1, defaultScope, "ru"); err != nil {
+		t.Fatalf("Failed to delete commands: %v", err)
+	}
+	if _, err := repo.GetCommands(1, defaultScope, "ru"); err != gorm.ErrRecordNotFound {
+		t.Errorf("Expected localized commands to be deleted, got %v", err)
+	}
+	if _, err := repo.GetCommands(1, defaultScope, ""); err != nil {
+		t.Errorf("Expected commands for all languages to remain, got %v", err)
+	}
+
+	if err := repo.DeleteAllCommands(1); err != nil {
+		t.Fatalf("Failed to delete all commands: %v", err)
+	}
+	all, _ = repo.GetAllCommands(1)
+	if len(all) != 0 {
+		t.Errorf("Expected no command sets for bot 1, got %d", len(all))
+	}
+	if _, err := repo.GetCommands(2, defaultScope, ""); err != nil {
+		t.Errorf("Expected other bot's commands to remain, got %v", err)
+	}
+}
+
+func TestBotRepository_StubRules(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBotRepository(db)
+
+	start, _ := models.NewStubRule(1, models.StubRuleConfig{Command: "start", Text: "Hello"})
+	weather, _ := models.NewStubRule(1, models.StubRuleConfig{Pattern: "weather", Text: "Sunny"})
+	otherBot, _ := models.NewStubRule(2, models.StubRuleConfig{Command: "start", Text: "Hi"})
+	for _, rule := range []*models.StubRule{start, weather, otherBot} {
+		if err := repo.CreateStubRule(rule); err != nil {
+			t.Fatalf("Failed to create stub rule: %v", err)
+		}
+	}
+
+	rules, err := repo.GetStubRules(1)
+	if err != nil {
+		t.Fatalf("Failed to get stub rules: %v", err)
+	}
+	if len(rules) != 2 || rules[0].ID != start.ID || rules[1].ID != weather.ID {
+		t.Errorf("Expected rules of bot 1 in insertion order, got %+v", rules)
+	}
+
+	if err := repo.DeleteStubRule(2, start.ID); err != gorm.ErrRecordNotFound {
+		t.Errorf("Expected ErrRecordNotFound for another bot's rule, got %v", err)
+	}
+	if err := repo.DeleteStubRule(1, start.ID); err != nil {
+		t.Fatalf("Failed to delete stub rule: %v", err)
+	}
+
+	help, _ := models.NewStubRule(1, models.StubRuleConfig{Command: "help", Text: "Help"})
+	if err := repo.ReplaceStubRules(1, []*models.StubRule{help}); err != nil {
+		t.Fatalf("Failed to replace stub rules: %v", err)
+	}
+	rules, _ = repo.GetStubRules(1)
+	if len(rules) != 1 || rules[0].Command != "help" {
+		t.Errorf("Expected only the replacement rule, got %+v", rules)
+	}
+
+	if err := repo.DeleteStubRules(1); err != nil {
+		t.Fatalf("Failed to delete stub rules: %v", err)
+	}
+	rules, _ = repo.GetStubRules(1)
+	if len(rules) != 0 {
+		t.Errorf("Expected no rules for bot 1, got %d", len(rules))
+	}
+	rules, _ = repo.GetStubRules(2)
+	if len(rules) != 1 {
+		t.Errorf("Expected other bot's rules to remain, got %d", len(rules))
+	}
+}
@@ -26,28 +26,36 @@ func (r *ChatRepository) Create(chat *models.Chat) error {
 // GetByID получает чат по ID
 func (r *ChatRepository) GetByID(id int64) (*models.Chat, error) {
 	var chat models.Chat
-	err := r.db.Preload("Members").Preload("LastMessage").Where("id = ?", id).First(&chat).Error
+	err := r.db.Preload("LastMessage").Where("id = ?", id).First(&chat).Error
 	if err != nil {
 		return nil, err
 	}
+	if err := r.loadMembers(&chat); err != nil {
+		return nil, err
+	}
 	return &chat, nil
 }
 
 // GetAll получает все чаты
 func (r *ChatRepository) GetAll() ([]models.Chat, error) {
 	var chats []models.Chat
-	err := r.db.Preload("Members").Preload("LastMessage").Find(&chats).Error
-	return chats, err
+	if err := r.db.Preload("LastMessage").Find(&chats).Error; err != nil {
+		return nil, err
+	}
+	return chats, r.loadMembers(chatPointers(chats)...)
 }
 
 // GetByUserID получает чаты пользователя
 func (r *ChatRepository) GetByUserID(userID int64) ([]models.Chat, error) {
 	var chats []models.Chat
-	err := r.db.Preload("Members").Preload("LastMessage").
+	err := r.db.Preload("LastMessage").
 		Joins("JOIN chat_members ON chats.id = chat_members.chat_id").
-		Where("chat_members.user_id = ?", userID).
+		Where("chat_members.user_id = ? AND chat_members.status IN ?", userID, models.ActiveChatMemberStatuses()).
 		Find(&chats).Error
-	return chats, err
+	if err != nil {
+		return nil, err
+	}
+	return chats, r.loadMembers(chatPointers(chats)...)
 }
 
 // Update обновляет чат
@@ -60,14 +68,15 @@ func (r *ChatRepository) Delete(id int64) error {
 	return r.db.Where("id = ?", id).Delete(&models.Chat{}).Error
 }
 
-// AddMember добавляет участника в чат
+// AddMember добавляет участника в чат. Вышедший пользователь возвращается в чат обычным участником
 func (r *ChatRepository) AddMember(chatID int64, userID int64) error {
 	chatMember := models.ChatMember{
 		ChatID:   chatID,
 		UserID:   userID,
+		Status:   models.ChatMemberStatusMember,
 		JoinedAt: time.Now(),
 	}
-	return r.db.Create(&chatMember).Error
+	return r.db.Save(&chatMember).Error
 }
 
 // RemoveMember удаляет участника из чата
@@ -75,15 +84,107 @@ func (r *ChatRepository) RemoveMember(chatID int64, userID int64) error {
 	return r.db.Where("chat_id = ? AND user_id = ?", chatID, userID).Delete(&models.ChatMember{}).Error
 }
 
+// GetMember получает участие пользователя в чате, в том числе вышедшего или заблокированного
+func (r *ChatRepository) GetMember(chatID int64, userID int64) (*models.ChatMember, error) {
+	var chatMember models.ChatMember
+	err := r.db.Where("chat_id = ? AND user_id = ?", chatID, userID).First(&chatMember).Error
+	if err != nil {
+		return nil, err
+	}
+	return &chatMember, nil
+}
+
+// SaveMember создает или обновляет участие пользователя в чате
+func (r *ChatRepository) SaveMember(chatMember *models.ChatMember) error {
+	return r.db.Save(chatMember).Error
+}
+
+// GetMemberRecords получает статусы и права участников, которые состоят в чате
+func (r *ChatRepository) GetMemberRecords(chatID int64) ([]models.ChatMember, error) {
+	var chatMembers []models.ChatMember
+	err := r.db.Where("chat_id = ? AND status IN ?", chatID, models.ActiveChatMemberStatuses()).
+		Order("joined_at ASC").
+		Find(&chatMembers).Error
+	return chatMembers, err
+}
+
+// GetAllMemberRecords получает записи всех пользователей, которые когда-либо состояли в чате,
+// включая вышедших и заблокированных
+func (r *ChatRepository) GetAllMemberRecords(chatID int64) ([]models.ChatMember, error) {
+	var chatMembers []models.ChatMember
+	err := r.db.Where("chat_id = ?", chatID).
+		Order("joined_at ASC").
+		Find(&chatMembers).Error
+	return chatMembers, err
+}
+
+// CountMembers возвращает количество участников, которые состоят в чате
+func (r *ChatRepository) CountMembers(chatID int64) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.ChatMember{}).
+		Where("chat_id = ? AND status IN ?", chatID, models.ActiveChatMemberStatuses()).
+		Count(&count).Error
+	return count, err
+}
+
 // GetMembers получает участников чата
 func (r *ChatRepository) GetMembers(chatID int64) ([]models.User, error) {
 	var users []models.User
 	err := r.db.Joins("JOIN chat_members ON users.id = chat_members.user_id").
-		Where("chat_members.chat_id = ?", chatID).
+		Where("chat_members.chat_id = ? AND chat_members.status IN ?", chatID, models.ActiveChatMemberStatuses()).
 		Find(&users).Error
 	return users, err
 }
 
+// loadMembers загружает участников чатов. Вышедшие и заблокированные пользователи не загружаются,
+// поэтому вместо Preload("Members") используется запрос с фильтром по статусу
+func (r *ChatRepository) loadMembers(chats ...*models.Chat) error {
+	if len(chats) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(chats))
+	for _, chat := range chats {
+		ids = append(ids, chat.ID)
+	}
+
+	var rows []struct {
+		ChatID int64
+		models.User
+	}
+	err := r.db.Model(&models.User{}).
+		Select("chat_members.chat_id, users.*").
+		Joins("JOIN chat_members ON users.id = chat_members.user_id").
+		Where("chat_members.chat_id IN ? AND chat_members.status IN ?", ids, models.ActiveChatMemberStatuses()).
+		Order("chat_members.joined_at ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	byChat := make(map[int64][]models.User, len(chats))
+	for _, row := range rows {
+		byChat[row.ChatID] = append(byChat[row.ChatID], row.User)
+	}
+	for _, chat := range chats {
+		chat.Members = byChat[chat.ID]
+		if chat.Members == nil {
+			chat.Members = []models.User{}
+		}
+	}
+
+	return nil
+}
+
+// chatPointers возвращает указатели на элементы среза чатов
+func chatPointers(chats []models.Chat) []*models.Chat {
+	pointers := make([]*models.Chat, 0, len(chats))
+	for i := range chats {
+		pointers = append(pointers, &chats[i])
+	}
+	return pointers
+}
+
 // UpdateUnreadCount обновляет счетчик непрочитанных сообщений
 func (r *ChatRepository) UpdateUnreadCount(chatID int64, count int) error {
 	return r.db.Model(&models.Chat{}).Where("id = ?", chatID).Update("unread_count", count).Error
@@ -92,7 +193,7 @@ func (r *ChatRepository) UpdateUnreadCount(chatID int64, count int) error {
 // GetPrivateChat получает приватный чат между двумя пользователями
 func (r *ChatRepository) GetPrivateChat(userID1, userID2 int64) (*models.Chat, error) {
 	var chat models.Chat
-	err := r.db.Preload("Members").
+	err := r.db.
 		Joins("JOIN chat_members cm1 ON chats.id = cm1.chat_id").
 		Joins("JOIN chat_members cm2 ON chats.id = cm2.chat_id").
 		Where("chats.type = ? AND cm1.user_id = ? AND cm2.user_id = ?", "private", userID1, userID2).
@@ -100,5 +201,8 @@ func (r *ChatRepository) GetPrivateChat(userID1, userID2 int64) (*models.Chat, e
 	if err != nil {
 		return nil, err
 	}
+	if err := r.loadMembers(&chat); err != nil {
+		return nil, err
+	}
 	return &chat, nil
 }
@@ -222,3 +222,80 @@ func TestChatRepository_DeleteNonExistent(t *testing.T) {
 		t.Errorf("Unexpected error when deleting non-existent chat: %v", err)
 	}
 }
+
+func TestChatRepository_Members(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewChatRepository(db)
+
+	chat := &models.Chat{ID: 100, Type: "group", Title: "Group"}
+	if err := repo.Create(chat); err != nil {
+		t.Fatalf("Failed to create chat: %v", err)
+	}
+	for _, user := range []*models.User{{ID: 1, Username: "owner"}, {ID: 2, Username: "member"}, {ID: 3, Username: "former"}} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	members := []*models.ChatMember{
+		{ChatID: chat.ID, UserID: 1, Status: models.ChatMemberStatusCreator, JoinedAt: time.Now()},
+		{ChatID: chat.ID, UserID: 2, Status: models.ChatMemberStatusMember, JoinedAt: time.Now().Add(time.Second)},
+		{ChatID: chat.ID, UserID: 3, Status: models.ChatMemberStatusLeft, JoinedAt: time.Now().Add(2 * time.Second)},
+	}
+	for _, member := range members {
+		if err := repo.SaveMember(member); err != nil {
+			t.Fatalf("Failed to save member: %v", err)
+		}
+	}
+
+	// Users who left the chat are kept but not loaded as members
+	retrieved, err := repo.GetByID(chat.ID)
+	if err != nil {
+		t.Fatalf("Failed to get chat: %v", err)
+	}
+	if len(retrieved.Members) != 2 || retrieved.Members[0].ID != 1 || retrieved.IsUserMember(3) {
+		t.Errorf("Expected active members [1 2], got %+v", retrieved.Members)
+	}
+
+	users, err := repo.GetMembers(chat.ID)
+	if err != nil || len(users) != 2 {
+		t.Errorf("Expected 2 active members, got %d (%v)", len(users), err)
+	}
+	records, err := repo.GetMemberRecords(chat.ID)
+	if err != nil || len(records) != 2 || records[0].Status != models.ChatMemberStatusCreator {
+		t.Errorf("Expected creator first among active member records, got %+v (%v)", records, err)
+	}
+	if chats, _ := repo.GetByUserID(3); len(chats) != 0 {
+		t.Errorf("Expected no chats for the user who left, got %d", len(chats))
+	}
+
+	// Saving an existing member updates the row
+	members[1].Status = models.ChatMemberStatusAdministrator
+	members[1].CanDeleteMessages = true
+	if err := repo.SaveMember(members[1]); err != nil {
+		t.Fatalf("Failed to update member: %v", err)
+	}
+	member, err := repo.GetMember(chat.ID, 2)
+	if err != nil {
+		t.Fatalf("Failed to get member: %v", err)
+	}
+	if member.Status != models.ChatMemberStatusAdministrator || !member.CanDeleteMessages {
+		t.Errorf("Expected administrator with can_delete_messages, got %+v", member)
+	}
+
+	former, err := repo.GetMember(chat.ID, 3)
+	if err != nil || former.Status != models.ChatMemberStatusLeft {
+		t.Errorf("Expected left member to be kept, got %+v (%v)", former, err)
+	}
+	if count, err := repo.CountMembers(chat.ID); err != nil || count != 2 {
+		t.Errorf("Expected 2 counted members, got %d (%v)", count, err)
+	}
+	if records, err := repo.GetAllMemberRecords(chat.ID); err != nil || len(records) != 3 {
+		t.Errorf("Expected all 3 member records including the left one, got %d (%v)", len(records), err)
+	}
+
+	all, err := repo.GetAll()
+	if err != nil || len(all) != 1 || len(all[0].Members) != 2 {
+		t.Errorf("Expected GetAll to load active members, got %+v (%v)", all, err)
+	}
+}
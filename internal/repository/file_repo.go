@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"telegram-emulator/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// FileRepository управляет операциями с файлами в базе данных
+type FileRepository struct {
+	db *gorm.DB
+}
+
+// NewFileRepository создает новый экземпляр FileRepository
+func NewFileRepository(db *gorm.DB) *FileRepository {
+	return &FileRepository{db: db}
+}
+
+// Create создает запись о файле
+func (r *FileRepository) Create(file *models.File) error {
+	return r.db.Create(file).Error
+}
+
+// GetByID получает файл по ID
+func (r *FileRepository) GetByID(id int64) (*models.File, error) {
+	var file models.File
+	err := r.db.Where("id = ?", id).First(&file).Error
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// GetByFileID получает файл по file_id
+func (r *FileRepository) GetByFileID(fileID string) (*models.File, error) {
+	var file models.File
+	err := r.db.Where("file_id = ?", fileID).First(&file).Error
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// GetByFilePath получает файл по пути к его содержимому
+func (r *FileRepository) GetByFilePath(filePath string) (*models.File, error) {
+	var file models.File
+	err := r.db.Where("file_path = ?", filePath).First(&file).Error
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// GetPhotoSizes получает все размеры фотографии, упорядоченные по возрастанию
+func (r *FileRepository) GetPhotoSizes(groupID string) ([]models.File, error) {
+	var files []models.File
+	err := r.db.Where("group_id = ? AND type = ?", groupID, models.FileTypePhoto).
+		Order("width * height ASC").Order("id ASC").
+		Find(&files).Error
+	return files, err
+}
+
+// SetFilePath устанавливает путь к содержимому файла
+func (r *FileRepository) SetFilePath(id int64, filePath string) error {
+	return r.db.Model(&models.File{}).Where("id = ?", id).Update("file_path", filePath).Error
+}
+
+// Delete удаляет запись о файле
+func (r *FileRepository) Delete(id int64) error {
+	return r.db.Delete(&models.File{}, id).Error
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"testing"
+
+	"telegram-emulator/internal/models"
+)
+
+func TestFileRepository_CreateAndGetByFileID(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewFileRepository(db)
+
+	file := &models.File{
+		FileID:       "file-id",
+		FileUniqueID: "unique-id",
+		Type:         models.FileTypeDocument,
+		FileName:     "report.pdf",
+		MimeType:     "application/pdf",
+		FileSize:     1024,
+	}
+	if err := repo.Create(file); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	if err := repo.SetFilePath(file.ID, "documents/file_1.pdf"); err != nil {
+		t.Fatalf("Failed to set file path: %v", err)
+	}
+
+	found, err := repo.GetByFileID("file-id")
+	if err != nil {
+		t.Fatalf("Failed to get file: %v", err)
+	}
+
+	if found.ID != file.ID || found.FileName != "report.pdf" {
+		t.Errorf("Expected file %d report.pdf, got %d %s", file.ID, found.ID, found.FileName)
+	}
+
+	if found.FilePath != "documents/file_1.pdf" {
+		t.Errorf("Expected file path documents/file_1.pdf, got %s", found.FilePath)
+	}
+
+	if _, err := repo.GetByFileID("missing"); err == nil {
+		t.Error("Expected error for unknown file_id")
+	}
+
+	byPath, err := repo.GetByFilePath("documents/file_1.pdf")
+	if err != nil {
+		t.Fatalf("Failed to get file by path: %v", err)
+	}
+	if byPath.FileID != "file-id" {
+		t.Errorf("Expected file-id, got %s", byPath.FileID)
+	}
+}
+
+func TestFileRepository_GetPhotoSizes(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewFileRepository(db)
+
+	sizes := []models.File{
+		{FileID: "large", Type: models.FileTypePhoto, Width: 1280, Height: 960, GroupID: "photo"},
+		{FileID: "small", Type: models.FileTypePhoto, Width: 90, Height: 68, GroupID: "photo"},
+		{FileID: "other", Type: models.FileTypePhoto, Width: 320, Height: 240, GroupID: "another"},
+	}
+	for i := range sizes {
+		if err := repo.Create(&sizes[i]); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	files, err := repo.GetPhotoSizes("photo")
+	if err != nil {
+		t.Fatalf("Failed to get photo sizes: %v", err)
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 sizes, got %d", len(files))
+	}
+
+	if files[0].FileID != "small" || files[1].FileID != "large" {
+		t.Errorf("Expected sizes ordered from small to large, got %s and %s", files[0].FileID, files[1].FileID)
+	}
+}
@@ -28,6 +28,9 @@ func (r *MessageRepository) GetByID(id int64) (*models.Message, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := r.loadReplies(&message); err != nil {
+		return nil, err
+	}
 	return &message, nil
 }
 
@@ -50,20 +53,66 @@ func (r *MessageRepository) GetByChatID(chatID int64, limit, offset int) ([]mode
 	}
 
 	// Загружаем связанные данные
+	replied := make([]*models.Message, 0, len(messages))
 	for i := range messages {
 		if err := r.db.Model(&messages[i]).Association("From").Find(&messages[i].From); err != nil {
 			return nil, err
 		}
+		replied = append(replied, &messages[i])
+	}
+	if err := r.loadReplies(replied...); err != nil {
+		return nil, err
 	}
 
 	return messages, nil
 }
 
+// loadReplies загружает сообщения, на которые отвечают messages.
+// Если исходное сообщение удалено, ReplyToMessage остается пустым
+func (r *MessageRepository) loadReplies(messages ...*models.Message) error {
+	var ids []int64
+	for _, message := range messages {
+		if message.ReplyToMessageID != nil {
+			ids = append(ids, *message.ReplyToMessageID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var replies []models.Message
+	if err := r.db.Preload("From").Where("id IN ?", ids).Find(&replies).Error; err != nil {
+		return err
+	}
+
+	byID := make(map[int64]*models.Message, len(replies))
+	for i := range replies {
+		byID[replies[i].ID] = &replies[i]
+	}
+	for _, message := range messages {
+		if message.ReplyToMessageID != nil {
+			message.ReplyToMessage = byID[*message.ReplyToMessageID]
+		}
+	}
+
+	return nil
+}
+
 // Update обновляет сообщение
 func (r *MessageRepository) Update(message *models.Message) error {
 	return r.db.Save(message).Error
 }
 
+// UpdateContent сохраняет отредактированные текст, сущности, клавиатуру и время редактирования
+func (r *MessageRepository) UpdateContent(message *models.Message) error {
+	return r.db.Model(&models.Message{}).Where("id = ?", message.ID).Updates(map[string]interface{}{
+		"text":         message.Text,
+		"entities":     message.EntitiesJSON,
+		"reply_markup": message.ReplyMarkupJSON,
+		"edit_date":    message.EditDate,
+	}).Error
+}
+
 // Delete удаляет сообщение
 func (r *MessageRepository) Delete(id int64) error {
 	return r.db.Where("id = ?", id).Delete(&models.Message{}).Error
@@ -470,3 +470,49 @@ func TestMessageRepository_MarkAsRead(t *testing.T) {
 		t.Errorf("Expected 0 unread messages, got %d", count)
 	}
 }
+
+func TestMessageRepository_LoadsReplies(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewMessageRepository(db)
+
+	original := &models.Message{ChatID: 1, FromID: 1, Text: "Question", Type: "text", Timestamp: time.Now(), CreatedAt: time.Now()}
+	if err := repo.Create(original); err != nil {
+		t.Fatalf("Failed to create message: %v", err)
+	}
+
+	reply := &models.Message{ChatID: 1, FromID: 2, Text: "Answer", Type: "text", Timestamp: time.Now(), CreatedAt: time.Now()}
+	reply.SetReplyTo(original)
+	if err := repo.Create(reply); err != nil {
+		t.Fatalf("Failed to create reply: %v", err)
+	}
+
+	retrieved, err := repo.GetByID(reply.ID)
+	if err != nil {
+		t.Fatalf("Failed to get reply: %v", err)
+	}
+	if retrieved.ReplyToMessage == nil || retrieved.ReplyToMessage.Text != "Question" {
+		t.Errorf("Expected replied message to be loaded, got %+v", retrieved.ReplyToMessage)
+	}
+
+	messages, err := repo.GetByChatID(1, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get messages: %v", err)
+	}
+	for _, message := range messages {
+		if message.ID == reply.ID && (message.ReplyToMessage == nil || message.ReplyToMessage.ID != original.ID) {
+			t.Errorf("Expected reply in chat history to reference the original, got %+v", message.ReplyToMessage)
+		}
+	}
+
+	// A reply to a deleted message keeps the id but has no nested message
+	if err := repo.Delete(original.ID); err != nil {
+		t.Fatalf("Failed to delete message: %v", err)
+	}
+	retrieved, err = repo.GetByID(reply.ID)
+	if err != nil {
+		t.Fatalf("Failed to get reply: %v", err)
+	}
+	if !retrieved.IsReply() || retrieved.ReplyToMessage != nil {
+		t.Errorf("Expected reply without nested message, got id %v message %+v", retrieved.ReplyToMessageID, retrieved.ReplyToMessage)
+	}
+}
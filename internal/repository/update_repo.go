@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"time"
+
+	"telegram-emulator/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UpdateRepository представляет репозиторий для работы с очередями обновлений ботов
+type UpdateRepository struct {
+	db *gorm.DB
+}
+
+// NewUpdateRepository создает новый экземпляр UpdateRepository
+func NewUpdateRepository(db *gorm.DB) *UpdateRepository {
+	return &UpdateRepository{db: db}
+}
+
+// Append назначает обновлению следующий update_id бота (не меньше minUpdateID) и сохраняет его в очереди
+func (r *UpdateRepository) Append(botID int64, update *models.Update, minUpdateID int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		sequence := models.UpdateSequence{BotID: botID, NextUpdateID: 1}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&sequence).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("bot_id = ?", botID).First(&sequence).Error; err != nil {
+			return err
+		}
+
+		updateID := sequence.NextUpdateID
+		if updateID < minUpdateID {
+			updateID = minUpdateID
+		}
+
+		update.UpdateID = updateID
+		if update.Timestamp.IsZero() {
+			update.Timestamp = time.Now()
+		}
+
+		record, err := models.NewUpdateRecord(botID, update)
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.UpdateSequence{}).
+			Where("bot_id = ?", botID).
+			Update("next_update_id", updateID+1).Error
+	})
+}
+
+// GetFrom получает обновления бота с update_id >= fromUpdateID в порядке возрастания
+func (r *UpdateRepository) GetFrom(botID int64, fromUpdateID int64, limit int) ([]models.Update, error) {
+	var records []models.UpdateRecord
+
+	query := r.db.Where("bot_id = ? AND update_id >= ?", botID, fromUpdateID).Order("update_id ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	updates := make([]models.Update, 0, len(records))
+	for i := range records {
+		update, err := records[i].ToUpdate()
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, *update)
+	}
+
+	return updates, nil
+}
+
+// GetNextUpdateID возвращает следующий update_id бота
+func (r *UpdateRepository) GetNextUpdateID(botID int64) (int64, error) {
+	var sequence models.UpdateSequence
+	err := r.db.Where("bot_id = ?", botID).Limit(1).Find(&sequence).Error
+	if err != nil {
+		return 0, err
+	}
+	if sequence.NextUpdateID == 0 {
+		return 1, nil
+	}
+	return sequence.NextUpdateID, nil
+}
+
+// Count возвращает количество обновлений в очереди бота
+func (r *UpdateRepository) Count(botID int64) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.UpdateRecord{}).Where("bot_id = ?", botID).Count(&count).Error
+	return count, err
+}
+
+// DeleteBefore удаляет обновления бота с update_id < updateID
+func (r *UpdateRepository) DeleteBefore(botID int64, updateID int64) error {
+	return r.db.Where("bot_id = ? AND update_id < ?", botID, updateID).Delete(&models.UpdateRecord{}).Error
+}
+
+// Delete удаляет одно обновление бота
+func (r *UpdateRepository) Delete(botID int64, updateID int64) error {
+	return r.db.Where("bot_id = ? AND update_id = ?", botID, updateID).Delete(&models.UpdateRecord{}).Error
+}
+
+// KeepLast оставляет в очереди бота только последние count обновлений
+func (r *UpdateRepository) KeepLast(botID int64, count int) error {
+	var records []models.UpdateRecord
+	err := r.db.Select("update_id").
+		Where("bot_id = ?", botID).
+		Order("update_id DESC").
+		Offset(count - 1).
+		Limit(1).
+		Find(&records).Error
+	if err != nil || len(records) == 0 {
+		return err
+	}
+	return r.DeleteBefore(botID, records[0].UpdateID)
+}
+
+// DeleteByBotID удаляет все обновления бота, сохраняя его счетчик update_id
+func (r *UpdateRepository) DeleteByBotID(botID int64) error {
+	return r.db.Where("bot_id = ?", botID).Delete(&models.UpdateRecord{}).Error
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"testing"
+
+	"telegram-emulator/internal/models"
+)
+
+func TestUpdateRepository_AppendAndGetFrom(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUpdateRepository(db)
+
+	message := &models.Message{ID: 42, ChatID: 7, Text: "Привет", Type: models.MessageTypeText}
+	for i := 0; i < 3; i++ {
+		if err := repo.Append(1, &models.Update{Message: message}, 0); err != nil {
+			t.Fatalf("Failed to append update: %v", err)
+		}
+	}
+
+	updates, err := repo.GetFrom(1, 2, 10)
+	if err != nil {
+		t.Fatalf("Failed to get updates: %v", err)
+	}
+
+	if len(updates) != 2 {
+		t.Fatalf("Expected 2 updates, got %d", len(updates))
+	}
+
+	if updates[0].UpdateID != 2 || updates[1].UpdateID != 3 {
+		t.Errorf("Expected update_ids 2 and 3, got %d and %d", updates[0].UpdateID, updates[1].UpdateID)
+	}
+
+	if updates[0].Message == nil || updates[0].Message.Text != "Привет" {
+		t.Errorf("Expected message payload to be restored, got %+v", updates[0].Message)
+	}
+}
+
+func TestUpdateRepository_SequencesArePerBot(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUpdateRepository(db)
+
+	first := &models.Update{}
+	second := &models.Update{}
+	other := &models.Update{}
+
+	if err := repo.Append(1, first, 0); err != nil {
+		t.Fatalf("Failed to append update: %v", err)
+	}
+	if err := repo.Append(1, second, 0); err != nil {
+		t.Fatalf("Failed to append update: %v", err)
+	}
+	if err := repo.Append(2, other, 0); err != nil {
+		t.Fatalf("Failed to append update: %v", err)
+	}
+
+	if first.UpdateID != 1 || second.UpdateID != 2 {
+		t.Errorf("Expected update_ids 1 and 2 for bot 1, got %d and %d", first.UpdateID, second.UpdateID)
+	}
+
+	if other.UpdateID != 1 {
+		t.Errorf("Expected update_id 1 for bot 2, got %d", other.UpdateID)
+	}
+}
+
+func TestUpdateRepository_AppendRespectsMinUpdateID(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUpdateRepository(db)
+
+	update := &models.Update{}
+	if err := repo.Append(1, update, 50); err != nil {
+		t.Fatalf("Failed to append update: %v", err)
+	}
+
+	if update.UpdateID != 50 {
+		t.Errorf("Expected update_id 50, got %d", update.UpdateID)
+	}
+
+	next, err := repo.GetNextUpdateID(1)
+	if err != nil {
+		t.Fatalf("Failed to get next update_id: %v", err)
+	}
+
+	if next != 51 {
+		t.Errorf("Expected next update_id 51, got %d", next)
+	}
+}
+
+func TestUpdateRepository_DeleteKeepsSequence(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUpdateRepository(db)
+
+	for i := 0; i < 5; i++ {
+		if err := repo.Append(1, &models.Update{}, 0); err != nil {
+			t.Fatalf("Failed to append update: %v", err)
+		}
+	}
+
+	if err := repo.DeleteBefore(1, 3); err != nil {
+		t.Fatalf("Failed to delete updates: %v", err)
+	}
+
+	count, err := repo.Count(1)
+	if err != nil {
+		t.Fatalf("Failed to count updates: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 updates after DeleteBefore, got %d", count)
+	}
+
+	if err := repo.KeepLast(1, 1); err != nil {
+		t.Fatalf("Failed to keep last update: %v", err)
+	}
+
+	updates, err := repo.GetFrom(1, 0, 10)
+	if err != nil {
+		t.Fatalf("Failed to get updates: %v", err)
+	}
+	if len(updates) != 1 || updates[0].UpdateID != 5 {
+		t.Errorf("Expected only update_id 5 to remain, got %+v", updates)
+	}
+
+	if err := repo.DeleteByBotID(1); err != nil {
+		t.Fatalf("Failed to delete updates: %v", err)
+	}
+
+	update := &models.Update{}
+	if err := repo.Append(1, update, 0); err != nil {
+		t.Fatalf("Failed to append update: %v", err)
+	}
+	if update.UpdateID != 6 {
+		t.Errorf("Expected update_id 6 after clearing queue, got %d", update.UpdateID)
+	}
+}
@@ -17,7 +17,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	}
 
 	// Auto migrate models
-	err = db.AutoMigrate(&models.User{}, &models.Chat{}, &models.Message{}, &models.Bot{}, &models.ChatMember{})
+	err = db.AutoMigrate(&models.User{}, &models.Chat{}, &models.Message{}, &models.Bot{}, &models.ChatMember{}, &models.UpdateRecord{}, &models.UpdateSequence{}, &models.File{}, &models.BotCommandSet{}, &models.StubRule{})
 	if err != nil {
 		t.Fatalf("Failed to migrate test database: %v", err)
 	}
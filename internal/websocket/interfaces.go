@@ -5,4 +5,7 @@ import "telegram-emulator/internal/models"
 // MessageManagerInterface определяет интерфейс для MessageManager
 type MessageManagerInterface interface {
 	SendMessage(chatID int64, fromUserID int64, text, messageType string, replyMarkup interface{}) (*models.Message, error)
+	SendFileMessage(chatID int64, fromUserID int64, messageType, fileID, caption string, replyToMessageID int64) (*models.Message, error)
+	SendReply(chatID int64, fromUserID int64, text string, replyToMessageID int64) (*models.Message, error)
+	SendChatAction(chatID, userID int64, action string) error
 }
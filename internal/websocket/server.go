@@ -17,9 +17,19 @@ import (
 	"go.uber.org/zap"
 )
 
+// callbackClientTTL задает время, в течение которого сервер помнит клиента, нажавшего inline кнопку
+const callbackClientTTL = time.Minute
+
+// callbackClient связывает callback query с клиентом, от которого он пришел
+type callbackClient struct {
+	client    *Client
+	createdAt time.Time
+}
+
 // Server представляет WebSocket сервер
 type Server struct {
 	clients        map[*Client]bool
+	callbacks      map[string]callbackClient // Клиенты, ожидающие ответа на callback query
 	broadcast      chan *Message
 	register       chan *Client
 	unregister     chan *Client
@@ -48,6 +58,7 @@ type Message struct {
 func NewServer() *Server {
 	return &Server{
 		clients:    make(map[*Client]bool),
+		callbacks:  make(map[string]callbackClient),
 		broadcast:  make(chan *Message),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
@@ -83,6 +94,11 @@ func (s *Server) Start() {
 				delete(s.clients, client)
 				close(client.send)
 			}
+			for queryID, pending := range s.callbacks {
+				if pending.client == client {
+					delete(s.callbacks, queryID)
+				}
+			}
 			s.mutex.Unlock()
 			s.logger.Info("Клиент отключен", zap.Int64("user_id", client.userID))
 
@@ -149,6 +165,50 @@ func (s *Server) BroadcastToUser(userID int64, messageType string, data interfac
 		zap.Int("clients_found", clientCount))
 }
 
+// trackCallbackQuery запоминает клиента, от которого пришел callback query
+func (s *Server) trackCallbackQuery(queryID string, client *Client) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	for id, pending := range s.callbacks {
+		if now.Sub(pending.createdAt) > callbackClientTTL {
+			delete(s.callbacks, id)
+		}
+	}
+	s.callbacks[queryID] = callbackClient{client: client, createdAt: now}
+}
+
+// SendCallbackAnswer отправляет ответ бота на callback query клиенту, нажавшему кнопку.
+// Если клиент неизвестен или уже отключился, ответ отправляется всем клиентам пользователя
+func (s *Server) SendCallbackAnswer(queryID string, userID int64, data interface{}) {
+	message := &Message{
+		Type: "callback_answer",
+		Data: data,
+	}
+
+	s.mutex.Lock()
+	pending, tracked := s.callbacks[queryID]
+	delete(s.callbacks, queryID)
+	if tracked && s.clients[pending.client] {
+		select {
+		case pending.client.send <- s.serializeMessage(message):
+			s.mutex.Unlock()
+			s.logger.Info("Ответ на callback query отправлен клиенту",
+				zap.String("callback_query_id", queryID),
+				zap.Int64("user_id", pending.client.userID))
+			return
+		default:
+			s.logger.Warn("Канал клиента переполнен, ответ на callback query не доставлен",
+				zap.String("callback_query_id", queryID),
+				zap.Int64("user_id", pending.client.userID))
+		}
+	}
+	s.mutex.Unlock()
+
+	s.BroadcastToUser(userID, message.Type, data)
+}
+
 // serializeMessage сериализует сообщение в JSON
 func (s *Server) serializeMessage(message *Message) []byte {
 	data, err := json.Marshal(message)
@@ -330,13 +390,48 @@ func (c *Client) handleSubscribe(data interface{}) {
 	}
 }
 
-// handleTyping обрабатывает событие печати
+// handleTyping обрабатывает событие печати.
+// Событие сохраняется как действие пользователя в чате и рассылается остальным участникам
 func (c *Client) handleTyping(data interface{}) {
-	// Отправляем событие печати другим пользователям в чате
-	c.server.Broadcast("typing", map[string]interface{}{
-		"user_id": c.userID,
-		"data":    data,
-	})
+	dataMap, ok := data.(map[string]interface{})
+	if !ok {
+		c.logger.Error("Неверный формат данных для typing")
+		return
+	}
+
+	// Обрабатываем chat_id как float64 или строку
+	var chatID int64
+	if chatIDFloat, ok := dataMap["chat_id"].(float64); ok {
+		chatID = int64(chatIDFloat)
+	} else if chatIDStr, ok := dataMap["chat_id"].(string); ok {
+		chatIDParsed, err := strconv.ParseInt(chatIDStr, 10, 64)
+		if err != nil {
+			c.logger.Error("Неверный формат chat_id", zap.Error(err))
+			return
+		}
+		chatID = chatIDParsed
+	} else {
+		c.logger.Error("Отсутствует или неверный формат chat_id в typing")
+		return
+	}
+
+	// Клиент может передать другое действие, например upload_photo
+	action, _ := dataMap["action"].(string)
+	if action == "" {
+		action = models.ChatActionTyping
+	}
+
+	if c.server.messageManager == nil {
+		c.logger.Error("MessageManager не установлен")
+		return
+	}
+
+	if err := c.server.messageManager.SendChatAction(chatID, c.userID, action); err != nil {
+		c.logger.Warn("Ошибка обработки события печати",
+			zap.Int64("chat_id", chatID),
+			zap.Int64("user_id", c.userID),
+			zap.Error(err))
+	}
 }
 
 // handlePing обрабатывает ping сообщения
@@ -380,12 +475,28 @@ func (c *Client) handleSendMessage(data interface{}) {
 		return
 	}
 
-	text, ok := dataMap["text"].(string)
-	if !ok {
+	// Сообщение с вложением ссылается на файл, загруженный через /api/files, текст становится подписью
+	text, _ := dataMap["text"].(string)
+	fileID, _ := dataMap["file_id"].(string)
+	messageType, _ := dataMap["type"].(string)
+	if fileID == "" && text == "" {
 		c.logger.Error("Отсутствует text в send_message")
 		return
 	}
 
+	// Ответ на сообщение: reply_to_message_id передается числом или строкой
+	var replyToMessageID int64
+	if replyToFloat, ok := dataMap["reply_to_message_id"].(float64); ok {
+		replyToMessageID = int64(replyToFloat)
+	} else if replyToStr, ok := dataMap["reply_to_message_id"].(string); ok && replyToStr != "" {
+		parsedReplyTo, err := strconv.ParseInt(replyToStr, 10, 64)
+		if err != nil {
+			c.logger.Error("Неверный формат reply_to_message_id", zap.Error(err))
+			return
+		}
+		replyToMessageID = parsedReplyTo
+	}
+
 	// Получаем from_user_id, если не передан - используем текущего пользователя
 	var fromUserID int64
 	if fromUserIDStr, ok := dataMap["from_user_id"].(string); ok {
@@ -419,7 +530,15 @@ func (c *Client) handleSendMessage(data interface{}) {
 			zap.String("text", text))
 
 		// Вызываем метод SendMessage напрямую через интерфейс
-		message, err := c.server.messageManager.SendMessage(chatID, fromUserID, text, "text", nil)
+		var message *models.Message
+		var err error
+		if fileID != "" {
+			message, err = c.server.messageManager.SendFileMessage(chatID, fromUserID, messageType, fileID, text, replyToMessageID)
+		} else if replyToMessageID != 0 {
+			message, err = c.server.messageManager.SendReply(chatID, fromUserID, text, replyToMessageID)
+		} else {
+			message, err = c.server.messageManager.SendMessage(chatID, fromUserID, text, "text", nil)
+		}
 
 		if err != nil {
 			c.logger.Error("Ошибка отправки сообщения", zap.Error(err))
@@ -503,6 +622,9 @@ func (c *Client) handleCallbackQuery(data interface{}) {
 		ChatInstance: "chat_instance",
 	}
 
+	// Запоминаем клиента, чтобы доставить ему ответ бота
+	c.server.trackCallbackQuery(callbackQueryID, c)
+
 	// Добавляем callback query в BotManager для обработки ботом
 	if c.server.botManager != nil {
 		// Используем reflection для вызова метода AddCallbackQuery
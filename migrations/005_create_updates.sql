@@ -0,0 +1,17 @@
+-- Создание таблицы очередей обновлений ботов
+CREATE TABLE IF NOT EXISTS updates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    bot_id INTEGER,
+    update_id INTEGER,
+    payload TEXT,
+    created_at DATETIME
+);
+
+-- Уникальный update_id в рамках бота
+CREATE UNIQUE INDEX IF NOT EXISTS idx_updates_bot_update ON updates(bot_id, update_id);
+
+-- Создание таблицы счетчиков update_id ботов
+CREATE TABLE IF NOT EXISTS update_sequences (
+    bot_id INTEGER PRIMARY KEY,
+    next_update_id INTEGER
+);
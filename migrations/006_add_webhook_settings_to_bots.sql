@@ -0,0 +1,5 @@
+-- Добавление параметров setWebhook в таблицу bots
+ALTER TABLE bots ADD COLUMN webhook_secret_token TEXT;
+ALTER TABLE bots ADD COLUMN webhook_ip_address TEXT;
+ALTER TABLE bots ADD COLUMN webhook_max_connections INTEGER DEFAULT 40;
+ALTER TABLE bots ADD COLUMN allowed_updates TEXT;
@@ -0,0 +1,2 @@
+-- Добавление времени редактирования в таблицу messages
+ALTER TABLE messages ADD COLUMN edit_date DATETIME;
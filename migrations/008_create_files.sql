@@ -0,0 +1,24 @@
+-- Создание таблицы файлов, загруженных в эмулятор
+CREATE TABLE IF NOT EXISTS files (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    file_id TEXT,
+    file_unique_id TEXT,
+    type TEXT,
+    file_name TEXT,
+    mime_type TEXT,
+    file_size INTEGER,
+    file_path TEXT,
+    width INTEGER,
+    height INTEGER,
+    duration INTEGER,
+    group_id TEXT,
+    created_at DATETIME
+);
+
+-- Индексы для поиска файлов по идентификаторам
+CREATE UNIQUE INDEX IF NOT EXISTS idx_files_file_id ON files(file_id);
+CREATE INDEX IF NOT EXISTS idx_files_file_unique_id ON files(file_unique_id);
+CREATE INDEX IF NOT EXISTS idx_files_group_id ON files(group_id);
+
+-- Добавление вложений в таблицу messages
+ALTER TABLE messages ADD COLUMN media TEXT;
@@ -0,0 +1,2 @@
+-- Добавление источника пересланного сообщения в таблицу messages
+ALTER TABLE messages ADD COLUMN forward_origin TEXT;
@@ -0,0 +1,3 @@
+-- Добавление ответа на сообщение в таблицу messages
+ALTER TABLE messages ADD COLUMN reply_to_message_id INTEGER;
+CREATE INDEX IF NOT EXISTS idx_messages_reply_to_message_id ON messages(reply_to_message_id);
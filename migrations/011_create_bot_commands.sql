@@ -0,0 +1,15 @@
+-- Создание таблицы списков команд ботов (setMyCommands)
+CREATE TABLE IF NOT EXISTS bot_commands (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    bot_id INTEGER,
+    scope_type TEXT,
+    scope_chat_id INTEGER,
+    scope_user_id INTEGER,
+    language_code TEXT,
+    commands TEXT,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+
+-- Один список команд на область видимости и язык
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bot_commands_scope ON bot_commands(bot_id, scope_type, scope_chat_id, scope_user_id, language_code);
@@ -0,0 +1,17 @@
+-- Создание таблицы правил ботов-заглушек
+CREATE TABLE IF NOT EXISTS stub_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    bot_id INTEGER,
+    command TEXT,
+    pattern TEXT,
+    text TEXT,
+    parse_mode TEXT,
+    reply_markup TEXT,
+    delay_ms INTEGER,
+    reply BOOLEAN,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+
+-- Индекс для выборки правил бота
+CREATE INDEX IF NOT EXISTS idx_stub_rules_bot_id ON stub_rules(bot_id);
@@ -0,0 +1,22 @@
+-- Добавление статуса, прав администратора и ограничений в таблицу chat_members
+ALTER TABLE chat_members ADD COLUMN status TEXT DEFAULT 'member';
+ALTER TABLE chat_members ADD COLUMN is_anonymous BOOLEAN DEFAULT false;
+ALTER TABLE chat_members ADD COLUMN can_manage_chat BOOLEAN DEFAULT false;
+ALTER TABLE chat_members ADD COLUMN can_delete_messages BOOLEAN DEFAULT false;
+ALTER TABLE chat_members ADD COLUMN can_manage_video_chats BOOLEAN DEFAULT false;
+ALTER TABLE chat_members ADD COLUMN can_restrict_members BOOLEAN DEFAULT false;
+ALTER TABLE chat_members ADD COLUMN can_promote_members BOOLEAN DEFAULT false;
+ALTER TABLE chat_members ADD COLUMN can_change_info BOOLEAN DEFAULT false;
+ALTER TABLE chat_members ADD COLUMN can_invite_users BOOLEAN DEFAULT false;
+ALTER TABLE chat_members ADD COLUMN can_pin_messages BOOLEAN DEFAULT false;
+ALTER TABLE chat_members ADD COLUMN permissions TEXT;
+ALTER TABLE chat_members ADD COLUMN until_date DATETIME;
+
+-- Создателем существующих групп становится первый участник
+UPDATE chat_members SET status = 'creator'
+WHERE (chat_id, user_id) IN (
+    SELECT cm.chat_id, cm.user_id FROM chat_members cm
+    JOIN chats ON chats.id = cm.chat_id
+    WHERE chats.type = 'group'
+      AND cm.joined_at = (SELECT MIN(joined_at) FROM chat_members WHERE chat_id = cm.chat_id)
+);
@@ -0,0 +1,2 @@
+-- Добавление администратора, назначившего участника, в таблицу chat_members
+ALTER TABLE chat_members ADD COLUMN promoted_by INTEGER DEFAULT 0;
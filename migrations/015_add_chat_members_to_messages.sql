@@ -0,0 +1,3 @@
+-- Добавление участников служебных сообщений о входе и выходе в таблицу messages
+ALTER TABLE messages ADD COLUMN new_chat_members TEXT;
+ALTER TABLE messages ADD COLUMN left_chat_member TEXT;
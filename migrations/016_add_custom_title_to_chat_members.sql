@@ -0,0 +1,2 @@
+-- Добавление звания администратора в таблицу chat_members
+ALTER TABLE chat_members ADD COLUMN custom_title TEXT DEFAULT '';
@@ -33,7 +33,7 @@ function App() {
     setUsers,
     setMessages,
     addMessage,
-
+    updateMessage,
     updateMessageStatus,
     updateChat,
     addDebugEvent,
@@ -52,6 +52,24 @@ function App() {
 
   const [isInitialized, setIsInitialized] = useState(false);
 
+  // Действия участников чатов (набор текста, загрузка файла): { [chatId]: { [userId]: action } }
+  const [chatActions, setChatActions] = useState({});
+
+  // Убирает действие пользователя. Если передан expiresAt, убирает только это действие, а не отправленное позже
+  const removeChatAction = (chatId, userId, expiresAt = null) => {
+    setChatActions(prev => {
+      const current = prev[chatId]?.[userId];
+      if (!current || (expiresAt && current.expires_at !== expiresAt)) return prev;
+
+      const chatState = { ...prev[chatId] };
+      delete chatState[userId];
+      return { ...prev, [chatId]: chatState };
+    });
+  };
+
+
+  // Меню команд ботов текущего чата: [{ bot_username, command, description }]
+  const [botCommands, setBotCommands] = useState([]);
 
   // Single useEffect for initialization
   useEffect(() => {
@@ -93,6 +111,39 @@ function App() {
     setup();
   }, [isInitialized, currentUser?.id]); // Add currentUser.id to dependencies
 
+  // Загружаем меню команд ботов, состоящих в текущем чате
+  useEffect(() => {
+    setBotCommands([]);
+    if (!currentChat || !currentUser) return;
+
+    const botMembers = (currentChat.members || []).filter(m => m.is_bot && m.id !== currentUser.id);
+    if (botMembers.length === 0) return;
+
+    let cancelled = false;
+    const loadBotCommands = async () => {
+      try {
+        const botsResponse = await apiService.getBots();
+        const bots = (botsResponse.bots || []).filter(bot => botMembers.some(m => m.username === bot.username));
+
+        const menus = await Promise.all(bots.map(async (bot) => {
+          const response = await apiService.getBotCommandMenu(bot.id, currentChat.id, currentUser.id, getCurrentLanguage());
+          return (response.commands || []).map(command => ({ ...command, bot_username: bot.username }));
+        }));
+
+        if (!cancelled) {
+          setBotCommands(menus.flat());
+        }
+      } catch (error) {
+        // console.error('Failed to load bot commands:', error);
+      }
+    };
+
+    loadBotCommands();
+    return () => {
+      cancelled = true;
+    };
+  }, [currentChat?.id, currentUser?.id]);
+
   // WebSocket event handlers
   useEffect(() => {
     // Register handlers regardless of connection state
@@ -110,6 +161,9 @@ function App() {
       // });
       // Check if message is from current user
       const isOwnMessage = data.from?.id === currentUser?.id;
+
+      // Сообщение завершает действие отправителя
+      removeChatAction(data.chat_id, data.from?.id);
       
       // Check if there's already a temporary message with the same text and sender
       const currentState = useStore.getState();
@@ -214,6 +268,42 @@ function App() {
       });
     };
 
+    const handleMessageEdited = (data) => {
+      updateMessage(data.chat_id, data.id, {
+        text: data.text,
+        entities: data.entities,
+        reply_markup: data.reply_markup,
+        edit_date: data.edit_date
+      });
+    };
+
+    const handleCallbackAnswer = (data) => {
+      if (data.show_alert && data.text) {
+        window.alert(data.text);
+      }
+      addDebugEvent({
+        id: `callback-answer-${Date.now()}-${Math.random().toString(36).substr(2, 9)}`,
+        timestamp: format(new Date(), 'HH:mm:ss', { locale: getCurrentLanguage() === 'ru' ? ru : enUS }),
+        type: 'info',
+        description: `${t('callbackAnswer', getCurrentLanguage())}: ${data.text || data.url || data.callback_query_id}`
+      });
+    };
+
+    const handleChatAction = (data) => {
+      setChatActions(prev => ({
+        ...prev,
+        [data.chat_id]: { ...prev[data.chat_id], [data.user_id]: data }
+      }));
+
+      // Действие отображается до expires_at, если раньше не придет сообщение
+      const timeout = Math.max(new Date(data.expires_at).getTime() - Date.now(), 0) || 5000;
+      setTimeout(() => removeChatAction(data.chat_id, data.user_id, data.expires_at), timeout);
+    };
+
+    const handleChatActionCancel = (data) => {
+      removeChatAction(data.chat_id, data.user_id);
+    };
+
     const handleMessageStatusUpdate = (data) => {
       updateMessageStatus(data.message_id, data.status);
       // Log for debugging status issues
@@ -285,7 +375,11 @@ function App() {
     wsService.on('connect', handleConnect);
     wsService.on('connect_error', handleConnectError);
     wsService.on('message', handleMessage);
+    wsService.on('message_edited', handleMessageEdited);
+    wsService.on('callback_answer', handleCallbackAnswer);
     wsService.on('message_status_update', handleMessageStatusUpdate);
+    wsService.on('chat_action', handleChatAction);
+    wsService.on('chat_action_cancel', handleChatActionCancel);
     wsService.on('chat_update', handleChatUpdate);
     wsService.on('user_update', handleUserUpdate);
     wsService.on('debug_event', handleDebugEvent);
@@ -300,7 +394,11 @@ function App() {
       wsService.off('connect', handleConnect);
       wsService.off('connect_error', handleConnectError);
       wsService.off('message', handleMessage);
+      wsService.off('message_edited', handleMessageEdited);
+      wsService.off('callback_answer', handleCallbackAnswer);
       wsService.off('message_status_update', handleMessageStatusUpdate);
+      wsService.off('chat_action', handleChatAction);
+      wsService.off('chat_action_cancel', handleChatActionCancel);
       wsService.off('chat_update', handleChatUpdate);
       wsService.off('user_update', handleUserUpdate);
       wsService.off('debug_event', handleDebugEvent);
@@ -460,7 +558,8 @@ function App() {
     }
   };
 
-  const handleSendMessage = async (text) => {
+  // replyTo - сообщение, на которое отвечает пользователь
+  const handleSendMessage = async (text, replyTo = null) => {
     if (!currentChat || !currentUser || !text.trim()) return;
 
     try {
@@ -472,6 +571,7 @@ function App() {
         from_id: currentUser.id,
         text: text.trim(),
         type: 'text',
+        reply_to_message: replyTo,
         status: 'sending',
         timestamp: new Date(),
         is_outgoing: true
@@ -508,7 +608,7 @@ function App() {
 
       // Send message via WebSocket
       if (wsService.connected) {
-        wsService.sendMessage(currentChat.id, text.trim(), currentUser.id);
+        wsService.sendMessage(currentChat.id, text.trim(), currentUser.id, replyTo?.id);
       } else {
         addDebugEvent({
           id: `ws-not-connected-${Date.now()}-${Math.random().toString(36).substr(2, 9)}`,
@@ -529,6 +629,57 @@ function App() {
     }
   };
 
+  // Определяет тип сообщения по MIME типу выбранного файла
+  const getFileMessageType = (file) => {
+    if (file.type.startsWith('image/') && file.type !== 'image/svg+xml') return 'photo';
+    if (file.type === 'audio/ogg') return 'voice';
+    if (file.type.startsWith('audio/')) return 'audio';
+    if (file.type.startsWith('video/')) return 'video';
+    return 'file';
+  };
+
+  const handleSendFile = async (file, caption = '', replyTo = null) => {
+    if (!currentChat || !currentUser || !file) return;
+
+    const type = getFileMessageType(file);
+    try {
+      // Сначала загружаем файл, затем отправляем сообщение со ссылкой на него
+      const uploaded = await apiService.uploadFile(file, type);
+
+      addMessage(currentChat.id, {
+        id: `temp-${Date.now()}-${Math.random().toString(36).substr(2, 9)}`,
+        chat_id: currentChat.id,
+        from: currentUser,
+        from_id: currentUser.id,
+        text: caption.trim(),
+        type: uploaded.type,
+        media: uploaded.media,
+        reply_to_message: replyTo,
+        status: 'sending',
+        timestamp: new Date(),
+        is_outgoing: true
+      });
+
+      if (wsService.connected) {
+        wsService.sendFileMessage(currentChat.id, uploaded.file_id, uploaded.type, caption.trim(), currentUser.id, replyTo?.id);
+      } else {
+        addDebugEvent({
+          id: `ws-not-connected-${Date.now()}-${Math.random().toString(36).substr(2, 9)}`,
+          timestamp: format(new Date(), 'HH:mm:ss', { locale: getCurrentLanguage() === 'ru' ? ru : enUS }),
+          type: 'error',
+          description: t('websocketConnectionError', getCurrentLanguage())
+        });
+      }
+    } catch (error) {
+      addDebugEvent({
+        id: `file-error-${Date.now()}-${Math.random().toString(36).substr(2, 9)}`,
+        timestamp: format(new Date(), 'HH:mm:ss', { locale: getCurrentLanguage() === 'ru' ? ru : enUS }),
+        type: 'error',
+        description: `${t('fileUploadError', getCurrentLanguage())}: ${error.message}`
+      });
+    }
+  };
+
   const handleDeleteUser = async (userId) => {
     try {
       await apiService.deleteUser(userId);
@@ -682,6 +833,10 @@ function App() {
           messages={currentChat ? messages[currentChat.id] || [] : []}
           currentUser={currentUser}
           onSendMessage={handleSendMessage}
+          onSendFile={handleSendFile}
+          onTyping={() => currentChat && wsService.connected && wsService.sendTyping(currentChat.id)}
+          chatActions={currentChat ? Object.values(chatActions[currentChat.id] || {}) : []}
+          botCommands={botCommands}
           onShowMembers={() => setShowChatMembersModal(true)}
           onCallbackQuery={handleCallbackQuery}
         />
@@ -1,19 +1,21 @@
 import React, { useState, useEffect } from 'react';
-import { X, UserPlus, UserMinus, Users } from 'lucide-react';
+import { X, UserPlus, UserMinus, Users, Shield, ShieldOff } from 'lucide-react';
 import apiService from '../services/api';
 import useStore from '../store';
 import { t, getCurrentLanguage } from '../locales';
 
 const ChatMembersModal = ({ isOpen, onClose, chat }) => {
   const [members, setMembers] = useState([]);
+  const [memberStatuses, setMemberStatuses] = useState({});
   const [availableUsers, setAvailableUsers] = useState([]);
   const [isLoading, setIsLoading] = useState(false);
   const [isAddingMember, setIsAddingMember] = useState(false);
   const [isRemovingMember, setIsRemovingMember] = useState(false);
+  const [isChangingRole, setIsChangingRole] = useState(false);
   const [error, setError] = useState('');
   const [successMessage, setSuccessMessage] = useState('');
 
-  const { users, addDebugEvent } = useStore();
+  const { users, currentUser, addDebugEvent } = useStore();
 
   useEffect(() => {
     if (isOpen && chat) {
@@ -38,6 +40,11 @@ const ChatMembersModal = ({ isOpen, onClose, chat }) => {
       // Get chat members
       const response = await apiService.getChatMembers(chat.id);
       setMembers(response.members || []);
+      const statuses = {};
+      (response.chat_members || []).forEach(chatMember => {
+        statuses[chatMember.user_id] = chatMember.status;
+      });
+      setMemberStatuses(statuses);
     } catch (error) {
       // console.error('Failed to load chat members:', error);
       setError(t('failedToLoadMembers', getCurrentLanguage()));
@@ -66,7 +73,7 @@ const ChatMembersModal = ({ isOpen, onClose, chat }) => {
     setSuccessMessage('');
     
     try {
-      await apiService.addChatMember(chat.id, userId);
+      await apiService.addChatMember(chat.id, userId, currentUser?.id);
       
       addDebugEvent({
         id: `member-added-${Date.now()}-${Math.random().toString(36).substr(2, 9)}`,
@@ -103,7 +110,7 @@ const ChatMembersModal = ({ isOpen, onClose, chat }) => {
     setSuccessMessage('');
     
     try {
-      await apiService.removeChatMember(chat.id, userId);
+      await apiService.removeChatMember(chat.id, userId, currentUser?.id);
       
       addDebugEvent({
         id: `member-removed-${Date.now()}-${Math.random().toString(36).substr(2, 9)}`,
@@ -127,17 +134,41 @@ const ChatMembersModal = ({ isOpen, onClose, chat }) => {
     }
   };
 
+  const handleChangeRole = async (userId, promote) => {
+    if (!chat) return;
+
+    setIsChangingRole(true);
+    setError('');
+
+    try {
+      if (promote) {
+        await apiService.promoteChatMember(chat.id, userId);
+      } else {
+        await apiService.demoteChatMember(chat.id, userId);
+      }
+      await loadMembers();
+    } catch (error) {
+      setError(t('failedToChangeRole', getCurrentLanguage()));
+    } finally {
+      setIsChangingRole(false);
+    }
+  };
+
   const getMemberRole = (member) => {
     if (chat?.type === 'private') {
       return t('participant', getCurrentLanguage());
     }
-    
-    // For groups and channels, role logic can be added
-    if (member.id === chat?.created_by) {
-      return t('creator', getCurrentLanguage());
+
+    switch (memberStatuses[member.id]) {
+      case 'creator':
+        return t('creator', getCurrentLanguage());
+      case 'administrator':
+        return t('administrator', getCurrentLanguage());
+      case 'restricted':
+        return t('restricted', getCurrentLanguage());
+      default:
+        return t('member', getCurrentLanguage());
     }
-    
-    return t('member', getCurrentLanguage());
   };
 
   if (!isOpen || !chat) return null;
@@ -220,19 +251,33 @@ const ChatMembersModal = ({ isOpen, onClose, chat }) => {
                       </div>
                     </div>
                     
-                    {chat.type === 'group' && (
-                      <button
-                        onClick={() => handleRemoveMember(member.id)}
-                        disabled={isRemovingMember}
-                        className="p-2 text-red-500 hover:text-red-600 hover:bg-red-500/10 rounded-lg transition-colors disabled:opacity-50 disabled:cursor-not-allowed"
-                        title={t('removeMember', language)}
-                      >
-                        {isRemovingMember ? (
-                          <div className="w-4 h-4 border-2 border-red-500 border-t-transparent rounded-full animate-spin"></div>
-                        ) : (
-                          <UserMinus className="w-4 h-4" />
-                        )}
-                      </button>
+                    {chat.type === 'group' && memberStatuses[member.id] !== 'creator' && (
+                      <div className="flex items-center">
+                        <button
+                          onClick={() => handleChangeRole(member.id, memberStatuses[member.id] !== 'administrator')}
+                          disabled={isChangingRole}
+                          className="p-2 text-telegram-primary hover:bg-telegram-primary/10 rounded-lg transition-colors disabled:opacity-50 disabled:cursor-not-allowed"
+                          title={t(memberStatuses[member.id] === 'administrator' ? 'demoteMember' : 'promoteMember', language)}
+                        >
+                          {memberStatuses[member.id] === 'administrator' ? (
+                            <ShieldOff className="w-4 h-4" />
+                          ) : (
+                            <Shield className="w-4 h-4" />
+                          )}
+                        </button>
+                        <button
+                          onClick={() => handleRemoveMember(member.id)}
+                          disabled={isRemovingMember}
+                          className="p-2 text-red-500 hover:text-red-600 hover:bg-red-500/10 rounded-lg transition-colors disabled:opacity-50 disabled:cursor-not-allowed"
+                          title={t('removeMember', language)}
+                        >
+                          {isRemovingMember ? (
+                            <div className="w-4 h-4 border-2 border-red-500 border-t-transparent rounded-full animate-spin"></div>
+                          ) : (
+                            <UserMinus className="w-4 h-4" />
+                          )}
+                        </button>
+                      </div>
                     )}
                   </div>
                 ))}
@@ -1,16 +1,23 @@
 import React, { useState, useRef, useEffect } from 'react';
-import { Send, Paperclip, Mic, Smile, Users } from 'lucide-react';
+import { Send, Paperclip, Mic, Smile, Users, Reply, X, Menu } from 'lucide-react';
 
 import clsx from 'clsx';
 import MessageBubble from './MessageBubble';
 import { t, getCurrentLanguage } from '../locales';
 
-const ChatWindow = ({ chat, messages, currentUser, onSendMessage, onShowMembers, onCallbackQuery }) => {
+// Интервал, с которым повторяется событие печати, пока пользователь набирает текст
+const TYPING_INTERVAL_MS = 4000;
+
+const ChatWindow = ({ chat, messages, currentUser, chatActions = [], botCommands = [], onSendMessage, onSendFile, onTyping, onShowMembers, onCallbackQuery }) => {
   const [inputText, setInputText] = useState('');
+  const [replyTo, setReplyTo] = useState(null);
+  const [showCommands, setShowCommands] = useState(false);
 
   const [currentKeyboard, setCurrentKeyboard] = useState(null);
   const messagesEndRef = useRef(null);
   const inputRef = useRef(null);
+  const fileInputRef = useRef(null);
+  const lastTypingRef = useRef(0);
 
   // Auto-scroll to last message
   useEffect(() => {
@@ -43,16 +50,25 @@ const ChatWindow = ({ chat, messages, currentUser, onSendMessage, onShowMembers,
     }
   }, [messages]);
 
-  // Скрываем клавиатуру при смене чата
+  // Скрываем клавиатуру, меню команд и отменяем ответ при смене чата
   useEffect(() => {
     setCurrentKeyboard(null);
+    setReplyTo(null);
+    setShowCommands(false);
   }, [chat?.id]);
 
+  const handleReply = (message) => {
+    setReplyTo(message);
+    inputRef.current?.focus();
+  };
+
   const handleSendMessage = () => {
     if (!inputText.trim() || !chat) return;
     
-    onSendMessage(inputText);
+    onSendMessage(inputText, replyTo);
     setInputText('');
+    setReplyTo(null);
+    lastTypingRef.current = 0;
     // setIsTyping(false);
     
     // Если клавиатура одноразовая, убираем её
@@ -61,6 +77,36 @@ const ChatWindow = ({ chat, messages, currentUser, onSendMessage, onShowMembers,
     }
   };
 
+  // Отправляет выбранный файл, текст из поля ввода становится подписью
+  const handleFileSelect = (e) => {
+    const file = e.target.files?.[0];
+    e.target.value = '';
+    if (!file || !chat || !onSendFile) return;
+
+    onSendFile(file, inputText, replyTo);
+    setInputText('');
+    setReplyTo(null);
+  };
+
+  // Команды для подсказки: всё меню по кнопке или команды, начинающиеся с введенного "/..."
+  const getCommandSuggestions = () => {
+    if (showCommands) return botCommands;
+
+    const match = inputText.match(/^\/(\w*)$/);
+    if (!match) return [];
+    return botCommands.filter(c => c.command.startsWith(match[1].toLowerCase()));
+  };
+
+  // Отправляет команду из меню. В группах команда адресуется конкретному боту, как в Telegram
+  const handleCommandSelect = (botCommand) => {
+    const mention = chat.type === 'private' ? '' : `@${botCommand.bot_username}`;
+    onSendMessage(`/${botCommand.command}${mention}`, replyTo);
+    setInputText('');
+    setReplyTo(null);
+    setShowCommands(false);
+    lastTypingRef.current = 0;
+  };
+
   const handleKeyPress = (e) => {
     if (e.key === 'Enter' && !e.shiftKey) {
       e.preventDefault();
@@ -70,7 +116,13 @@ const ChatWindow = ({ chat, messages, currentUser, onSendMessage, onShowMembers,
 
   const handleInputChange = (e) => {
     setInputText(e.target.value);
-    // setIsTyping(e.target.value.length > 0);
+
+    // Сообщаем о наборе текста не чаще TYPING_INTERVAL_MS, как клиенты Telegram
+    const now = Date.now();
+    if (onTyping && e.target.value && now - lastTypingRef.current >= TYPING_INTERVAL_MS) {
+      lastTypingRef.current = now;
+      onTyping();
+    }
   };
 
   const renderKeyboard = () => {
@@ -127,6 +179,20 @@ const ChatWindow = ({ chat, messages, currentUser, onSendMessage, onShowMembers,
     return chat.title?.charAt(0).toUpperCase() || '?';
   };
 
+  // Текст действия участника чата, например «печатает...»
+  const getChatActionText = () => {
+    const chatAction = chatActions.find(a => a.user_id !== currentUser?.id);
+    if (!chatAction) return null;
+
+    const language = getCurrentLanguage();
+    const key = 'action' + chatAction.action.split('_').map(w => w.charAt(0).toUpperCase() + w.slice(1)).join('');
+    const text = t(key, language);
+    if (chat.type === 'private') return text;
+
+    const name = chatAction.user?.first_name || chatAction.user?.username || t('unknown', language);
+    return `${name} ${text}`;
+  };
+
   const getOnlineStatus = () => {
     if (!chat || chat.type !== 'private') return null;
     
@@ -165,7 +231,11 @@ const ChatWindow = ({ chat, messages, currentUser, onSendMessage, onShowMembers,
           <h2 className="text-telegram-text font-medium truncate">
             {getChatTitle()}
           </h2>
-          {getOnlineStatus() && (
+          {getChatActionText() ? (
+            <p className="text-sm text-telegram-primary truncate">
+              {getChatActionText()}
+            </p>
+          ) : getOnlineStatus() && (
             <p className={clsx(
               'text-sm',
               getOnlineStatus() === 'online' ? 'text-green-500' : 'text-telegram-text-secondary'
@@ -211,6 +281,7 @@ const ChatWindow = ({ chat, messages, currentUser, onSendMessage, onShowMembers,
                 currentUser={currentUser}
                 onSendMessage={onSendMessage}
                 onCallbackQuery={onCallbackQuery}
+                onReply={handleReply}
               />
             ))}
             <div ref={messagesEndRef} />
@@ -220,10 +291,70 @@ const ChatWindow = ({ chat, messages, currentUser, onSendMessage, onShowMembers,
 
       {/* Input field */}
       <div className="p-4 border-t border-telegram-border bg-telegram-sidebar">
+        {/* Message being replied to */}
+        {replyTo && (
+          <div className="flex items-center mb-2 pl-3 border-l-2 border-telegram-primary">
+            <Reply className="w-4 h-4 text-telegram-primary mr-2 flex-shrink-0" />
+            <div className="flex-1 min-w-0">
+              <div className="text-xs text-telegram-primary font-medium">
+                {t('replyingTo', getCurrentLanguage())} {replyTo.from?.first_name || replyTo.from?.username || ''}
+              </div>
+              <div className="text-sm text-telegram-text-secondary truncate">
+                {replyTo.text || t('file', getCurrentLanguage())}
+              </div>
+            </div>
+            <button
+              onClick={() => setReplyTo(null)}
+              title={t('cancelReply', getCurrentLanguage())}
+              className="p-1 text-telegram-secondary hover:text-telegram-text transition-colors"
+            >
+              <X className="w-4 h-4" />
+            </button>
+          </div>
+        )}
+
+        {/* Bot command menu */}
+        {getCommandSuggestions().length > 0 && (
+          <div className="mb-2 max-h-48 overflow-y-auto border border-telegram-border rounded-lg bg-telegram-bg">
+            {getCommandSuggestions().map((botCommand) => (
+              <button
+                key={`${botCommand.bot_username}-${botCommand.command}`}
+                onClick={() => handleCommandSelect(botCommand)}
+                className="w-full flex items-baseline px-3 py-2 text-left hover:bg-telegram-primary/10 transition-colors"
+              >
+                <span className="text-telegram-primary font-medium mr-3">/{botCommand.command}</span>
+                <span className="text-sm text-telegram-text-secondary truncate">{botCommand.description}</span>
+              </button>
+            ))}
+          </div>
+        )}
+
         <div className="flex items-end space-x-2">
           {/* Action buttons */}
           <div className="flex space-x-1">
-            <button className="p-2 text-telegram-secondary hover:text-telegram-text transition-colors">
+            {botCommands.length > 0 && (
+              <button
+                onClick={() => setShowCommands(!showCommands)}
+                title={t('botCommands', getCurrentLanguage())}
+                className={clsx(
+                  'p-2 transition-colors',
+                  showCommands ? 'text-telegram-primary' : 'text-telegram-secondary hover:text-telegram-text'
+                )}
+              >
+                <Menu className="w-5 h-5" />
+              </button>
+            )}
+            <input
+              ref={fileInputRef}
+              type="file"
+              className="hidden"
+              onChange={handleFileSelect}
+            />
+            <button
+              onClick={() => fileInputRef.current?.click()}
+              title={t('attachFile', getCurrentLanguage())}
+              className="p-2 text-telegram-secondary hover:text-telegram-text transition-colors"
+            >
               <Paperclip className="w-5 h-5" />
             </button>
             <button className="p-2 text-telegram-secondary hover:text-telegram-text transition-colors">
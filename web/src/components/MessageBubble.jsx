@@ -1,12 +1,13 @@
 import React from 'react';
 import { format } from 'date-fns';
 import { ru, enUS } from 'date-fns/locale';
-import { Check, CheckCheck } from 'lucide-react';
+import { Check, CheckCheck, Reply } from 'lucide-react';
 import clsx from 'clsx';
 import { t, getCurrentLanguage } from '../locales';
 import { parseTelegramText, processCommandsInFormattedText } from '../utils/textParser.jsx';
+import apiService from '../services/api';
 
-const MessageBubble = ({ message, isOwn, onSendMessage, onCallbackQuery }) => {
+const MessageBubble = ({ message, isOwn, onSendMessage, onCallbackQuery, onReply }) => {
   const formatTime = (timestamp) => {
     try {
       const language = getCurrentLanguage();
@@ -39,8 +40,121 @@ const MessageBubble = ({ message, isOwn, onSendMessage, onCallbackQuery }) => {
     return message.from?.first_name || message.from?.username || t('unknown', language);
   };
 
+  // Вложение и участники служебных сообщений приходят объектом по WebSocket и JSON строкой из REST API
+  const parseMedia = (media) => {
+    if (!media) return null;
+    if (typeof media !== 'string') return media;
+    try {
+      return JSON.parse(media);
+    } catch (error) {
+      return null;
+    }
+  };
+
+  const getMedia = () => parseMedia(message.media);
+
+  // Цитата сообщения, на которое отвечает это сообщение
+  const renderReplyQuote = () => {
+    const reply = message.reply_to_message;
+    if (!reply) return null;
+
+    const language = getCurrentLanguage();
+    const media = parseMedia(reply.media);
+    let preview = reply.text;
+    if (!preview) {
+      if (media?.photo) preview = t('photo', language);
+      else if (media?.voice) preview = t('voiceMessage', language);
+      else if (media?.audio) preview = t('audio', language);
+      else if (media?.video) preview = t('video', language);
+      else preview = t('file', language);
+    }
+
+    return (
+      <div className="mb-1 pl-2 border-l-2 border-telegram-primary text-sm">
+        <div className="font-medium text-telegram-primary truncate">
+          {reply.from?.first_name || reply.from?.username || t('unknown', language)}
+        </div>
+        <div className="opacity-75 truncate">{preview}</div>
+      </div>
+    );
+  };
+
+  // Ответить можно только на сообщение, уже сохраненное на сервере
+  const canReply = onReply && !(typeof message.id === 'string' && message.id.startsWith('temp-'));
+
+  const renderCaption = () => {
+    if (!message.text) return null;
+    return (
+      <div className="mt-1 whitespace-pre-wrap break-words">
+        {renderTextWithCommands(message.text)}
+      </div>
+    );
+  };
+
+  const renderMedia = (media) => {
+    const language = getCurrentLanguage();
+
+    if (media.photo?.length > 0) {
+      const largest = media.photo[media.photo.length - 1];
+      return (
+        <img
+          src={apiService.getFileUrl(largest.file_id)}
+          alt={t('photo', language)}
+          className="rounded-lg max-w-full"
+          style={{ maxHeight: '320px' }}
+        />
+      );
+    }
+
+    if (media.voice || media.audio) {
+      const audio = media.voice || media.audio;
+      return (
+        <div className="space-y-1">
+          <div className="text-sm">
+            {media.voice ? `🎤 ${t('voiceMessage', language)}` : `🎵 ${media.audio.title || media.audio.file_name || t('audio', language)}`}
+          </div>
+          <audio controls src={apiService.getFileUrl(audio.file_id)} className="max-w-full" />
+        </div>
+      );
+    }
+
+    if (media.video) {
+      return (
+        <video controls src={apiService.getFileUrl(media.video.file_id)} className="rounded-lg max-w-full" />
+      );
+    }
+
+    if (media.document) {
+      return (
+        <a
+          href={apiService.getFileUrl(media.document.file_id)}
+          target="_blank"
+          rel="noopener noreferrer"
+          className="flex items-center space-x-2"
+        >
+          <div className="w-8 h-8 bg-telegram-secondary rounded flex items-center justify-center">
+            📎
+          </div>
+          <span className="underline break-all">{media.document.file_name || t('file', language)}</span>
+        </a>
+      );
+    }
+
+    return null;
+  };
+
   const getMessageContent = () => {
     const language = getCurrentLanguage();
+    const media = getMedia();
+    if (media) {
+      return (
+        <div>
+          {renderMedia(media)}
+          {renderCaption()}
+        </div>
+      );
+    }
+
     switch (message.type) {
       case 'text':
         return (
@@ -114,6 +228,27 @@ const MessageBubble = ({ message, isOwn, onSendMessage, onCallbackQuery }) => {
     return parts;
   };
 
+  // Текст служебного сообщения о входе или выходе участников группы
+  const getServiceText = () => {
+    const language = getCurrentLanguage();
+    const name = (user) => user?.first_name || user?.username || t('unknown', language);
+    const fromId = message.from?.id ?? message.from_id;
+
+    if (message.type === 'new_chat_members') {
+      const users = parseMedia(message.new_chat_members) || [];
+      if (users.length === 1 && users[0].id === fromId) {
+        return `${name(users[0])} ${t('serviceJoined', language)}`;
+      }
+      return `${name(message.from)} ${t('serviceAdded', language)} ${users.map(name).join(', ')}`;
+    }
+
+    const user = parseMedia(message.left_chat_member);
+    if (user?.id === fromId) {
+      return `${name(user)} ${t('serviceLeft', language)}`;
+    }
+    return `${name(message.from)} ${t('serviceRemoved', language)} ${name(user)}`;
+  };
+
   const renderInlineKeyboard = () => {
     if (!message.reply_markup || !message.reply_markup.inline_keyboard) return null;
 
@@ -142,6 +277,16 @@ const MessageBubble = ({ message, isOwn, onSendMessage, onCallbackQuery }) => {
     );
   };
 
+  if (message.type === 'new_chat_members' || message.type === 'left_chat_member') {
+    return (
+      <div className="flex justify-center">
+        <div className="px-3 py-1 rounded-full bg-telegram-sidebar text-xs text-telegram-text-secondary">
+          {getServiceText()}
+        </div>
+      </div>
+    );
+  }
+
   return (
     <div className={clsx(
       'flex',
@@ -163,6 +308,7 @@ const MessageBubble = ({ message, isOwn, onSendMessage, onCallbackQuery }) => {
           'message-bubble',
           isOwn ? 'outgoing' : 'incoming'
         )}>
+          {renderReplyQuote()}
           {getMessageContent()}
           {renderInlineKeyboard()}
         </div>
@@ -175,6 +321,16 @@ const MessageBubble = ({ message, isOwn, onSendMessage, onCallbackQuery }) => {
           <span className="text-xs text-telegram-text-secondary">
             {formatTime(message.timestamp)}
           </span>
+
+          {canReply && (
+            <button
+              onClick={() => onReply(message)}
+              title={t('reply', getCurrentLanguage())}
+              className="text-telegram-text-secondary hover:text-telegram-primary transition-colors"
+            >
+              <Reply className="w-3 h-3" />
+            </button>
+          )}
           
           {isOwn && (
             <div className="flex items-center">
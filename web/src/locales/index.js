@@ -83,6 +83,29 @@ export const locales = {
     file: 'Файл',
     voiceMessage: 'Голосовое сообщение',
     photo: 'Фото',
+    audio: 'Аудио',
+    video: 'Видео',
+    serviceJoined: 'вступил(а) в группу',
+    serviceAdded: 'добавил(а)',
+    serviceLeft: 'покинул(а) группу',
+    serviceRemoved: 'удалил(а)',
+    attachFile: 'Прикрепить файл',
+    botCommands: 'Команды бота',
+    fileUploadError: 'Ошибка загрузки файла',
+    reply: 'Ответить',
+    cancelReply: 'Отменить ответ',
+    replyingTo: 'В ответ',
+    actionTyping: 'печатает...',
+    actionUploadPhoto: 'отправляет фото...',
+    actionRecordVideo: 'записывает видео...',
+    actionUploadVideo: 'отправляет видео...',
+    actionRecordVoice: 'записывает голосовое...',
+    actionUploadVoice: 'отправляет голосовое...',
+    actionUploadDocument: 'отправляет файл...',
+    actionChooseSticker: 'выбирает стикер...',
+    actionFindLocation: 'ищет место...',
+    actionRecordVideoNote: 'записывает видеосообщение...',
+    actionUploadVideoNote: 'отправляет видеосообщение...',
     
     // Боты
     bots: 'Боты',
@@ -129,6 +152,7 @@ export const locales = {
     maxReconnectAttemptsExceeded: 'Превышено максимальное количество попыток переподключения',
     websocketConnected: 'WebSocket соединение установлено',
     websocketConnectionError: 'Ошибка подключения WebSocket',
+    callbackAnswer: 'Ответ бота на нажатие кнопки',
     appInitialization: 'Инициализация приложения',
     appReady: 'Приложение готово к работе',
     initializationError: 'Ошибка инициализации',
@@ -154,6 +178,11 @@ export const locales = {
     participant: 'Участник',
     member: 'Участник',
     creator: 'Создатель',
+    administrator: 'Администратор',
+    restricted: 'Ограничен',
+    promoteMember: 'Назначить администратором',
+    demoteMember: 'Снять администратора',
+    failedToChangeRole: 'Не удалось изменить права участника',
     events: 'События',
     
     // Debug events
@@ -289,6 +318,29 @@ export const locales = {
     file: 'File',
     voiceMessage: 'Voice message',
     photo: 'Photo',
+    audio: 'Audio',
+    video: 'Video',
+    serviceJoined: 'joined the group',
+    serviceAdded: 'added',
+    serviceLeft: 'left the group',
+    serviceRemoved: 'removed',
+    attachFile: 'Attach file',
+    botCommands: 'Bot commands',
+    fileUploadError: 'File upload error',
+    reply: 'Reply',
+    cancelReply: 'Cancel reply',
+    replyingTo: 'Reply to',
+    actionTyping: 'is typing...',
+    actionUploadPhoto: 'is sending a photo...',
+    actionRecordVideo: 'is recording a video...',
+    actionUploadVideo: 'is sending a video...',
+    actionRecordVoice: 'is recording a voice message...',
+    actionUploadVoice: 'is sending a voice message...',
+    actionUploadDocument: 'is sending a file...',
+    actionChooseSticker: 'is choosing a sticker...',
+    actionFindLocation: 'is finding a location...',
+    actionRecordVideoNote: 'is recording a video message...',
+    actionUploadVideoNote: 'is sending a video message...',
     
     // Bots
     bots: 'Bots',
@@ -335,6 +387,7 @@ export const locales = {
     maxReconnectAttemptsExceeded: 'Maximum reconnection attempts exceeded',
     websocketConnected: 'WebSocket connection established',
     websocketConnectionError: 'WebSocket connection error',
+    callbackAnswer: 'Bot answered button press',
     appInitialization: 'Application initialization',
     appReady: 'Application ready to work',
     initializationError: 'Initialization error',
@@ -360,6 +413,11 @@ export const locales = {
     participant: 'Participant',
     member: 'Member',
     creator: 'Creator',
+    administrator: 'Administrator',
+    restricted: 'Restricted',
+    promoteMember: 'Promote to administrator',
+    demoteMember: 'Dismiss administrator',
+    failedToChangeRole: 'Failed to change member rights',
     events: 'Events',
     
     // Debug events
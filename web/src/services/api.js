@@ -93,15 +93,16 @@ class ApiService {
     return this.request(`/chats/${chatId}/messages?limit=${limit}&offset=${offset}`);
   }
 
-  async addChatMember(chatId, userId) {
+  async addChatMember(chatId, userId, fromUserId) {
     return this.request(`/chats/${chatId}/members`, {
       method: 'POST',
-      body: JSON.stringify({ user_id: userId }),
+      body: JSON.stringify({ user_id: userId, from_user_id: fromUserId }),
     });
   }
 
-  async removeChatMember(chatId, userId) {
-    return this.request(`/chats/${chatId}/members/${userId}`, {
+  async removeChatMember(chatId, userId, fromUserId) {
+    const query = fromUserId ? `?from_user_id=${fromUserId}` : '';
+    return this.request(`/chats/${chatId}/members/${userId}${query}`, {
       method: 'DELETE',
     });
   }
@@ -110,6 +111,19 @@ class ApiService {
     return this.request(`/chats/${chatId}/members`);
   }
 
+  async promoteChatMember(chatId, userId, rights = {}) {
+    return this.request(`/chats/${chatId}/members/${userId}/promote`, {
+      method: 'POST',
+      body: JSON.stringify(rights),
+    });
+  }
+
+  async demoteChatMember(chatId, userId) {
+    return this.request(`/chats/${chatId}/members/${userId}/demote`, {
+      method: 'POST',
+    });
+  }
+
   // Messages API
   async sendMessage(chatId, messageData) {
     return this.request(`/chats/${chatId}/messages`, {
@@ -118,6 +132,29 @@ class ApiService {
     });
   }
 
+  // Files API
+  async uploadFile(file, type) {
+    const formData = new FormData();
+    formData.append('type', type);
+    formData.append('file', file);
+
+    // Content-Type с границей multipart выставляет браузер
+    const response = await fetch(`${this.baseURL}/files`, {
+      method: 'POST',
+      body: formData,
+    });
+
+    if (!response.ok) {
+      throw new Error(`HTTP error! status: ${response.status}`);
+    }
+
+    return await response.json();
+  }
+
+  getFileUrl(fileId) {
+    return `${this.baseURL}/files/${encodeURIComponent(fileId)}`;
+  }
+
   async getMessageById(messageId) {
     return this.request(`/messages/${messageId}`);
   }
@@ -158,6 +195,16 @@ class ApiService {
     });
   }
 
+  async getBotCommands(botId) {
+    return this.request(`/bots/${botId}/commands`);
+  }
+
+  // Меню команд бота, которое видит пользователь userId в чате chatId
+  async getBotCommandMenu(botId, chatId, userId, languageCode = '') {
+    const params = new URLSearchParams({ chat_id: chatId, user_id: userId, language_code: languageCode });
+    return this.request(`/bots/${botId}/commands/menu?${params}`);
+  }
+
   async sendBotMessage(botId, messageData) {
     return this.request(`/bots/${botId}/sendMessage`, {
       method: 'POST',
@@ -100,6 +100,9 @@ class WebSocketService {
               case 'message':
                 this.triggerEvent('message', message.data);
                 break;
+              case 'message_edited':
+                this.triggerEvent('message_edited', message.data);
+                break;
               case 'message_status_update':
                 this.triggerEvent('message_status_update', message.data);
                 break;
@@ -124,6 +127,9 @@ class WebSocketService {
               case 'callback_query':
                 this.triggerEvent('callback_query', message.data);
                 break;
+              case 'callback_answer':
+                this.triggerEvent('callback_answer', message.data);
+                break;
 
               default:
                 // console.log('Unknown message type:', message.type);
@@ -250,11 +256,30 @@ class WebSocketService {
     this.emit('unsubscribe', { events });
   }
 
-  sendMessage(chatId, text, fromUserId) {
+  sendMessage(chatId, text, fromUserId, replyToMessageId = null) {
     this.emit('send_message', {
       chat_id: chatId,
       text,
-      from_user_id: fromUserId
+      from_user_id: fromUserId,
+      ...(replyToMessageId && { reply_to_message_id: replyToMessageId })
+    });
+  }
+
+  sendFileMessage(chatId, fileId, type, caption, fromUserId, replyToMessageId = null) {
+    this.emit('send_message', {
+      chat_id: chatId,
+      file_id: fileId,
+      type,
+      text: caption,
+      from_user_id: fromUserId,
+      ...(replyToMessageId && { reply_to_message_id: replyToMessageId })
+    });
+  }
+
+  sendTyping(chatId, action = 'typing') {
+    this.emit('typing', {
+      chat_id: chatId,
+      action
     });
   }
 